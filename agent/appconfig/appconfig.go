@@ -112,7 +112,11 @@ func DefaultConfig() SsmagentConfig {
 	var ssm = SsmCfg{
 		HealthFrequencyMinutes:                DefaultSsmHealthFrequencyMinutes,
 		AssociationFrequencyMinutes:           DefaultSsmAssociationFrequencyMinutes,
+		AssociationMaxFrequencyMinutes:        DefaultSsmAssociationMaxFrequencyMinutes,
 		AssociationRetryLimit:                 5,
+		AssociationWorkersLimit:               DefaultSsmAssociationWorkersLimit,
+		AssociationSplayMinutes:               DefaultSsmAssociationSplayMinutes,
+		AssociationTriggerDebounceSeconds:     DefaultSsmAssociationTriggerDebounceSeconds,
 		CustomInventoryDefaultLocation:        DefaultCustomInventoryFolder,
 		AssociationLogsRetentionDurationHours: DefaultAssociationLogsRetentionDurationHours,
 		RunCommandLogsRetentionDurationHours:  DefaultRunCommandLogsRetentionDurationHours,
@@ -126,6 +130,28 @@ func DefaultConfig() SsmagentConfig {
 		Version: "1",
 	}
 	var birdwatcher BirdwatcherCfg
+	var packages = PackageCfg{
+		RetainedPreviousVersions:        DefaultPackageRetainedPreviousVersions,
+		FailedRetentionDurationHours:    DefaultPackageFailedRetentionDurationHours,
+		RepositoryQuotaMB:               DefaultPackageRepositoryQuotaMB,
+		GarbageCollectionFrequencyHours: DefaultPackageGarbageCollectionFrequencyHours,
+	}
+	var trace = TraceCfg{
+		JSONLinesEnabled:            true,
+		OpenTelemetryTimeoutSeconds: DefaultTraceOpenTelemetryTimeoutSeconds,
+	}
+	var download = DownloadCfg{
+		BandwidthLimitKBps: DefaultDownloadBandwidthLimitKBps,
+		ParallelChunks:     DefaultDownloadParallelChunks,
+		ChunkSizeMB:        DefaultDownloadChunkSizeMB,
+		ResumeAttempts:     DefaultDownloadResumeAttempts,
+	}
+	var inventory = InventoryCfg{
+		GathererConcurrency:    DefaultInventoryGathererConcurrency,
+		GathererTimeoutSeconds: DefaultInventoryGathererTimeoutSeconds,
+		HistoryRetentionCount:  DefaultInventoryHistoryRetentionCount,
+		CustomTimeoutSeconds:   DefaultInventoryCustomTimeoutSeconds,
+	}
 
 	var ssmagentCfg = SsmagentConfig{
 		Profile:     credsProfile,
@@ -135,6 +161,10 @@ func DefaultConfig() SsmagentConfig {
 		Os:          os,
 		S3:          s3,
 		Birdwatcher: birdwatcher,
+		Packages:    packages,
+		Trace:       trace,
+		Download:    download,
+		Inventory:   inventory,
 	}
 
 	return ssmagentCfg
@@ -59,6 +59,26 @@ func parser(config *SsmagentConfig) {
 		DefaultSsmAssociationFrequencyMinutesMin,
 		DefaultSsmAssociationFrequencyMinutesMax,
 		DefaultSsmAssociationFrequencyMinutes)
+	config.Ssm.AssociationMaxFrequencyMinutes = getNumericValue(
+		config.Ssm.AssociationMaxFrequencyMinutes,
+		DefaultSsmAssociationMaxFrequencyMinutesMin,
+		DefaultSsmAssociationMaxFrequencyMinutesMax,
+		DefaultSsmAssociationMaxFrequencyMinutes)
+	config.Ssm.AssociationWorkersLimit = getNumericValue(
+		config.Ssm.AssociationWorkersLimit,
+		DefaultSsmAssociationWorkersLimitMin,
+		DefaultSsmAssociationWorkersLimitMax,
+		DefaultSsmAssociationWorkersLimit)
+	config.Ssm.AssociationSplayMinutes = getNumericValue(
+		config.Ssm.AssociationSplayMinutes,
+		DefaultSsmAssociationSplayMinutesMin,
+		DefaultSsmAssociationSplayMinutesMax,
+		DefaultSsmAssociationSplayMinutes)
+	config.Ssm.AssociationTriggerDebounceSeconds = getNumericValue(
+		config.Ssm.AssociationTriggerDebounceSeconds,
+		DefaultSsmAssociationTriggerDebounceSecondsMin,
+		DefaultSsmAssociationTriggerDebounceSecondsMax,
+		DefaultSsmAssociationTriggerDebounceSeconds)
 	config.Ssm.AssociationLogsRetentionDurationHours = getNumericValueAboveMin(
 		config.Ssm.AssociationLogsRetentionDurationHours,
 		DefaultStateOrchestrationLogsRetentionDurationHoursMin,
@@ -68,6 +88,75 @@ func parser(config *SsmagentConfig) {
 		DefaultStateOrchestrationLogsRetentionDurationHoursMin,
 		DefaultRunCommandLogsRetentionDurationHours)
 
+	// Package repository config
+	config.Packages.RetainedPreviousVersions = getNumericValue(
+		config.Packages.RetainedPreviousVersions,
+		DefaultPackageRetainedPreviousVersionsMin,
+		DefaultPackageRetainedPreviousVersionsMax,
+		DefaultPackageRetainedPreviousVersions)
+	config.Packages.FailedRetentionDurationHours = getNumericValueAboveMin(
+		config.Packages.FailedRetentionDurationHours,
+		DefaultPackageFailedRetentionDurationHoursMin,
+		DefaultPackageFailedRetentionDurationHours)
+	config.Packages.RepositoryQuotaMB = getNumericValueAboveMin(
+		config.Packages.RepositoryQuotaMB,
+		DefaultPackageRepositoryQuotaMBMin,
+		DefaultPackageRepositoryQuotaMB)
+	config.Packages.GarbageCollectionFrequencyHours = getNumericValue(
+		config.Packages.GarbageCollectionFrequencyHours,
+		DefaultPackageGarbageCollectionFrequencyHoursMin,
+		DefaultPackageGarbageCollectionFrequencyHoursMax,
+		DefaultPackageGarbageCollectionFrequencyHours)
+
+	// Trace export config
+	config.Trace.OpenTelemetryTimeoutSeconds = getNumericValue(
+		config.Trace.OpenTelemetryTimeoutSeconds,
+		DefaultTraceOpenTelemetryTimeoutSecondsMin,
+		DefaultTraceOpenTelemetryTimeoutSecondsMax,
+		DefaultTraceOpenTelemetryTimeoutSeconds)
+
+	// Artifact download config
+	config.Download.BandwidthLimitKBps = getNumericValueAboveMin(
+		config.Download.BandwidthLimitKBps,
+		DefaultDownloadBandwidthLimitKBpsMin,
+		DefaultDownloadBandwidthLimitKBps)
+	config.Download.ParallelChunks = getNumericValue(
+		config.Download.ParallelChunks,
+		DefaultDownloadParallelChunksMin,
+		DefaultDownloadParallelChunksMax,
+		DefaultDownloadParallelChunks)
+	config.Download.ChunkSizeMB = getNumericValue(
+		config.Download.ChunkSizeMB,
+		DefaultDownloadChunkSizeMBMin,
+		DefaultDownloadChunkSizeMBMax,
+		DefaultDownloadChunkSizeMB)
+	config.Download.ResumeAttempts = getNumericValue(
+		config.Download.ResumeAttempts,
+		DefaultDownloadResumeAttemptsMin,
+		DefaultDownloadResumeAttemptsMax,
+		DefaultDownloadResumeAttempts)
+
+	// Inventory config
+	config.Inventory.GathererConcurrency = getNumericValue(
+		config.Inventory.GathererConcurrency,
+		DefaultInventoryGathererConcurrencyMin,
+		DefaultInventoryGathererConcurrencyMax,
+		DefaultInventoryGathererConcurrency)
+	config.Inventory.GathererTimeoutSeconds = getNumericValue(
+		config.Inventory.GathererTimeoutSeconds,
+		DefaultInventoryGathererTimeoutSecondsMin,
+		DefaultInventoryGathererTimeoutSecondsMax,
+		DefaultInventoryGathererTimeoutSeconds)
+	config.Inventory.HistoryRetentionCount = getNumericValue(
+		config.Inventory.HistoryRetentionCount,
+		DefaultInventoryHistoryRetentionCountMin,
+		DefaultInventoryHistoryRetentionCountMax,
+		DefaultInventoryHistoryRetentionCount)
+	config.Inventory.CustomTimeoutSeconds = getNumericValue(
+		config.Inventory.CustomTimeoutSeconds,
+		DefaultInventoryCustomTimeoutSecondsMin,
+		DefaultInventoryCustomTimeoutSecondsMax,
+		DefaultInventoryCustomTimeoutSeconds)
 }
 
 // TODO https://sim.amazon.com/issues/SSM-3439
@@ -40,6 +40,26 @@ const (
 	DefaultSsmAssociationFrequencyMinutesMin = 5
 	DefaultSsmAssociationFrequencyMinutesMax = 60
 
+	// association polls back off up to the max frequency while the associations are unchanged
+	DefaultSsmAssociationMaxFrequencyMinutes    = 30
+	DefaultSsmAssociationMaxFrequencyMinutesMin = 5
+	DefaultSsmAssociationMaxFrequencyMinutesMax = 240
+
+	// associations touching different resources can run at the same time, one at a time by default
+	DefaultSsmAssociationWorkersLimit    = 1
+	DefaultSsmAssociationWorkersLimitMin = 1
+	DefaultSsmAssociationWorkersLimitMax = 16
+
+	// splay of cron association schedules, disabled by default
+	DefaultSsmAssociationSplayMinutes    = 0
+	DefaultSsmAssociationSplayMinutesMin = 0
+	DefaultSsmAssociationSplayMinutesMax = 1440
+
+	// events triggering an association within the debounce window run it once
+	DefaultSsmAssociationTriggerDebounceSeconds    = 10
+	DefaultSsmAssociationTriggerDebounceSecondsMin = 0
+	DefaultSsmAssociationTriggerDebounceSecondsMax = 3600
+
 	//aws-ssm-agent bookkeeping constants
 	DefaultLocationOfPending     = "pending"
 	DefaultLocationOfCurrent     = "current"
@@ -53,6 +73,50 @@ const (
 	DefaultRunCommandLogsRetentionDurationHours            = 336 // 14 days default retention
 	DefaultStateOrchestrationLogsRetentionDurationHoursMin = 8   // Min retention of 8hrs as some processes may not timeout before this and don't want logs to be deleted before the process completes
 
+	//aws-ssm-agent package repository retention for configurePackage
+	DefaultPackageRetainedPreviousVersions           = 1 // keep one previous version for rollback
+	DefaultPackageRetainedPreviousVersionsMin        = 0
+	DefaultPackageRetainedPreviousVersionsMax        = 20
+	DefaultPackageFailedRetentionDurationHours       = 72 // Failed and Uninstalled packages are kept for 3 days
+	DefaultPackageFailedRetentionDurationHoursMin    = 1
+	DefaultPackageRepositoryQuotaMB                  = 0 // no quota
+	DefaultPackageRepositoryQuotaMBMin               = 0
+	DefaultPackageGarbageCollectionFrequencyHours    = 24
+	DefaultPackageGarbageCollectionFrequencyHoursMin = 1
+	DefaultPackageGarbageCollectionFrequencyHoursMax = 168
+
+	//aws-ssm-agent trace export
+	DefaultTraceOpenTelemetryTimeoutSeconds    = 5
+	DefaultTraceOpenTelemetryTimeoutSecondsMin = 1
+	DefaultTraceOpenTelemetryTimeoutSecondsMax = 60
+
+	//aws-ssm-agent artifact downloads
+	DefaultDownloadBandwidthLimitKBps    = 0 // unlimited
+	DefaultDownloadBandwidthLimitKBpsMin = 0
+	DefaultDownloadParallelChunks        = 1 // ranges are downloaded one at a time
+	DefaultDownloadParallelChunksMin     = 1
+	DefaultDownloadParallelChunksMax     = 16
+	DefaultDownloadChunkSizeMB           = 16
+	DefaultDownloadChunkSizeMBMin        = 1
+	DefaultDownloadChunkSizeMBMax        = 1024
+	DefaultDownloadResumeAttempts        = 3
+	DefaultDownloadResumeAttemptsMin     = 0
+	DefaultDownloadResumeAttemptsMax     = 10
+
+	//aws-ssm-agent inventory gatherers
+	DefaultInventoryGathererConcurrency       = 4
+	DefaultInventoryGathererConcurrencyMin    = 1
+	DefaultInventoryGathererConcurrencyMax    = 16
+	DefaultInventoryGathererTimeoutSeconds    = 600
+	DefaultInventoryGathererTimeoutSecondsMin = 10
+	DefaultInventoryGathererTimeoutSecondsMax = 3600
+	DefaultInventoryHistoryRetentionCount     = 48
+	DefaultInventoryHistoryRetentionCountMin  = 0
+	DefaultInventoryHistoryRetentionCountMax  = 1000
+	DefaultInventoryCustomTimeoutSeconds      = 60
+	DefaultInventoryCustomTimeoutSecondsMin   = 1
+	DefaultInventoryCustomTimeoutSecondsMax   = 3600
+
 	//aws-ssm-agent bookkeeping constants for long running plugins
 	LongRunningPluginsLocation         = "longrunningplugins"
 	LongRunningPluginsHealthCheck      = "healthcheck"
@@ -64,10 +128,17 @@ const (
 	InventoryRootDirName         = "inventory"
 	CustomInventoryRootDirName   = "custom"
 	InventoryContentHashFileName = "contentHash"
+	InventorySnapshotDirName     = "snapshot"
+	InventoryChangeLogFileName   = "changeLog"
+	InventoryHistoryDirName      = "history"
 
 	//aws-ssm-agent bookkeeping constants for compliance
 	ComplianceRootDirName         = "compliance"
 	ComplianceContentHashFileName = "contentHash"
+	// ComplianceItemsFileName is the file in the orchestration directory of a plugin where scripts write compliance items
+	ComplianceItemsFileName = "compliance.json"
+	// ComplianceItemsFileEnvVariable is the environment variable that gives scripts the path of their compliance items file
+	ComplianceItemsFileEnvVariable = "AWS_SSM_COMPLIANCE_ITEMS_FILE"
 
 	// DefaultDocumentRootDirName is the root directory for storing command states
 	DefaultDocumentRootDirName = "document"
@@ -141,6 +212,9 @@ const (
 	// PluginNameAwsApplications is the name of the Applications plugin
 	PluginNameAwsApplications = "aws:applications"
 
+	// PluginNameAwsFileState is the name of the file state plugin
+	PluginNameAwsFileState = "aws:fileState"
+
 	AppConfigFileName    = "amazon-ssm-agent.json"
 	SeelogConfigFileName = "seelog.xml"
 
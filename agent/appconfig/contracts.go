@@ -35,7 +35,23 @@ type SsmCfg struct {
 	Endpoint                    string
 	HealthFrequencyMinutes      int
 	AssociationFrequencyMinutes int
-	AssociationRetryLimit       int
+	// AssociationMaxFrequencyMinutes is the interval the association polls back off to while the associations are unchanged
+	AssociationMaxFrequencyMinutes int
+	AssociationRetryLimit          int
+	// AssociationWorkersLimit is the number of associations that can run at the same time
+	AssociationWorkersLimit int
+	// AssociationSplayMinutes is the window within which each instance delays cron association schedules
+	AssociationSplayMinutes int
+	// AssociationTriggerDebounceSeconds is the window within which the events triggering an association run it once
+	AssociationTriggerDebounceSeconds int
+	// AssociationAllowedWindows restrict associations to run within one of the windows when any is set
+	AssociationAllowedWindows []AssociationWindowCfg
+	// AssociationBlackoutWindows are the windows during which associations never run
+	AssociationBlackoutWindows []AssociationWindowCfg
+	// AssociationAuditMode runs every association in audit mode, which reports the changes without applying them
+	AssociationAuditMode bool
+	// AssociationAuditOnly are the ids or names of the associations that run in audit mode
+	AssociationAuditOnly []string
 	// TODO: test hook, can be removed before release
 	// this is to skip ssl verification for the beta self signed certs
 	InsecureSkipVerify                    bool
@@ -44,6 +60,20 @@ type SsmCfg struct {
 	RunCommandLogsRetentionDurationHours  int
 }
 
+// AssociationWindowCfg represents a recurring local window for running associations, e.g. 09:00 to 17:00 on weekdays
+type AssociationWindowCfg struct {
+	Name string
+	// Days are the days the window starts on, e.g. Mon, every day when empty
+	Days []string
+	// Start and End are the times of day formatted as hh:mm, the window ends the next day when End is before Start
+	Start string
+	End   string
+	// TimeZone is the IANA time zone of the window, UTC when empty
+	TimeZone string
+	// DocumentNames are the association documents the window applies to, every document when empty
+	DocumentNames []string
+}
+
 // AgentInfo represents metadata for amazon-ssm-agent
 type AgentInfo struct {
 	Name                 string
@@ -80,6 +110,39 @@ type BirdwatcherCfg struct {
 	ForceEnable bool
 }
 
+// PackageCfg represents configuration for the local package repository used by ConfigurePackage
+type PackageCfg struct {
+	RetainedPreviousVersions        int
+	FailedRetentionDurationHours    int
+	RepositoryQuotaMB               int
+	GarbageCollectionFrequencyHours int
+}
+
+// DownloadCfg represents configuration for artifact downloads.
+// The bandwidth limit applies to each process separately, it is not a cap shared by the agent, its document workers
+// and the updater: processes downloading at the same time together use up to the limit times their number.
+type DownloadCfg struct {
+	BandwidthLimitKBps int // download bandwidth of each process, 0 means unlimited
+	ParallelChunks     int // number of ranges of a large file downloaded at the same time
+	ChunkSizeMB        int
+	ResumeAttempts     int // number of times an interrupted download is resumed before it fails
+}
+
+// TraceCfg represents configuration for exporting plugin execution traces
+type TraceCfg struct {
+	JSONLinesEnabled            bool   // write traces as JSON Lines to the orchestration directory
+	OpenTelemetryEndpoint       string // OTLP/HTTP traces endpoint of a local collector, empty to disable
+	OpenTelemetryTimeoutSeconds int
+}
+
+// InventoryCfg represents configuration for the inventory plugin
+type InventoryCfg struct {
+	GathererConcurrency    int // number of gatherers run at the same time
+	GathererTimeoutSeconds int // time after which a gatherer is stopped and reported as failed
+	HistoryRetentionCount  int // number of collections kept in the local inventory history, 0 disables the history
+	CustomTimeoutSeconds   int // time after which a custom inventory executable is killed
+}
+
 // SsmagentConfig stores agent configuration values.
 type SsmagentConfig struct {
 	Profile     CredentialProfile
@@ -90,4 +153,8 @@ type SsmagentConfig struct {
 	Os          OsInfo
 	S3          S3Cfg
 	Birdwatcher BirdwatcherCfg
+	Packages    PackageCfg
+	Trace       TraceCfg
+	Download    DownloadCfg
+	Inventory   InventoryCfg
 }
@@ -0,0 +1,75 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package atexpr provides logic for parsing and scheduling one-time at expressions
+package atexpr
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// atTimeLayout is the layout of the time of an at expression, e.g. at(2018-01-01T10:00:00)
+const atTimeLayout = "2006-01-02T15:04:05"
+
+// AtExpression represents a one-time schedule, e.g. at(2018-01-01T10:00:00)
+type AtExpression struct {
+	runTime time.Time
+}
+
+// Parse returns a new AtExpression pointer for an expression in UTC.
+// An error is returned if a malformed at expression is supplied.
+func Parse(atLine string) (*AtExpression, error) {
+	return ParseInLocation(atLine, time.UTC)
+}
+
+// ParseInLocation returns a new AtExpression pointer for an expression in the given time zone.
+// An error is returned if a malformed at expression is supplied.
+func ParseInLocation(atLine string, location *time.Location) (*AtExpression, error) {
+	atRegularExpression := regexp.MustCompile("(?i)^at\\(\\s*([^()\\s]+)\\s*\\)$")
+	match := atRegularExpression.FindStringSubmatch(atLine)
+	if match == nil {
+		return nil, fmt.Errorf("Schedule expression is not a valid at expression.")
+	}
+
+	runTime, err := time.ParseInLocation(atTimeLayout, match[1], location)
+	if err != nil {
+		return nil, fmt.Errorf("Schedule expression is not a valid at expression. Time should be formatted as yyyy-mm-ddThh:mm:ss.")
+	}
+	return &AtExpression{runTime: runTime}, nil
+}
+
+// RunTime returns the time instant the expression runs at
+func (expr *AtExpression) RunTime() time.Time {
+	return expr.runTime
+}
+
+// Delay returns an expression running the given duration after this expression
+func (expr *AtExpression) Delay(duration time.Duration) *AtExpression {
+	return &AtExpression{runTime: expr.runTime.Add(duration)}
+}
+
+// Next returns the time instant of the expression if it follows `fromTime`.
+//
+// The `time.Location` of the returned time instant is the same as that of
+// `fromTime`.
+//
+// The zero value of time.Time is returned once the time instant has passed
+// or if a `fromTime` is itself a zero value.
+func (expr *AtExpression) Next(fromTime time.Time) time.Time {
+	if fromTime.IsZero() || !expr.runTime.After(fromTime) {
+		return time.Time{}
+	}
+	return expr.runTime.In(fromTime.Location())
+}
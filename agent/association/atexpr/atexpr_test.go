@@ -0,0 +1,90 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package atexpr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseReturnsAtExpressionSuccessfullyWhenItIsValid(t *testing.T) {
+	// Act
+	atExpression, err := Parse("at(2018-01-01T10:00:00)")
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC), atExpression.RunTime())
+}
+
+func TestParseReturnsAtExpressionSuccessfullyWhenCaseInSensitivity(t *testing.T) {
+	// Act
+	atExpression, err := Parse("AT( 2018-01-01T10:00:00 )")
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC), atExpression.RunTime())
+}
+
+func TestParseInLocationReturnsRunTimeInTheTimeZone(t *testing.T) {
+	// Assemble
+	location, _ := time.LoadLocation("Asia/Tokyo")
+
+	// Act
+	atExpression, err := ParseInLocation("at(2018-01-01T10:00:00)", location)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2018, 1, 1, 1, 0, 0, 0, time.UTC), atExpression.RunTime().UTC())
+}
+
+func TestParseReturnsErrorWhenAtExpressionIsInvalid(t *testing.T) {
+	for _, expression := range []string{
+		"at()",
+		"at(2018-01-01)",
+		"at(2018-13-01T10:00:00)",
+		"at(2018-01-01T10:00:00",
+		"at(2018-01-01T10:00:00)abc",
+	} {
+		atExpression, err := Parse(expression)
+
+		assert.Nil(t, atExpression, expression)
+		assert.NotNil(t, err, expression)
+	}
+}
+
+func TestNextReturnsRunTimeUntilItHasPassed(t *testing.T) {
+	// Assemble
+	atExpression, _ := Parse("at(2018-01-01T10:00:00)")
+	runTime := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	// Act and Assert
+	assert.Equal(t, runTime, atExpression.Next(runTime.Add(-time.Hour)))
+	assert.True(t, atExpression.Next(runTime).IsZero())
+	assert.True(t, atExpression.Next(runTime.Add(time.Hour)).IsZero())
+	assert.True(t, atExpression.Next(time.Time{}).IsZero())
+}
+
+func TestDelayReturnsExpressionRunningLater(t *testing.T) {
+	// Assemble
+	atExpression, _ := Parse("at(2018-01-01T10:00:00)")
+
+	// Act
+	delayed := atExpression.Delay(90 * time.Second)
+
+	// Assert
+	assert.Equal(t, time.Date(2018, 1, 1, 10, 1, 30, 0, time.UTC), delayed.RunTime())
+	assert.Equal(t, time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC), atExpression.RunTime())
+}
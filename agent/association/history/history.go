@@ -0,0 +1,207 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package history keeps a bounded local history of the association runs, so what the associations did
+// on the instance can be inspected without access to the service
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	// HistoryFileName represents the file persisting the association runs
+	HistoryFileName = "AssociationHistory.json"
+
+	// maxRuns is the number of runs kept, the oldest are dropped beyond it
+	maxRuns = 500
+	// maxOutputSummaryLength is the length of the output kept for each step
+	maxOutputSummaryLength = 1000
+	// outputTruncatedSuffix ends the output summaries that were truncated
+	outputTruncatedSuffix = "--output truncated--"
+)
+
+// T represents the history of the association runs
+type T interface {
+	Record(log log.T, run Run) error
+}
+
+// Run is one run of an association
+type Run struct {
+	AssociationID   string
+	DocumentName    string
+	DocumentVersion string
+	RunID           string
+	StartDateTime   time.Time
+	EndDateTime     *time.Time `json:",omitempty"`
+	Status          string
+	Message         string `json:",omitempty"`
+	Steps           []Step `json:",omitempty"`
+}
+
+// Step is the result of one step of an association run
+type Step struct {
+	PluginID      string
+	PluginName    string
+	Status        contracts.ResultStatus
+	Code          int
+	StartDateTime time.Time
+	EndDateTime   time.Time
+	OutputSummary string `json:",omitempty"`
+}
+
+// History persists the association runs to a file
+type History struct {
+	location string
+	lock     sync.Mutex
+}
+
+// GetLocation returns the folder of the association history of the instance
+func GetLocation(instanceID string) string {
+	return filepath.Join(appconfig.DefaultDataStorePath,
+		instanceID,
+		appconfig.DefaultDocumentRootDirName,
+		appconfig.DefaultLocationOfAssociation)
+}
+
+// NewHistory returns the history of the association runs of the given instance
+func NewHistory(instanceID string) *History {
+	return NewHistoryWithLocation(GetLocation(instanceID))
+}
+
+// NewHistoryWithLocation returns the history of the association runs in the given folder
+func NewHistoryWithLocation(location string) *History {
+	return &History{location: location}
+}
+
+// NewSteps returns the steps of a run from the results of its plugins, ordered by start time
+func NewSteps(pluginResults map[string]*contracts.PluginResult) []Step {
+	steps := []Step{}
+	for pluginID, result := range pluginResults {
+		if result == nil {
+			continue
+		}
+		output := ""
+		if result.Output != nil {
+			output = fmt.Sprint(result.Output)
+		}
+		if len(output) > maxOutputSummaryLength {
+			output = output[:maxOutputSummaryLength-len(outputTruncatedSuffix)] + outputTruncatedSuffix
+		}
+		steps = append(steps, Step{
+			PluginID:      pluginID,
+			PluginName:    result.PluginName,
+			Status:        result.Status,
+			Code:          result.Code,
+			StartDateTime: result.StartDateTime,
+			EndDateTime:   result.EndDateTime,
+			OutputSummary: output,
+		})
+	}
+	sort.Sort(byStartDateTime(steps))
+	return steps
+}
+
+// byStartDateTime sorts steps by their start time, then by plugin id
+type byStartDateTime []Step
+
+func (s byStartDateTime) Len() int      { return len(s) }
+func (s byStartDateTime) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byStartDateTime) Less(i, j int) bool {
+	if s[i].StartDateTime.Equal(s[j].StartDateTime) {
+		return s[i].PluginID < s[j].PluginID
+	}
+	return s[i].StartDateTime.Before(s[j].StartDateTime)
+}
+
+// Record adds a run to the history, or updates the run with the same association and run id.
+// The start of a run recorded when it started is kept when its completion is recorded.
+func (h *History) Record(log log.T, run Run) (err error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	var runs []Run
+	if runs, err = ReadRuns(h.location); err != nil {
+		log.Errorf("Discarding association history, %v", err)
+		runs = []Run{}
+	}
+
+	found := false
+	for i, recorded := range runs {
+		if recorded.AssociationID == run.AssociationID && recorded.RunID == run.RunID {
+			if run.StartDateTime.IsZero() {
+				run.StartDateTime = recorded.StartDateTime
+			}
+			runs[i] = run
+			found = true
+			break
+		}
+	}
+	if !found {
+		runs = append(runs, run)
+	}
+	if len(runs) > maxRuns {
+		runs = runs[len(runs)-maxRuns:]
+	}
+	return h.save(runs)
+}
+
+// save persists the runs, writing a temporary file first so the cli never reads a partial history
+func (h *History) save(runs []Run) (err error) {
+	var data []byte
+	if data, err = json.Marshal(runs); err != nil {
+		return fmt.Errorf("failed to marshal association history, %v", err)
+	}
+	if err = fileutil.MakeDirs(h.location); err != nil {
+		return fmt.Errorf("cannot make directory of %v because: %v", h.location, err)
+	}
+	fileName := filepath.Join(h.location, HistoryFileName)
+	tempFileName := fileName + ".tmp"
+	if err = ioutil.WriteFile(tempFileName, data, os.FileMode(int(appconfig.ReadWriteAccess))); err != nil {
+		return fmt.Errorf("failed to write association history, %v", err)
+	}
+	if err = os.Rename(tempFileName, fileName); err != nil {
+		os.Remove(tempFileName)
+		return fmt.Errorf("failed to write association history, %v", err)
+	}
+	return nil
+}
+
+// ReadRuns returns the runs in the history in the given folder, oldest first
+func ReadRuns(location string) (runs []Run, err error) {
+	runs = []Run{}
+	fileName := filepath.Join(location, HistoryFileName)
+	if !fileutil.Exists(fileName) {
+		return runs, nil
+	}
+	var data []byte
+	if data, err = ioutil.ReadFile(fileName); err != nil {
+		return runs, fmt.Errorf("failed to read association history, %v", err)
+	}
+	if err = json.Unmarshal(data, &runs); err != nil {
+		return []Run{}, fmt.Errorf("association history %v is corrupted, %v", fileName, err)
+	}
+	return runs, nil
+}
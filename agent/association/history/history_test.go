@@ -0,0 +1,80 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package history
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordKeepsTheStartOfTheRunOnCompletion(t *testing.T) {
+	location, _ := ioutil.TempDir("", "history")
+	defer os.RemoveAll(location)
+	logger := log.NewMockLog()
+	history := NewHistoryWithLocation(location)
+	startTime := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	endTime := startTime.Add(time.Minute)
+
+	assert.Nil(t, history.Record(logger, Run{AssociationID: "assoc-1", RunID: "run-1", StartDateTime: startTime, Status: contracts.AssociationStatusInProgress}))
+	assert.Nil(t, history.Record(logger, Run{AssociationID: "assoc-2", RunID: "run-1", StartDateTime: startTime, Status: contracts.AssociationStatusInProgress}))
+	assert.Nil(t, history.Record(logger, Run{AssociationID: "assoc-1", RunID: "run-1", EndDateTime: &endTime, Status: contracts.AssociationStatusSuccess}))
+
+	runs, err := ReadRuns(location)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(runs))
+	assert.Equal(t, "assoc-1", runs[0].AssociationID)
+	assert.Equal(t, startTime, runs[0].StartDateTime)
+	assert.Equal(t, endTime, *runs[0].EndDateTime)
+	assert.Equal(t, contracts.AssociationStatusSuccess, runs[0].Status)
+}
+
+func TestRecordDropsTheOldestRuns(t *testing.T) {
+	location, _ := ioutil.TempDir("", "history")
+	defer os.RemoveAll(location)
+	logger := log.NewMockLog()
+	history := NewHistoryWithLocation(location)
+
+	for i := 0; i < maxRuns+2; i++ {
+		assert.Nil(t, history.Record(logger, Run{AssociationID: "assoc-1", RunID: fmt.Sprintf("run-%v", i)}))
+	}
+
+	runs, err := ReadRuns(location)
+	assert.Nil(t, err)
+	assert.Equal(t, maxRuns, len(runs))
+	assert.Equal(t, "run-2", runs[0].RunID)
+}
+
+func TestNewStepsSummarizesTheOutputOfThePlugins(t *testing.T) {
+	startTime := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	steps := NewSteps(map[string]*contracts.PluginResult{
+		"configure": {PluginName: "aws:runShellScript", Status: contracts.ResultStatusSuccess, StartDateTime: startTime.Add(time.Second), Output: strings.Repeat("x", 2*maxOutputSummaryLength)},
+		"install":   {PluginName: "aws:configurePackage", Status: contracts.ResultStatusFailed, Code: 1, StartDateTime: startTime, Output: "failed to download"},
+		"skipped":   nil,
+	})
+
+	assert.Equal(t, 2, len(steps))
+	assert.Equal(t, "install", steps[0].PluginID)
+	assert.Equal(t, "failed to download", steps[0].OutputSummary)
+	assert.Equal(t, "configure", steps[1].PluginID)
+	assert.Equal(t, maxOutputSummaryLength, len(steps[1].OutputSummary))
+	assert.True(t, strings.HasSuffix(steps[1].OutputSummary, outputTruncatedSuffix))
+}
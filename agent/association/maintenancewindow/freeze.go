@@ -0,0 +1,88 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package maintenancewindow
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+)
+
+// FreezeFileName is the name of the file holding the association freeze of the instance
+const FreezeFileName = "AssociationFreeze.json"
+
+// Freeze defers every association of the instance, e.g. during a deploy
+type Freeze struct {
+	Frozen  bool
+	Reason  string `json:",omitempty"`
+	SetTime time.Time
+	Until   *time.Time `json:",omitempty"`
+}
+
+// IsActive returns true if the freeze defers associations at the given time
+func (f Freeze) IsActive(now time.Time) bool {
+	return f.Frozen && (f.Until == nil || now.Before(*f.Until))
+}
+
+// GetFreezeLocation returns the folder of the association freeze of the instance
+func GetFreezeLocation(instanceID string) string {
+	return filepath.Join(appconfig.DefaultDataStorePath,
+		instanceID,
+		appconfig.DefaultDocumentRootDirName,
+		appconfig.DefaultLocationOfAssociation)
+}
+
+// LoadFreeze returns the association freeze in the given folder, associations are not frozen if it was never set
+func LoadFreeze(location string) (freeze Freeze, err error) {
+	fileName := filepath.Join(location, FreezeFileName)
+	if !fileutil.Exists(fileName) {
+		return Freeze{}, nil
+	}
+	var data []byte
+	if data, err = ioutil.ReadFile(fileName); err != nil {
+		return Freeze{}, fmt.Errorf("failed to read association freeze, %v", err)
+	}
+	if err = json.Unmarshal(data, &freeze); err != nil {
+		return Freeze{}, fmt.Errorf("association freeze %v is corrupted, %v", fileName, err)
+	}
+	return freeze, nil
+}
+
+// SaveFreeze persists the association freeze in the given folder
+func SaveFreeze(location string, freeze Freeze) (err error) {
+	var data []byte
+	if data, err = json.Marshal(freeze); err != nil {
+		return fmt.Errorf("failed to marshal association freeze, %v", err)
+	}
+	if err = fileutil.MakeDirs(location); err != nil {
+		return fmt.Errorf("cannot make directory of %v because: %v", location, err)
+	}
+	// write a temporary file first so the agent never reads a partial freeze
+	fileName := filepath.Join(location, FreezeFileName)
+	tempFileName := fileName + ".tmp"
+	if err = ioutil.WriteFile(tempFileName, data, os.FileMode(int(appconfig.ReadWriteAccess))); err != nil {
+		return fmt.Errorf("failed to write association freeze, %v", err)
+	}
+	if err = os.Rename(tempFileName, fileName); err != nil {
+		os.Remove(tempFileName)
+		return fmt.Errorf("failed to write association freeze, %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package maintenancewindow restricts when associations run on the instance with locally configured
+// allowed and blackout windows and a freeze that defers every association
+package maintenancewindow
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	// timeOfDayLayout is the layout of the start and end of a window, e.g. 09:00
+	timeOfDayLayout = "15:04"
+	// lookAheadDays bounds the search for the next time an association may run
+	lookAheadDays = 8
+	// recheckInterval is how often an association deferred for an unknown duration is checked again
+	recheckInterval = time.Minute
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// T represents the local restrictions on running associations
+type T interface {
+	Check(log log.T, documentName string, now time.Time) *Deferral
+}
+
+// Deferral describes why and until when a due association is deferred
+type Deferral struct {
+	// Until is the time the association may run, nil when it is deferred until the freeze is lifted
+	Until *time.Time
+	// NextCheck is the time the association is checked again
+	NextCheck time.Time
+	Reason    string
+}
+
+// MaintenanceWindows checks the configured windows and the freeze of the instance
+type MaintenanceWindows struct {
+	policy         *Policy
+	freezeLocation string
+}
+
+// NewMaintenanceWindows returns the maintenance windows of the instance configured in the agent config
+func NewMaintenanceWindows(instanceID string, config appconfig.SsmCfg) *MaintenanceWindows {
+	return &MaintenanceWindows{
+		policy:         NewPolicy(config.AssociationAllowedWindows, config.AssociationBlackoutWindows),
+		freezeLocation: GetFreezeLocation(instanceID),
+	}
+}
+
+// Check returns the deferral of an association of the document due at `now`, nil when it may run
+func (m *MaintenanceWindows) Check(log log.T, documentName string, now time.Time) *Deferral {
+	freeze, err := LoadFreeze(m.freezeLocation)
+	if err != nil {
+		// the freeze may have been set, associations are not run until the state can be read again
+		log.Errorf("Unable to read association freeze, %v", err)
+		freeze = Freeze{Frozen: true, Reason: "the freeze state is unreadable"}
+	}
+	return m.policy.Check(documentName, now, freeze)
+}
+
+// window is a parsed recurring window
+type window struct {
+	name      string
+	days      map[time.Weekday]bool
+	hour      int
+	minute    int
+	length    time.Duration
+	location  *time.Location
+	documents map[string]bool
+}
+
+// period is an occurrence of a window
+type period struct {
+	start time.Time
+	end   time.Time
+}
+
+// parseWindow validates a window of the agent config
+func parseWindow(config appconfig.AssociationWindowCfg) (*window, error) {
+	w := &window{
+		name:      config.Name,
+		days:      map[time.Weekday]bool{},
+		location:  time.UTC,
+		documents: map[string]bool{},
+	}
+	if w.name == "" {
+		w.name = fmt.Sprintf("%v-%v", config.Start, config.End)
+	}
+
+	for _, day := range config.Days {
+		weekday, found := weekdays[strings.ToLower(strings.TrimSpace(day))]
+		if !found {
+			return nil, fmt.Errorf("window %v has an unknown day %v", w.name, day)
+		}
+		w.days[weekday] = true
+	}
+
+	start, err := time.Parse(timeOfDayLayout, config.Start)
+	if err != nil {
+		return nil, fmt.Errorf("window %v has an invalid start %v, it should be formatted as hh:mm", w.name, config.Start)
+	}
+	end, err := time.Parse(timeOfDayLayout, config.End)
+	if err != nil {
+		return nil, fmt.Errorf("window %v has an invalid end %v, it should be formatted as hh:mm", w.name, config.End)
+	}
+	w.hour, w.minute = start.Hour(), start.Minute()
+	// a window ending before it starts ends the next day, and a window ending when it starts lasts a whole day
+	w.length = end.Sub(start)
+	if w.length <= 0 {
+		w.length += 24 * time.Hour
+	}
+
+	if config.TimeZone != "" {
+		if w.location, err = time.LoadLocation(config.TimeZone); err != nil {
+			return nil, fmt.Errorf("window %v has an unknown time zone %v", w.name, config.TimeZone)
+		}
+	}
+
+	for _, documentName := range config.DocumentNames {
+		w.documents[documentName] = true
+	}
+	return w, nil
+}
+
+// appliesTo returns true if the window restricts associations of the document
+func (w *window) appliesTo(documentName string) bool {
+	return len(w.documents) == 0 || w.documents[documentName]
+}
+
+// periods returns the occurrences of the window starting on the given number of days from the day of `from`
+func (w *window) periods(from time.Time, days int) []period {
+	result := []period{}
+	local := from.In(w.location)
+	for i := 0; i < days; i++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()+i, 0, 0, 0, 0, w.location)
+		if len(w.days) > 0 && !w.days[day.Weekday()] {
+			continue
+		}
+		start := time.Date(day.Year(), day.Month(), day.Day(), w.hour, w.minute, 0, 0, w.location)
+		result = append(result, period{start: start, end: start.Add(w.length)})
+	}
+	return result
+}
+
+// contains returns true if the time is within an occurrence of the window
+func (w *window) contains(t time.Time) bool {
+	// an occurrence that started the day before may not have ended yet
+	for _, p := range w.periods(t.AddDate(0, 0, -1), 2) {
+		if !t.Before(p.start) && t.Before(p.end) {
+			return true
+		}
+	}
+	return false
+}
+
+// Policy holds the allowed and blackout windows of the instance
+type Policy struct {
+	allowed  []*window
+	blackout []*window
+	// err is set when a window is invalid, every association is deferred then so a mistake never opens a window
+	err error
+}
+
+// NewPolicy returns the policy of the given allowed and blackout windows
+func NewPolicy(allowed []appconfig.AssociationWindowCfg, blackout []appconfig.AssociationWindowCfg) *Policy {
+	policy := &Policy{}
+	for _, config := range allowed {
+		w, err := parseWindow(config)
+		if err != nil {
+			policy.err = fmt.Errorf("invalid allowed association window, %v", err)
+			return policy
+		}
+		policy.allowed = append(policy.allowed, w)
+	}
+	for _, config := range blackout {
+		w, err := parseWindow(config)
+		if err != nil {
+			policy.err = fmt.Errorf("invalid association blackout window, %v", err)
+			return policy
+		}
+		policy.blackout = append(policy.blackout, w)
+	}
+	return policy
+}
+
+// Check returns the deferral of an association of the document due at `now`, nil when it may run
+func (p *Policy) Check(documentName string, now time.Time, freeze Freeze) *Deferral {
+	if freeze.IsActive(now) {
+		deferral := &Deferral{Reason: "associations are frozen on this instance"}
+		if freeze.Reason != "" {
+			deferral.Reason = fmt.Sprintf("%v, %v", deferral.Reason, freeze.Reason)
+		}
+		if freeze.Until != nil {
+			deferral.Until = freeze.Until
+			deferral.NextCheck = *freeze.Until
+		} else {
+			deferral.NextCheck = now.Add(recheckInterval)
+		}
+		return deferral
+	}
+
+	if p.err != nil {
+		return &Deferral{Reason: p.err.Error(), NextCheck: now.Add(recheckInterval)}
+	}
+
+	allowed := applicableWindows(p.allowed, documentName)
+	blackout := applicableWindows(p.blackout, documentName)
+	if isOpen(allowed, blackout, now) {
+		return nil
+	}
+
+	deferral := &Deferral{Reason: "outside of the allowed association windows"}
+	for _, w := range blackout {
+		if w.contains(now) {
+			deferral.Reason = fmt.Sprintf("within the association blackout window %v", w.name)
+			break
+		}
+	}
+	if next, found := nextOpening(allowed, blackout, now); found {
+		deferral.Until = &next
+		deferral.NextCheck = next
+	} else {
+		deferral.NextCheck = now.Add(recheckInterval)
+	}
+	return deferral
+}
+
+// applicableWindows returns the windows that restrict associations of the document
+func applicableWindows(windows []*window, documentName string) []*window {
+	result := []*window{}
+	for _, w := range windows {
+		if w.appliesTo(documentName) {
+			result = append(result, w)
+		}
+	}
+	return result
+}
+
+// isOpen returns true if the time is within an allowed window, if any, and outside of every blackout window
+func isOpen(allowed []*window, blackout []*window, t time.Time) bool {
+	for _, w := range blackout {
+		if w.contains(t) {
+			return false
+		}
+	}
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, w := range allowed {
+		if w.contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// nextOpening returns the first time after `now` at which associations may run,
+// it is either the start of an allowed window or the end of a blackout window
+func nextOpening(allowed []*window, blackout []*window, now time.Time) (time.Time, bool) {
+	candidates := timeList{}
+	from := now.AddDate(0, 0, -1)
+	for _, w := range allowed {
+		for _, p := range w.periods(from, lookAheadDays+1) {
+			candidates = append(candidates, p.start)
+		}
+	}
+	for _, w := range blackout {
+		for _, p := range w.periods(from, lookAheadDays+1) {
+			candidates = append(candidates, p.end)
+		}
+	}
+	sort.Sort(candidates)
+
+	for _, candidate := range candidates {
+		if candidate.After(now) && isOpen(allowed, blackout, candidate) {
+			return candidate.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// timeList sorts times in ascending order
+type timeList []time.Time
+
+func (l timeList) Len() int           { return len(l) }
+func (l timeList) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
+func (l timeList) Less(i, j int) bool { return l[i].Before(l[j]) }
@@ -0,0 +1,177 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package maintenancewindow
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/stretchr/testify/assert"
+)
+
+const patchDocumentName = "AWS-RunPatchBaseline"
+
+var marketHours = appconfig.AssociationWindowCfg{
+	Name:          "market hours",
+	Days:          []string{"Mon", "Tue", "Wed", "Thu", "Fri"},
+	Start:         "09:30",
+	End:           "16:00",
+	TimeZone:      "America/New_York",
+	DocumentNames: []string{patchDocumentName},
+}
+
+func TestCheckAllowsAssociationsWithoutWindows(t *testing.T) {
+	policy := NewPolicy(nil, nil)
+
+	assert.Nil(t, policy.Check(patchDocumentName, time.Date(2018, 1, 1, 15, 0, 0, 0, time.UTC), Freeze{}))
+}
+
+func TestCheckDefersAssociationUntilTheEndOfTheBlackoutWindow(t *testing.T) {
+	// Assemble
+	policy := NewPolicy(nil, []appconfig.AssociationWindowCfg{marketHours})
+	// Monday 10:00 in New York
+	now := time.Date(2018, 1, 1, 15, 0, 0, 0, time.UTC)
+
+	// Act
+	deferral := policy.Check(patchDocumentName, now, Freeze{})
+
+	// Assert
+	assert.NotNil(t, deferral)
+	assert.Equal(t, time.Date(2018, 1, 1, 21, 0, 0, 0, time.UTC), *deferral.Until)
+	assert.Equal(t, *deferral.Until, deferral.NextCheck)
+	assert.Contains(t, deferral.Reason, "market hours")
+}
+
+func TestCheckAllowsAssociationOutsideOfTheBlackoutWindow(t *testing.T) {
+	policy := NewPolicy(nil, []appconfig.AssociationWindowCfg{marketHours})
+
+	// Monday 08:00 and 16:00 in New York
+	assert.Nil(t, policy.Check(patchDocumentName, time.Date(2018, 1, 1, 13, 0, 0, 0, time.UTC), Freeze{}))
+	assert.Nil(t, policy.Check(patchDocumentName, time.Date(2018, 1, 1, 21, 0, 0, 0, time.UTC), Freeze{}))
+	// Saturday 10:00 in New York
+	assert.Nil(t, policy.Check(patchDocumentName, time.Date(2018, 1, 6, 15, 0, 0, 0, time.UTC), Freeze{}))
+}
+
+func TestCheckAllowsAssociationOfOtherDocumentsDuringTheBlackoutWindow(t *testing.T) {
+	policy := NewPolicy(nil, []appconfig.AssociationWindowCfg{marketHours})
+
+	assert.Nil(t, policy.Check("AWS-GatherSoftwareInventory", time.Date(2018, 1, 1, 15, 0, 0, 0, time.UTC), Freeze{}))
+}
+
+func TestCheckDefersAssociationUntilTheNextAllowedWindow(t *testing.T) {
+	// Assemble
+	weekend := appconfig.AssociationWindowCfg{Days: []string{"Saturday", "Sunday"}, Start: "02:00", End: "04:00"}
+	policy := NewPolicy([]appconfig.AssociationWindowCfg{weekend}, nil)
+
+	// Act
+	deferral := policy.Check(patchDocumentName, time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC), Freeze{})
+
+	// Assert
+	assert.NotNil(t, deferral)
+	assert.Equal(t, time.Date(2018, 1, 6, 2, 0, 0, 0, time.UTC), *deferral.Until)
+	assert.Nil(t, policy.Check(patchDocumentName, time.Date(2018, 1, 7, 3, 59, 0, 0, time.UTC), Freeze{}))
+}
+
+func TestCheckAllowsAssociationInAWindowEndingTheNextDay(t *testing.T) {
+	// Assemble
+	nightly := appconfig.AssociationWindowCfg{Start: "22:00", End: "02:00"}
+	policy := NewPolicy([]appconfig.AssociationWindowCfg{nightly}, nil)
+
+	// Act
+	deferral := policy.Check(patchDocumentName, time.Date(2018, 1, 1, 3, 0, 0, 0, time.UTC), Freeze{})
+
+	// Assert
+	assert.Nil(t, policy.Check(patchDocumentName, time.Date(2018, 1, 1, 1, 0, 0, 0, time.UTC), Freeze{}))
+	assert.Nil(t, policy.Check(patchDocumentName, time.Date(2018, 1, 1, 23, 0, 0, 0, time.UTC), Freeze{}))
+	assert.NotNil(t, deferral)
+	assert.Equal(t, time.Date(2018, 1, 1, 22, 0, 0, 0, time.UTC), *deferral.Until)
+}
+
+func TestCheckDefersAssociationUntilTheBlackoutWindowWithinAnAllowedWindowEnds(t *testing.T) {
+	// Assemble
+	allDay := appconfig.AssociationWindowCfg{Start: "00:00", End: "00:00"}
+	deploy := appconfig.AssociationWindowCfg{Name: "deploy", Start: "09:00", End: "17:00"}
+	policy := NewPolicy([]appconfig.AssociationWindowCfg{allDay}, []appconfig.AssociationWindowCfg{deploy})
+
+	// Act
+	deferral := policy.Check(patchDocumentName, time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC), Freeze{})
+
+	// Assert
+	assert.NotNil(t, deferral)
+	assert.Equal(t, time.Date(2018, 1, 1, 17, 0, 0, 0, time.UTC), *deferral.Until)
+}
+
+func TestCheckDefersEveryAssociationWhenAWindowIsInvalid(t *testing.T) {
+	for _, invalid := range []appconfig.AssociationWindowCfg{
+		{Start: "9am", End: "17:00"},
+		{Start: "09:00", End: "25:00"},
+		{Days: []string{"Funday"}, Start: "09:00", End: "17:00"},
+		{Start: "09:00", End: "17:00", TimeZone: "Mars/Olympus"},
+	} {
+		// Act
+		policy := NewPolicy(nil, []appconfig.AssociationWindowCfg{invalid})
+		now := time.Date(2018, 1, 1, 20, 0, 0, 0, time.UTC)
+		deferral := policy.Check("AWS-GatherSoftwareInventory", now, Freeze{})
+
+		// Assert
+		assert.NotNil(t, deferral)
+		assert.Nil(t, deferral.Until)
+		assert.True(t, deferral.NextCheck.After(now))
+	}
+}
+
+func TestCheckDefersEveryAssociationWhileFrozen(t *testing.T) {
+	// Assemble
+	policy := NewPolicy(nil, nil)
+	now := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+	until := now.Add(2 * time.Hour)
+
+	// Act
+	indefinite := policy.Check(patchDocumentName, now, Freeze{Frozen: true, Reason: "deploying"})
+	temporary := policy.Check(patchDocumentName, now, Freeze{Frozen: true, Until: &until})
+	expired := policy.Check(patchDocumentName, until, Freeze{Frozen: true, Until: &until})
+
+	// Assert
+	assert.NotNil(t, indefinite)
+	assert.Nil(t, indefinite.Until)
+	assert.Equal(t, now.Add(recheckInterval), indefinite.NextCheck)
+	assert.Contains(t, indefinite.Reason, "deploying")
+	assert.NotNil(t, temporary)
+	assert.Equal(t, until, *temporary.Until)
+	assert.Nil(t, expired)
+}
+
+func TestFreezeIsSavedAndLoaded(t *testing.T) {
+	// Assemble
+	location, _ := ioutil.TempDir("", "maintenancewindow")
+	defer os.RemoveAll(location)
+	until := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Act
+	notSet, err := LoadFreeze(location)
+	assert.Nil(t, err)
+	err = SaveFreeze(location, Freeze{Frozen: true, Reason: "deploying", Until: &until})
+	assert.Nil(t, err)
+	freeze, err := LoadFreeze(location)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.False(t, notSet.Frozen)
+	assert.True(t, freeze.Frozen)
+	assert.Equal(t, "deploying", freeze.Reason)
+	assert.Equal(t, until, *freeze.Until)
+}
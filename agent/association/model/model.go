@@ -35,6 +35,13 @@ type InstanceAssociation struct {
 	ParsedExpression  scheduleexpression.ScheduleExpression
 	Document          *string
 	Errors            []error
+	// DeferredUntil and DeferredReason describe why a due association is deferred by the local maintenance windows,
+	// DeferredUntil is nil when the association is deferred until further notice
+	DeferredUntil  *time.Time `json:",omitempty"`
+	DeferredReason string     `json:",omitempty"`
+	// RunRequested is true when the association was requested to run now, e.g. by a run request or a trigger,
+	// and hasn't run since. The association stays due until it runs, even when it is deferred.
+	RunRequested bool `json:",omitempty"`
 }
 
 // ParseExpression parses the expression with the given association
@@ -81,12 +88,6 @@ func (newAssoc *InstanceAssociation) SetNextScheduledDate(log log.T) {
 		return
 	}
 
-	// Run association immediately if association has not been run before
-	if newAssoc.Association.LastExecutionDate == nil {
-		newAssoc.RunNow()
-		return
-	}
-
 	if newAssoc.ParsedExpression == nil {
 		if err := newAssoc.ParseExpression(log); err != nil {
 			log.Errorf("Skipping association %v as there was an error parsing schedule expression %v."+
@@ -98,9 +99,28 @@ func (newAssoc *InstanceAssociation) SetNextScheduledDate(log log.T) {
 		}
 	}
 
+	// Run association immediately if association has not been run before,
+	// unless it runs once at a given time which may be in the past if the instance was offline
+	if newAssoc.Association.LastExecutionDate == nil {
+		if oneTimeExpression, ok := newAssoc.ParsedExpression.(scheduleexpression.OneTimeExpression); ok {
+			newAssoc.NextScheduledDate = aws.Time(oneTimeExpression.RunTime().UTC())
+			log.Infof("Association %v runs once at %v", *newAssoc.Association.AssociationId, times.ToIsoDashUTC(*newAssoc.NextScheduledDate))
+			return
+		}
+		newAssoc.RunNow()
+		return
+	}
+
 	// Set next schedule date of association according to it's schedule
-	newAssoc.NextScheduledDate = aws.Time(
-		newAssoc.ParsedExpression.Next(newAssoc.Association.LastExecutionDate.UTC()).UTC())
+	nextScheduledDate := newAssoc.ParsedExpression.Next(newAssoc.Association.LastExecutionDate.UTC())
+	if nextScheduledDate.IsZero() {
+		log.Infof("Skipping association %v as its schedule expression %v has no time after the last execution date %v",
+			*newAssoc.Association.AssociationId, *newAssoc.Association.ScheduleExpression,
+			times.ToIsoDashUTC(*newAssoc.Association.LastExecutionDate))
+		newAssoc.NextScheduledDate = nil
+		return
+	}
+	newAssoc.NextScheduledDate = aws.Time(nextScheduledDate.UTC())
 	log.Infof("Based upon expression %v and last execution date %v, next scheduled date for association %v is %v",
 		*newAssoc.Association.ScheduleExpression, times.ToIsoDashUTC(*newAssoc.Association.LastExecutionDate),
 		*newAssoc.Association.AssociationId, times.ToIsoDashUTC(*newAssoc.NextScheduledDate))
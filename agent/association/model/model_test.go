@@ -384,3 +384,46 @@ func TestNextScheduledDateIsNilWhenAssociationExpressionTypeIsUnknown(t *testing
 	// Assert
 	assert.Nil(t, assocRawData.NextScheduledDate)
 }
+
+func TestNextScheduledDateIsRunTimeWhenAtExpressionHasNotBeenExecuted(t *testing.T) {
+	// Assemble
+	logger := log.Logger()
+
+	testInstanceAssociation := InstanceAssociation{}
+
+	testInstanceAssociation.Association = &ssm.InstanceAssociationSummary{}
+	assocId := "b2f71a28-cbe1-4429-b848-26c7e1f5ad0d"
+	testInstanceAssociation.Association.AssociationId = &assocId
+	testAtExpression := "at(2009-11-20T20:34:58)"
+	testInstanceAssociation.Association.ScheduleExpression = &testAtExpression
+
+	expectedNextScheduledDateTime := time.Date(
+		2009, 11, 20, 20, 34, 58, 0, time.UTC)
+	// Act
+	testInstanceAssociation.SetNextScheduledDate(logger)
+
+	// Assert
+	assert.Equal(t, expectedNextScheduledDateTime, *testInstanceAssociation.NextScheduledDate)
+}
+
+func TestNextScheduledDateIsNilWhenAtExpressionHasBeenExecuted(t *testing.T) {
+	// Assemble
+	logger := log.Logger()
+
+	testInstanceAssociation := InstanceAssociation{}
+
+	testInstanceAssociation.Association = &ssm.InstanceAssociationSummary{}
+	assocId := "b2f71a28-cbe1-4429-b848-26c7e1f5ad0d"
+	testInstanceAssociation.Association.AssociationId = &assocId
+	testAtExpression := "at(2009-11-20T20:34:58)"
+	testInstanceAssociation.Association.ScheduleExpression = &testAtExpression
+	lastExecutionDateTime := time.Date(
+		2009, 11, 20, 20, 35, 10, 0, time.UTC)
+	testInstanceAssociation.Association.LastExecutionDate = &lastExecutionDateTime
+
+	// Act
+	testInstanceAssociation.SetNextScheduledDate(logger)
+
+	// Assert
+	assert.Nil(t, testInstanceAssociation.NextScheduledDate)
+}
@@ -0,0 +1,368 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package outbox persists the association status and compliance updates until the service accepts them,
+// so results of associations executed while the service is unreachable are eventually reported in order
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
+
+const (
+	// OutboxFileName represents the file persisting the pending updates
+	OutboxFileName = "AssociationOutbox.json"
+
+	// KindAssociationStatus represents association status updates, including the plugin progress reports
+	KindAssociationStatus = "AssociationStatus"
+	// KindAssociationCompliance represents association compliance updates
+	KindAssociationCompliance = "AssociationCompliance"
+	// KindAssociationDrift represents the drift compliance updates of associations running in audit mode
+	KindAssociationDrift = "AssociationDrift"
+	// KindCustomCompliance represents the updates of the compliance items reported by association plugins and scripts
+	KindCustomCompliance = "CustomCompliance"
+
+	// maxMessages is the number of pending updates kept, the oldest are dropped beyond it
+	maxMessages = 1000
+	// maxAttempts is the number of failed deliveries after which an update is dropped
+	maxAttempts = 20
+	// maxMessageAge is the age after which an update that couldn't be delivered is dropped
+	maxMessageAge = 7 * 24 * time.Hour
+	// initialBackoff is the wait before the first retry, it doubles with every failed attempt
+	initialBackoff = 30 * time.Second
+	// maxBackoff is the maximum wait between retries
+	maxBackoff = time.Hour
+)
+
+// Message is one pending update
+type Message struct {
+	Sequence        uint64
+	Kind            string
+	AssociationID   string
+	RunID           string
+	CreatedTime     time.Time
+	Attempts        int
+	NextAttemptTime time.Time
+	LastError       string `json:",omitempty"`
+	Payload         json.RawMessage
+}
+
+// Sender delivers one message of a kind to the service
+type Sender func(log log.T, message Message) error
+
+// T represents the outbox of association updates
+type T interface {
+	RegisterSender(kind string, sender Sender)
+	Enqueue(log log.T, kind string, associationID string, runID string, payload interface{}) error
+	Flush(log log.T)
+}
+
+// outboxFile is the content of the outbox file
+type outboxFile struct {
+	NextSequence uint64
+	Messages     []Message
+}
+
+// Outbox keeps the pending updates in a file and delivers them in the order they were enqueued
+type Outbox struct {
+	location  string
+	lock      sync.Mutex
+	flushLock sync.Mutex
+	senders   map[string]Sender
+	content   outboxFile
+	loaded    bool
+}
+
+// permanentError is an error retrying can't resolve
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+// Permanent marks an error of a sender as permanent, the message is dropped instead of retried
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// ClassifyAwsError returns the error of an AWS API call, marked as permanent when the service rejected the request
+func ClassifyAwsError(err error) error {
+	if requestFailure, ok := err.(awserr.RequestFailure); ok {
+		statusCode := requestFailure.StatusCode()
+		switch requestFailure.Code() {
+		case "ThrottlingException", "TooManyUpdates", "RequestLimitExceeded", "AccessDeniedException":
+			return err
+		}
+		if statusCode >= 400 && statusCode < 500 && statusCode != 403 && statusCode != 429 {
+			return Permanent(err)
+		}
+	}
+	return err
+}
+
+// NewOutbox returns the outbox of the given instance
+func NewOutbox(instanceID string) *Outbox {
+	return NewOutboxWithLocation(GetLocation(instanceID))
+}
+
+// NewOutboxWithLocation returns the outbox in the given folder
+func NewOutboxWithLocation(location string) *Outbox {
+	return &Outbox{
+		location: location,
+		senders:  make(map[string]Sender),
+	}
+}
+
+// GetLocation returns the folder of the outbox of the given instance
+func GetLocation(instanceID string) string {
+	return filepath.Join(appconfig.DefaultDataStorePath,
+		instanceID,
+		appconfig.DefaultDocumentRootDirName,
+		appconfig.DefaultLocationOfAssociation)
+}
+
+// ReadMessages returns the pending messages of the outbox in the given folder
+func ReadMessages(location string) (messages []Message, err error) {
+	var content outboxFile
+	fileName := filepath.Join(location, OutboxFileName)
+	if !fileutil.Exists(fileName) {
+		return []Message{}, nil
+	}
+	var data []byte
+	if data, err = ioutil.ReadFile(fileName); err != nil {
+		return nil, fmt.Errorf("failed to read association outbox, %v", err)
+	}
+	if err = json.Unmarshal(data, &content); err != nil {
+		return nil, fmt.Errorf("association outbox %v is corrupted, %v", fileName, err)
+	}
+	return content.Messages, nil
+}
+
+// RegisterSender sets the sender delivering the messages of a kind
+func (o *Outbox) RegisterSender(kind string, sender Sender) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.senders[kind] = sender
+}
+
+// Enqueue persists an update to deliver.
+// A pending status update of the same association run is superseded by the new one, since only the latest is kept by the service,
+// and a pending compliance update is superseded by any new compliance update of the same kind, since it carries all the compliance items.
+func (o *Outbox) Enqueue(log log.T, kind string, associationID string, runID string, payload interface{}) (err error) {
+	var data []byte
+	if data, err = json.Marshal(payload); err != nil {
+		return fmt.Errorf("failed to marshal %v update, %v", kind, err)
+	}
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.load(log)
+
+	now := time.Now().UTC()
+	messages := []Message{}
+	for _, message := range o.content.Messages {
+		if supersedes(kind, associationID, runID, message) {
+			log.Debugf("Dropping %v update %v of association %v superseded by a newer update", message.Kind, message.Sequence, message.AssociationID)
+			continue
+		}
+		messages = append(messages, message)
+	}
+	o.content.NextSequence++
+	messages = append(messages, Message{
+		Sequence:        o.content.NextSequence,
+		Kind:            kind,
+		AssociationID:   associationID,
+		RunID:           runID,
+		CreatedTime:     now,
+		NextAttemptTime: now,
+		Payload:         data,
+	})
+	if len(messages) > maxMessages {
+		log.Errorf("Association outbox is full, dropping %v oldest updates", len(messages)-maxMessages)
+		messages = messages[len(messages)-maxMessages:]
+	}
+	o.content.Messages = messages
+	return o.save()
+}
+
+// Flush delivers the messages that are due in order, it stops at the first message that fails so it's retried first after its backoff
+func (o *Outbox) Flush(log log.T) {
+	o.flushLock.Lock()
+	defer o.flushLock.Unlock()
+
+	for {
+		message, sender, found := o.nextDue(log)
+		if !found {
+			return
+		}
+
+		var err error
+		if sender == nil {
+			err = Permanent(fmt.Errorf("no sender for %v updates", message.Kind))
+		} else {
+			err = sender(log, message)
+		}
+
+		if !o.complete(log, message, err) {
+			return
+		}
+	}
+}
+
+// nextDue returns the oldest message if it's due
+func (o *Outbox) nextDue(log log.T) (message Message, sender Sender, found bool) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.load(log)
+
+	if len(o.content.Messages) == 0 {
+		return
+	}
+	message = o.content.Messages[0]
+	if message.NextAttemptTime.After(time.Now().UTC()) {
+		return
+	}
+	return message, o.senders[message.Kind], true
+}
+
+// complete records the result of a delivery, it returns false when the flush should stop
+func (o *Outbox) complete(log log.T, message Message, deliveryErr error) bool {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	index := -1
+	for i, pending := range o.content.Messages {
+		if pending.Sequence == message.Sequence {
+			index = i
+			break
+		}
+	}
+	// the message was superseded while it was delivered
+	if index < 0 {
+		return deliveryErr == nil
+	}
+
+	pending := &o.content.Messages[index]
+	remove := deliveryErr == nil
+	if deliveryErr != nil {
+		pending.Attempts++
+		pending.LastError = deliveryErr.Error()
+		if _, permanent := deliveryErr.(permanentError); permanent {
+			log.Errorf("Dropping %v update of association %v, %v", pending.Kind, pending.AssociationID, deliveryErr)
+			remove = true
+		} else if pending.Attempts >= maxAttempts || time.Since(pending.CreatedTime) > maxMessageAge {
+			log.Errorf("Dropping %v update of association %v after %v attempts, %v", pending.Kind, pending.AssociationID, pending.Attempts, deliveryErr)
+			remove = true
+		} else {
+			pending.NextAttemptTime = time.Now().UTC().Add(backoff(pending.Attempts))
+			log.Infof("Delivery of %v update of association %v failed, retrying at %v, %v",
+				pending.Kind, pending.AssociationID, pending.NextAttemptTime, deliveryErr)
+		}
+	}
+	if remove {
+		o.content.Messages = append(o.content.Messages[:index], o.content.Messages[index+1:]...)
+	}
+
+	if err := o.save(); err != nil {
+		log.Errorf("Unable to persist association outbox, %v", err)
+	}
+	if deliveryErr != nil {
+		if _, permanent := deliveryErr.(permanentError); permanent {
+			return true
+		}
+		return false
+	}
+	return true
+}
+
+// load reads the outbox file the first time the outbox is used, lock must be held by the caller
+func (o *Outbox) load(log log.T) {
+	if o.loaded {
+		return
+	}
+	o.loaded = true
+	o.content = outboxFile{Messages: []Message{}}
+
+	fileName := filepath.Join(o.location, OutboxFileName)
+	if !fileutil.Exists(fileName) {
+		return
+	}
+	data, err := ioutil.ReadFile(fileName)
+	if err == nil {
+		err = json.Unmarshal(data, &o.content)
+	}
+	if err != nil {
+		log.Errorf("Discarding unreadable association outbox %v, %v", fileName, err)
+		o.content = outboxFile{Messages: []Message{}}
+		return
+	}
+	log.Infof("Loaded %v pending association updates", len(o.content.Messages))
+}
+
+// save writes the outbox file, lock must be held by the caller
+func (o *Outbox) save() (err error) {
+	var data []byte
+	if data, err = json.Marshal(o.content); err != nil {
+		return fmt.Errorf("failed to marshal association outbox, %v", err)
+	}
+	if err = fileutil.MakeDirs(o.location); err != nil {
+		return fmt.Errorf("cannot make directory of %v because: %v", o.location, err)
+	}
+	// write a temporary file first so a crash while saving never leaves a partial outbox
+	fileName := filepath.Join(o.location, OutboxFileName)
+	tempFileName := fileName + ".tmp"
+	if err = ioutil.WriteFile(tempFileName, data, os.FileMode(int(appconfig.ReadWriteAccess))); err != nil {
+		return fmt.Errorf("failed to write association outbox, %v", err)
+	}
+	if err = os.Rename(tempFileName, fileName); err != nil {
+		os.Remove(tempFileName)
+		return fmt.Errorf("failed to write association outbox, %v", err)
+	}
+	return nil
+}
+
+// supersedes returns true if a new update of the given kind makes the pending message obsolete
+func supersedes(kind string, associationID string, runID string, pending Message) bool {
+	if pending.Kind != kind {
+		return false
+	}
+	if kind == KindAssociationCompliance || kind == KindCustomCompliance {
+		return true
+	}
+	return pending.AssociationID == associationID && pending.RunID == runID
+}
+
+// backoff returns the wait before the next attempt after the given number of failed attempts
+func backoff(attempts int) time.Duration {
+	wait := initialBackoff
+	for i := 1; i < attempts && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > maxBackoff {
+		wait = maxBackoff
+	}
+	return wait
+}
@@ -0,0 +1,159 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package outbox
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/stretchr/testify/assert"
+)
+
+type testUpdate struct {
+	Status string
+}
+
+// recordingSender records the delivered statuses and fails while failing is set
+type recordingSender struct {
+	delivered []string
+	failing   error
+}
+
+func (s *recordingSender) send(log log.T, message Message) error {
+	if s.failing != nil {
+		return s.failing
+	}
+	var update testUpdate
+	if err := json.Unmarshal(message.Payload, &update); err != nil {
+		return err
+	}
+	s.delivered = append(s.delivered, message.AssociationID+":"+update.Status)
+	return nil
+}
+
+func newTestOutbox(t *testing.T) (*Outbox, *recordingSender, string) {
+	location, err := ioutil.TempDir("", "outbox")
+	assert.Nil(t, err)
+	sender := &recordingSender{}
+	box := NewOutboxWithLocation(location)
+	box.RegisterSender(KindAssociationStatus, sender.send)
+	box.RegisterSender(KindAssociationCompliance, sender.send)
+	return box, sender, location
+}
+
+func TestFlushDeliversInOrder(t *testing.T) {
+	box, sender, location := newTestOutbox(t)
+	defer os.RemoveAll(location)
+	logger := log.NewMockLog()
+
+	assert.Nil(t, box.Enqueue(logger, KindAssociationStatus, "assoc-1", "run-1", testUpdate{"InProgress"}))
+	assert.Nil(t, box.Enqueue(logger, KindAssociationStatus, "assoc-2", "run-1", testUpdate{"Success"}))
+	box.Flush(logger)
+
+	assert.Equal(t, []string{"assoc-1:InProgress", "assoc-2:Success"}, sender.delivered)
+	messages, err := ReadMessages(location)
+	assert.Nil(t, err)
+	assert.Empty(t, messages)
+}
+
+func TestFailedDeliveryIsRetriedAfterRestart(t *testing.T) {
+	box, sender, location := newTestOutbox(t)
+	defer os.RemoveAll(location)
+	logger := log.NewMockLog()
+
+	sender.failing = errors.New("RequestError: send request failed")
+	assert.Nil(t, box.Enqueue(logger, KindAssociationStatus, "assoc-1", "run-1", testUpdate{"Pending"}))
+	box.Flush(logger)
+	assert.Nil(t, box.Enqueue(logger, KindAssociationStatus, "assoc-1", "run-1", testUpdate{"Success"}))
+	assert.Nil(t, box.Enqueue(logger, KindAssociationStatus, "assoc-1", "run-2", testUpdate{"Failed"}))
+	box.Flush(logger)
+
+	// the status of run-1 superseded the pending one, run-2 waits behind it
+	messages, err := ReadMessages(location)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(messages))
+	assert.Equal(t, "run-1", messages[0].RunID)
+	assert.Equal(t, 1, messages[0].Attempts)
+	assert.Equal(t, "RequestError: send request failed", messages[0].LastError)
+	assert.True(t, messages[0].NextAttemptTime.After(time.Now()))
+	assert.Equal(t, "run-2", messages[1].RunID)
+	assert.Equal(t, 0, messages[1].Attempts)
+
+	// a restarted agent delivers the pending updates once the retry is due
+	restarted := NewOutboxWithLocation(location)
+	sender = &recordingSender{}
+	restarted.RegisterSender(KindAssociationStatus, sender.send)
+	restarted.lock.Lock()
+	restarted.load(logger)
+	restarted.content.Messages[0].NextAttemptTime = time.Now().Add(-time.Second)
+	restarted.lock.Unlock()
+	restarted.Flush(logger)
+	assert.Equal(t, []string{"assoc-1:Success", "assoc-1:Failed"}, sender.delivered)
+}
+
+func TestPermanentErrorDropsMessage(t *testing.T) {
+	box, sender, location := newTestOutbox(t)
+	defer os.RemoveAll(location)
+	logger := log.NewMockLog()
+
+	sender.failing = ClassifyAwsError(awserr.NewRequestFailure(awserr.New("AssociationDoesNotExist", "not found", nil), 400, "request"))
+	assert.Nil(t, box.Enqueue(logger, KindAssociationStatus, "assoc-1", "run-1", testUpdate{"Success"}))
+	box.Flush(logger)
+
+	messages, err := ReadMessages(location)
+	assert.Nil(t, err)
+	assert.Empty(t, messages)
+}
+
+func TestComplianceUpdateSupersedesPendingCompliance(t *testing.T) {
+	box, sender, location := newTestOutbox(t)
+	defer os.RemoveAll(location)
+	logger := log.NewMockLog()
+
+	sender.failing = errors.New("RequestError: send request failed")
+	assert.Nil(t, box.Enqueue(logger, KindAssociationCompliance, "assoc-1", "", testUpdate{"Compliant"}))
+	assert.Nil(t, box.Enqueue(logger, KindAssociationCompliance, "assoc-2", "", testUpdate{"NonCompliant"}))
+
+	messages, err := ReadMessages(location)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(messages))
+	assert.Equal(t, "assoc-2", messages[0].AssociationID)
+}
+
+func TestClassifyAwsError(t *testing.T) {
+	throttled := awserr.NewRequestFailure(awserr.New("ThrottlingException", "rate exceeded", nil), 400, "request")
+	serverError := awserr.NewRequestFailure(awserr.New("InternalServerError", "error", nil), 500, "request")
+	rejected := awserr.NewRequestFailure(awserr.New("InvalidInstanceId", "invalid", nil), 400, "request")
+
+	_, permanent := ClassifyAwsError(throttled).(permanentError)
+	assert.False(t, permanent)
+	_, permanent = ClassifyAwsError(serverError).(permanentError)
+	assert.False(t, permanent)
+	_, permanent = ClassifyAwsError(rejected).(permanentError)
+	assert.True(t, permanent)
+	_, permanent = ClassifyAwsError(errors.New("connection refused")).(permanentError)
+	assert.False(t, permanent)
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, backoff(1))
+	assert.Equal(t, time.Minute, backoff(2))
+	assert.Equal(t, time.Hour, backoff(maxAttempts))
+}
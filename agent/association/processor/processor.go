@@ -22,14 +22,22 @@ import (
 	"regexp"
 
 	"path"
+	"sort"
 	"strings"
 
 	"github.com/aws/amazon-ssm-agent/agent/association/cache"
+	"github.com/aws/amazon-ssm-agent/agent/association/history"
+	"github.com/aws/amazon-ssm-agent/agent/association/maintenancewindow"
 	"github.com/aws/amazon-ssm-agent/agent/association/model"
+	"github.com/aws/amazon-ssm-agent/agent/association/outbox"
+	"github.com/aws/amazon-ssm-agent/agent/association/resources"
+	"github.com/aws/amazon-ssm-agent/agent/association/runrequest"
 	"github.com/aws/amazon-ssm-agent/agent/association/schedulemanager"
 	"github.com/aws/amazon-ssm-agent/agent/association/schedulemanager/signal"
 	assocScheduler "github.com/aws/amazon-ssm-agent/agent/association/scheduler"
+	"github.com/aws/amazon-ssm-agent/agent/association/schedulestore"
 	"github.com/aws/amazon-ssm-agent/agent/association/service"
+	"github.com/aws/amazon-ssm-agent/agent/association/trigger"
 	complianceUploader "github.com/aws/amazon-ssm-agent/agent/compliance/uploader"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
@@ -39,7 +47,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/platform"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
 	"github.com/aws/amazon-ssm-agent/agent/times"
-	"github.com/carlescere/scheduler"
+	"github.com/aws/aws-sdk-go/aws"
 )
 
 const (
@@ -49,11 +57,13 @@ const (
 	documentLevelTimeOutDurationHour        = 2
 	outputMessageTemplate            string = "%v out of %v plugin%v processed, %v success, %v failed, %v timedout, %v skipped"
 	defaultRetryWaitOnBootInSeconds         = 30
+	// runRequestsPollIntervalSeconds is how often the requests to run associations now are checked
+	runRequestsPollIntervalSeconds = 10
 )
 
 // Processor contains the logic for processing association
 type Processor struct {
-	pollJob            *scheduler.Job
+	poller             *assocScheduler.Poller
 	assocSvc           service.T
 	complianceUploader complianceUploader.T
 	context            context.T
@@ -63,10 +73,26 @@ type Processor struct {
 	resChan            chan contracts.DocumentResult
 	defaultPlugin      pluginutil.DefaultPlugin
 	onBoot             bool
+	scheduleStore      schedulestore.T
+	outbox             outbox.T
+	maintenanceWindows maintenancewindow.T
+	workersLimit       int
+	history            history.T
+	runRequests        string
+	// takenRunRequests are the run requests that made their association due, by association id,
+	// they are removed once the run of the association is recorded
+	takenRunRequests map[string]runrequest.Request
+	runRequestsLock  sync.Mutex
+	triggers         *trigger.Manager
+	// polledAssociations identifies the associations returned by the last successful poll, nil before the first poll
+	polledAssociations *string
 }
 
 var lock sync.RWMutex
 
+// stopExecutionSignal stops running the scheduled associations, e.g. when an association requested a reboot
+var stopExecutionSignal = signal.StopExecutionSignal
+
 // NewAssociationProcessor returns a new Processor with the given context.
 func NewAssociationProcessor(context context.T, instanceID string) *Processor {
 	assocContext := context.With("[" + name + "]")
@@ -82,13 +108,15 @@ func NewAssociationProcessor(context context.T, instanceID string) *Processor {
 		OsVersion: config.Os.Version,
 	}
 
-	assocSvc := service.NewAssociationService(name)
-	uploader := complianceUploader.NewComplianceUploader(context)
+	// status and compliance updates share the outbox so they are delivered in the order of execution
+	updates := outbox.NewOutbox(instanceID)
+	assocSvc := service.NewAssociationService(name, updates)
+	uploader := complianceUploader.NewComplianceUploader(context, instanceID, updates)
 
 	//TODO Rename everything to service and move package to framework
 	//association has no cancel worker
-	proc := processor.NewEngineProcessor(assocContext, documentWorkersLimit, documentWorkersLimit, []contracts.DocumentType{contracts.Association})
-	return &Processor{
+	proc := processor.NewEngineProcessor(assocContext, config.Ssm.AssociationWorkersLimit, documentWorkersLimit, []contracts.DocumentType{contracts.Association})
+	p := &Processor{
 		context:            assocContext,
 		assocSvc:           assocSvc,
 		complianceUploader: uploader,
@@ -96,7 +124,16 @@ func NewAssociationProcessor(context context.T, instanceID string) *Processor {
 		stopSignal:         make(chan bool),
 		proc:               proc,
 		onBoot:             true,
+		scheduleStore:      schedulestore.NewScheduleStore(instanceID),
+		outbox:             updates,
+		maintenanceWindows: maintenancewindow.NewMaintenanceWindows(instanceID, config.Ssm),
+		workersLimit:       config.Ssm.AssociationWorkersLimit,
+		history:            history.NewHistory(instanceID),
+		runRequests:        runrequest.GetLocation(instanceID),
 	}
+	debounce := time.Duration(config.Ssm.AssociationTriggerDebounceSeconds) * time.Second
+	p.triggers = trigger.NewManager(assocContext.Log(), instanceID, debounce, p.runTriggeredAssociation)
+	return p
 }
 
 // StartAssociationWorker starts worker to process scheduled association
@@ -111,37 +148,44 @@ func (p *Processor) InitializeAssociationProcessor() {
 	log.Info("Initializing association scheduling service")
 	signal.InitializeAssociationSignalService(log, p.runScheduledAssociation)
 	log.Info("Association scheduling service initialized")
+	p.restoreSchedule(log)
 	log.Info("Launching response handler")
 	go p.lisenToResponses()
+	go p.watchRunRequests()
 }
 
-// SetPollJob represents setter for PollJob
-func (p *Processor) SetPollJob(job *scheduler.Job) {
-	p.pollJob = job
+// SetPoller represents setter for Poller
+func (p *Processor) SetPoller(poller *assocScheduler.Poller) {
+	p.poller = poller
 }
 
-// ProcessAssociation poll and process all the associations
-func (p *Processor) ProcessAssociation() {
+// ProcessAssociation poll and process all the associations, the result adapts the interval until the next poll
+func (p *Processor) ProcessAssociation() assocScheduler.PollResult {
 	log := p.context.Log()
 	associations := []*model.InstanceAssociation{}
 
 	if p.isStopped() {
 		log.Debug("Stopping association processor...")
-		return
+		return assocScheduler.PollUnchanged
 	}
 
 	instanceID, err := sys.InstanceID()
 	if err != nil {
 		log.Error("Unable to retrieve instance id", err)
-		return
+		return assocScheduler.PollFailed
 	}
 
 	p.assocSvc.CreateNewServiceIfUnHealthy(log)
 	p.complianceUploader.CreateNewServiceIfUnHealthy(log)
 
+	// retry the updates that couldn't be delivered
+	if p.outbox != nil {
+		p.outbox.Flush(log)
+	}
+
 	if associations, err = p.assocSvc.ListInstanceAssociations(log, instanceID); err != nil {
 		log.Errorf("Unable to load instance associations, %v", err)
-		return
+		return assocScheduler.PollFailed
 	}
 
 	// to account for any tag expansion delays on boot, call list associations again
@@ -152,7 +196,7 @@ func (p *Processor) ProcessAssociation() {
 			time.Sleep(defaultRetryWaitOnBootInSeconds * time.Second)
 			if associations, err = p.assocSvc.ListInstanceAssociations(log, instanceID); err != nil {
 				log.Errorf("Unable to load instance associations, %v", err)
-				return
+				return assocScheduler.PollFailed
 			}
 		}
 	}
@@ -163,10 +207,11 @@ func (p *Processor) ProcessAssociation() {
 	}
 
 	// read from cache or load association details from service
+	result := p.pollResult(associations)
 	for _, assoc := range associations {
 		var assocContent string
 		if assocContent, err = jsonutil.Marshal(assoc); err != nil {
-			return
+			return assocScheduler.PollFailed
 		}
 		log.Debug("Association content is \n", jsonutil.Indent(assocContent))
 
@@ -177,9 +222,12 @@ func (p *Processor) ProcessAssociation() {
 				err)
 			log.Error(err)
 			assoc.Errors = append(assoc.Errors, err)
+			// the details are loaded again by the next poll, which is sooner after a failure
+			result = assocScheduler.PollFailed
 			p.assocSvc.UpdateInstanceAssociationStatus(
 				log,
 				*assoc.Association.AssociationId,
+				service.NoRunID,
 				*assoc.Association.Name,
 				*assoc.Association.InstanceId,
 				contracts.AssociationStatusFailed,
@@ -206,6 +254,7 @@ func (p *Processor) ProcessAssociation() {
 				p.assocSvc.UpdateInstanceAssociationStatus(
 					log,
 					*assoc.Association.AssociationId,
+					service.NoRunID,
 					*assoc.Association.Name,
 					*assoc.Association.InstanceId,
 					contracts.AssociationStatusFailed,
@@ -227,10 +276,174 @@ func (p *Processor) ProcessAssociation() {
 	}
 
 	schedulemanager.Refresh(log, associations)
+	p.saveSchedule(log)
+	p.updateTriggers(log)
 	signal.ExecuteAssociation(log)
+	return result
 }
 
-// runScheduledAssociation runs the next scheduled association
+// pollResult returns if the polled associations changed since the last successful poll,
+// the associations are identified by their id and checksum which changes when they are updated
+func (p *Processor) pollResult(associations []*model.InstanceAssociation) assocScheduler.PollResult {
+	identities := []string{}
+	for _, assoc := range associations {
+		identities = append(identities, aws.StringValue(assoc.Association.AssociationId)+":"+aws.StringValue(assoc.Association.Checksum))
+	}
+	sort.Strings(identities)
+	polledAssociations := strings.Join(identities, ",")
+
+	if p.polledAssociations != nil && *p.polledAssociations == polledAssociations {
+		return assocScheduler.PollUnchanged
+	}
+	p.polledAssociations = &polledAssociations
+	return assocScheduler.PollChanged
+}
+
+// restoreSchedule schedules the associations persisted before the agent restarted,
+// so associations keep running when the service isn't reachable on boot.
+// The schedule is reconciled with the service by the next successful poll.
+func (p *Processor) restoreSchedule(log log.T) {
+	if p.scheduleStore == nil {
+		return
+	}
+
+	associations, err := p.scheduleStore.Load(log)
+	if err != nil {
+		log.Errorf("Unable to restore association schedule, %v", err)
+		return
+	}
+	if len(associations) == 0 {
+		return
+	}
+
+	// the triggers of the restored associations fire even when the service isn't reachable on boot
+	defer p.updateTriggers(log)
+	if !schedulemanager.Restore(log, associations) {
+		log.Debug("Association schedule already refreshed from the service, skipping persisted schedule")
+		return
+	}
+	// documents of the restored associations are reused unless the association checksum changes
+	associationCache := cache.GetCache()
+	for _, assoc := range associations {
+		associationCache.Add(*assoc.Association.AssociationId, assoc)
+	}
+	signal.ExecuteAssociation(log)
+}
+
+// watchRunRequests runs the associations requested to run now from the cli, until the processor is stopped
+func (p *Processor) watchRunRequests() {
+	ticker := time.NewTicker(runRequestsPollIntervalSeconds * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stopSignal:
+			return
+		case <-ticker.C:
+			p.processRunRequests(p.context.Log())
+		}
+	}
+}
+
+// processRunRequests makes the associations requested to run now due, they still wait for the local maintenance windows
+func (p *Processor) processRunRequests(log log.T) {
+	if p.runRequests == "" {
+		return
+	}
+
+	p.runRequestsLock.Lock()
+	defer p.runRequestsLock.Unlock()
+	if p.takenRunRequests == nil {
+		p.takenRunRequests = map[string]runrequest.Request{}
+	}
+
+	requested := false
+	for _, request := range runrequest.List(log, p.runRequests) {
+		// keep the request until the current run completes so it is not lost
+		if schedulemanager.IsAssociationInProgress(request.AssociationID) {
+			log.Infof("Association %v requested to run now is in progress, it will run again once the current run completes", request.AssociationID)
+			continue
+		}
+		if taken, found := p.takenRunRequests[request.AssociationID]; found &&
+			taken.RequestedTime.Equal(request.RequestedTime) && schedulemanager.IsRunRequested(request.AssociationID) {
+			continue
+		}
+		if !schedulemanager.RunAssociationNow(log, request.AssociationID) {
+			log.Errorf("Association %v requested to run now is not scheduled on this instance", request.AssociationID)
+			runrequest.Remove(log, p.runRequests, request)
+			continue
+		}
+		// the request is removed once the run is recorded, so it isn't lost if the run is deferred or the agent stops
+		p.takenRunRequests[request.AssociationID] = request
+		requested = true
+	}
+	if requested {
+		p.saveSchedule(log)
+		signal.ExecuteAssociation(log)
+	}
+}
+
+// completeRunRequest removes the run request that made the association due once its run is recorded
+func (p *Processor) completeRunRequest(log log.T, associationID string) {
+	p.runRequestsLock.Lock()
+	defer p.runRequestsLock.Unlock()
+
+	if request, found := p.takenRunRequests[associationID]; found {
+		delete(p.takenRunRequests, associationID)
+		runrequest.Remove(log, p.runRequests, request)
+	}
+}
+
+// updateTriggers watches the events of the triggers of the scheduled associations
+func (p *Processor) updateTriggers(log log.T) {
+	if p.triggers == nil {
+		return
+	}
+
+	p.triggers.Update(log, schedulemanager.Schedules())
+}
+
+// runTriggeredAssociation makes an association due when one of its triggers fires,
+// triggered runs wait for the local maintenance windows like scheduled runs
+func (p *Processor) runTriggeredAssociation(log log.T, associationID string, reason string) {
+	if p.isStopped() {
+		return
+	}
+	if schedulemanager.IsAssociationInProgress(associationID) {
+		log.Infof("Association %v triggered by %v is already in progress", associationID, reason)
+		return
+	}
+	if !schedulemanager.RunAssociationNow(log, associationID) {
+		log.Debugf("Association %v triggered by %v is no longer scheduled", associationID, reason)
+		return
+	}
+	p.saveSchedule(log)
+	signal.ExecuteAssociation(log)
+}
+
+// recordRun adds the run to the local association history
+func (p *Processor) recordRun(log log.T, run history.Run) {
+	if p.history == nil {
+		return
+	}
+
+	if err := p.history.Record(log, run); err != nil {
+		log.Errorf("Unable to record association run in the history, %v", err)
+	}
+}
+
+// saveSchedule persists the association schedule with the last execution date and status of each association
+func (p *Processor) saveSchedule(log log.T) {
+	if p.scheduleStore == nil {
+		return
+	}
+
+	if err := p.scheduleStore.Save(log, schedulemanager.ScheduleSnapshot()); err != nil {
+		log.Errorf("Unable to persist association schedule, %v", err)
+	}
+}
+
+// runScheduledAssociation runs the due associations. Associations touching different resources run at the same time
+// up to the workers limit, the others run once the associations touching their resources complete.
 func (p *Processor) runScheduledAssociation(log log.T) {
 	lock.Lock()
 	defer lock.Unlock()
@@ -242,37 +455,42 @@ func (p *Processor) runScheduledAssociation(log log.T) {
 		}
 	}()
 
-	var (
-		scheduledAssociation *model.InstanceAssociation
-		err                  error
-	)
-
-	if scheduledAssociation, err = schedulemanager.LoadNextScheduledAssociation(log); err != nil {
-		log.Errorf("Unable to get next scheduled association, %v, system will retry later", err)
-		return
+	currentTime := time.Now().UTC()
+	dueAssociations := p.loadRunnableAssociations(log, currentTime)
+	if len(dueAssociations) > 0 {
+		// persist the status of the associations so they aren't run again if the agent restarts while they run
+		defer p.saveSchedule(log)
+	}
+	for _, scheduledAssociation := range dueAssociations {
+		p.startAssociation(log, scheduledAssociation)
 	}
 
-	if scheduledAssociation == nil {
-		// if no scheduled association found at given time, get the next scheduled time and wait
-		nextScheduledDate := schedulemanager.LoadNextScheduledDate(log)
-		if nextScheduledDate != nil {
-			signal.ResetWaitTimerForNextScheduledAssociation(log, *nextScheduledDate)
-		} else {
-			log.Debug("No association scheduled at this time, system will retry later")
-		}
-		return
+	// due associations that could not start are run when a running association completes
+	nextScheduledDate := schedulemanager.LoadNextScheduledDateAfter(log, currentTime)
+	if nextScheduledDate != nil {
+		signal.ResetWaitTimerForNextScheduledAssociation(log, *nextScheduledDate)
+	} else {
+		log.Debug("No association scheduled at this time, system will retry later")
 	}
+}
 
-	// stop previous wait timer if there is scheduled association
-	signal.StopWaitTimerForNextScheduledAssociation()
+// startAssociation submits a due association unless it is in progress, all the workers are busy
+// or it touches the resources of a running association
+func (p *Processor) startAssociation(log log.T, scheduledAssociation *model.InstanceAssociation) {
+	var err error
+	associationID := *scheduledAssociation.Association.AssociationId
 
-	if schedulemanager.IsAssociationInProgress(*scheduledAssociation.Association.AssociationId) {
+	if schedulemanager.IsAssociationInProgress(associationID) {
+		if schedulemanager.IsAssociationRunning(associationID) {
+			return
+		}
 		if isAssociationTimedOut(scheduledAssociation) {
 			err = fmt.Errorf("Association stuck at InProgress for longer than %v hours", documentLevelTimeOutDurationHour)
 			log.Error(err)
 			p.assocSvc.UpdateInstanceAssociationStatus(
 				log,
 				*scheduledAssociation.Association.AssociationId,
+				service.NoRunID,
 				*scheduledAssociation.Association.Name,
 				*scheduledAssociation.Association.InstanceId,
 				contracts.AssociationStatusFailed,
@@ -293,21 +511,19 @@ func (p *Processor) runScheduledAssociation(log log.T) {
 		return
 	}
 
-	log.Debugf("Update association %v to pending ", *scheduledAssociation.Association.AssociationId)
-	// Update association status to pending
-	p.assocSvc.UpdateInstanceAssociationStatus(
-		log,
-		*scheduledAssociation.Association.AssociationId,
-		*scheduledAssociation.Association.Name,
-		*scheduledAssociation.Association.InstanceId,
-		contracts.AssociationStatusPending,
-		contracts.AssociationErrorCodeNoError,
-		times.ToIso8601UTC(time.Now()),
-		contracts.AssociationPendingMessage,
-		service.NoOutputUrl)
+	touched := resources.ExclusiveSet()
+	if scheduledAssociation.Document != nil {
+		if touched, err = resources.ForDocument(*scheduledAssociation.Document); err != nil {
+			log.Errorf("Association %v runs alone, %v", associationID, err)
+		}
+	}
+	if !schedulemanager.StartAssociation(log, associationID, touched, p.workersLimit) {
+		return
+	}
 
 	var docState *contracts.DocumentState
 	if docState, err = p.parseAssociation(scheduledAssociation); err != nil {
+		schedulemanager.CompleteAssociation(associationID)
 		err = fmt.Errorf("Encountered error while parsing association %v, %v",
 			docState.DocumentInformation.AssociationID,
 			err)
@@ -315,6 +531,7 @@ func (p *Processor) runScheduledAssociation(log log.T) {
 		p.assocSvc.UpdateInstanceAssociationStatus(
 			log,
 			*scheduledAssociation.Association.AssociationId,
+			docState.DocumentInformation.RunID,
 			*scheduledAssociation.Association.Name,
 			*scheduledAssociation.Association.InstanceId,
 			contracts.AssociationStatusFailed,
@@ -329,14 +546,49 @@ func (p *Processor) runScheduledAssociation(log log.T) {
 			*scheduledAssociation.Association.DocumentVersion,
 			contracts.AssociationStatusFailed,
 			time.Now().UTC())
+		failedTime := time.Now().UTC()
+		p.recordRun(log, history.Run{
+			AssociationID:   associationID,
+			DocumentName:    *scheduledAssociation.Association.Name,
+			DocumentVersion: *scheduledAssociation.Association.DocumentVersion,
+			RunID:           docState.DocumentInformation.RunID,
+			StartDateTime:   failedTime,
+			EndDateTime:     &failedTime,
+			Status:          contracts.AssociationStatusFailed,
+			Message:         err.Error(),
+		})
 		return
 	}
+
+	if p.isAuditOnly(scheduledAssociation) {
+		log.Infof("Association %v runs in audit mode, changes are reported without being applied", associationID)
+		for i := range docState.InstancePluginsInformation {
+			docState.InstancePluginsInformation[i].Configuration.AuditOnly = true
+		}
+	}
+
+	// the association is parsed first so the status updates of the run all carry its run id
+	log.Debugf("Update association %v to pending ", *scheduledAssociation.Association.AssociationId)
+	// Update association status to pending
+	p.assocSvc.UpdateInstanceAssociationStatus(
+		log,
+		*scheduledAssociation.Association.AssociationId,
+		docState.DocumentInformation.RunID,
+		*scheduledAssociation.Association.Name,
+		*scheduledAssociation.Association.InstanceId,
+		contracts.AssociationStatusPending,
+		contracts.AssociationErrorCodeNoError,
+		times.ToIso8601UTC(time.Now()),
+		contracts.AssociationPendingMessage,
+		service.NoOutputUrl)
+
 	updatePluginAssociationInstances(*scheduledAssociation.Association.AssociationId, docState)
 	log = p.context.With("[associationId=" + docState.DocumentInformation.AssociationID + "]").Log()
 	instanceID, _ := sys.InstanceID()
 	p.assocSvc.UpdateInstanceAssociationStatus(
 		log,
 		docState.DocumentInformation.AssociationID,
+		docState.DocumentInformation.RunID,
 		docState.DocumentInformation.DocumentName,
 		instanceID,
 		contracts.AssociationStatusInProgress,
@@ -345,9 +597,76 @@ func (p *Processor) runScheduledAssociation(log log.T) {
 		contracts.AssociationInProgressMessage,
 		service.NoOutputUrl)
 
+	p.recordRun(log, history.Run{
+		AssociationID:   docState.DocumentInformation.AssociationID,
+		DocumentName:    docState.DocumentInformation.DocumentName,
+		DocumentVersion: docState.DocumentInformation.DocumentVersion,
+		RunID:           docState.DocumentInformation.RunID,
+		StartDateTime:   time.Now().UTC(),
+		Status:          contracts.AssociationStatusInProgress,
+	})
 	p.proc.Submit(*docState)
 }
 
+// loadRunnableAssociations returns the due associations that the local maintenance windows allow to run,
+// due associations outside of the windows are deferred to the next window
+func (p *Processor) loadRunnableAssociations(log log.T, currentTime time.Time) []*model.InstanceAssociation {
+	runnable := []*model.InstanceAssociation{}
+	for _, scheduledAssociation := range schedulemanager.LoadDueAssociations(log, currentTime) {
+		// running associations stay due until they complete
+		if schedulemanager.IsAssociationRunning(*scheduledAssociation.Association.AssociationId) {
+			continue
+		}
+		if p.maintenanceWindows == nil {
+			runnable = append(runnable, scheduledAssociation)
+			continue
+		}
+
+		deferral := p.maintenanceWindows.Check(log, *scheduledAssociation.Association.Name, currentTime)
+		if deferral == nil {
+			runnable = append(runnable, scheduledAssociation)
+			continue
+		}
+		if schedulemanager.DeferAssociation(log, *scheduledAssociation.Association.AssociationId, deferral.NextCheck, deferral.Until, deferral.Reason) {
+			p.reportDeferral(log, scheduledAssociation, deferral)
+		}
+	}
+	return runnable
+}
+
+// isAuditOnly returns if the association runs in audit mode, for every association or as one of the audited associations
+func (p *Processor) isAuditOnly(scheduledAssociation *model.InstanceAssociation) bool {
+	config := p.context.AppConfig()
+	if config.Ssm.AssociationAuditMode {
+		return true
+	}
+	for _, audited := range config.Ssm.AssociationAuditOnly {
+		if audited == *scheduledAssociation.Association.AssociationId || audited == *scheduledAssociation.Association.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// reportDeferral updates the association status with the deferral so it is visible in the service
+func (p *Processor) reportDeferral(log log.T, assoc *model.InstanceAssociation, deferral *maintenancewindow.Deferral) {
+	until := "until further notice"
+	if deferral.Until != nil {
+		until = "until " + times.ToIso8601UTC(*deferral.Until)
+	}
+	p.assocSvc.UpdateInstanceAssociationStatus(
+		log,
+		*assoc.Association.AssociationId,
+		service.NoRunID,
+		*assoc.Association.Name,
+		*assoc.Association.InstanceId,
+		contracts.AssociationStatusPending,
+		contracts.AssociationErrorCodeNoError,
+		times.ToIso8601UTC(time.Now()),
+		fmt.Sprintf(contracts.AssociationDeferredMessage, until, deferral.Reason),
+		service.NoOutputUrl)
+}
+
 func isAssociationTimedOut(assoc *model.InstanceAssociation) bool {
 	if assoc.Association.LastExecutionDate == nil {
 		return false
@@ -369,8 +688,11 @@ func (p *Processor) Stop() {
 		close(p.stopSignal)
 	}
 
-	assocScheduler.Stop(p.pollJob)
+	p.poller.Stop()
 	signal.Stop()
+	if p.triggers != nil {
+		p.triggers.Stop()
+	}
 }
 
 // isStopped returns if the association processor has been stopped
@@ -433,6 +755,7 @@ func (p *Processor) parseAssociation(rawData *model.InstanceAssociation) (*contr
 func (r *Processor) pluginExecutionReport(
 	log log.T,
 	associationID string,
+	runID string,
 	pluginID string,
 	outputs map[string]*contracts.PluginResult,
 	totalNumberOfPlugins int) {
@@ -462,6 +785,7 @@ func (r *Processor) pluginExecutionReport(
 	r.assocSvc.UpdateInstanceAssociationStatus(
 		log,
 		associationID,
+		runID,
 		"",
 		instanceID,
 		contracts.AssociationStatusInProgress,
@@ -475,6 +799,7 @@ func (r *Processor) pluginExecutionReport(
 func (r *Processor) associationExecutionReport(
 	log log.T,
 	associationID string,
+	runID string,
 	documentName string,
 	documentVersion string,
 	outputs map[string]*contracts.PluginResult,
@@ -495,6 +820,7 @@ func (r *Processor) associationExecutionReport(
 	r.assocSvc.UpdateInstanceAssociationStatus(
 		log,
 		associationID,
+		runID,
 		documentName,
 		instanceID,
 		associationStatus,
@@ -512,16 +838,89 @@ func (r *Processor) associationExecutionReport(
 		time.Now().UTC())
 }
 
+// associationDriftReport updates the drift compliance with the changes found by the plugins that ran in audit mode,
+// the drift of an association is cleared once it runs without audit mode
+func (r *Processor) associationDriftReport(log log.T, res contracts.DocumentResult) {
+	audited := false
+	drift := []contracts.DriftItem{}
+	for _, pluginResult := range res.PluginResults {
+		if pluginResult != nil && pluginResult.Audited {
+			audited = true
+			drift = append(drift, pluginResult.Drift...)
+		}
+	}
+	instanceID, _ := sys.InstanceID()
+	if !audited {
+		// the drift found while the association ran in audit mode no longer applies
+		if err := r.complianceUploader.ClearAssociationDrift(res.AssociationID, instanceID, time.Now().UTC()); err != nil {
+			log.Errorf("Unable to clear association drift, %v", err)
+		}
+		return
+	}
+
+	log.Infof("Association %v found %v drift items", res.AssociationID, len(drift))
+	if err := r.complianceUploader.UpdateAssociationDrift(
+		res.AssociationID,
+		instanceID,
+		res.DocumentName,
+		res.DocumentVersion,
+		drift,
+		time.Now().UTC()); err != nil {
+		log.Errorf("Unable to update association drift, %v", err)
+	}
+}
+
+// customComplianceReport updates the custom compliance types with the compliance items reported by the plugins and scripts
+func (r *Processor) customComplianceReport(log log.T, res contracts.DocumentResult) {
+	items := []contracts.ComplianceItem{}
+	for _, pluginResult := range res.PluginResults {
+		if pluginResult != nil {
+			items = append(items, pluginResult.ComplianceItems...)
+		}
+	}
+
+	log.Debugf("Association %v reported %v compliance items", res.AssociationID, len(items))
+	instanceID, _ := sys.InstanceID()
+	if err := r.complianceUploader.UpdateCustomCompliance(
+		res.AssociationID,
+		instanceID,
+		res.DocumentName,
+		res.DocumentVersion,
+		items,
+		time.Now().UTC()); err != nil {
+		log.Errorf("Unable to update custom compliance, %v", err)
+	}
+}
+
+// associationHistoryReport records the completion of the association run, with the result of each step, in the history
+func (r *Processor) associationHistoryReport(log log.T, res contracts.DocumentResult) {
+	_, _, runtimeStatuses := contracts.DocumentResultAggregator(log, "", res.PluginResults)
+	executionSummary, _ := buildOutput(runtimeStatuses, res.NPlugins)
+	endTime := time.Now().UTC()
+	r.recordRun(log, history.Run{
+		AssociationID:   res.AssociationID,
+		DocumentName:    res.DocumentName,
+		DocumentVersion: res.DocumentVersion,
+		RunID:           res.RunID,
+		EndDateTime:     &endTime,
+		Status:          string(res.Status),
+		Message:         executionSummary,
+		Steps:           history.NewSteps(res.PluginResults),
+	})
+}
+
 func (r *Processor) lisenToResponses() {
 	log := r.context.Log()
 	for res := range r.resChan {
 		if res.LastPlugin != "" {
 			log.Infof("update association status upon plugin $v completion", res.LastPlugin)
-			r.pluginExecutionReport(log, res.AssociationID, res.LastPlugin, res.PluginResults, res.NPlugins)
+			r.pluginExecutionReport(log, res.AssociationID, res.RunID, res.LastPlugin, res.PluginResults, res.NPlugins)
 		}
 		if res.Status == contracts.ResultStatusSuccessAndReboot {
-			signal.StopExecutionSignal()
-			return
+			// no association starts before the reboot, the results of the associations still running are handled
+			// until the processor stops so their resources are released
+			stopExecutionSignal()
+			continue
 		}
 		//send asociation completion response
 		if res.LastPlugin == "" {
@@ -531,6 +930,7 @@ func (r *Processor) lisenToResponses() {
 				r.associationExecutionReport(
 					log,
 					res.AssociationID,
+					res.RunID,
 					res.DocumentName,
 					res.DocumentVersion,
 					res.PluginResults,
@@ -545,6 +945,7 @@ func (r *Processor) lisenToResponses() {
 				r.associationExecutionReport(
 					log,
 					res.AssociationID,
+					res.RunID,
 					res.DocumentName,
 					res.DocumentVersion,
 					res.PluginResults,
@@ -552,6 +953,15 @@ func (r *Processor) lisenToResponses() {
 					contracts.AssociationErrorCodeNoError,
 					string(res.Status))
 			}
+			if res.Status != contracts.ResultStatusFailed {
+				r.associationDriftReport(log, res)
+			}
+			r.customComplianceReport(log, res)
+			r.associationHistoryReport(log, res)
+			if r.triggers != nil {
+				r.triggers.RunRecorded(log, res.AssociationID)
+			}
+			r.completeRunRequest(log, res.AssociationID)
 			instanceID, _ := sys.InstanceID()
 			//clean association logs once the document state is moved to completed
 			//clean completed document state files and orchestration dirs. Takes care of only files generated by association in the folder
@@ -561,7 +971,9 @@ func (r *Processor) lisenToResponses() {
 				r.context.AppConfig().Ssm.AssociationLogsRetentionDurationHours,
 				isAssociationLogFile)
 			//TODO move this part to service
+			schedulemanager.CompleteAssociation(res.AssociationID)
 			schedulemanager.UpdateNextScheduledDate(log, res.AssociationID)
+			r.saveSchedule(log)
 			signal.ExecuteAssociation(log)
 
 		}
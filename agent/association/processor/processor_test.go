@@ -16,12 +16,19 @@ package processor
 
 import (
 	"errors"
+	"io/ioutil"
+	"os"
 	"testing"
 	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/association/maintenancewindow"
 	"github.com/aws/amazon-ssm-agent/agent/association/model"
+	"github.com/aws/amazon-ssm-agent/agent/association/resources"
+	"github.com/aws/amazon-ssm-agent/agent/association/runrequest"
 	"github.com/aws/amazon-ssm-agent/agent/association/schedulemanager"
+	"github.com/aws/amazon-ssm-agent/agent/association/schedulemanager/signal"
+	assocScheduler "github.com/aws/amazon-ssm-agent/agent/association/scheduler"
 	"github.com/aws/amazon-ssm-agent/agent/association/service"
 	complianceUploader "github.com/aws/amazon-ssm-agent/agent/compliance/uploader"
 	"github.com/aws/amazon-ssm-agent/agent/context"
@@ -31,7 +38,6 @@ import (
 	messageContracts "github.com/aws/amazon-ssm-agent/agent/runcommand/contracts"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ssm"
-	"github.com/carlescere/scheduler"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -44,14 +50,14 @@ func TestNewAssociationProcessor(t *testing.T) {
 	assert.NotNil(t, process)
 }
 
-func TestSetJob(t *testing.T) {
+func TestSetPoller(t *testing.T) {
 	processor := Processor{}
-	job := scheduler.Job{}
+	poller := assocScheduler.Poller{}
 
-	processor.SetPollJob(&job)
+	processor.SetPoller(&poller)
 
-	assert.NotNil(t, processor.pollJob)
-	assert.Equal(t, processor.pollJob, &job)
+	assert.NotNil(t, processor.poller)
+	assert.Equal(t, processor.poller, &poller)
 }
 
 func TestProcessAssociationUnableToGetAssociation(t *testing.T) {
@@ -75,8 +81,9 @@ func TestProcessAssociationUnableToGetAssociation(t *testing.T) {
 		mock.AnythingOfType("*model.InstanceAssociation")).Return(nil)
 	complianceUploader.On("CreateNewServiceIfUnHealthy", mock.AnythingOfType("*log.Mock"))
 
-	processor.ProcessAssociation()
+	result := processor.ProcessAssociation()
 
+	assert.Equal(t, assocScheduler.PollFailed, result)
 	assert.True(t, complianceUploader.AssertNumberOfCalls(t, "CreateNewServiceIfUnHealthy", 1))
 	assert.True(t, svcMock.AssertNumberOfCalls(t, "CreateNewServiceIfUnHealthy", 1))
 	assert.True(t, svcMock.AssertNumberOfCalls(t, "ListInstanceAssociations", 1))
@@ -234,6 +241,37 @@ func TestProcessAssociationSuccessful(t *testing.T) {
 	assert.True(t, complianceUploader.AssertNumberOfCalls(t, "UpdateAssociationCompliance", 0))
 }
 
+func TestProcessAssociationReturnsWhetherAssociationsChanged(t *testing.T) {
+	processor := createProcessor()
+	svcMock := service.NewMockDefault()
+	assocRawData := createAssociationRawData()
+	sys = &systemStub{}
+	complianceUploader := complianceUploader.NewMockDefault()
+
+	processor.assocSvc = svcMock
+	processor.complianceUploader = complianceUploader
+
+	svcMock.On("CreateNewServiceIfUnHealthy", mock.AnythingOfType("*log.Mock"))
+	svcMock.On(
+		"ListInstanceAssociations",
+		mock.AnythingOfType("*log.Mock"),
+		mock.AnythingOfType("string")).Return(assocRawData, nil)
+	svcMock.On(
+		"LoadAssociationDetail",
+		mock.AnythingOfType("*log.Mock"),
+		mock.AnythingOfType("*model.InstanceAssociation")).Return(nil)
+	complianceUploader.On("CreateNewServiceIfUnHealthy", mock.AnythingOfType("*log.Mock"))
+
+	// the associations of the first poll are new
+	assert.Equal(t, assocScheduler.PollChanged, processor.ProcessAssociation())
+	assert.Equal(t, assocScheduler.PollUnchanged, processor.ProcessAssociation())
+
+	// an updated association has a new checksum
+	assocRawData[0].Association.Checksum = aws.String("updated-checksum")
+	assert.Equal(t, assocScheduler.PollChanged, processor.ProcessAssociation())
+	assert.Equal(t, assocScheduler.PollUnchanged, processor.ProcessAssociation())
+}
+
 //make sure this operation is thread safe
 func TestUpdatePluginAssociationInstances(t *testing.T) {
 	testAssociationID := "testAssociationID"
@@ -291,6 +329,282 @@ func TestRemovePluginAssociationInstances(t *testing.T) {
 	assert.Equal(t, resultMap, pluginAssociationInstances)
 }
 
+func TestLoadRunnableAssociationsDefersAssociationOutsideOfTheMaintenanceWindows(t *testing.T) {
+	// Assemble
+	processor := createProcessor()
+	svcMock := service.NewMockDefault()
+	processor.assocSvc = svcMock
+	until := time.Now().UTC().Add(time.Hour)
+	processor.maintenanceWindows = &maintenanceWindowsStub{
+		deferral: &maintenancewindow.Deferral{Until: &until, NextCheck: until, Reason: "within the association blackout window deploy"},
+	}
+	assocRawData := createAssociationRawData()
+	assocRawData[0].Association.LastExecutionDate = nil
+	schedulemanager.Refresh(log.NewMockLog(), assocRawData)
+	svcMock.On(
+		"UpdateInstanceAssociationStatus",
+		mock.AnythingOfType("*log.Mock"),
+		"Id-Test",
+		"Test-Association",
+		"test-association-id",
+		mock.AnythingOfType("*ssm.InstanceAssociationExecutionResult"))
+
+	// Act
+	first := processor.loadRunnableAssociations(log.NewMockLog(), time.Now().UTC())
+	second := processor.loadRunnableAssociations(log.NewMockLog(), time.Now().UTC())
+
+	// Assert
+	assert.Empty(t, first)
+	assert.Empty(t, second)
+	assert.True(t, svcMock.AssertNumberOfCalls(t, "UpdateInstanceAssociationStatus", 1))
+	scheduled := schedulemanager.Schedules()[0]
+	assert.Equal(t, until, *scheduled.NextScheduledDate)
+	assert.Equal(t, until, *scheduled.DeferredUntil)
+}
+
+func TestLoadRunnableAssociationsReturnsAssociationWithinTheMaintenanceWindows(t *testing.T) {
+	// Assemble
+	processor := createProcessor()
+	processor.maintenanceWindows = &maintenanceWindowsStub{}
+	assocRawData := createAssociationRawData()
+	assocRawData[0].Association.LastExecutionDate = nil
+	schedulemanager.Refresh(log.NewMockLog(), assocRawData)
+
+	// Act
+	scheduled := processor.loadRunnableAssociations(log.NewMockLog(), time.Now().UTC())
+
+	// Assert
+	assert.Equal(t, 1, len(scheduled))
+	assert.Equal(t, "Id-Test", *scheduled[0].Association.AssociationId)
+}
+
+func TestProcessRunRequestsMakesTheRequestedAssociationsDue(t *testing.T) {
+	// Assemble
+	processor := createProcessor()
+	processor.runRequests, _ = ioutil.TempDir("", "runrequests")
+	defer os.RemoveAll(processor.runRequests)
+	assocRawData := createAssociationRawData()
+	schedulemanager.Refresh(log.NewMockLog(), assocRawData)
+	assert.Empty(t, schedulemanager.LoadDueAssociations(log.NewMockLog(), time.Now().UTC()))
+	runrequest.Add(processor.runRequests, runrequest.Request{AssociationID: "Id-Test", RequestedTime: time.Now().UTC()})
+	runrequest.Add(processor.runRequests, runrequest.Request{AssociationID: "Id-Unknown", RequestedTime: time.Now().UTC()})
+
+	// Act
+	processor.processRunRequests(log.NewMockLog())
+
+	// Assert
+	due := schedulemanager.LoadDueAssociations(log.NewMockLog(), time.Now().UTC())
+	assert.Equal(t, 1, len(due))
+	assert.Equal(t, "Id-Test", *due[0].Association.AssociationId)
+	requests := runrequest.List(log.NewMockLog(), processor.runRequests)
+	assert.Equal(t, 1, len(requests))
+	assert.Equal(t, "Id-Test", requests[0].AssociationID)
+
+	// Act
+	processor.completeRunRequest(log.NewMockLog(), "Id-Test")
+
+	// Assert
+	assert.Empty(t, runrequest.List(log.NewMockLog(), processor.runRequests))
+}
+
+func TestRunRequestDeferredByTheMaintenanceWindowsRunsAfterRefresh(t *testing.T) {
+	// Assemble
+	processor := createProcessor()
+	processor.runRequests, _ = ioutil.TempDir("", "runrequests")
+	defer os.RemoveAll(processor.runRequests)
+	svcMock := service.NewMockDefault()
+	processor.assocSvc = svcMock
+	svcMock.On(
+		"UpdateInstanceAssociationStatus",
+		mock.AnythingOfType("*log.Mock"),
+		"Id-Deferred",
+		"Test-Association",
+		"test-association-id",
+		mock.AnythingOfType("*ssm.InstanceAssociationExecutionResult"))
+	until := time.Now().UTC().Add(time.Hour)
+	processor.maintenanceWindows = &maintenanceWindowsStub{
+		deferral: &maintenancewindow.Deferral{Until: &until, NextCheck: until, Reason: "within the association blackout window deploy"},
+	}
+	assocRawData := createAssociationRawData()
+	assocRawData[0].Association.AssociationId = aws.String("Id-Deferred")
+	schedulemanager.Refresh(log.NewMockLog(), assocRawData)
+	runrequest.Add(processor.runRequests, runrequest.Request{AssociationID: "Id-Deferred", RequestedTime: time.Now().UTC()})
+
+	// Act
+	processor.processRunRequests(log.NewMockLog())
+	deferred := processor.loadRunnableAssociations(log.NewMockLog(), time.Now().UTC())
+	assocRawData = createAssociationRawData()
+	assocRawData[0].Association.AssociationId = aws.String("Id-Deferred")
+	schedulemanager.Refresh(log.NewMockLog(), assocRawData)
+	processor.maintenanceWindows = &maintenanceWindowsStub{}
+	runnable := processor.loadRunnableAssociations(log.NewMockLog(), time.Now().UTC())
+
+	// Assert
+	assert.Empty(t, deferred)
+	assert.Equal(t, 1, len(runnable))
+	assert.Equal(t, "Id-Deferred", *runnable[0].Association.AssociationId)
+	assert.Equal(t, 1, len(runrequest.List(log.NewMockLog(), processor.runRequests)))
+}
+
+func TestProcessRunRequestsKeepsTheRequestsOfAssociationsInProgress(t *testing.T) {
+	// Assemble
+	processor := createProcessor()
+	processor.runRequests, _ = ioutil.TempDir("", "runrequests")
+	defer os.RemoveAll(processor.runRequests)
+	assocRawData := createAssociationRawData()
+	assocRawData[0].Association.AssociationId = aws.String("Id-InProgress")
+	assocRawData[0].Association.DetailedStatus = aws.String(contracts.AssociationStatusInProgress)
+	schedulemanager.Refresh(log.NewMockLog(), assocRawData)
+	runrequest.Add(processor.runRequests, runrequest.Request{AssociationID: "Id-InProgress", RequestedTime: time.Now().UTC()})
+
+	// Act
+	processor.processRunRequests(log.NewMockLog())
+
+	// Assert
+	assert.Empty(t, schedulemanager.LoadDueAssociations(log.NewMockLog(), time.Now().UTC()))
+	requests := runrequest.List(log.NewMockLog(), processor.runRequests)
+	assert.Equal(t, 1, len(requests))
+	assert.Equal(t, "Id-InProgress", requests[0].AssociationID)
+
+	// Act
+	assocRawData = createAssociationRawData()
+	assocRawData[0].Association.AssociationId = aws.String("Id-InProgress")
+	schedulemanager.Refresh(log.NewMockLog(), assocRawData)
+	processor.processRunRequests(log.NewMockLog())
+
+	// Assert
+	due := schedulemanager.LoadDueAssociations(log.NewMockLog(), time.Now().UTC())
+	assert.Equal(t, 1, len(due))
+	assert.Equal(t, 1, len(runrequest.List(log.NewMockLog(), processor.runRequests)))
+
+	// Act
+	processor.completeRunRequest(log.NewMockLog(), "Id-InProgress")
+
+	// Assert
+	assert.Empty(t, runrequest.List(log.NewMockLog(), processor.runRequests))
+}
+
+func TestRunTriggeredAssociationMakesTheAssociationDue(t *testing.T) {
+	// Assemble
+	processor := createProcessor()
+	assocRawData := createAssociationRawData()
+	assocRawData[0].Association.AssociationId = aws.String("Id-Triggered")
+	schedulemanager.Refresh(log.NewMockLog(), assocRawData)
+	assert.Empty(t, schedulemanager.LoadDueAssociations(log.NewMockLog(), time.Now().UTC()))
+
+	// Act
+	processor.runTriggeredAssociation(log.NewMockLog(), "Id-Unknown", "agent start")
+	processor.runTriggeredAssociation(log.NewMockLog(), "Id-Triggered", "agent start")
+
+	// Assert
+	due := schedulemanager.LoadDueAssociations(log.NewMockLog(), time.Now().UTC())
+	assert.Equal(t, 1, len(due))
+	assert.Equal(t, "Id-Triggered", *due[0].Association.AssociationId)
+}
+
+func TestAssociationDriftReportUpdatesDriftOfAuditedPluginsAndClearsItOtherwise(t *testing.T) {
+	// Assemble
+	processor := createProcessor()
+	uploaderMock := complianceUploader.NewMockDefault()
+	processor.complianceUploader = uploaderMock
+	drift := []contracts.DriftItem{{ID: "/etc/motd:content", Title: "update the content of /etc/motd"}}
+	res := contracts.DocumentResult{
+		AssociationID:   "Id-Test",
+		DocumentName:    "Test-Association",
+		DocumentVersion: "1",
+		PluginResults: map[string]*contracts.PluginResult{
+			"fileState": {Status: contracts.ResultStatusSuccess, Audited: true, Drift: drift},
+			"runScript": {Status: contracts.ResultStatusSkipped},
+		},
+	}
+	uploaderMock.On("UpdateAssociationDrift", "Id-Test", mock.Anything, "Test-Association", "1", drift, mock.AnythingOfType("time.Time")).Return(nil)
+	uploaderMock.On("ClearAssociationDrift", "Id-Test", mock.Anything, mock.AnythingOfType("time.Time")).Return(nil)
+
+	// Act
+	processor.associationDriftReport(log.NewMockLog(), res)
+	res.PluginResults = map[string]*contracts.PluginResult{"runScript": {Status: contracts.ResultStatusSuccess}}
+	processor.associationDriftReport(log.NewMockLog(), res)
+
+	// Assert
+	assert.True(t, uploaderMock.AssertNumberOfCalls(t, "UpdateAssociationDrift", 1))
+	assert.True(t, uploaderMock.AssertNumberOfCalls(t, "ClearAssociationDrift", 1))
+}
+
+func TestCustomComplianceReportUpdatesComplianceItemsOfAllPlugins(t *testing.T) {
+	// Assemble
+	processor := createProcessor()
+	uploaderMock := complianceUploader.NewMockDefault()
+	processor.complianceUploader = uploaderMock
+	item := contracts.ComplianceItem{Type: "CIS", ID: "5.2.8", Status: "NON_COMPLIANT"}
+	res := contracts.DocumentResult{
+		AssociationID:   "Id-Test",
+		DocumentName:    "Test-Association",
+		DocumentVersion: "1",
+		PluginResults: map[string]*contracts.PluginResult{
+			"runShellScript": {Status: contracts.ResultStatusSuccess, ComplianceItems: []contracts.ComplianceItem{item}},
+		},
+	}
+	uploaderMock.On("UpdateCustomCompliance", "Id-Test", mock.Anything, "Test-Association", "1", []contracts.ComplianceItem{item}, mock.AnythingOfType("time.Time")).Return(nil)
+
+	// Act
+	processor.customComplianceReport(log.NewMockLog(), res)
+
+	// Assert
+	assert.True(t, uploaderMock.AssertNumberOfCalls(t, "UpdateCustomCompliance", 1))
+}
+
+func TestListenToResponsesCompletesRunningAssociationsAfterRebootRequest(t *testing.T) {
+	// Assemble
+	processor := createProcessor()
+	sys = &systemStub{}
+	savedBookkeeping := assocBookkeeping
+	assocBookkeeping = &bookkeepingStub{}
+	stopped := false
+	stopExecutionSignal = func() { stopped = true }
+	defer func() {
+		assocBookkeeping = savedBookkeeping
+		stopExecutionSignal = signal.StopExecutionSignal
+	}()
+	svcMock := service.NewMockDefault()
+	svcMock.On("UpdateInstanceAssociationStatus", mock.Anything, "Id-Running", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	processor.assocSvc = svcMock
+	uploaderMock := complianceUploader.NewMockDefault()
+	uploaderMock.On("UpdateAssociationCompliance", "Id-Running", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	uploaderMock.On("UpdateCustomCompliance", "Id-Running", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	processor.complianceUploader = uploaderMock
+	running, _ := resources.Parse([]string{"path:/etc/nginx"})
+	schedulemanager.StartAssociation(log.NewMockLog(), "Id-Running", running, appconfig.DefaultSsmAssociationWorkersLimitMax)
+	processor.resChan = make(chan contracts.DocumentResult, 2)
+	processor.resChan <- contracts.DocumentResult{AssociationID: "Id-Rebooting", Status: contracts.ResultStatusSuccessAndReboot}
+	processor.resChan <- contracts.DocumentResult{AssociationID: "Id-Running", Status: contracts.ResultStatusFailed}
+	close(processor.resChan)
+
+	// Act
+	processor.lisenToResponses()
+
+	// Assert
+	assert.True(t, stopped)
+	assert.False(t, schedulemanager.IsAssociationRunning("Id-Running"))
+	// the result of the association still running is reported
+	svcMock.AssertExpectations(t)
+	uploaderMock.AssertExpectations(t)
+}
+
+// bookkeepingStub keeps the orchestration logs
+type bookkeepingStub struct{}
+
+func (bookkeepingStub) DeleteOldOrchestrationLogs(log log.T, instanceID, orchestrationRootDirName string, retentionDurationHours int, isIntendedFileNameFormat func(string) bool) {
+}
+
+// maintenanceWindowsStub defers every association with the given deferral
+type maintenanceWindowsStub struct {
+	deferral *maintenancewindow.Deferral
+}
+
+func (m *maintenanceWindowsStub) Check(log log.T, documentName string, now time.Time) *maintenancewindow.Deferral {
+	return m.deferral
+}
+
 func mockParser(parserMock *parserMock, payload *messageContracts.SendCommandPayload, docState contracts.DocumentState) {
 	parserMock.On(
 		"InitializeDocumentState",
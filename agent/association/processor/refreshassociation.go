@@ -131,6 +131,7 @@ func (p *Processor) refreshAssociation(log log.T, associationIds []string, orche
 			p.assocSvc.UpdateInstanceAssociationStatus(
 				log,
 				*assoc.Association.AssociationId,
+				service.NoRunID,
 				*assoc.Association.Name,
 				*assoc.Association.InstanceId,
 				contracts.AssociationStatusFailed,
@@ -152,6 +153,7 @@ func (p *Processor) refreshAssociation(log log.T, associationIds []string, orche
 				p.assocSvc.UpdateInstanceAssociationStatus(
 					log,
 					*assoc.Association.AssociationId,
+					service.NoRunID,
 					*assoc.Association.Name,
 					*assoc.Association.InstanceId,
 					contracts.AssociationStatusFailed,
@@ -172,6 +174,7 @@ func (p *Processor) refreshAssociation(log log.T, associationIds []string, orche
 				p.assocSvc.UpdateInstanceAssociationStatus(
 					log,
 					*assoc.Association.AssociationId,
+					service.NoRunID,
 					*assoc.Association.Name,
 					*assoc.Association.InstanceId,
 					contracts.AssociationStatusPending,
@@ -185,6 +188,8 @@ func (p *Processor) refreshAssociation(log log.T, associationIds []string, orche
 	}
 
 	schedulemanager.Refresh(log, associations)
+	p.saveSchedule(log)
+	p.updateTriggers(log)
 
 	if applyAll {
 		out.AppendInfo(log, "All associations have been requested to execute immediately")
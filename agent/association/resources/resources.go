@@ -0,0 +1,159 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package resources describes the resources an association document touches,
+// so associations that touch different resources can run at the same time
+package resources
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+)
+
+const (
+	// Exclusive is the resource of a document that conflicts with every other document
+	Exclusive = "*"
+	// PackageManager is the lock of the package manager of the instance
+	PackageManager = "lock:package-manager"
+
+	lockPrefix = "lock:"
+	pathPrefix = "path:"
+)
+
+// impliedResources are the resources touched by plugins whatever the document declares
+var impliedResources = map[string][]string{
+	appconfig.PluginNameAwsConfigurePackage: {PackageManager},
+	appconfig.PluginNameAwsApplications:     {PackageManager},
+	appconfig.PluginNameAwsAgentUpdate:      {Exclusive},
+	appconfig.PluginEC2ConfigUpdate:         {Exclusive},
+}
+
+// Set is the set of resources touched by a document
+type Set struct {
+	exclusive bool
+	locks     map[string]bool
+	paths     []string
+}
+
+// ExclusiveSet returns the resources of a document that conflicts with every other document
+func ExclusiveSet() Set {
+	return Set{exclusive: true}
+}
+
+// Parse returns the set of the declared resources, a resource is either a named lock, e.g. lock:package-manager,
+// a path prefix, e.g. path:/etc/nginx, or * for a document that conflicts with every other document.
+// A name without prefix is a lock.
+func Parse(declared []string) (Set, error) {
+	set := Set{locks: map[string]bool{}}
+	for _, resource := range declared {
+		resource = strings.TrimSpace(resource)
+		lowerCasedResource := strings.ToLower(resource)
+		switch {
+		case resource == Exclusive:
+			set.exclusive = true
+		case strings.HasPrefix(lowerCasedResource, pathPrefix):
+			value := strings.TrimSpace(resource[len(pathPrefix):])
+			if value == "" {
+				return ExclusiveSet(), fmt.Errorf("resource %v has no path", resource)
+			}
+			set.paths = append(set.paths, cleanPath(value))
+		default:
+			name := strings.TrimPrefix(lowerCasedResource, lockPrefix)
+			if name == "" {
+				return ExclusiveSet(), fmt.Errorf("resource %v has no name", resource)
+			}
+			set.locks[name] = true
+		}
+	}
+	return set, nil
+}
+
+// ForDocument returns the resources declared by the document and the ones its plugins touch.
+// A document that declares no resources conflicts with every other document.
+func ForDocument(document string) (Set, error) {
+	var content contracts.DocumentContent
+	if err := json.Unmarshal([]byte(document), &content); err != nil {
+		return ExclusiveSet(), fmt.Errorf("unable to read the resources of the document, %v", err)
+	}
+	if len(content.Resources) == 0 {
+		return ExclusiveSet(), nil
+	}
+
+	declared := append([]string{}, content.Resources...)
+	for pluginName := range content.RuntimeConfig {
+		declared = append(declared, impliedResources[pluginName]...)
+	}
+	for _, step := range content.MainSteps {
+		if step != nil {
+			declared = append(declared, impliedResources[step.Action]...)
+		}
+	}
+	return Parse(declared)
+}
+
+// ConflictsWith returns true if both sets touch the same resource
+func (s Set) ConflictsWith(other Set) bool {
+	if s.exclusive || other.exclusive {
+		return true
+	}
+	for name := range s.locks {
+		if other.locks[name] {
+			return true
+		}
+	}
+	for _, p := range s.paths {
+		for _, otherPath := range other.paths {
+			if isWithin(p, otherPath) || isWithin(otherPath, p) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// String returns the resources of the set, e.g. for logging
+func (s Set) String() string {
+	if s.exclusive {
+		return Exclusive
+	}
+	resources := []string{}
+	for name := range s.locks {
+		resources = append(resources, lockPrefix+name)
+	}
+	for _, p := range s.paths {
+		resources = append(resources, pathPrefix+p)
+	}
+	sort.Strings(resources)
+	return strings.Join(resources, ", ")
+}
+
+// cleanPath normalizes a path prefix so windows and unix paths are compared the same way,
+// paths differing only by case conflict which serializes associations rather than running them together
+func cleanPath(p string) string {
+	p = strings.Replace(p, "\\", "/", -1)
+	return strings.ToLower(path.Clean(p))
+}
+
+// isWithin returns true if the path is the prefix or within the prefix, comparing whole path segments
+func isWithin(p string, prefix string) bool {
+	if p == prefix || prefix == "/" {
+		return true
+	}
+	return strings.HasPrefix(p, strings.TrimSuffix(prefix, "/")+"/")
+}
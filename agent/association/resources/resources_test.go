@@ -0,0 +1,85 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocksConflictWhenTheyHaveTheSameName(t *testing.T) {
+	patching, _ := Parse([]string{"lock:package-manager"})
+	installing, _ := Parse([]string{"Package-Manager"})
+	configuring, _ := Parse([]string{"lock:nginx"})
+
+	assert.True(t, patching.ConflictsWith(installing))
+	assert.False(t, patching.ConflictsWith(configuring))
+}
+
+func TestPathsConflictWhenOneIsWithinTheOther(t *testing.T) {
+	etc, _ := Parse([]string{"path:/etc"})
+	nginx, _ := Parse([]string{"path:/etc/nginx/"})
+	nginxLogs, _ := Parse([]string{"path:/var/log/nginx"})
+	nginxLogsBackup, _ := Parse([]string{"path:/var/log/nginx-backup"})
+	windows, _ := Parse([]string{"path:C:\\ProgramData\\App"})
+	windowsConfig, _ := Parse([]string{"path:c:/programdata/app/config"})
+
+	assert.True(t, etc.ConflictsWith(nginx))
+	assert.True(t, nginx.ConflictsWith(etc))
+	assert.False(t, nginx.ConflictsWith(nginxLogs))
+	assert.False(t, nginxLogs.ConflictsWith(nginxLogsBackup))
+	assert.True(t, windows.ConflictsWith(windowsConfig))
+}
+
+func TestExclusiveSetConflictsWithEverySet(t *testing.T) {
+	nginx, _ := Parse([]string{"path:/etc/nginx"})
+	declaredExclusive, _ := Parse([]string{"*"})
+	empty, _ := Parse([]string{})
+
+	assert.True(t, ExclusiveSet().ConflictsWith(nginx))
+	assert.True(t, declaredExclusive.ConflictsWith(empty))
+	assert.False(t, empty.ConflictsWith(nginx))
+}
+
+func TestParseReturnsErrorForEmptyResource(t *testing.T) {
+	for _, resource := range []string{"path:", "lock:", ""} {
+		set, err := Parse([]string{resource})
+
+		assert.NotNil(t, err, resource)
+		assert.True(t, set.exclusive, resource)
+	}
+}
+
+func TestForDocumentReturnsExclusiveSetWhenNoResourceIsDeclared(t *testing.T) {
+	set, err := ForDocument(`{"schemaVersion": "2.2", "mainSteps": [{"action": "aws:runShellScript", "name": "run"}]}`)
+
+	assert.Nil(t, err)
+	assert.True(t, set.exclusive)
+}
+
+func TestForDocumentReturnsDeclaredAndImpliedResources(t *testing.T) {
+	set, err := ForDocument(`{"schemaVersion": "2.2", "resources": ["path:/etc/nginx"],
+		"mainSteps": [{"action": "aws:configurePackage", "name": "install"}]}`)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "lock:package-manager, path:/etc/nginx", set.String())
+}
+
+func TestForDocumentReturnsExclusiveSetWhenDocumentIsInvalid(t *testing.T) {
+	set, err := ForDocument(`{"resources": "path:/etc/nginx"}`)
+
+	assert.NotNil(t, err)
+	assert.True(t, set.exclusive)
+}
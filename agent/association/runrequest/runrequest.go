@@ -0,0 +1,120 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package runrequest passes the requests to run associations immediately from the cli to the agent,
+// each request is a file named after the association in the run requests folder
+package runrequest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// RunRequestsDirName is the folder holding the pending run requests
+const RunRequestsDirName = "RunRequests"
+
+// Request is a request to run an association immediately
+type Request struct {
+	AssociationID string
+	RequestedTime time.Time
+}
+
+// GetLocation returns the folder of the run requests of the instance
+func GetLocation(instanceID string) string {
+	return filepath.Join(appconfig.DefaultDataStorePath,
+		instanceID,
+		appconfig.DefaultDocumentRootDirName,
+		appconfig.DefaultLocationOfAssociation,
+		RunRequestsDirName)
+}
+
+// Add persists a run request in the given folder, it replaces the pending request of the same association
+func Add(location string, request Request) (err error) {
+	if request.AssociationID == "" || strings.ContainsAny(request.AssociationID, `/\`) || strings.HasPrefix(request.AssociationID, ".") {
+		return fmt.Errorf("invalid association id %v", request.AssociationID)
+	}
+	var data []byte
+	if data, err = json.Marshal(request); err != nil {
+		return fmt.Errorf("failed to marshal run request, %v", err)
+	}
+	if err = fileutil.MakeDirs(location); err != nil {
+		return fmt.Errorf("cannot make directory of %v because: %v", location, err)
+	}
+	// write a temporary file first so the agent never reads a partial request
+	fileName := filepath.Join(location, request.AssociationID)
+	tempFileName := filepath.Join(location, "."+request.AssociationID+".tmp")
+	if err = ioutil.WriteFile(tempFileName, data, os.FileMode(int(appconfig.ReadWriteAccess))); err != nil {
+		return fmt.Errorf("failed to write run request, %v", err)
+	}
+	if err = os.Rename(tempFileName, fileName); err != nil {
+		os.Remove(tempFileName)
+		return fmt.Errorf("failed to write run request, %v", err)
+	}
+	return nil
+}
+
+// List returns the pending run requests in the given folder, invalid requests are logged and removed
+func List(log log.T, location string) (requests []Request) {
+	requests = []Request{}
+	fileNames, err := fileutil.GetFileNames(location)
+	if err != nil {
+		return requests
+	}
+	for _, fileName := range fileNames {
+		if strings.HasPrefix(fileName, ".") {
+			continue
+		}
+		path := filepath.Join(location, fileName)
+		var request Request
+		if data, err := ioutil.ReadFile(path); err != nil {
+			log.Errorf("Unable to read run request %v, %v", path, err)
+			continue
+		} else if err = json.Unmarshal(data, &request); err != nil || request.AssociationID != fileName {
+			log.Errorf("Ignoring invalid run request %v", path)
+			if err = os.Remove(path); err != nil {
+				log.Errorf("Unable to remove run request %v, %v", path, err)
+			}
+			continue
+		}
+		requests = append(requests, request)
+	}
+	return requests
+}
+
+// Remove deletes the given run request once it has been handled, a newer request of the same association is kept
+func Remove(log log.T, location string, request Request) {
+	path := filepath.Join(location, request.AssociationID)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Errorf("Unable to read run request %v, %v", path, err)
+		}
+		return
+	}
+	var pending Request
+	if err = json.Unmarshal(data, &pending); err == nil && !pending.RequestedTime.Equal(request.RequestedTime) {
+		return
+	}
+	if err = os.Remove(path); err != nil && !os.IsNotExist(err) {
+		log.Errorf("Unable to remove run request %v, %v", path, err)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runrequest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListReturnsThePendingRequestsUntilTheyAreRemoved(t *testing.T) {
+	location, _ := ioutil.TempDir("", "runrequest")
+	defer os.RemoveAll(location)
+	logger := log.NewMockLog()
+	requestedTime := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Nil(t, Add(location, Request{AssociationID: "assoc-1", RequestedTime: requestedTime}))
+	assert.Nil(t, Add(location, Request{AssociationID: "assoc-1", RequestedTime: requestedTime.Add(time.Minute)}))
+	ioutil.WriteFile(filepath.Join(location, "assoc-2"), []byte("invalid"), 0600)
+
+	requests := List(logger, location)
+
+	assert.Equal(t, []Request{{AssociationID: "assoc-1", RequestedTime: requestedTime.Add(time.Minute)}}, requests)
+	assert.Equal(t, requests, List(logger, location))
+	_, err := os.Stat(filepath.Join(location, "assoc-2"))
+	assert.True(t, os.IsNotExist(err))
+
+	// a newer request of the association is kept
+	assert.Nil(t, Add(location, Request{AssociationID: "assoc-1", RequestedTime: requestedTime.Add(2 * time.Minute)}))
+	Remove(logger, location, requests[0])
+	assert.Equal(t, 1, len(List(logger, location)))
+
+	Remove(logger, location, List(logger, location)[0])
+	assert.Empty(t, List(logger, location))
+}
+
+func TestAddRejectsInvalidAssociationIds(t *testing.T) {
+	location, _ := ioutil.TempDir("", "runrequest")
+	defer os.RemoveAll(location)
+
+	for _, associationID := range []string{"", "../assoc-1", ".assoc-1"} {
+		assert.NotNil(t, Add(location, Request{AssociationID: associationID}), associationID)
+	}
+	assert.Empty(t, List(log.NewMockLog(), location))
+}
@@ -0,0 +1,177 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scheduleexpression
+
+import (
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/association/atexpr"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/platform"
+	"github.com/gorhill/cronexpr"
+)
+
+const (
+	modifierTimeZone = "tz"
+	modifierSplay    = "splay"
+)
+
+var modifierRegularExpression = regexp.MustCompile("(?i)\\s+(tz|splay)\\(([^()]*)\\)\\s*$")
+var splayRegularExpression = regexp.MustCompile("(?i)^(\\d+)\\s*(second|seconds|minute|minutes|hour|hours)$")
+
+// decoupling for easy testability
+var instanceID = platform.InstanceID
+var defaultSplay = func() time.Duration {
+	config, err := appconfig.Config(false)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(config.Ssm.AssociationSplayMinutes) * time.Minute
+}
+
+// modifiers are the options following a schedule expression
+type modifiers struct {
+	location *time.Location
+	// splay is nil when the expression has no splay, the default splay of the agent applies to cron expressions then
+	splay *time.Duration
+}
+
+// zonedExpression evaluates a cron expression in a time zone
+type zonedExpression struct {
+	expression ScheduleExpression
+	location   *time.Location
+}
+
+// Next returns the next time of the expression evaluated in its time zone, in the location of `fromTime`
+func (expr *zonedExpression) Next(fromTime time.Time) time.Time {
+	next := expr.expression.Next(fromTime.In(expr.location))
+	if next.IsZero() {
+		return next
+	}
+	return next.In(fromTime.Location())
+}
+
+// splayedExpression delays every time of an expression by an offset
+type splayedExpression struct {
+	expression ScheduleExpression
+	offset     time.Duration
+}
+
+// Next returns the next delayed time of the expression following `fromTime`
+func (expr *splayedExpression) Next(fromTime time.Time) time.Time {
+	next := expr.expression.Next(fromTime.Add(-expr.offset))
+	if next.IsZero() {
+		return next
+	}
+	return next.Add(expr.offset)
+}
+
+// parseModifiers splits the modifiers from the end of a schedule expression
+func parseModifiers(scheduleExpression string) (baseExpression string, result modifiers, err error) {
+	baseExpression = strings.TrimSpace(scheduleExpression)
+	for {
+		match := modifierRegularExpression.FindStringSubmatch(baseExpression)
+		if match == nil {
+			return baseExpression, result, nil
+		}
+		baseExpression = strings.TrimSpace(baseExpression[:len(baseExpression)-len(match[0])])
+		value := strings.TrimSpace(match[2])
+
+		switch strings.ToLower(match[1]) {
+		case modifierTimeZone:
+			if result.location != nil {
+				return "", result, fmt.Errorf("Time zone is set more than once in expression %v", scheduleExpression)
+			}
+			if result.location, err = time.LoadLocation(value); err != nil || value == "" {
+				return "", result, fmt.Errorf("Unknown time zone %v in expression %v", value, scheduleExpression)
+			}
+		case modifierSplay:
+			if result.splay != nil {
+				return "", result, fmt.Errorf("Splay is set more than once in expression %v", scheduleExpression)
+			}
+			var splay time.Duration
+			if splay, err = parseSplay(value); err != nil {
+				return "", result, fmt.Errorf("%v in expression %v", err, scheduleExpression)
+			}
+			result.splay = &splay
+		}
+	}
+}
+
+// parseSplay parses the window of a splay modifier, e.g. 30 minutes
+func parseSplay(value string) (time.Duration, error) {
+	match := splayRegularExpression.FindStringSubmatch(value)
+	if match == nil {
+		return 0, fmt.Errorf("Splay %v is invalid, it should be a number of seconds, minutes or hours", value)
+	}
+	number, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Splay %v is invalid, %v", value, err)
+	}
+
+	unit := time.Second
+	switch strings.ToLower(match[2]) {
+	case "minute", "minutes":
+		unit = time.Minute
+	case "hour", "hours":
+		unit = time.Hour
+	}
+	splay := time.Duration(number) * unit
+	if splay > 24*time.Hour {
+		return 0, fmt.Errorf("Splay %v is invalid, it should not exceed 24 hours", value)
+	}
+	return splay, nil
+}
+
+// apply delays the schedule expression by the splay offset of the instance
+func (m modifiers) apply(log log.T, expression ScheduleExpression) (ScheduleExpression, error) {
+	var window time.Duration
+	if m.splay != nil {
+		window = *m.splay
+	} else {
+		switch expression.(type) {
+		case *cronexpr.Expression, *zonedExpression:
+			window = defaultSplay()
+		}
+	}
+	if window < time.Second {
+		return expression, nil
+	}
+
+	offset := splayOffset(log, window)
+	if atExpression, isAt := expression.(*atexpr.AtExpression); isAt {
+		return atExpression.Delay(offset), nil
+	}
+	return &splayedExpression{expression: expression, offset: offset}, nil
+}
+
+// splayOffset returns the offset of the instance within the splay window, it is derived from the instance id
+// so it is the same for every evaluation on the instance and spread across instances
+func splayOffset(log log.T, window time.Duration) time.Duration {
+	id, err := instanceID()
+	if err != nil {
+		log.Errorf("Unable to retrieve instance id for the splay of the schedule, %v", err)
+		return 0
+	}
+	hash := fnv.New64a()
+	hash.Write([]byte(id))
+	seconds := uint64(window / time.Second)
+	return time.Duration(hash.Sum64()%seconds) * time.Second
+}
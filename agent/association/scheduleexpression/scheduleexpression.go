@@ -16,11 +16,13 @@
 package scheduleexpression
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
 	"time"
 
+	"github.com/aws/amazon-ssm-agent/agent/association/atexpr"
 	"github.com/aws/amazon-ssm-agent/agent/association/rateexpr"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/gorhill/cronexpr"
@@ -29,6 +31,7 @@ import (
 const (
 	expressionTypeCron = "cron"
 	expressionTypeRate = "rate"
+	expressionTypeAt   = "at("
 )
 
 //ScheduleExpression defines operations of a valid schedule expression which association/model makes use of
@@ -36,10 +39,39 @@ type ScheduleExpression interface {
 	Next(fromTime time.Time) time.Time
 }
 
+// OneTimeExpression is a schedule expression that runs once at a given time, e.g. at(2018-01-01T10:00:00)
+type OneTimeExpression interface {
+	ScheduleExpression
+	RunTime() time.Time
+}
+
+// CreateScheduleExpression parses a cron, rate or at expression.
+// The expression can be followed by modifiers, tz(<IANA time zone>) evaluates cron and at expressions in the time zone
+// instead of UTC and splay(<number> <unit>) delays the schedule of each instance by an offset derived from its instance id,
+// e.g. cron(0 0 * * ? *) tz(Europe/Paris) splay(30 minutes).
 func CreateScheduleExpression(log log.T, scheduleExpression string) (ScheduleExpression, error) {
+	baseExpression, modifiers, err := parseModifiers(scheduleExpression)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	parsedExpression, err := createBaseScheduleExpression(log, baseExpression, modifiers.location)
+	if err != nil {
+		return nil, err
+	}
+	return modifiers.apply(log, parsedExpression)
+}
+
+// createBaseScheduleExpression parses a schedule expression without modifiers
+func createBaseScheduleExpression(log log.T, scheduleExpression string, location *time.Location) (ScheduleExpression, error) {
 
 	lowerCasedScheduledExpression := strings.ToLower(scheduleExpression)
 
+	if strings.HasPrefix(lowerCasedScheduledExpression, expressionTypeAt) {
+		return createAtExpression(log, scheduleExpression, location)
+	}
+
 	if strings.HasPrefix(lowerCasedScheduledExpression, expressionTypeCron) {
 		err := validateCronExpression(log, scheduleExpression)
 		if err != nil {
@@ -50,6 +82,9 @@ func CreateScheduleExpression(log log.T, scheduleExpression string) (ScheduleExp
 		parsedCronExpression, err := cronexpr.Parse(cronExpression)
 
 		if err == nil {
+			if location != nil {
+				return &zonedExpression{expression: parsedCronExpression, location: location}, nil
+			}
 			return parsedCronExpression, nil
 		} else {
 			message := fmt.Sprintf("Error %v received while parsing cron expression %v", err, scheduleExpression)
@@ -58,6 +93,10 @@ func CreateScheduleExpression(log log.T, scheduleExpression string) (ScheduleExp
 		}
 	}
 
+	if location != nil {
+		return nil, fmt.Errorf("Time zone is only supported for cron and at expressions, expression %v", scheduleExpression)
+	}
+
 	if strings.HasPrefix(lowerCasedScheduledExpression, expressionTypeRate) {
 		parsedRateExpression, err := rateexpr.Parse(scheduleExpression)
 
@@ -73,6 +112,20 @@ func CreateScheduleExpression(log log.T, scheduleExpression string) (ScheduleExp
 	return nil, fmt.Errorf("Unknown expression type detected in expression %v", scheduleExpression)
 }
 
+// createAtExpression parses an at expression in the given time zone, UTC by default
+func createAtExpression(log log.T, scheduleExpression string, location *time.Location) (ScheduleExpression, error) {
+	if location == nil {
+		location = time.UTC
+	}
+	parsedAtExpression, err := atexpr.ParseInLocation(scheduleExpression, location)
+	if err != nil {
+		message := fmt.Sprintf("An error %v received while parsing at expression %v", err, scheduleExpression)
+		log.Error(message)
+		return nil, errors.New(message)
+	}
+	return parsedAtExpression, nil
+}
+
 func validateCronExpression(log log.T, scheduleExpression string) error {
 	cronRegularExpression := regexp.MustCompile("(?i)(cron\\(.*\\))")
 	result := cronRegularExpression.FindAllStringSubmatch(scheduleExpression, -1)
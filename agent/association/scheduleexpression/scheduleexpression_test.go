@@ -15,7 +15,9 @@ package scheduleexpression
 
 import (
 	"testing"
+	"time"
 
+	"github.com/aws/amazon-ssm-agent/agent/association/atexpr"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/stretchr/testify/assert"
 )
@@ -124,10 +126,161 @@ func TestParseReturnsErrorWhenScheduleExpressionIsOfUnknownType(t *testing.T) {
 	logger := log.Logger()
 
 	// Act
-	parsedExpression, err := CreateScheduleExpression(logger, "at(12:00)")
+	parsedExpression, err := CreateScheduleExpression(logger, "every(12:00)")
 
 	// Assert
 	assert.Nil(t, parsedExpression)
 	assert.NotNil(t, err)
-	assert.Equal(t, "Unknown expression type detected in expression at(12:00)", err.Error())
+	assert.Equal(t, "Unknown expression type detected in expression every(12:00)", err.Error())
+}
+
+func TestParseReturnsAtExpressionForValidAtExpression(t *testing.T) {
+	// Assemble
+	logger := log.Logger()
+
+	// Act
+	parsedExpression, err := CreateScheduleExpression(logger, "at(2018-01-01T10:00:00)")
+
+	// Assert
+	assert.Nil(t, err)
+	oneTimeExpression, ok := parsedExpression.(OneTimeExpression)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC), oneTimeExpression.RunTime())
+}
+
+func TestParseEvaluatesCronExpressionInTimeZone(t *testing.T) {
+	// Assemble
+	logger := log.Logger()
+	fromTime := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Act
+	parsedExpression, err := CreateScheduleExpression(logger, "cron(0 10 * * ? *) tz(Asia/Tokyo)")
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2018, 1, 1, 1, 0, 0, 0, time.UTC), parsedExpression.Next(fromTime))
+}
+
+func TestParseEvaluatesAtExpressionInTimeZone(t *testing.T) {
+	// Assemble
+	logger := log.Logger()
+
+	// Act
+	parsedExpression, err := CreateScheduleExpression(logger, "at(2018-01-01T10:00:00) tz(Asia/Tokyo)")
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2018, 1, 1, 1, 0, 0, 0, time.UTC), parsedExpression.(OneTimeExpression).RunTime().UTC())
+}
+
+func TestParseReturnsErrorForUnknownTimeZone(t *testing.T) {
+	// Assemble
+	logger := log.Logger()
+
+	// Act
+	parsedExpression, err := CreateScheduleExpression(logger, "cron(0 10 * * ? *) tz(Mars/Olympus)")
+
+	// Assert
+	assert.Nil(t, parsedExpression)
+	assert.NotNil(t, err)
+}
+
+func TestParseReturnsErrorForTimeZoneOfRateExpression(t *testing.T) {
+	// Assemble
+	logger := log.Logger()
+
+	// Act
+	parsedExpression, err := CreateScheduleExpression(logger, "rate(30 minutes) tz(Europe/Paris)")
+
+	// Assert
+	assert.Nil(t, parsedExpression)
+	assert.NotNil(t, err)
+}
+
+func TestParseReturnsErrorForInvalidSplay(t *testing.T) {
+	// Assemble
+	logger := log.Logger()
+
+	for _, expression := range []string{
+		"cron(0 10 * * ? *) splay(30)",
+		"cron(0 10 * * ? *) splay(2 days)",
+		"cron(0 10 * * ? *) splay(25 hours)",
+		"cron(0 10 * * ? *) splay(1 minute) splay(2 minutes)",
+	} {
+		// Act
+		parsedExpression, err := CreateScheduleExpression(logger, expression)
+
+		// Assert
+		assert.Nil(t, parsedExpression, expression)
+		assert.NotNil(t, err, expression)
+	}
+}
+
+func TestSplayDelaysCronExpressionByTheSameOffsetForAnInstance(t *testing.T) {
+	// Assemble
+	logger := log.Logger()
+	restore := setSplayDependencies("i-1234567890abcdef0", 0)
+	defer restore()
+	fromTime := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	scheduledTime := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	// Act
+	first, err := CreateScheduleExpression(logger, "cron(0 10 * * ? *) splay(30 minutes)")
+	second, _ := CreateScheduleExpression(logger, "cron(0 10 * * ? *) splay(30 minutes)")
+
+	// Assert
+	assert.Nil(t, err)
+	next := first.Next(fromTime)
+	assert.Equal(t, next, second.Next(fromTime))
+	assert.False(t, next.Before(scheduledTime))
+	assert.True(t, next.Before(scheduledTime.Add(30*time.Minute)))
+	// the splayed run of the current day is not skipped once the scheduled time has passed
+	assert.Equal(t, next, first.Next(scheduledTime))
+	assert.Equal(t, next.Add(24*time.Hour), first.Next(next))
+}
+
+func TestSplayOffsetDiffersAcrossInstances(t *testing.T) {
+	// Assemble
+	logger := log.Logger()
+	offsets := map[time.Duration]bool{}
+
+	// Act
+	for _, id := range []string{"i-0000000000000001", "i-0000000000000002", "i-0000000000000003", "i-0000000000000004"} {
+		restore := setSplayDependencies(id, 0)
+		offsets[splayOffset(logger, time.Hour)] = true
+		restore()
+	}
+
+	// Assert
+	assert.True(t, len(offsets) > 1)
+}
+
+func TestDefaultSplayAppliesToCronExpressionOnly(t *testing.T) {
+	// Assemble
+	logger := log.Logger()
+	restore := setSplayDependencies("i-1234567890abcdef0", time.Hour)
+	defer restore()
+	fromTime := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Act
+	cronExpression, _ := CreateScheduleExpression(logger, "cron(0 10 * * ? *)")
+	unsplayedCronExpression, _ := CreateScheduleExpression(logger, "cron(0 10 * * ? *) splay(0 minutes)")
+	atExpression, _ := CreateScheduleExpression(logger, "at(2018-01-01T10:00:00)")
+	rateExpression, _ := CreateScheduleExpression(logger, "rate(30 minutes)")
+
+	// Assert
+	assert.IsType(t, &splayedExpression{}, cronExpression)
+	assert.Equal(t, time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC), unsplayedCronExpression.Next(fromTime))
+	assert.IsType(t, &atexpr.AtExpression{}, atExpression)
+	assert.Equal(t, time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC), atExpression.(OneTimeExpression).RunTime())
+	assert.Equal(t, fromTime.Add(30*time.Minute), rateExpression.Next(fromTime))
+}
+
+func setSplayDependencies(id string, splay time.Duration) (restore func()) {
+	originalInstanceID, originalDefaultSplay := instanceID, defaultSplay
+	instanceID = func() (string, error) { return id, nil }
+	defaultSplay = func() time.Duration { return splay }
+	return func() {
+		instanceID, defaultSplay = originalInstanceID, originalDefaultSplay
+	}
 }
@@ -17,10 +17,12 @@ package schedulemanager
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/association/model"
+	"github.com/aws/amazon-ssm-agent/agent/association/resources"
 	complianceModel "github.com/aws/amazon-ssm-agent/agent/compliance/model"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
@@ -32,11 +34,38 @@ import (
 var associations = []*model.InstanceAssociation{}
 var lock sync.RWMutex
 
+// refreshed is set once the schedule was refreshed with the associations from the service
+var refreshed bool
+
+// running holds the resources touched by the associations that are running, by association id
+var running = map[string]resources.Set{}
+
 // Refresh refreshes cached associationRawData
 func Refresh(log log.T, assocs []*model.InstanceAssociation) {
 	lock.Lock()
 	defer lock.Unlock()
 
+	refreshed = true
+	refresh(log, assocs)
+}
+
+// Restore schedules the associations persisted before the agent restarted,
+// it returns false when the schedule was already refreshed with the associations from the service
+func Restore(log log.T, assocs []*model.InstanceAssociation) bool {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if refreshed {
+		return false
+	}
+	log.Infof("Restoring schedule manager with %v persisted associations", len(assocs))
+	refresh(log, assocs)
+	return true
+}
+
+// refresh replaces the scheduled associations, lock must be held by the caller
+func refresh(log log.T, assocs []*model.InstanceAssociation) {
+	previousAssociations := associations
 	associations = []*model.InstanceAssociation{}
 	log.Debugf("Refreshing schedule manager with %v associations", len(assocs))
 
@@ -50,7 +79,20 @@ func Refresh(log log.T, assocs []*model.InstanceAssociation) {
 
 	numberOfNewAssoc := 0
 	for _, assoc := range associations {
+		// keep the deferral of the association so it is not reported again
+		for _, previousAssoc := range previousAssociations {
+			if *previousAssoc.Association.AssociationId == *assoc.Association.AssociationId {
+				assoc.DeferredUntil = previousAssoc.DeferredUntil
+				assoc.DeferredReason = previousAssoc.DeferredReason
+				assoc.RunRequested = assoc.RunRequested || previousAssoc.RunRequested
+				break
+			}
+		}
 		assoc.SetNextScheduledDate(log)
+		// a requested run that was deferred stays due until the association runs
+		if assoc.RunRequested {
+			assoc.RunNow()
+		}
 		if assoc.NextScheduledDate != nil {
 			log.Infof("Scheduling association %v, setting next ScheduledDate to %v", *assoc.Association.AssociationId, times.ToIsoDashUTC(*assoc.NextScheduledDate))
 		}
@@ -100,6 +142,47 @@ func LoadNextScheduledAssociation(log log.T) (*model.InstanceAssociation, error)
 	return nil, nil
 }
 
+// LoadDueAssociations returns the associations that are due at the given time, the ones due the longest first
+func LoadDueAssociations(log log.T, currentTime time.Time) []*model.InstanceAssociation {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	due := byNextScheduledDate{}
+	for _, assoc := range associations {
+		if assoc.NextScheduledDate != nil && !assoc.NextScheduledDate.After(currentTime) {
+			due = append(due, assoc)
+		}
+	}
+	sort.Stable(due)
+	return due
+}
+
+// LoadNextScheduledDateAfter returns the next scheduled date following the given time
+func LoadNextScheduledDateAfter(log log.T, fromTime time.Time) *time.Time {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	var nextScheduleDate *time.Time
+	for _, assoc := range associations {
+		if assoc.NextScheduledDate == nil || !assoc.NextScheduledDate.After(fromTime) {
+			continue
+		}
+		if nextScheduleDate == nil || nextScheduleDate.After(*assoc.NextScheduledDate) {
+			nextScheduleDate = assoc.NextScheduledDate
+		}
+	}
+	return nextScheduleDate
+}
+
+// byNextScheduledDate sorts associations by their next scheduled date
+type byNextScheduledDate []*model.InstanceAssociation
+
+func (a byNextScheduledDate) Len() int      { return len(a) }
+func (a byNextScheduledDate) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byNextScheduledDate) Less(i, j int) bool {
+	return a[i].NextScheduledDate.Before(*a[j].NextScheduledDate)
+}
+
 // LoadNextScheduledDate returns next scheduled date
 func LoadNextScheduledDate(log log.T) *time.Time {
 	lock.RLock()
@@ -131,6 +214,9 @@ func UpdateNextScheduledDate(log log.T, associationID string) {
 	for _, assoc := range associations {
 		if *assoc.Association.AssociationId == associationID {
 			assoc.Association.LastExecutionDate = aws.Time(time.Now().UTC())
+			assoc.DeferredUntil = nil
+			assoc.DeferredReason = ""
+			assoc.RunRequested = false
 			assoc.SetNextScheduledDate(log)
 			if assoc.NextScheduledDate != nil {
 				log.Infof("Scheduling association %v, setting next ScheduledDate to %v", *assoc.Association.AssociationId, times.ToIsoDashUTC(*assoc.NextScheduledDate))
@@ -140,6 +226,59 @@ func UpdateNextScheduledDate(log log.T, associationID string) {
 	}
 }
 
+// DeferAssociation postpones the next run of a due association to the given time, it returns true
+// when the association was not deferred before or the reason or end of its deferral changed
+func DeferAssociation(log log.T, associationID string, nextCheck time.Time, until *time.Time, reason string) bool {
+	lock.Lock()
+	defer lock.Unlock()
+
+	for _, assoc := range associations {
+		if *assoc.Association.AssociationId == associationID {
+			assoc.NextScheduledDate = aws.Time(nextCheck.UTC())
+			changed := assoc.DeferredReason != reason ||
+				(assoc.DeferredUntil == nil) != (until == nil) ||
+				(until != nil && !assoc.DeferredUntil.Equal(*until))
+			assoc.DeferredUntil = until
+			assoc.DeferredReason = reason
+			if changed {
+				log.Infof("Deferring association %v, %v, checking again at %v", associationID, reason, times.ToIsoDashUTC(nextCheck))
+			}
+			return changed
+		}
+	}
+	return false
+}
+
+// RunAssociationNow makes the given association due immediately until it runs, it returns false when the association
+// isn't scheduled
+func RunAssociationNow(log log.T, associationID string) bool {
+	lock.Lock()
+	defer lock.Unlock()
+
+	for _, assoc := range associations {
+		if *assoc.Association.AssociationId == associationID {
+			assoc.RunNow()
+			assoc.RunRequested = true
+			log.Infof("Association %v requested to run now", associationID)
+			return true
+		}
+	}
+	return false
+}
+
+// IsRunRequested returns if the given association was requested to run now and hasn't run since
+func IsRunRequested(associationID string) bool {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	for _, assoc := range associations {
+		if *assoc.Association.AssociationId == associationID {
+			return assoc.RunRequested
+		}
+	}
+	return false
+}
+
 // UpdateAssociationStatus sets detailed status for the given association
 func UpdateAssociationStatus(associationID string, status string) {
 	lock.Lock()
@@ -153,11 +292,59 @@ func UpdateAssociationStatus(associationID string, status string) {
 	}
 }
 
-// IsAssociationInProgress returns if given association has detailed status as InProgress
+// StartAssociation marks the association as running with the resources it touches, it returns false
+// without marking it when all the workers are busy or a running association touches the same resources
+func StartAssociation(log log.T, associationID string, touched resources.Set, workersLimit int) bool {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if _, isRunning := running[associationID]; isRunning {
+		return false
+	}
+	if workersLimit < 1 {
+		workersLimit = 1
+	}
+	if len(running) >= workersLimit {
+		log.Debugf("All %v association workers are busy, association %v will run later", workersLimit, associationID)
+		return false
+	}
+	for runningAssociationID, runningResources := range running {
+		if touched.ConflictsWith(runningResources) {
+			log.Infof("Association %v touches the resources of running association %v (%v), it will run once it completes",
+				associationID, runningAssociationID, runningResources)
+			return false
+		}
+	}
+	running[associationID] = touched
+	return true
+}
+
+// CompleteAssociation releases the resources of a running association
+func CompleteAssociation(associationID string) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	delete(running, associationID)
+}
+
+// IsAssociationRunning returns if the given association was started by this agent and has not completed yet
+func IsAssociationRunning(associationID string) bool {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	_, isRunning := running[associationID]
+	return isRunning
+}
+
+// IsAssociationInProgress returns if given association is running or has detailed status as InProgress
 func IsAssociationInProgress(associationID string) bool {
 	lock.Lock()
 	defer lock.Unlock()
 
+	if _, isRunning := running[associationID]; isRunning {
+		return true
+	}
+
 	for _, assoc := range associations {
 		if *assoc.Association.AssociationId == associationID {
 			if assoc.Association.DetailedStatus == nil {
@@ -182,6 +369,21 @@ func Schedules() []*model.InstanceAssociation {
 	return associations
 }
 
+// ScheduleSnapshot returns a copy of the scheduled associations that can be read while the schedule changes
+func ScheduleSnapshot() []*model.InstanceAssociation {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	snapshot := make([]*model.InstanceAssociation, 0, len(associations))
+	for _, assoc := range associations {
+		assocCopy := *assoc
+		summaryCopy := *assoc.Association
+		assocCopy.Association = &summaryCopy
+		snapshot = append(snapshot, &assocCopy)
+	}
+	return snapshot
+}
+
 func AssociationExists(associationID string) bool {
 	for _, assoc := range associations {
 		if *assoc.Association.AssociationId == associationID {
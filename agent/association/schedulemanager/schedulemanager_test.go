@@ -0,0 +1,92 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package schedulemanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/association/model"
+	"github.com/aws/amazon-ssm-agent/agent/association/resources"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartAssociationRunsAssociationsTouchingDifferentResources(t *testing.T) {
+	// Assemble
+	logger := log.NewMockLog()
+	patching, _ := resources.Parse([]string{resources.PackageManager})
+	installing, _ := resources.Parse([]string{resources.PackageManager, "path:/opt/app"})
+	configuring, _ := resources.Parse([]string{"path:/etc/nginx"})
+	defer CompleteAssociation("patching")
+	defer CompleteAssociation("configuring")
+
+	// Act and Assert
+	assert.True(t, StartAssociation(logger, "patching", patching, 4))
+	assert.False(t, StartAssociation(logger, "installing", installing, 4))
+	assert.True(t, StartAssociation(logger, "configuring", configuring, 4))
+	assert.False(t, StartAssociation(logger, "patching", patching, 4))
+	assert.True(t, IsAssociationRunning("patching"))
+	assert.True(t, IsAssociationInProgress("configuring"))
+	assert.False(t, IsAssociationInProgress("installing"))
+
+	CompleteAssociation("patching")
+	assert.False(t, IsAssociationInProgress("patching"))
+	assert.True(t, StartAssociation(logger, "installing", installing, 4))
+	CompleteAssociation("installing")
+}
+
+func TestStartAssociationRespectsTheWorkersLimit(t *testing.T) {
+	// Assemble
+	logger := log.NewMockLog()
+	nginx, _ := resources.Parse([]string{"path:/etc/nginx"})
+	app, _ := resources.Parse([]string{"path:/opt/app"})
+	defer CompleteAssociation("nginx")
+
+	// Act and Assert
+	assert.True(t, StartAssociation(logger, "nginx", nginx, 1))
+	assert.False(t, StartAssociation(logger, "app", app, 1))
+	assert.True(t, StartAssociation(logger, "app", app, 2))
+	CompleteAssociation("app")
+}
+
+func TestRequestedRunStaysDueAcrossRefreshUntilTheAssociationRuns(t *testing.T) {
+	// Assemble
+	logger := log.NewMockLog()
+	scheduled := func() []*model.InstanceAssociation {
+		return []*model.InstanceAssociation{{
+			Association: &ssm.InstanceAssociationSummary{
+				AssociationId:      aws.String("requested"),
+				ScheduleExpression: aws.String("rate(30 minutes)"),
+				LastExecutionDate:  aws.Time(time.Now().UTC()),
+			},
+		}}
+	}
+	Refresh(logger, scheduled())
+	assert.Empty(t, LoadDueAssociations(logger, time.Now().UTC()))
+
+	// Act and Assert
+	assert.True(t, RunAssociationNow(logger, "requested"))
+	DeferAssociation(logger, "requested", time.Now().UTC().Add(time.Hour), nil, "within the association blackout window deploy")
+	Refresh(logger, scheduled())
+	assert.True(t, IsRunRequested("requested"))
+	assert.Equal(t, 1, len(LoadDueAssociations(logger, time.Now().UTC())))
+
+	UpdateNextScheduledDate(logger, "requested")
+	Refresh(logger, scheduled())
+	assert.False(t, IsRunRequested("requested"))
+	assert.Empty(t, LoadDueAssociations(logger, time.Now().UTC()))
+}
@@ -0,0 +1,204 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package scheduler provides ability to create scheduled job
+package scheduler
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// PollResult is the outcome of an association poll, it adapts the interval until the next poll
+type PollResult int
+
+const (
+	// PollUnchanged is a poll that found the associations unchanged
+	PollUnchanged PollResult = iota
+	// PollChanged is a poll that found new, updated or removed associations
+	PollChanged
+	// PollFailed is a poll that could not load the associations
+	PollFailed
+)
+
+const (
+	// minPollInterval is the interval after a change or a first failure
+	minPollInterval = time.Minute
+	// pollJitterPercent is the maximum variation of the interval, so the polls of the fleet are spread
+	pollJitterPercent = 20
+)
+
+var (
+	activePoller *Poller
+	pollerLock   sync.Mutex
+)
+
+// Poller runs the association poll at an adaptive interval. The interval grows up to the max interval while the
+// associations are unchanged, and is shortened after a change or an error. A push hint polls immediately,
+// the interval doesn't depend on the hints as they may be lost.
+type Poller struct {
+	log         log.T
+	task        func() PollResult
+	frequency   time.Duration
+	maxInterval time.Duration
+	interval    time.Duration
+	lastResult  PollResult
+	wake        chan string
+	quit        chan bool
+	stopOnce    sync.Once
+}
+
+// CreatePoller runs the given poll task, the interval starts at frequencyInMinutes and grows up to maxFrequencyInMinutes
+// while the associations are unchanged
+func CreatePoller(log log.T, task func() PollResult, frequencyInMinutes int, maxFrequencyInMinutes int) *Poller {
+	p := newPoller(log, task, frequencyInMinutes, maxFrequencyInMinutes)
+
+	pollerLock.Lock()
+	activePoller = p
+	pollerLock.Unlock()
+
+	go p.run()
+	return p
+}
+
+// newPoller returns a poller that isn't started
+func newPoller(log log.T, task func() PollResult, frequencyInMinutes int, maxFrequencyInMinutes int) *Poller {
+	if maxFrequencyInMinutes < frequencyInMinutes {
+		maxFrequencyInMinutes = frequencyInMinutes
+	}
+	return &Poller{
+		log:         log,
+		task:        task,
+		frequency:   time.Duration(frequencyInMinutes) * time.Minute,
+		maxInterval: time.Duration(maxFrequencyInMinutes) * time.Minute,
+		interval:    time.Duration(frequencyInMinutes) * time.Minute,
+		lastResult:  PollUnchanged,
+		wake:        make(chan string, 1),
+		quit:        make(chan bool),
+	}
+}
+
+// RequestPoll polls the associations early, e.g. when the service pushes a hint that the associations changed.
+// It returns false when no poller runs.
+func RequestPoll(reason string) bool {
+	pollerLock.Lock()
+	p := activePoller
+	pollerLock.Unlock()
+
+	if p == nil {
+		return false
+	}
+	// a poll already requested covers this request
+	select {
+	case p.wake <- reason:
+	default:
+	}
+	return true
+}
+
+// Stop stops the poller
+func (p *Poller) Stop() {
+	if p == nil {
+		return
+	}
+	p.stopOnce.Do(func() {
+		close(p.quit)
+	})
+
+	pollerLock.Lock()
+	defer pollerLock.Unlock()
+	if activePoller == p {
+		activePoller = nil
+	}
+}
+
+// run polls until the poller is stopped
+func (p *Poller) run() {
+	// spread the first polls of the fleet
+	sleepMilli(time.Now(), defaultSleepDurationInMilliSeconds)
+
+	for {
+		select {
+		case <-p.quit:
+			return
+		default:
+		}
+
+		wait := jitter(p.nextInterval(p.task()))
+		p.log.Debugf("Next association poll in %v", wait)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-p.quit:
+			timer.Stop()
+			return
+		case <-timer.C:
+		case reason := <-p.wake:
+			timer.Stop()
+			p.log.Infof("Polling associations early, %v", reason)
+			// the fleet receives the same hint at the same time, spread the polls
+			hintDelay := time.NewTimer(pushHintDelay())
+			select {
+			case <-p.quit:
+				hintDelay.Stop()
+				return
+			case <-hintDelay.C:
+			}
+		}
+	}
+}
+
+// nextInterval returns the interval until the next poll given the result of the last poll. Changes are followed
+// by frequent polls to pick up related changes, errors are retried sooner unless they persist and unchanged
+// associations back off up to the max interval.
+func (p *Poller) nextInterval(result PollResult) time.Duration {
+	switch result {
+	case PollChanged:
+		p.interval = minPollInterval
+	case PollFailed:
+		if p.lastResult == PollFailed {
+			p.interval = minDuration(p.interval*2, p.frequency)
+		} else {
+			p.interval = minPollInterval
+		}
+	default:
+		p.interval = minDuration(p.interval*2, p.maxInterval)
+	}
+	p.lastResult = result
+	return p.interval
+}
+
+// minDuration returns the shorter of both durations
+func minDuration(a time.Duration, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// jitter varies the interval randomly by up to pollJitterPercent
+var jitter = func(interval time.Duration) time.Duration {
+	maxJitter := int64(interval) * pollJitterPercent / 100
+	if maxJitter <= 0 {
+		return interval
+	}
+	return interval + time.Duration(rand.Int63n(2*maxJitter+1)-maxJitter)
+}
+
+// pushHintDelay returns the random delay of the poll requested by a push hint
+var pushHintDelay = func() time.Duration {
+	return time.Duration(rand.Intn(defaultSleepDurationInMilliSeconds)) * time.Millisecond
+}
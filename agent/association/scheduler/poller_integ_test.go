@@ -24,25 +24,26 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-var pollCalls int = 0
-
-func TestCreatingScheduler(t *testing.T) {
+func TestCreatingPoller(t *testing.T) {
 	context := context.NewMockDefault()
-	//override sleepMilli so it will not sleep before the poll
+	//override sleepMilli and pushHintDelay so they will not sleep before the poll
 	sleepMilli = func(pollStartTime time.Time, sleepDurationInMilliseconds int) {}
+	pushHintDelay = func() time.Duration { return 0 }
 	var testPollFrequencyInMinutes = 10
-
-	job, err := CreateScheduler(context.Log(), incrementPollCalls, testPollFrequencyInMinutes)
-	if err != nil {
-		time.Sleep(100 * time.Millisecond)
-		assert.True(t, pollCalls == 1)
-
-		ScheduleNextRun(job)
-		time.Sleep(100 * time.Millisecond)
-		assert.True(t, pollCalls == 2)
+	polls := make(chan bool, 10)
+
+	poller := CreatePoller(context.Log(), func() PollResult {
+		polls <- true
+		return PollUnchanged
+	}, testPollFrequencyInMinutes, testPollFrequencyInMinutes)
+	defer poller.Stop()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-polls:
+		case <-time.After(time.Second):
+			assert.Fail(t, "associations were not polled")
+		}
+		RequestPoll("integration test")
 	}
 }
-
-func incrementPollCalls() {
-	pollCalls++
-}
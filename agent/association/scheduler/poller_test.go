@@ -0,0 +1,85 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextIntervalAdaptsToPollResults(t *testing.T) {
+	poller := newPoller(log.NewMockLog(), nil, 10, 30)
+
+	for _, poll := range []struct {
+		result   PollResult
+		interval time.Duration
+	}{
+		{PollUnchanged, 20 * time.Minute},
+		{PollUnchanged, 30 * time.Minute},
+		{PollUnchanged, 30 * time.Minute},
+		{PollChanged, time.Minute},
+		{PollUnchanged, 2 * time.Minute},
+		{PollFailed, time.Minute},
+		{PollFailed, 2 * time.Minute},
+		{PollFailed, 4 * time.Minute},
+		{PollFailed, 8 * time.Minute},
+		// persisting errors are retried at the configured frequency
+		{PollFailed, 10 * time.Minute},
+		{PollFailed, 10 * time.Minute},
+		{PollUnchanged, 20 * time.Minute},
+	} {
+		assert.Equal(t, poll.interval, poller.nextInterval(poll.result))
+	}
+}
+
+func TestMaxIntervalIsAtLeastFrequency(t *testing.T) {
+	poller := newPoller(log.NewMockLog(), nil, 30, 10)
+
+	assert.Equal(t, 30*time.Minute, poller.nextInterval(PollUnchanged))
+}
+
+func TestJitterSpreadsInterval(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		interval := jitter(10 * time.Minute)
+
+		assert.True(t, interval >= 8*time.Minute && interval <= 12*time.Minute, interval)
+	}
+}
+
+func TestRequestPollPollsImmediately(t *testing.T) {
+	sleepMilli = func(pollStartTime time.Time, sleepDurationInMilliseconds int) {}
+	pushHintDelay = func() time.Duration { return 0 }
+	polls := make(chan bool, 10)
+	task := func() PollResult {
+		polls <- true
+		return PollUnchanged
+	}
+
+	assert.False(t, RequestPoll("association updated"))
+	poller := CreatePoller(log.NewMockLog(), task, 10, 30)
+	<-polls
+
+	assert.True(t, RequestPoll("association updated"))
+	select {
+	case <-polls:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "push hint didn't poll the associations")
+	}
+
+	poller.Stop()
+	assert.False(t, RequestPoll("association updated"))
+}
@@ -17,48 +17,13 @@ package scheduler
 import (
 	"math/rand"
 	"time"
-
-	"github.com/aws/amazon-ssm-agent/agent/log"
-	"github.com/carlescere/scheduler"
 )
 
 const (
 	defaultSleepDurationInMilliSeconds int = 30000
 )
 
-// CreateScheduler runs a given poll job every pollFrequencyMinutes
-func CreateScheduler(log log.T, task func(), frequencyInMinutes int) (job *scheduler.Job, err error) {
-	if job, err = scheduler.Every(frequencyInMinutes).Minutes().Run(func() {
-		loop(task, log, job)
-	}); err != nil {
-		return nil, log.Errorf("unable to create scheduler, %v", err)
-	}
-	return job, nil
-}
-
-// Stop stops the scheduler.
-func Stop(job *scheduler.Job) {
-	if job != nil {
-		job.Quit <- true
-	}
-}
-
-// loop executes the task provided when creates scheduler
-func loop(task func(), log log.T, job *scheduler.Job) {
-	// time lock to only have one loop active anytime.
-	// this is extra insurance to prevent any race condition
-	taskStartTime := time.Now()
-
-	sleepMilli(taskStartTime, defaultSleepDurationInMilliSeconds)
-
-	task()
-}
-
-// ScheduleNextRun skips waiting and schedule next run immediately
-func ScheduleNextRun(j *scheduler.Job) {
-	j.SkipWait <- true
-}
-
+// sleepMilli sleeps a random duration up to sleepDurationInMilliseconds so the polls of the fleet are spread
 var sleepMilli = func(pollStartTime time.Time, sleepDurationInMilliseconds int) {
 	sleepDurationInMilliseconds = rand.Intn(sleepDurationInMilliseconds)
 	if time.Since(pollStartTime) < 1*time.Second {
@@ -0,0 +1,198 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package schedulestore persists the association schedule and the association documents in the data store,
+// so the scheduled associations keep running after an agent restart when the service is not reachable
+package schedulestore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/association/model"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// ScheduleFileName represents the file persisting the association schedule
+const ScheduleFileName = "AssociationSchedule.json"
+
+// T represents the persistence of the association schedule
+type T interface {
+	Save(log log.T, associations []*model.InstanceAssociation) error
+	Load(log log.T) ([]*model.InstanceAssociation, error)
+}
+
+// Entry is one persisted association with its document and the date of its last execution
+type Entry struct {
+	CreateDate   time.Time
+	Association  *ssm.InstanceAssociationSummary
+	Document     *string
+	RunRequested bool `json:",omitempty"`
+}
+
+// Schedule is the persisted association schedule
+type Schedule struct {
+	SavedTime time.Time
+	Entries   []Entry
+}
+
+// scheduleFile is the content of the schedule file, the checksum is the sha256 of the schedule
+// so a truncated or modified file is never used to run associations
+type scheduleFile struct {
+	Checksum string
+	Schedule json.RawMessage
+}
+
+// ScheduleStore persists the association schedule to a file
+type ScheduleStore struct {
+	instanceID string
+	location   string
+	lock       sync.Mutex
+}
+
+// NewScheduleStore returns the store of the association schedule of the given instance
+func NewScheduleStore(instanceID string) *ScheduleStore {
+	return NewScheduleStoreWithLocation(instanceID, filepath.Join(appconfig.DefaultDataStorePath,
+		instanceID,
+		appconfig.DefaultDocumentRootDirName,
+		appconfig.DefaultLocationOfAssociation))
+}
+
+// NewScheduleStoreWithLocation returns the store of the association schedule in the given folder
+func NewScheduleStoreWithLocation(instanceID string, location string) *ScheduleStore {
+	return &ScheduleStore{
+		instanceID: instanceID,
+		location:   location,
+	}
+}
+
+// Save persists the given associations, associations without document are skipped
+func (s *ScheduleStore) Save(log log.T, associations []*model.InstanceAssociation) (err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	schedule := Schedule{
+		SavedTime: time.Now().UTC(),
+		Entries:   []Entry{},
+	}
+	for _, assoc := range associations {
+		if assoc.Association == nil || assoc.Document == nil {
+			continue
+		}
+		schedule.Entries = append(schedule.Entries, Entry{
+			CreateDate:   assoc.CreateDate,
+			Association:  assoc.Association,
+			Document:     assoc.Document,
+			RunRequested: assoc.RunRequested,
+		})
+	}
+
+	var content, fileContent []byte
+	if content, err = json.Marshal(schedule); err != nil {
+		return fmt.Errorf("failed to marshal association schedule, %v", err)
+	}
+	if fileContent, err = json.Marshal(scheduleFile{Checksum: checksum(content), Schedule: content}); err != nil {
+		return fmt.Errorf("failed to marshal association schedule, %v", err)
+	}
+
+	if err = fileutil.MakeDirs(s.location); err != nil {
+		return fmt.Errorf("cannot make directory of %v because: %v", s.location, err)
+	}
+	// write a temporary file first so a crash while saving never leaves a partial schedule
+	fileName := s.fileName()
+	tempFileName := fileName + ".tmp"
+	if err = ioutil.WriteFile(tempFileName, fileContent, os.FileMode(int(appconfig.ReadWriteAccess))); err != nil {
+		return fmt.Errorf("failed to write association schedule, %v", err)
+	}
+	if err = os.Rename(tempFileName, fileName); err != nil {
+		os.Remove(tempFileName)
+		return fmt.Errorf("failed to write association schedule, %v", err)
+	}
+	log.Debugf("Persisted %v associations to %v", len(schedule.Entries), fileName)
+	return nil
+}
+
+// Load returns the persisted associations, no associations are returned when nothing was persisted
+func (s *ScheduleStore) Load(log log.T) (associations []*model.InstanceAssociation, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	associations = []*model.InstanceAssociation{}
+	fileName := s.fileName()
+	if !fileutil.Exists(fileName) {
+		return associations, nil
+	}
+
+	var content []byte
+	var file scheduleFile
+	var schedule Schedule
+	if content, err = ioutil.ReadFile(fileName); err != nil {
+		return associations, fmt.Errorf("failed to read association schedule, %v", err)
+	}
+	if err = json.Unmarshal(content, &file); err != nil {
+		return associations, fmt.Errorf("association schedule %v is corrupted, %v", fileName, err)
+	}
+	if checksum(file.Schedule) != file.Checksum {
+		return associations, fmt.Errorf("association schedule %v is corrupted, checksum mismatch", fileName)
+	}
+	if err = json.Unmarshal(file.Schedule, &schedule); err != nil {
+		return associations, fmt.Errorf("association schedule %v is corrupted, %v", fileName, err)
+	}
+
+	for _, entry := range schedule.Entries {
+		if err := s.validate(entry); err != nil {
+			log.Errorf("Skipping persisted association, %v", err)
+			continue
+		}
+		associations = append(associations, &model.InstanceAssociation{
+			CreateDate:   entry.CreateDate,
+			Association:  entry.Association,
+			Document:     entry.Document,
+			RunRequested: entry.RunRequested,
+		})
+	}
+	log.Debugf("Loaded %v associations persisted at %v", len(associations), schedule.SavedTime)
+	return associations, nil
+}
+
+// validate checks the persisted association has what is needed to run it on this instance
+func (s *ScheduleStore) validate(entry Entry) error {
+	assoc := entry.Association
+	if assoc == nil || assoc.AssociationId == nil || assoc.Name == nil || assoc.DocumentVersion == nil ||
+		assoc.Checksum == nil || entry.Document == nil {
+		return fmt.Errorf("association is incomplete")
+	}
+	if assoc.InstanceId == nil || *assoc.InstanceId != s.instanceID {
+		return fmt.Errorf("association %v doesn't target instance %v", *assoc.AssociationId, s.instanceID)
+	}
+	return nil
+}
+
+func (s *ScheduleStore) fileName() string {
+	return filepath.Join(s.location, ScheduleFileName)
+}
+
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
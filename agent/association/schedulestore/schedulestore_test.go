@@ -0,0 +1,110 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package schedulestore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/association/model"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/stretchr/testify/assert"
+)
+
+const testInstanceID = "i-1234567890"
+
+func newAssociation(associationID string, instanceID string) *model.InstanceAssociation {
+	return &model.InstanceAssociation{
+		CreateDate: time.Now().UTC(),
+		Association: &ssm.InstanceAssociationSummary{
+			AssociationId:      aws.String(associationID),
+			Name:               aws.String("AWS-RunShellScript"),
+			DocumentVersion:    aws.String("1"),
+			Checksum:           aws.String("checksum-" + associationID),
+			InstanceId:         aws.String(instanceID),
+			ScheduleExpression: aws.String("rate(30 minutes)"),
+			LastExecutionDate:  aws.Time(time.Date(2017, 3, 1, 10, 0, 0, 0, time.UTC)),
+		},
+		Document: aws.String(`{"schemaVersion": "1.2"}`),
+	}
+}
+
+func newTestStore(t *testing.T) (*ScheduleStore, string) {
+	location, err := ioutil.TempDir("", "schedulestore")
+	assert.Nil(t, err)
+	return NewScheduleStoreWithLocation(testInstanceID, location), location
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	store, location := newTestStore(t)
+	defer os.RemoveAll(location)
+	logger := log.NewMockLog()
+
+	noDocument := newAssociation("assoc-3", testInstanceID)
+	noDocument.Document = nil
+	requested := newAssociation("assoc-1", testInstanceID)
+	requested.RunRequested = true
+	assert.Nil(t, store.Save(logger, []*model.InstanceAssociation{
+		requested,
+		newAssociation("assoc-2", "i-other"),
+		noDocument,
+	}))
+
+	associations, err := store.Load(logger)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(associations))
+	assert.Equal(t, "assoc-1", *associations[0].Association.AssociationId)
+	assert.Equal(t, `{"schemaVersion": "1.2"}`, *associations[0].Document)
+	assert.True(t, associations[0].Association.LastExecutionDate.Equal(time.Date(2017, 3, 1, 10, 0, 0, 0, time.UTC)))
+	assert.Nil(t, associations[0].NextScheduledDate)
+	assert.True(t, associations[0].RunRequested)
+}
+
+func TestLoadWithoutSchedule(t *testing.T) {
+	store, location := newTestStore(t)
+	defer os.RemoveAll(location)
+
+	associations, err := store.Load(log.NewMockLog())
+	assert.Nil(t, err)
+	assert.Empty(t, associations)
+}
+
+func TestLoadCorruptedSchedule(t *testing.T) {
+	store, location := newTestStore(t)
+	defer os.RemoveAll(location)
+	logger := log.NewMockLog()
+	assert.Nil(t, store.Save(logger, []*model.InstanceAssociation{newAssociation("assoc-1", testInstanceID)}))
+
+	fileName := filepath.Join(location, ScheduleFileName)
+	content, err := ioutil.ReadFile(fileName)
+	assert.Nil(t, err)
+
+	// modified schedule
+	modified := []byte(string(content[:len(content)-20]) + "X" + string(content[len(content)-19:]))
+	assert.Nil(t, ioutil.WriteFile(fileName, modified, 0600))
+	associations, err := store.Load(logger)
+	assert.NotNil(t, err)
+	assert.Empty(t, associations)
+
+	// truncated schedule
+	assert.Nil(t, ioutil.WriteFile(fileName, content[:len(content)/2], 0600))
+	associations, err = store.Load(logger)
+	assert.NotNil(t, err)
+	assert.Empty(t, associations)
+}
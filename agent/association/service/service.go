@@ -23,6 +23,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/association/cache"
 	"github.com/aws/amazon-ssm-agent/agent/association/model"
+	"github.com/aws/amazon-ssm-agent/agent/association/outbox"
 	"github.com/aws/amazon-ssm-agent/agent/association/schedulemanager"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
@@ -40,6 +41,8 @@ const (
 	latestDoc                      = "$LATEST"
 	cronExpressionEveryFiveMinutes = "cron(0 0/5 * 1/1 * ? *)"
 	NoOutputUrl                    = ""
+	// NoRunID is the run id of status updates that don't belong to an execution of the association
+	NoRunID = ""
 )
 
 type associationApiMode string
@@ -68,6 +71,7 @@ type T interface {
 	UpdateInstanceAssociationStatus(
 		log log.T,
 		associationID string,
+		runID string,
 		associationName string,
 		instanceID string,
 		status string,
@@ -84,16 +88,33 @@ type AssociationService struct {
 	ssmSvc     ssmsvc.Service
 	stopPolicy *sdkutil.StopPolicy
 	name       string
+	outbox     outbox.T
 }
 
-// NewAssociationService returns a new association service
-func NewAssociationService(name string) *AssociationService {
+// StatusUpdate is an association status update waiting in the outbox
+type StatusUpdate struct {
+	AssociationID    string
+	AssociationName  string
+	InstanceID       string
+	Status           string
+	ErrorCode        string
+	ExecutionDate    string
+	ExecutionSummary string
+	OutputUrl        string
+}
+
+// NewAssociationService returns a new association service, status updates are delivered through the given outbox
+func NewAssociationService(name string, updates outbox.T) *AssociationService {
 	ssmService := ssmsvc.NewService()
 	policy := sdkutil.NewStopPolicy(name, stopPolicyErrorThreshold)
 	svc := AssociationService{
 		ssmSvc:     ssmService,
 		stopPolicy: policy,
 		name:       name,
+		outbox:     updates,
+	}
+	if updates != nil {
+		updates.RegisterSender(outbox.KindAssociationStatus, svc.sendInstanceAssociationStatus)
 	}
 
 	return &svc
@@ -206,10 +227,12 @@ func (s *AssociationService) DescribeAssociation(log log.T, instanceID string, d
 	return s.ssmSvc.DescribeAssociation(log, instanceID, docName)
 }
 
-// UpdateInstanceAssociationStatus will get the Association and related document string
+// UpdateInstanceAssociationStatus updates the status of the given run of an association.
+// The update is persisted in the outbox first, so it is retried until the service accepts it.
 func (s *AssociationService) UpdateInstanceAssociationStatus(
 	log log.T,
 	associationID string,
+	runID string,
 	associationName string,
 	instanceID string,
 	status string,
@@ -218,10 +241,51 @@ func (s *AssociationService) UpdateInstanceAssociationStatus(
 	executionSummary string,
 	outputUrl string) {
 
-	var err error
 	// Update status in schedulemanager to ensure state matches with the one on the service
 	schedulemanager.UpdateAssociationStatus(associationID, status)
 
+	update := StatusUpdate{
+		AssociationID:    associationID,
+		AssociationName:  associationName,
+		InstanceID:       instanceID,
+		Status:           status,
+		ErrorCode:        errorCode,
+		ExecutionDate:    executionDate,
+		ExecutionSummary: executionSummary,
+		OutputUrl:        outputUrl,
+	}
+	if s.outbox == nil {
+		s.updateInstanceAssociationStatus(log, update)
+		return
+	}
+	if err := s.outbox.Enqueue(log, outbox.KindAssociationStatus, associationID, runID, update); err != nil {
+		log.Errorf("unable to persist association status update, %v", err)
+		s.updateInstanceAssociationStatus(log, update)
+		return
+	}
+	s.outbox.Flush(log)
+}
+
+// sendInstanceAssociationStatus delivers a status update from the outbox
+func (s *AssociationService) sendInstanceAssociationStatus(log log.T, message outbox.Message) error {
+	var update StatusUpdate
+	if err := jsonutil.Remarshal(message.Payload, &update); err != nil {
+		return outbox.Permanent(fmt.Errorf("invalid association status update, %v", err))
+	}
+	return s.updateInstanceAssociationStatus(log, update)
+}
+
+// updateInstanceAssociationStatus calls the service to update the association status
+func (s *AssociationService) updateInstanceAssociationStatus(log log.T, update StatusUpdate) (err error) {
+	associationID := update.AssociationID
+	associationName := update.AssociationName
+	instanceID := update.InstanceID
+	status := update.Status
+	errorCode := update.ErrorCode
+	executionDate := update.ExecutionDate
+	executionSummary := update.ExecutionSummary
+	outputUrl := update.OutputUrl
+
 	if s.IsInstanceAssociationApiMode() {
 		date := times.ParseIso8601UTC(executionDate)
 
@@ -259,24 +323,22 @@ func (s *AssociationService) UpdateInstanceAssociationStatus(
 			// Otherwise log the error and return.
 
 			if associationID == associationName {
-				s.UpdateAssociationStatus(log, associationName, instanceID, status, executionSummary)
+				return s.updateAssociationStatus(log, associationName, instanceID, status, executionSummary)
 			}
 
-			return
+			return outbox.ClassifyAwsError(err)
 		}
 
 		var responseContent string
 		if responseContent, err = jsonutil.Marshal(response); err != nil {
 			log.Error("could not marshal response! ", err)
-			return
+			return nil
 		}
 		log.Info("Update instance association status response content is ", jsonutil.Indent(responseContent))
-		return
+		return nil
 	}
 
-	s.UpdateAssociationStatus(log, associationName, instanceID, status, executionSummary)
-
-	return
+	return s.updateAssociationStatus(log, associationName, instanceID, status, executionSummary)
 }
 
 // UsingInstanceAssociationApi represents if the agent is using new InstanceAssociationApi for listing and updating
@@ -329,16 +391,27 @@ func (s *AssociationService) UpdateAssociationStatus(
 	status string,
 	executionSummary string) {
 
+	s.updateAssociationStatus(log, associationName, instanceID, status, executionSummary)
+}
+
+// updateAssociationStatus calls the legacy api to update association status
+func (s *AssociationService) updateAssociationStatus(
+	log log.T,
+	associationName string,
+	instanceID string,
+	status string,
+	executionSummary string) error {
+
 	config, err := appconfig.Config(false)
 	if err != nil {
 		log.Errorf("unable to load config, %v", err)
-		return
+		return err
 	}
 
 	agentInfoContent, err := jsonutil.Marshal(config.Agent)
 	if err != nil {
 		log.Error("could not marshal agentInfo! ", err)
-		return
+		return err
 	}
 
 	currentTime := time.Now().UTC()
@@ -352,7 +425,7 @@ func (s *AssociationService) UpdateAssociationStatus(
 	associationStatusContent, err := jsonutil.Marshal(associationStatus)
 	if err != nil {
 		log.Error("could not marshal associationStatus! ", err)
-		return
+		return err
 	}
 	log.Info("Update association status content is ", jsonutil.Indent(associationStatusContent))
 
@@ -366,13 +439,14 @@ func (s *AssociationService) UpdateAssociationStatus(
 	if err != nil {
 		log.Errorf("unable to update association status, %v", err)
 		sdkutil.HandleAwsError(log, err, s.stopPolicy)
-		return
+		return outbox.ClassifyAwsError(err)
 	}
 
 	responseContent, err := jsonutil.Marshal(response)
 	if err != nil {
 		log.Error("could not marshal response! ", err)
-		return
+		return nil
 	}
 	log.Info("Update association status response content is ", jsonutil.Indent(responseContent))
+	return nil
 }
@@ -52,6 +52,7 @@ func (m *AssociationServiceMock) LoadAssociationDetail(log log.T, assoc *model.I
 func (m *AssociationServiceMock) UpdateInstanceAssociationStatus(
 	log log.T,
 	associationID string,
+	runID string,
 	associationName string,
 	instanceID string,
 	status string,
@@ -0,0 +1,45 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build darwin freebsd linux netbsd openbsd
+
+// Package trigger runs associations when local events happen
+package trigger
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// uptimeFile reports the time elapsed since the instance booted, on linux
+const uptimeFile = "/proc/uptime"
+
+// platformBootTime returns when the instance booted
+func platformBootTime() (time.Time, error) {
+	data, err := ioutil.ReadFile(uptimeFile)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unable to read the uptime, %v", err)
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return time.Time{}, fmt.Errorf("unable to read the uptime from %v", uptimeFile)
+	}
+	uptime, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unable to read the uptime, %v", err)
+	}
+	return time.Now().Add(-time.Duration(uptime * float64(time.Second))), nil
+}
@@ -0,0 +1,39 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build windows
+
+// Package trigger runs associations when local events happen
+package trigger
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var getTickCount64 = syscall.NewLazyDLL("kernel32.dll").NewProc("GetTickCount64")
+
+// platformBootTime returns when the instance booted
+func platformBootTime() (time.Time, error) {
+	if err := getTickCount64.Find(); err != nil {
+		return time.Time{}, err
+	}
+	low, high, _ := getTickCount64.Call()
+	milliseconds := uint64(low)
+	// 32 bit processes receive the upper half of the 64 bit result separately
+	if unsafe.Sizeof(low) == 4 {
+		milliseconds |= uint64(high) << 32
+	}
+	return time.Now().Add(-time.Duration(milliseconds) * time.Millisecond), nil
+}
@@ -0,0 +1,550 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package trigger
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/association/model"
+	"github.com/aws/amazon-ssm-agent/agent/association/schedulemanager"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/datauploader"
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// BootMarkersDirName represents the folder persisting, for each association, the boot time of the instance
+	// its boot trigger last ran for
+	BootMarkersDirName = "LastBoot"
+
+	// bootTimeTolerance absorbs the drift of the boot time computed from the uptime
+	bootTimeTolerance = time.Minute
+	// networkPollInterval is how often the network interfaces are checked
+	networkPollInterval = 15 * time.Second
+	// applicationTypeName is the inventory type of the installed packages
+	applicationTypeName = "AWS:Application"
+)
+
+var (
+	bootTime                = platformBootTime
+	upInterfaces            = platformUpInterfaces
+	isAssociationInProgress = schedulemanager.IsAssociationInProgress
+)
+
+// RunFunc runs the association whose trigger fired, reason describes the event
+type RunFunc func(log log.T, associationID string, reason string)
+
+// changeLog is the inventory change log the package triggers read the installed packages from
+type changeLog interface {
+	GetChanges(typeName string, maxResults int) (changes []datauploader.Change, err error)
+	ChangeLogPath() string
+}
+
+// Manager watches the events of the triggers of the scheduled associations and runs the associations when they happen
+type Manager struct {
+	log      log.T
+	run      RunFunc
+	debounce time.Duration
+	location string
+	changes  changeLog
+
+	lock               sync.Mutex
+	triggers           map[string][]Trigger
+	started            map[string]bool
+	pending            map[string]*time.Timer
+	updated            bool
+	bootRuns           map[string]time.Time
+	watcher            *fsnotify.Watcher
+	watched            map[string]bool
+	lastPackageCapture string
+	watchingNetwork    bool
+	stopSignal         chan bool
+	stopped            bool
+}
+
+// GetLocation returns the folder of the trigger state of the instance
+func GetLocation(instanceID string) string {
+	return filepath.Join(appconfig.DefaultDataStorePath,
+		instanceID,
+		appconfig.DefaultDocumentRootDirName,
+		appconfig.DefaultLocationOfAssociation)
+}
+
+// NewManager returns the manager of the triggers of the associations of the given instance,
+// events triggering an association within the debounce window run it once
+func NewManager(log log.T, instanceID string, debounce time.Duration, run RunFunc) *Manager {
+	var changes changeLog
+	if inventoryChangeLog, err := datauploader.NewChangeLogImplWithLocation(log, appconfig.InventoryRootDirName); err != nil {
+		log.Warnf("Package triggers are disabled, %v", err)
+	} else {
+		changes = inventoryChangeLog
+	}
+	return newManager(log, GetLocation(instanceID), changes, debounce, run)
+}
+
+// newManager returns a manager persisting its state in the given folder
+func newManager(log log.T, location string, changes changeLog, debounce time.Duration, run RunFunc) *Manager {
+	m := &Manager{
+		log:        log,
+		run:        run,
+		debounce:   debounce,
+		location:   location,
+		changes:    changes,
+		triggers:   map[string][]Trigger{},
+		started:    map[string]bool{},
+		pending:    map[string]*time.Timer{},
+		bootRuns:   map[string]time.Time{},
+		watched:    map[string]bool{},
+		stopSignal: make(chan bool),
+	}
+	// packages installed before the agent started don't trigger associations
+	if changes != nil {
+		if recorded, err := changes.GetChanges(applicationTypeName, 0); err == nil {
+			for _, change := range recorded {
+				if change.CaptureTime > m.lastPackageCapture {
+					m.lastPackageCapture = change.CaptureTime
+				}
+			}
+		}
+	}
+	return m
+}
+
+// Update reconciles the watched events with the triggers of the given associations. The agent start trigger
+// of an association fires the first time the association is seen by the agent, the boot triggers fire
+// on the first update after the agent starts only.
+func (m *Manager) Update(log log.T, associations []*model.InstanceAssociation) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.stopped {
+		return
+	}
+
+	triggers := map[string][]Trigger{}
+	for _, assoc := range associations {
+		if assoc.Document == nil {
+			continue
+		}
+		associationID := *assoc.Association.AssociationId
+		declared, err := ForDocument(*assoc.Document)
+		if err != nil {
+			log.Errorf("Association %v runs on its schedule only, %v", associationID, err)
+			continue
+		}
+		if len(declared) > 0 {
+			triggers[associationID] = declared
+		}
+	}
+
+	// the pending runs of the associations that are no longer triggered are canceled
+	for associationID, timer := range m.pending {
+		if _, found := triggers[associationID]; !found {
+			timer.Stop()
+			delete(m.pending, associationID)
+			delete(m.bootRuns, associationID)
+		}
+	}
+	m.triggers = triggers
+
+	for associationID, declared := range triggers {
+		if m.started[associationID] {
+			continue
+		}
+		m.started[associationID] = true
+		for _, trigger := range declared {
+			if trigger.Kind == AgentStart {
+				m.fire(log, associationID, "agent start")
+			}
+		}
+	}
+	if !m.updated {
+		m.updated = true
+		m.fireBootTriggers(log)
+	}
+
+	m.watchFiles(log)
+	if !m.watchingNetwork && m.hasTrigger(NetworkUp) {
+		m.watchingNetwork = true
+		go m.watchNetwork()
+	}
+}
+
+// Stop stops watching the events and cancels the pending runs
+func (m *Manager) Stop() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.stopped {
+		return
+	}
+	m.stopped = true
+	close(m.stopSignal)
+	for associationID, timer := range m.pending {
+		timer.Stop()
+		delete(m.pending, associationID)
+	}
+	if m.watcher != nil {
+		if err := m.watcher.Close(); err != nil {
+			m.log.Debugf("Error closing the trigger file watcher, %v", err)
+		}
+	}
+}
+
+// fire runs the association once the debounce window after the event elapses, the events of the association
+// within the window are coalesced. Events happening while the association is in progress are ignored,
+// as they are usually caused by the association itself. Lock must be held by the caller.
+func (m *Manager) fire(log log.T, associationID string, reason string) {
+	if _, pending := m.pending[associationID]; pending {
+		log.Debugf("Association %v already triggered, ignoring %v", associationID, reason)
+		return
+	}
+	if isAssociationInProgress(associationID) {
+		log.Debugf("Association %v is in progress, ignoring %v", associationID, reason)
+		return
+	}
+	log.Infof("Association %v triggered by %v", associationID, reason)
+	m.pending[associationID] = time.AfterFunc(m.debounce, func() {
+		m.runPending(associationID, reason)
+	})
+}
+
+// runPending runs a triggered association unless its run was canceled
+func (m *Manager) runPending(associationID string, reason string) {
+	m.lock.Lock()
+	if _, pending := m.pending[associationID]; !pending || m.stopped {
+		m.lock.Unlock()
+		return
+	}
+	delete(m.pending, associationID)
+	m.lock.Unlock()
+
+	m.run(m.log, associationID, reason)
+}
+
+// RunRecorded persists the boot time of the instance for an association whose boot trigger fired,
+// once its run is recorded, so the boot trigger doesn't run again until the instance boots again
+func (m *Manager) RunRecorded(log log.T, associationID string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	booted, found := m.bootRuns[associationID]
+	if !found {
+		return
+	}
+	delete(m.bootRuns, associationID)
+
+	location := filepath.Join(m.location, BootMarkersDirName)
+	if err := fileutil.MakeDirs(location); err != nil {
+		log.Errorf("Cannot make directory of %v because: %v", location, err)
+		return
+	}
+	// write a temporary file first so the boot time is never read partially written
+	markerPath := filepath.Join(location, associationID)
+	tempPath := filepath.Join(location, "."+associationID+".tmp")
+	if err := ioutil.WriteFile(tempPath, []byte(booted.UTC().Format(time.RFC3339)), os.FileMode(int(appconfig.ReadWriteAccess))); err != nil {
+		log.Errorf("Unable to persist the boot time of association %v, %v", associationID, err)
+		return
+	}
+	if err := os.Rename(tempPath, markerPath); err != nil {
+		os.Remove(tempPath)
+		log.Errorf("Unable to persist the boot time of association %v, %v", associationID, err)
+	}
+}
+
+// hasTrigger returns if any association has a trigger of the given kind
+func (m *Manager) hasTrigger(kind string) bool {
+	for _, declared := range m.triggers {
+		for _, trigger := range declared {
+			if trigger.Kind == kind {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fireBootTriggers fires the boot triggers of the associations whose boot triggers didn't run since the instance booted,
+// the boot triggers run each time the agent starts when the boot time is unknown. Lock must be held by the caller.
+func (m *Manager) fireBootTriggers(log log.T) {
+	if !m.hasTrigger(Boot) {
+		return
+	}
+	booted, err := bootTime()
+	if err != nil {
+		log.Debugf("Boot triggers run each time the agent starts, %v", err)
+	}
+	for associationID, declared := range m.triggers {
+		for _, trigger := range declared {
+			if trigger.Kind != Boot {
+				continue
+			}
+			if err == nil {
+				if !m.isNewBoot(associationID, booted) {
+					break
+				}
+				m.bootRuns[associationID] = booted
+			}
+			m.fire(log, associationID, "instance boot")
+			break
+		}
+	}
+}
+
+// isNewBoot returns if the instance booted since the boot trigger of the association last ran
+func (m *Manager) isNewBoot(associationID string, booted time.Time) bool {
+	data, err := ioutil.ReadFile(filepath.Join(m.location, BootMarkersDirName, associationID))
+	if err != nil {
+		return true
+	}
+	lastBoot, err := time.Parse(time.RFC3339, strings.TrimSpace(string(data)))
+	if err != nil {
+		return true
+	}
+	elapsed := booted.Sub(lastBoot)
+	return elapsed > bootTimeTolerance || elapsed < -bootTimeTolerance
+}
+
+// watchFiles watches the folders of the file triggers and of the inventory change log. A file trigger on a directory
+// watches the files directly within the directory, a file trigger on a file watches its parent directory
+// so the file is watched even when it is replaced. Folders that can't be watched yet are retried on the next update.
+func (m *Manager) watchFiles(log log.T) {
+	dirs := map[string]bool{}
+	for _, declared := range m.triggers {
+		for _, trigger := range declared {
+			if trigger.Kind == File {
+				dirs[watchedDirectory(trigger.Value)] = true
+			} else if trigger.Kind == PackageInstalled && m.changes != nil {
+				dirs[filepath.Dir(m.changes.ChangeLogPath())] = true
+			}
+		}
+	}
+
+	if len(dirs) > 0 && m.watcher == nil {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			log.Errorf("File and package triggers are disabled, unable to create the file watcher, %v", err)
+			return
+		}
+		m.watcher = watcher
+		go m.handleFileEvents(watcher)
+	}
+	for dir := range m.watched {
+		if !dirs[dir] {
+			m.watcher.Remove(dir)
+			delete(m.watched, dir)
+		}
+	}
+	for dir := range dirs {
+		if m.watched[dir] {
+			continue
+		}
+		if err := m.watcher.Add(dir); err != nil {
+			log.Warnf("Unable to watch %v for association triggers, %v", dir, err)
+			continue
+		}
+		m.watched[dir] = true
+	}
+}
+
+// watchedDirectory returns the directory watched for a file trigger
+func watchedDirectory(path string) string {
+	if fileutil.IsDirectory(path) {
+		return path
+	}
+	return filepath.Dir(path)
+}
+
+// handleFileEvents handles the events of the file watcher until it is closed
+func (m *Manager) handleFileEvents(watcher *fsnotify.Watcher) {
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			m.onFileEvent(event)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			m.log.Debugf("Error watching the files of association triggers, %v", err)
+		}
+	}
+}
+
+// onFileEvent fires the file triggers of the changed file, and the package triggers when inventory updates its change log
+func (m *Manager) onFileEvent(event fsnotify.Event) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.stopped {
+		return
+	}
+
+	name := filepath.Clean(event.Name)
+	for associationID, declared := range m.triggers {
+		for _, trigger := range declared {
+			if trigger.Kind == File && (name == trigger.Value || filepath.Dir(name) == trigger.Value) {
+				m.fire(m.log, associationID, fmt.Sprintf("%v of %v", event.Op, name))
+				break
+			}
+		}
+	}
+	if m.changes != nil && name == filepath.Clean(m.changes.ChangeLogPath()) {
+		m.onPackagesChanged()
+	}
+}
+
+// onPackagesChanged fires the package triggers of the packages installed or updated since the last inventory change seen,
+// lock must be held by the caller
+func (m *Manager) onPackagesChanged() {
+	changes, err := m.changes.GetChanges(applicationTypeName, 0)
+	if err != nil {
+		m.log.Debugf("Unable to read the installed packages, %v", err)
+		return
+	}
+
+	installed := []string{}
+	lastCapture := m.lastPackageCapture
+	for _, change := range changes {
+		if change.CaptureTime <= m.lastPackageCapture {
+			continue
+		}
+		if change.CaptureTime > lastCapture {
+			lastCapture = change.CaptureTime
+		}
+		for _, entry := range change.Added {
+			installed = appendPackageName(installed, entry)
+		}
+		for _, entry := range change.Changed {
+			installed = appendPackageName(installed, entry.New)
+		}
+	}
+	m.lastPackageCapture = lastCapture
+	if len(installed) == 0 {
+		return
+	}
+
+	for associationID, declared := range m.triggers {
+		for _, trigger := range declared {
+			if trigger.Kind == PackageInstalled && containsPackage(installed, trigger.Value) {
+				m.fire(m.log, associationID, "installation of "+strings.Join(installed, ", "))
+				break
+			}
+		}
+	}
+}
+
+// appendPackageName appends the name of the inventory entry of a package
+func appendPackageName(names []string, entry map[string]*string) []string {
+	if name, found := entry["Name"]; found && name != nil && *name != "" {
+		return append(names, *name)
+	}
+	return names
+}
+
+// containsPackage returns if the package of the given name is installed, any package matches an empty name
+func containsPackage(installed []string, name string) bool {
+	if name == "" {
+		return true
+	}
+	for _, packageName := range installed {
+		if strings.EqualFold(packageName, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// watchNetwork polls the network interfaces until the manager is stopped, the first poll is the baseline
+func (m *Manager) watchNetwork() {
+	ticker := time.NewTicker(networkPollInterval)
+	defer ticker.Stop()
+
+	var up map[string]bool
+	for {
+		if current, err := upInterfaces(); err != nil {
+			m.log.Debugf("Unable to list the network interfaces, %v", err)
+		} else {
+			if up != nil {
+				m.onNetworkChanged(up, current)
+			}
+			up = current
+		}
+
+		select {
+		case <-m.stopSignal:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// onNetworkChanged fires the network up triggers when an interface that was down is up
+func (m *Manager) onNetworkChanged(previous map[string]bool, current map[string]bool) {
+	cameUp := []string{}
+	for name := range current {
+		if !previous[name] {
+			cameUp = append(cameUp, name)
+		}
+	}
+	if len(cameUp) == 0 {
+		return
+	}
+	sort.Strings(cameUp)
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.stopped {
+		return
+	}
+	for associationID, declared := range m.triggers {
+		for _, trigger := range declared {
+			if trigger.Kind == NetworkUp {
+				m.fire(m.log, associationID, "network interface "+strings.Join(cameUp, ", ")+" up")
+				break
+			}
+		}
+	}
+}
+
+// platformUpInterfaces returns the names of the network interfaces that are up and have an address, loopback excepted
+func platformUpInterfaces() (map[string]bool, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	up := map[string]bool{}
+	for _, i := range interfaces {
+		if i.Flags&net.FlagUp == 0 || i.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		if addresses, err := i.Addrs(); err == nil && len(addresses) > 0 {
+			up[i.Name] = true
+		}
+	}
+	return up, nil
+}
@@ -0,0 +1,97 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package trigger runs associations when local events happen, in addition to their schedule,
+// e.g. when the agent starts, the instance boots, a watched file changes, a network interface comes up
+// or a package is installed
+package trigger
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+)
+
+const (
+	// AgentStart triggers the association once each time the agent starts
+	AgentStart = "agent-start"
+	// Boot triggers the association once after the instance boots
+	Boot = "boot"
+	// NetworkUp triggers the association when a network interface comes up
+	NetworkUp = "network-up"
+	// File triggers the association when the file, or a file directly within the directory, changes
+	File = "file"
+	// PackageInstalled triggers the association when inventory detects an installed or updated package,
+	// optionally only the package of the given name
+	PackageInstalled = "package-installed"
+)
+
+// Trigger is a local event that runs an association
+type Trigger struct {
+	Kind  string
+	Value string
+}
+
+// String returns the trigger as declared in a document, e.g. for logging
+func (t Trigger) String() string {
+	if t.Value == "" {
+		return t.Kind
+	}
+	return t.Kind + ":" + t.Value
+}
+
+// Parse returns the declared triggers, a trigger is one of agent-start, boot, network-up, file:<path>,
+// package-installed or package-installed:<name>
+func Parse(declared []string) ([]Trigger, error) {
+	triggers := []Trigger{}
+	for _, trigger := range declared {
+		trigger = strings.TrimSpace(trigger)
+		kind, value := trigger, ""
+		if i := strings.Index(trigger, ":"); i >= 0 {
+			kind, value = trigger[:i], strings.TrimSpace(trigger[i+1:])
+		}
+		kind = strings.ToLower(strings.TrimSpace(kind))
+
+		switch kind {
+		case AgentStart, Boot, NetworkUp:
+			if value != "" {
+				return nil, fmt.Errorf("trigger %v takes no value", trigger)
+			}
+		case File:
+			if value == "" {
+				return nil, fmt.Errorf("trigger %v has no path", trigger)
+			}
+			if !filepath.IsAbs(value) {
+				return nil, fmt.Errorf("trigger %v has a relative path", trigger)
+			}
+			value = filepath.Clean(value)
+		case PackageInstalled:
+		default:
+			return nil, fmt.Errorf("unknown trigger %v", trigger)
+		}
+		triggers = append(triggers, Trigger{Kind: kind, Value: value})
+	}
+	return triggers, nil
+}
+
+// ForDocument returns the triggers declared by the document
+func ForDocument(document string) ([]Trigger, error) {
+	var content contracts.DocumentContent
+	if err := json.Unmarshal([]byte(document), &content); err != nil {
+		return nil, fmt.Errorf("unable to read the triggers of the document, %v", err)
+	}
+	return Parse(content.Triggers)
+}
@@ -0,0 +1,320 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package trigger
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/association/model"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/datauploader"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/stretchr/testify/assert"
+)
+
+const testDebounce = 50 * time.Millisecond
+
+type fakeChangeLog struct {
+	changes []datauploader.Change
+	path    string
+}
+
+func (f *fakeChangeLog) GetChanges(typeName string, maxResults int) ([]datauploader.Change, error) {
+	return f.changes, nil
+}
+
+func (f *fakeChangeLog) ChangeLogPath() string {
+	return f.path
+}
+
+// runs records the associations run by a manager
+type runs chan string
+
+func (r runs) run(log log.T, associationID string, reason string) {
+	r <- associationID
+}
+
+// expect returns the associations run within the timeout, sorted
+func (r runs) expect(timeout time.Duration) []string {
+	run := []string{}
+	for {
+		select {
+		case associationID := <-r:
+			run = append(run, associationID)
+		case <-time.After(timeout):
+			sort.Strings(run)
+			return run
+		}
+	}
+}
+
+func newTestManager(t *testing.T, changes changeLog) (*Manager, runs, string) {
+	location, err := ioutil.TempDir("", "trigger")
+	assert.Nil(t, err)
+	triggered := make(runs, 10)
+	return newManager(log.NewMockLog(), location, changes, testDebounce, triggered.run), triggered, location
+}
+
+func triggeredAssociation(associationID string, document string) *model.InstanceAssociation {
+	return &model.InstanceAssociation{
+		Association: &ssm.InstanceAssociationSummary{AssociationId: aws.String(associationID)},
+		Document:    aws.String(document),
+	}
+}
+
+// packagesChanged handles a change of the inventory change log as the file watcher does
+func packagesChanged(manager *Manager) {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+	manager.onPackagesChanged()
+}
+
+func notInProgress(associationID string) bool {
+	return false
+}
+
+func TestParse(t *testing.T) {
+	triggers, err := Parse([]string{"agent-start", " Boot ", "network-up", "file:/etc/nginx/", "package-installed", "package-installed:nginx"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []Trigger{
+		{Kind: AgentStart},
+		{Kind: Boot},
+		{Kind: NetworkUp},
+		{Kind: File, Value: filepath.Clean("/etc/nginx")},
+		{Kind: PackageInstalled},
+		{Kind: PackageInstalled, Value: "nginx"},
+	}, triggers)
+}
+
+func TestParseReturnsErrorForInvalidTrigger(t *testing.T) {
+	for _, trigger := range []string{"", "reboot", "boot:now", "file:", "file:nginx.conf"} {
+		_, err := Parse([]string{trigger})
+
+		assert.NotNil(t, err, trigger)
+	}
+}
+
+func TestForDocument(t *testing.T) {
+	triggers, err := ForDocument(`{"schemaVersion": "2.2", "triggers": ["agent-start"]}`)
+	assert.Nil(t, err)
+	assert.Equal(t, []Trigger{{Kind: AgentStart}}, triggers)
+
+	triggers, err = ForDocument(`{"schemaVersion": "2.2"}`)
+	assert.Nil(t, err)
+	assert.Empty(t, triggers)
+
+	_, err = ForDocument(`{`)
+	assert.NotNil(t, err)
+}
+
+func TestAgentStartTriggerRunsAssociationOnce(t *testing.T) {
+	isAssociationInProgress = notInProgress
+	manager, triggered, location := newTestManager(t, nil)
+	defer os.RemoveAll(location)
+	defer manager.Stop()
+
+	associations := []*model.InstanceAssociation{
+		triggeredAssociation("started", `{"triggers": ["agent-start"]}`),
+		triggeredAssociation("scheduled", `{}`),
+	}
+	manager.Update(log.NewMockLog(), associations)
+	manager.Update(log.NewMockLog(), associations)
+
+	assert.Equal(t, []string{"started"}, triggered.expect(4*testDebounce))
+}
+
+func TestBootTriggerRunsAssociationOncePerBoot(t *testing.T) {
+	isAssociationInProgress = notInProgress
+	booted := time.Now().Add(-time.Hour)
+	bootTime = func() (time.Time, error) { return booted, nil }
+	defer func() { bootTime = platformBootTime }()
+
+	location, err := ioutil.TempDir("", "trigger")
+	assert.Nil(t, err)
+	defer os.RemoveAll(location)
+	associations := []*model.InstanceAssociation{triggeredAssociation("onboot", `{"triggers": ["boot"]}`)}
+
+	for _, restart := range []struct {
+		bootTime time.Time
+		run      []string
+	}{
+		{booted, []string{"onboot"}},
+		// the boot time computed from the uptime drifts slightly between agent restarts
+		{booted.Add(time.Second), []string{}},
+		{booted.Add(30 * time.Minute), []string{"onboot"}},
+	} {
+		booted = restart.bootTime
+		triggered := make(runs, 10)
+		manager := newManager(log.NewMockLog(), location, nil, testDebounce, triggered.run)
+		manager.Update(log.NewMockLog(), associations)
+
+		run := triggered.expect(4 * testDebounce)
+		assert.Equal(t, restart.run, run)
+		for _, associationID := range run {
+			manager.RunRecorded(log.NewMockLog(), associationID)
+		}
+		manager.Stop()
+	}
+}
+
+func TestBootTriggerRunsAgainWhenTheRunWasNotRecorded(t *testing.T) {
+	isAssociationInProgress = notInProgress
+	booted := time.Now().Add(-time.Hour)
+	bootTime = func() (time.Time, error) { return booted, nil }
+	defer func() { bootTime = platformBootTime }()
+
+	location, err := ioutil.TempDir("", "trigger")
+	assert.Nil(t, err)
+	defer os.RemoveAll(location)
+	associations := []*model.InstanceAssociation{
+		triggeredAssociation("onboot", `{"triggers": ["boot"]}`),
+		triggeredAssociation("recorded", `{"triggers": ["boot"]}`),
+	}
+
+	triggered := make(runs, 10)
+	manager := newManager(log.NewMockLog(), location, nil, testDebounce, triggered.run)
+	manager.Update(log.NewMockLog(), associations)
+	assert.Equal(t, []string{"onboot", "recorded"}, triggered.expect(4*testDebounce))
+	// the agent stops before the run of onboot is recorded
+	manager.RunRecorded(log.NewMockLog(), "recorded")
+	manager.Stop()
+
+	triggered = make(runs, 10)
+	manager = newManager(log.NewMockLog(), location, nil, testDebounce, triggered.run)
+	defer manager.Stop()
+	manager.Update(log.NewMockLog(), associations)
+	assert.Equal(t, []string{"onboot"}, triggered.expect(4*testDebounce))
+}
+
+func TestBootTriggerDoesNotRunForAssociationsSeenAfterStart(t *testing.T) {
+	isAssociationInProgress = notInProgress
+	bootTime = func() (time.Time, error) { return time.Now().Add(-time.Hour), nil }
+	defer func() { bootTime = platformBootTime }()
+	manager, triggered, location := newTestManager(t, nil)
+	defer os.RemoveAll(location)
+	defer manager.Stop()
+
+	manager.Update(log.NewMockLog(), []*model.InstanceAssociation{triggeredAssociation("scheduled", `{}`)})
+	manager.Update(log.NewMockLog(), []*model.InstanceAssociation{
+		triggeredAssociation("scheduled", `{}`),
+		triggeredAssociation("onboot", `{"triggers": ["boot"]}`),
+	})
+
+	assert.Empty(t, triggered.expect(4*testDebounce))
+}
+
+func TestEventsWithinDebounceWindowRunAssociationOnce(t *testing.T) {
+	isAssociationInProgress = notInProgress
+	manager, triggered, location := newTestManager(t, nil)
+	defer os.RemoveAll(location)
+	defer manager.Stop()
+
+	manager.Update(log.NewMockLog(), []*model.InstanceAssociation{triggeredAssociation("online", `{"triggers": ["network-up"]}`)})
+	manager.onNetworkChanged(map[string]bool{}, map[string]bool{"eth0": true})
+	manager.onNetworkChanged(map[string]bool{"eth0": true}, map[string]bool{"eth0": true, "eth1": true})
+	manager.onNetworkChanged(map[string]bool{"eth0": true, "eth1": true}, map[string]bool{"eth0": true})
+
+	assert.Equal(t, []string{"online"}, triggered.expect(4*testDebounce))
+}
+
+func TestEventsAreIgnoredWhileAssociationInProgress(t *testing.T) {
+	isAssociationInProgress = func(associationID string) bool { return true }
+	defer func() { isAssociationInProgress = notInProgress }()
+	manager, triggered, location := newTestManager(t, nil)
+	defer os.RemoveAll(location)
+	defer manager.Stop()
+
+	manager.Update(log.NewMockLog(), []*model.InstanceAssociation{triggeredAssociation("running", `{"triggers": ["agent-start"]}`)})
+
+	assert.Empty(t, triggered.expect(4*testDebounce))
+}
+
+func TestUpdateCancelsPendingRunOfAssociationNoLongerTriggered(t *testing.T) {
+	isAssociationInProgress = notInProgress
+	manager, triggered, location := newTestManager(t, nil)
+	defer os.RemoveAll(location)
+	defer manager.Stop()
+
+	manager.Update(log.NewMockLog(), []*model.InstanceAssociation{triggeredAssociation("removed", `{"triggers": ["agent-start"]}`)})
+	manager.Update(log.NewMockLog(), []*model.InstanceAssociation{})
+
+	assert.Empty(t, triggered.expect(4*testDebounce))
+}
+
+func TestFileTriggerRunsAssociationWhenFileChanges(t *testing.T) {
+	isAssociationInProgress = notInProgress
+	manager, triggered, location := newTestManager(t, nil)
+	defer os.RemoveAll(location)
+	defer manager.Stop()
+
+	config := filepath.Join(location, "nginx.conf")
+	assert.Nil(t, ioutil.WriteFile(config, []byte("worker_processes 1;"), 0600))
+	documents := map[string]string{
+		"config":    `{"triggers": ["file:` + filepath.ToSlash(config) + `"]}`,
+		"directory": `{"triggers": ["file:` + filepath.ToSlash(location) + `"]}`,
+		"other":     `{"triggers": ["file:` + filepath.ToSlash(filepath.Join(location, "other.conf")) + `"]}`,
+	}
+	associations := []*model.InstanceAssociation{}
+	for associationID, document := range documents {
+		associations = append(associations, triggeredAssociation(associationID, document))
+	}
+	manager.Update(log.NewMockLog(), associations)
+
+	assert.Nil(t, ioutil.WriteFile(config, []byte("worker_processes 2;"), 0600))
+
+	assert.Equal(t, []string{"config", "directory"}, triggered.expect(20*testDebounce))
+}
+
+func TestPackageTriggerRunsAssociationWhenPackageIsInstalled(t *testing.T) {
+	isAssociationInProgress = notInProgress
+	changes := &fakeChangeLog{
+		changes: []datauploader.Change{
+			{TypeName: applicationTypeName, CaptureTime: "2018-01-01T00:00:00Z", Added: []map[string]*string{{"Name": aws.String("nginx")}}},
+		},
+	}
+	manager, triggered, location := newTestManager(t, changes)
+	defer os.RemoveAll(location)
+	defer manager.Stop()
+	manager.Update(log.NewMockLog(), []*model.InstanceAssociation{
+		triggeredAssociation("nginx", `{"triggers": ["package-installed:nginx"]}`),
+		triggeredAssociation("any", `{"triggers": ["package-installed"]}`),
+	})
+
+	// packages installed before the agent started are ignored
+	packagesChanged(manager)
+	assert.Empty(t, triggered.expect(4*testDebounce))
+
+	changes.changes = append(changes.changes, datauploader.Change{
+		TypeName:    applicationTypeName,
+		CaptureTime: "2018-01-02T00:00:00Z",
+		Changed:     []datauploader.EntryChange{{Key: "curl", New: map[string]*string{"Name": aws.String("curl")}}},
+	})
+	packagesChanged(manager)
+	assert.Equal(t, []string{"any"}, triggered.expect(4*testDebounce))
+
+	changes.changes = append(changes.changes, datauploader.Change{
+		TypeName:    applicationTypeName,
+		CaptureTime: "2018-01-03T00:00:00Z",
+		Added:       []map[string]*string{{"Name": aws.String("NGINX")}},
+	})
+	packagesChanged(manager)
+	assert.Equal(t, []string{"any", "nginx"}, triggered.expect(4*testDebounce))
+}
@@ -0,0 +1,154 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/localpackages"
+	"github.com/aws/amazon-ssm-agent/agent/trace"
+)
+
+const (
+	cleanPackageRepositoryCommand = "clean-package-repository"
+	cleanPackageRepositoryDryRun  = "dry-run"
+)
+
+const cleanPackageRepositoryCommandHelp = `NAME:
+    {{.CleanPackageRepositoryCommandName}}
+
+DESCRIPTION
+    Removes package versions that are no longer needed from the local repository used by aws:configurePackage.
+    The installed version of each package and packages with an install or uninstall in progress are never removed.
+    Retention is controlled by the Packages section of the agent configuration.
+
+SYNOPSIS
+    {{.CleanPackageRepositoryCommandName}}
+    [{{.DryRunFlag}}]
+
+PARAMETERS
+    {{.DryRunFlag}} Report the package versions that would be removed without removing them.
+
+EXAMPLES
+    This example lists the package versions that would be removed.
+
+    Command:
+
+      {{.SsmCliName}} {{.CleanPackageRepositoryCommandName}} {{.DryRunFlag}}
+
+    Output:
+      {
+        "removed": [
+          {
+            "name": "AWSPVDriver",
+            "version": "8.2.1",
+            "reason": "exceeds retained previous versions",
+            "bytes": 10485760
+          }
+        ],
+        "reclaimedbytes": 10485760,
+        "repositorybytes": 20971520,
+        "dryrun": true
+      }
+
+OUTPUT
+    The removed package versions and the repository size after garbage collection in JSON format
+`
+
+type cleanPackageRepositoryHelpParams struct {
+	SsmCliName                        string
+	CleanPackageRepositoryCommandName string
+	DryRunFlag                        string
+}
+
+func init() {
+	cliutil.Register(&CleanPackageRepositoryCommand{})
+}
+
+type CleanPackageRepositoryCommand struct {
+	helpText string
+}
+
+// Execute validates and executes the clean-package-repository cli command
+func (c *CleanPackageRepositoryCommand) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	validation := c.validateCleanPackageRepositoryCommandInput(subcommands, parameters)
+	// return validation errors if any were found
+	if len(validation) > 0 {
+		return errors.New(strings.Join(validation, "\n")), ""
+	}
+
+	config, _ := appconfig.Config(false)
+	policy := localpackages.NewRetentionPolicy(config.Packages)
+	_, policy.DryRun = parameters[cleanPackageRepositoryDryRun]
+
+	// wait for the package actions of the agent to complete
+	unlockRepository, err := localpackages.LockRepository(true)
+	if err != nil {
+		return err, ""
+	}
+	defer unlockRepository()
+
+	tracer := trace.NewTracer(log.NewMockLog())
+	result, err := localpackages.NewRepository().CollectGarbage(tracer, policy)
+	if err != nil {
+		return err, ""
+	}
+
+	output, _ := jsonutil.MarshalIndent(result)
+	return nil, output
+}
+
+// Help prints help for the clean-package-repository cli command
+func (c *CleanPackageRepositoryCommand) Help() string {
+	if len(c.helpText) == 0 {
+		t, _ := template.New("CleanPackageRepositoryCommandHelp").Parse(cleanPackageRepositoryCommandHelp)
+		params := cleanPackageRepositoryHelpParams{cliutil.SsmCliName, cleanPackageRepositoryCommand, cliutil.FormatFlag(cleanPackageRepositoryDryRun)}
+		buf := new(bytes.Buffer)
+		t.Execute(buf, params)
+		c.helpText = buf.String()
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (CleanPackageRepositoryCommand) Name() string {
+	return cleanPackageRepositoryCommand
+}
+
+// validateCleanPackageRepositoryCommandInput checks the subcommands and parameters for required values, format, and unsupported values
+func (CleanPackageRepositoryCommand) validateCleanPackageRepositoryCommandInput(subcommands []string, parameters map[string][]string) []string {
+	validation := make([]string, 0)
+	if subcommands != nil && len(subcommands) > 0 {
+		validation = append(validation, fmt.Sprintf("%v does not support subcommand %v", cleanPackageRepositoryCommand, subcommands), "")
+		return validation // invalid subcommand is an attempt to execute something that really isn't this command, so the rest of the validation is skipped in this case
+	}
+
+	for key, values := range parameters {
+		if key != cleanPackageRepositoryDryRun {
+			validation = append(validation, fmt.Sprintf("unknown parameter %v", cliutil.FormatFlag(key)))
+		} else if len(values) > 0 {
+			validation = append(validation, fmt.Sprintf("%v does not take a value", cliutil.FormatFlag(key)))
+		}
+	}
+	return validation
+}
@@ -0,0 +1,189 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/aws/amazon-ssm-agent/agent/association/history"
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/platform"
+)
+
+const (
+	describeAssociationRunsCommand       = "describe-association-runs"
+	describeAssociationRunsAssociationID = "association-id"
+	describeAssociationRunsMaxResults    = "max-results"
+
+	// defaultMaxAssociationRuns is the number of runs described when max-results isn't set
+	defaultMaxAssociationRuns = 10
+)
+
+const describeAssociationRunsCommandHelp = `NAME:
+    {{.DescribeAssociationRunsCommandName}}
+
+DESCRIPTION
+    Describes the association runs recorded in the local association history, most recent first,
+    with the status and a summary of the output of each step. The history keeps the last 500 runs.
+
+SYNOPSIS
+    {{.DescribeAssociationRunsCommandName}}
+    [{{.AssociationIDFlag}}]
+    [{{.MaxResultsFlag}}]
+
+PARAMETERS
+    {{.AssociationIDFlag}} (string) Only describe the runs of this association.
+
+    {{.MaxResultsFlag}} (integer) The number of runs to describe, 10 by default.
+
+EXAMPLES
+    This example describes the last run of an association.
+
+    Command:
+
+      {{.SsmCliName}} {{.DescribeAssociationRunsCommandName}} {{.AssociationIDFlag}} 8dfe3659-4309-493a-8755-0123456789ab {{.MaxResultsFlag}} 1
+
+    Output:
+      [
+        {
+          "AssociationID": "8dfe3659-4309-493a-8755-0123456789ab",
+          "DocumentName": "AWS-RunShellScript",
+          "DocumentVersion": "1",
+          "RunID": "2018-01-01T00-00-00.000Z",
+          "StartDateTime": "2018-01-01T00:00:00Z",
+          "EndDateTime": "2018-01-01T00:00:05Z",
+          "Status": "Success",
+          "Message": "1 out of 1 plugin processed, 1 success, 0 failed, 0 timedout, 0 skipped",
+          "Steps": [
+            {
+              "PluginID": "aws:runShellScript",
+              "PluginName": "aws:runShellScript",
+              "Status": "Success",
+              "Code": 0,
+              "StartDateTime": "2018-01-01T00:00:01Z",
+              "EndDateTime": "2018-01-01T00:00:05Z",
+              "OutputSummary": "nginx is running"
+            }
+          ]
+        }
+      ]
+
+OUTPUT
+    The association runs in JSON format
+`
+
+type describeAssociationRunsHelpParams struct {
+	SsmCliName                         string
+	DescribeAssociationRunsCommandName string
+	AssociationIDFlag                  string
+	MaxResultsFlag                     string
+}
+
+func init() {
+	cliutil.Register(&DescribeAssociationRunsCommand{})
+}
+
+type DescribeAssociationRunsCommand struct {
+	helpText string
+}
+
+// Execute validates and executes the describe-association-runs cli command
+func (c *DescribeAssociationRunsCommand) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	validation, associationID, maxResults := c.validateDescribeAssociationRunsCommandInput(subcommands, parameters)
+	// return validation errors if any were found
+	if len(validation) > 0 {
+		return errors.New(strings.Join(validation, "\n")), ""
+	}
+
+	instanceID, err := platform.InstanceID()
+	if err != nil {
+		return fmt.Errorf("unable to retrieve instance id, %v", err), ""
+	}
+	runs, err := history.ReadRuns(history.GetLocation(instanceID))
+	if err != nil {
+		return err, ""
+	}
+
+	result := []history.Run{}
+	for i := len(runs) - 1; i >= 0 && len(result) < maxResults; i-- {
+		if associationID == "" || runs[i].AssociationID == associationID {
+			result = append(result, runs[i])
+		}
+	}
+
+	output, _ := jsonutil.MarshalIndent(result)
+	return nil, output
+}
+
+// Help prints help for the describe-association-runs cli command
+func (c *DescribeAssociationRunsCommand) Help() string {
+	if len(c.helpText) == 0 {
+		t, _ := template.New("DescribeAssociationRunsCommandHelp").Parse(describeAssociationRunsCommandHelp)
+		params := describeAssociationRunsHelpParams{cliutil.SsmCliName, describeAssociationRunsCommand,
+			cliutil.FormatFlag(describeAssociationRunsAssociationID),
+			cliutil.FormatFlag(describeAssociationRunsMaxResults)}
+		buf := new(bytes.Buffer)
+		t.Execute(buf, params)
+		c.helpText = buf.String()
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (DescribeAssociationRunsCommand) Name() string {
+	return describeAssociationRunsCommand
+}
+
+// validateDescribeAssociationRunsCommandInput checks the subcommands and parameters for required values, format, and unsupported values
+func (DescribeAssociationRunsCommand) validateDescribeAssociationRunsCommandInput(subcommands []string, parameters map[string][]string) (validation []string, associationID string, maxResults int) {
+	validation = make([]string, 0)
+	maxResults = defaultMaxAssociationRuns
+	if subcommands != nil && len(subcommands) > 0 {
+		validation = append(validation, fmt.Sprintf("%v does not support subcommand %v", describeAssociationRunsCommand, subcommands), "")
+		return validation, "", maxResults // invalid subcommand is an attempt to execute something that really isn't this command, so the rest of the validation is skipped in this case
+	}
+
+	if values, exists := parameters[describeAssociationRunsAssociationID]; exists {
+		if len(values) != 1 || len(values[0]) == 0 {
+			validation = append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(describeAssociationRunsAssociationID)))
+		} else {
+			associationID = values[0]
+		}
+	}
+
+	if values, exists := parameters[describeAssociationRunsMaxResults]; exists {
+		if len(values) != 1 {
+			validation = append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(describeAssociationRunsMaxResults)))
+		} else if value, err := strconv.Atoi(values[0]); err != nil || value < 1 {
+			validation = append(validation, fmt.Sprintf("invalid value %v for parameter %v, expected a positive number", values[0], cliutil.FormatFlag(describeAssociationRunsMaxResults)))
+		} else {
+			maxResults = value
+		}
+	}
+
+	// look for unsupported parameters
+	for key := range parameters {
+		if key != describeAssociationRunsAssociationID && key != describeAssociationRunsMaxResults {
+			validation = append(validation, fmt.Sprintf("unknown parameter %v", cliutil.FormatFlag(key)))
+		}
+	}
+	return validation, associationID, maxResults
+}
@@ -0,0 +1,166 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/localpackages"
+	"github.com/aws/amazon-ssm-agent/agent/trace"
+)
+
+const (
+	describePackageCommand = "describe-package"
+	describePackageName    = "name"
+)
+
+const describePackageCommandHelp = `NAME:
+    {{.DescribePackageCommandName}}
+
+DESCRIPTION
+    Describes the install state of a package in the local repository used by aws:configurePackage,
+    including the versions in the repository and the history of its install states.
+
+SYNOPSIS
+    {{.DescribePackageCommandName}}
+    {{.NameFlag}}
+
+PARAMETERS
+    {{.NameFlag}} (string) Name of the package.
+
+EXAMPLES
+    This example describes the AWSPVDriver package.
+
+    Command:
+
+      {{.SsmCliName}} {{.DescribePackageCommandName}} {{.NameFlag}} AWSPVDriver
+
+    Output:
+      {
+        "name": "AWSPVDriver",
+        "state": "Installed",
+        "inprogress": false,
+        "version": "8.2.3",
+        "installedversion": "8.2.3",
+        "lastinstalledversion": "8.2.3",
+        "retrycount": 0,
+        "time": "2018-01-01T00:00:10Z",
+        "versions": [
+          "8.2.3"
+        ],
+        "history": [
+          {
+            "state": "Installing",
+            "version": "8.2.3",
+            "time": "2018-01-01T00:00:00Z"
+          },
+          {
+            "state": "Installed",
+            "version": "8.2.3",
+            "time": "2018-01-01T00:00:10Z"
+          }
+        ]
+      }
+
+OUTPUT
+    The install state of the package in JSON format
+`
+
+type describePackageHelpParams struct {
+	SsmCliName                 string
+	DescribePackageCommandName string
+	NameFlag                   string
+}
+
+func init() {
+	cliutil.Register(&DescribePackageCommand{})
+}
+
+type DescribePackageCommand struct {
+	helpText string
+}
+
+// Execute validates and executes the describe-package cli command
+func (c *DescribePackageCommand) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	validation, packageName := c.validateDescribePackageCommandInput(subcommands, parameters)
+	// return validation errors if any were found
+	if len(validation) > 0 {
+		return errors.New(strings.Join(validation, "\n")), ""
+	}
+
+	tracer := trace.NewTracer(log.NewMockLog())
+	description, err := localpackages.NewRepository().DescribePackage(tracer, packageName)
+	if err != nil {
+		return err, ""
+	}
+
+	output, _ := jsonutil.MarshalIndent(description)
+	return nil, output
+}
+
+// Help prints help for the describe-package cli command
+func (c *DescribePackageCommand) Help() string {
+	if len(c.helpText) == 0 {
+		t, _ := template.New("DescribePackageCommandHelp").Parse(describePackageCommandHelp)
+		params := describePackageHelpParams{cliutil.SsmCliName, describePackageCommand, cliutil.FormatFlag(describePackageName)}
+		buf := new(bytes.Buffer)
+		t.Execute(buf, params)
+		c.helpText = buf.String()
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (DescribePackageCommand) Name() string {
+	return describePackageCommand
+}
+
+// validateDescribePackageCommandInput checks the subcommands and parameters for required values, format, and unsupported values
+func (DescribePackageCommand) validateDescribePackageCommandInput(subcommands []string, parameters map[string][]string) (validation []string, packageName string) {
+	validation = make([]string, 0)
+	if subcommands != nil && len(subcommands) > 0 {
+		validation = append(validation, fmt.Sprintf("%v does not support subcommand %v", describePackageCommand, subcommands), "")
+		return validation, "" // invalid subcommand is an attempt to execute something that really isn't this command, so the rest of the validation is skipped in this case
+	}
+
+	validation, packageName = validatePackageNameParameter(validation, parameters)
+
+	// look for unsupported parameters
+	for key := range parameters {
+		if key != describePackageName {
+			validation = append(validation, fmt.Sprintf("unknown parameter %v", cliutil.FormatFlag(key)))
+		}
+	}
+	return validation, packageName
+}
+
+// validatePackageNameParameter checks that exactly one package name was provided
+func validatePackageNameParameter(validation []string, parameters map[string][]string) ([]string, string) {
+	if _, exists := parameters[describePackageName]; !exists {
+		return append(validation, fmt.Sprintf("%v is required", cliutil.FormatFlag(describePackageName))), ""
+	}
+	if len(parameters[describePackageName]) != 1 || len(parameters[describePackageName][0]) == 0 {
+		return append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(describePackageName))), ""
+	}
+	return validation, parameters[describePackageName][0]
+}
@@ -0,0 +1,167 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/aws/amazon-ssm-agent/agent/association/outbox"
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/platform"
+)
+
+const (
+	getAssociationOutboxCommand       = "get-association-outbox"
+	getAssociationOutboxAssociationID = "association-id"
+)
+
+const getAssociationOutboxCommandHelp = `NAME:
+    {{.GetAssociationOutboxCommandName}}
+
+DESCRIPTION
+    Lists the association status and compliance updates that the agent has not delivered to the service yet,
+    in the order they will be delivered. Updates are retried with backoff until the service accepts them.
+
+SYNOPSIS
+    {{.GetAssociationOutboxCommandName}}
+    [{{.AssociationIDFlag}}]
+
+PARAMETERS
+    {{.AssociationIDFlag}} (string) Only list the updates of this association.
+
+EXAMPLES
+    This example lists the pending updates of an association.
+
+    Command:
+
+      {{.SsmCliName}} {{.GetAssociationOutboxCommandName}} {{.AssociationIDFlag}} 8dfe3659-4309-493a-8755-0123456789ab
+
+    Output:
+      [
+        {
+          "Sequence": 12,
+          "Kind": "AssociationStatus",
+          "AssociationID": "8dfe3659-4309-493a-8755-0123456789ab",
+          "RunID": "2018-01-01T00-00-00.000Z",
+          "CreatedTime": "2018-01-01T00:00:05Z",
+          "Attempts": 2,
+          "NextAttemptTime": "2018-01-01T00:02:05Z",
+          "LastError": "RequestError: send request failed",
+          "Payload": {
+            "AssociationID": "8dfe3659-4309-493a-8755-0123456789ab",
+            "AssociationName": "AWS-RunShellScript",
+            "InstanceID": "i-1234567890abcdef0",
+            "Status": "Success",
+            "ErrorCode": "",
+            "ExecutionDate": "2018-01-01T00:00:05.000Z",
+            "ExecutionSummary": "1 out of 1 plugin processed, 1 success, 0 failed, 0 timedout, 0 skipped",
+            "OutputUrl": ""
+          }
+        }
+      ]
+
+OUTPUT
+    The pending updates in JSON format
+`
+
+type getAssociationOutboxHelpParams struct {
+	SsmCliName                      string
+	GetAssociationOutboxCommandName string
+	AssociationIDFlag               string
+}
+
+func init() {
+	cliutil.Register(&GetAssociationOutboxCommand{})
+}
+
+type GetAssociationOutboxCommand struct {
+	helpText string
+}
+
+// Execute validates and executes the get-association-outbox cli command
+func (c *GetAssociationOutboxCommand) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	validation, associationID := c.validateGetAssociationOutboxCommandInput(subcommands, parameters)
+	// return validation errors if any were found
+	if len(validation) > 0 {
+		return errors.New(strings.Join(validation, "\n")), ""
+	}
+
+	instanceID, err := platform.InstanceID()
+	if err != nil {
+		return fmt.Errorf("unable to retrieve instance id, %v", err), ""
+	}
+	messages, err := outbox.ReadMessages(outbox.GetLocation(instanceID))
+	if err != nil {
+		return err, ""
+	}
+
+	result := []outbox.Message{}
+	for _, message := range messages {
+		if associationID == "" || message.AssociationID == associationID {
+			result = append(result, message)
+		}
+	}
+
+	output, _ := jsonutil.MarshalIndent(result)
+	return nil, output
+}
+
+// Help prints help for the get-association-outbox cli command
+func (c *GetAssociationOutboxCommand) Help() string {
+	if len(c.helpText) == 0 {
+		t, _ := template.New("GetAssociationOutboxCommandHelp").Parse(getAssociationOutboxCommandHelp)
+		params := getAssociationOutboxHelpParams{cliutil.SsmCliName, getAssociationOutboxCommand,
+			cliutil.FormatFlag(getAssociationOutboxAssociationID)}
+		buf := new(bytes.Buffer)
+		t.Execute(buf, params)
+		c.helpText = buf.String()
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (GetAssociationOutboxCommand) Name() string {
+	return getAssociationOutboxCommand
+}
+
+// validateGetAssociationOutboxCommandInput checks the subcommands and parameters for required values, format, and unsupported values
+func (GetAssociationOutboxCommand) validateGetAssociationOutboxCommandInput(subcommands []string, parameters map[string][]string) (validation []string, associationID string) {
+	validation = make([]string, 0)
+	if subcommands != nil && len(subcommands) > 0 {
+		validation = append(validation, fmt.Sprintf("%v does not support subcommand %v", getAssociationOutboxCommand, subcommands), "")
+		return validation, "" // invalid subcommand is an attempt to execute something that really isn't this command, so the rest of the validation is skipped in this case
+	}
+
+	if values, exists := parameters[getAssociationOutboxAssociationID]; exists {
+		if len(values) != 1 || len(values[0]) == 0 {
+			validation = append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(getAssociationOutboxAssociationID)))
+		} else {
+			associationID = values[0]
+		}
+	}
+
+	// look for unsupported parameters
+	for key := range parameters {
+		if key != getAssociationOutboxAssociationID {
+			validation = append(validation, fmt.Sprintf("unknown parameter %v", cliutil.FormatFlag(key)))
+		}
+	}
+	return validation, associationID
+}
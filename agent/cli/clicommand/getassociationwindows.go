@@ -0,0 +1,166 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/association/maintenancewindow"
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/platform"
+)
+
+const getAssociationWindowsCommand = "get-association-windows"
+
+const getAssociationWindowsCommandHelp = `NAME:
+    {{.GetAssociationWindowsCommandName}}
+
+DESCRIPTION
+    Shows the local windows restricting when associations run on the instance and the association freeze.
+    The windows are configured with Ssm.AssociationAllowedWindows and Ssm.AssociationBlackoutWindows
+    in the agent config, the freeze is set with {{.SetAssociationFreezeCommandName}}.
+
+SYNOPSIS
+    {{.GetAssociationWindowsCommandName}}
+
+EXAMPLES
+    This example shows the windows of an instance that does not patch during market hours.
+
+    Command:
+
+      {{.SsmCliName}} {{.GetAssociationWindowsCommandName}}
+
+    Output:
+      {
+        "AllowedWindows": [],
+        "BlackoutWindows": [
+          {
+            "Name": "market hours",
+            "Days": ["Mon", "Tue", "Wed", "Thu", "Fri"],
+            "Start": "09:30",
+            "End": "16:00",
+            "TimeZone": "America/New_York",
+            "DocumentNames": ["AWS-RunPatchBaseline"]
+          }
+        ],
+        "Freeze": {
+          "Frozen": false,
+          "SetTime": "2018-01-01T00:00:00Z"
+        },
+        "Frozen": false
+      }
+
+OUTPUT
+    The windows and the freeze in JSON format, Frozen is true while the freeze defers associations
+`
+
+type getAssociationWindowsHelpParams struct {
+	SsmCliName                       string
+	GetAssociationWindowsCommandName string
+	SetAssociationFreezeCommandName  string
+}
+
+// associationWindows is the output of the get-association-windows cli command
+type associationWindows struct {
+	AllowedWindows  []appconfig.AssociationWindowCfg
+	BlackoutWindows []appconfig.AssociationWindowCfg
+	Freeze          maintenancewindow.Freeze
+	Frozen          bool
+}
+
+func init() {
+	cliutil.Register(&GetAssociationWindowsCommand{})
+}
+
+type GetAssociationWindowsCommand struct {
+	helpText string
+}
+
+// Execute validates and executes the get-association-windows cli command
+func (c *GetAssociationWindowsCommand) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	validation := c.validateGetAssociationWindowsCommandInput(subcommands, parameters)
+	// return validation errors if any were found
+	if len(validation) > 0 {
+		return errors.New(strings.Join(validation, "\n")), ""
+	}
+
+	config, err := appconfig.Config(false)
+	if err != nil {
+		return fmt.Errorf("unable to load agent config, %v", err), ""
+	}
+	instanceID, err := platform.InstanceID()
+	if err != nil {
+		return fmt.Errorf("unable to retrieve instance id, %v", err), ""
+	}
+	freeze, err := maintenancewindow.LoadFreeze(maintenancewindow.GetFreezeLocation(instanceID))
+	if err != nil {
+		return err, ""
+	}
+
+	result := associationWindows{
+		AllowedWindows:  config.Ssm.AssociationAllowedWindows,
+		BlackoutWindows: config.Ssm.AssociationBlackoutWindows,
+		Freeze:          freeze,
+		Frozen:          freeze.IsActive(time.Now().UTC()),
+	}
+	if result.AllowedWindows == nil {
+		result.AllowedWindows = []appconfig.AssociationWindowCfg{}
+	}
+	if result.BlackoutWindows == nil {
+		result.BlackoutWindows = []appconfig.AssociationWindowCfg{}
+	}
+
+	output, _ := jsonutil.MarshalIndent(result)
+	return nil, output
+}
+
+// Help prints help for the get-association-windows cli command
+func (c *GetAssociationWindowsCommand) Help() string {
+	if len(c.helpText) == 0 {
+		t, _ := template.New("GetAssociationWindowsCommandHelp").Parse(getAssociationWindowsCommandHelp)
+		params := getAssociationWindowsHelpParams{cliutil.SsmCliName, getAssociationWindowsCommand, setAssociationFreezeCommand}
+		buf := new(bytes.Buffer)
+		t.Execute(buf, params)
+		c.helpText = buf.String()
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (GetAssociationWindowsCommand) Name() string {
+	return getAssociationWindowsCommand
+}
+
+// validateGetAssociationWindowsCommandInput checks the subcommands and parameters for unsupported values
+func (GetAssociationWindowsCommand) validateGetAssociationWindowsCommandInput(subcommands []string, parameters map[string][]string) (validation []string) {
+	validation = make([]string, 0)
+	if subcommands != nil && len(subcommands) > 0 {
+		validation = append(validation, fmt.Sprintf("%v does not support subcommand %v", getAssociationWindowsCommand, subcommands), "")
+		return validation // invalid subcommand is an attempt to execute something that really isn't this command, so the rest of the validation is skipped in this case
+	}
+
+	// look for unsupported parameters
+	for key := range parameters {
+		validation = append(validation, fmt.Sprintf("unknown parameter %v", cliutil.FormatFlag(key)))
+	}
+	return validation
+}
@@ -0,0 +1,207 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/platform"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/history"
+)
+
+const (
+	getInventoryCommand = "get-inventory"
+	getInventoryType    = "type"
+	getInventoryAt      = "at"
+	getInventoryDiff    = "diff"
+)
+
+const getInventoryCommandHelp = `NAME:
+    {{.GetInventoryCommandName}}
+
+DESCRIPTION
+    Gets inventory data from the local history of the data collected by aws:softwareInventory,
+    or the differences between two collections.
+
+SYNOPSIS
+    {{.GetInventoryCommandName}}
+    [{{.TypeFlag}}]
+    [{{.AtFlag}} | {{.DiffFlag}}]
+
+PARAMETERS
+    {{.TypeFlag}} (string) Only get data of this inventory type, e.g. AWS:Application.
+
+    {{.AtFlag}} (string) Get the most recent data collected at or before this time, in RFC3339 format.
+    The most recent data is returned by default.
+
+    {{.DiffFlag}} (list) Two times in RFC3339 format. Get the differences between the most recent data
+    collected at or before the first time and the most recent data collected at or before the second time.
+
+EXAMPLES
+    This example gets the applications that changed during a day.
+
+    Command:
+
+      {{.SsmCliName}} {{.GetInventoryCommandName}} {{.TypeFlag}} AWS:Application {{.DiffFlag}} 2018-01-01T00:00:00Z 2018-01-02T00:00:00Z
+
+    Output:
+      [
+        {
+          "TypeName": "AWS:Application",
+          "CaptureTime": "2018-01-01T23:30:00Z",
+          "Removed": [
+            {
+              "Architecture": "x86_64",
+              "Name": "telnet",
+              "Version": "0.17"
+            }
+          ]
+        }
+      ]
+
+OUTPUT
+    The inventory data or the differences in JSON format
+`
+
+type getInventoryHelpParams struct {
+	SsmCliName              string
+	GetInventoryCommandName string
+	TypeFlag                string
+	AtFlag                  string
+	DiffFlag                string
+}
+
+func init() {
+	cliutil.Register(&GetInventoryCommand{})
+}
+
+type GetInventoryCommand struct {
+	helpText string
+}
+
+// Execute validates and executes the get-inventory cli command
+func (c *GetInventoryCommand) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	validation, typeName, times := c.validateGetInventoryCommandInput(subcommands, parameters)
+	// return validation errors if any were found
+	if len(validation) > 0 {
+		return errors.New(strings.Join(validation, "\n")), ""
+	}
+
+	machineID, err := platform.InstanceID()
+	if err != nil {
+		return err, ""
+	}
+	store := history.NewStore(log.NewMockLog(), machineID, 0)
+
+	if len(times) == 2 {
+		var from, to history.Snapshot
+		if from, err = store.At(times[0]); err != nil {
+			return err, ""
+		}
+		if to, err = store.At(times[1]); err != nil {
+			return err, ""
+		}
+		output, _ := jsonutil.MarshalIndent(history.Diff(from.Filter(typeName), to.Filter(typeName)))
+		return nil, output
+	}
+
+	at := time.Now()
+	if len(times) == 1 {
+		at = times[0]
+	}
+	snapshot, err := store.At(at)
+	if err != nil {
+		return err, ""
+	}
+	output, _ := jsonutil.MarshalIndent(snapshot.Filter(typeName))
+	return nil, output
+}
+
+// Help prints help for the get-inventory cli command
+func (c *GetInventoryCommand) Help() string {
+	if len(c.helpText) == 0 {
+		t, _ := template.New("GetInventoryCommandHelp").Parse(getInventoryCommandHelp)
+		params := getInventoryHelpParams{cliutil.SsmCliName, getInventoryCommand,
+			cliutil.FormatFlag(getInventoryType), cliutil.FormatFlag(getInventoryAt), cliutil.FormatFlag(getInventoryDiff)}
+		buf := new(bytes.Buffer)
+		t.Execute(buf, params)
+		c.helpText = buf.String()
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (GetInventoryCommand) Name() string {
+	return getInventoryCommand
+}
+
+// validateGetInventoryCommandInput checks the subcommands and parameters for required values, format, and unsupported values.
+// It returns the time of --at, or both times of --diff.
+func (GetInventoryCommand) validateGetInventoryCommandInput(subcommands []string, parameters map[string][]string) (validation []string, typeName string, times []time.Time) {
+	validation = make([]string, 0)
+	if subcommands != nil && len(subcommands) > 0 {
+		validation = append(validation, fmt.Sprintf("%v does not support subcommand %v", getInventoryCommand, subcommands), "")
+		return validation, "", nil // invalid subcommand is an attempt to execute something that really isn't this command, so the rest of the validation is skipped in this case
+	}
+
+	if values, exists := parameters[getInventoryType]; exists {
+		if len(values) != 1 || len(values[0]) == 0 {
+			validation = append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(getInventoryType)))
+		} else {
+			typeName = values[0]
+		}
+	}
+
+	_, at := parameters[getInventoryAt]
+	_, diff := parameters[getInventoryDiff]
+	var values []string
+	switch {
+	case at && diff:
+		validation = append(validation, fmt.Sprintf("parameters %v and %v cannot be used together", cliutil.FormatFlag(getInventoryAt), cliutil.FormatFlag(getInventoryDiff)))
+	case at:
+		if values = parameters[getInventoryAt]; len(values) != 1 {
+			validation = append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(getInventoryAt)))
+			values = nil
+		}
+	case diff:
+		if values = parameters[getInventoryDiff]; len(values) != 2 {
+			validation = append(validation, fmt.Sprintf("expected 2 values for parameter %v", cliutil.FormatFlag(getInventoryDiff)))
+			values = nil
+		}
+	}
+	for _, value := range values {
+		if parsed, err := time.Parse(time.RFC3339, value); err != nil {
+			validation = append(validation, fmt.Sprintf("invalid time %v, expected RFC3339 format e.g. 2018-01-01T00:00:00Z", value))
+		} else {
+			times = append(times, parsed)
+		}
+	}
+
+	// look for unsupported parameters
+	for key := range parameters {
+		if key != getInventoryType && key != getInventoryAt && key != getInventoryDiff {
+			validation = append(validation, fmt.Sprintf("unknown parameter %v", cliutil.FormatFlag(key)))
+		}
+	}
+	return validation, typeName, times
+}
@@ -0,0 +1,181 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/datauploader"
+)
+
+const (
+	getInventoryChangesCommand    = "get-inventory-changes"
+	getInventoryChangesTypeName   = "type-name"
+	getInventoryChangesMaxResults = "max-results"
+)
+
+const getInventoryChangesCommandHelp = `NAME:
+    {{.GetInventoryChangesCommandName}}
+
+DESCRIPTION
+    Lists the changes of the inventory data uploaded by aws:softwareInventory, oldest first.
+    A change lists the entries of an inventory type added, removed or changed since the previous upload.
+
+SYNOPSIS
+    {{.GetInventoryChangesCommandName}}
+    [{{.TypeNameFlag}}]
+    [{{.MaxResultsFlag}}]
+
+PARAMETERS
+    {{.TypeNameFlag}} (string) Only list changes of this inventory type, e.g. AWS:Application.
+
+    {{.MaxResultsFlag}} (integer) Only list this number of most recent changes.
+
+EXAMPLES
+    This example lists the most recent change of the installed applications.
+
+    Command:
+
+      {{.SsmCliName}} {{.GetInventoryChangesCommandName}} {{.TypeNameFlag}} AWS:Application {{.MaxResultsFlag}} 1
+
+    Output:
+      [
+        {
+          "TypeName": "AWS:Application",
+          "CaptureTime": "2018-01-01T00:00:00Z",
+          "Added": [
+            {
+              "Architecture": "x86_64",
+              "Name": "jq",
+              "Version": "1.5"
+            }
+          ],
+          "Changed": [
+            {
+              "Key": "openssl/x86_64/",
+              "Old": {
+                "Architecture": "x86_64",
+                "Name": "openssl",
+                "Version": "1.0.2k"
+              },
+              "New": {
+                "Architecture": "x86_64",
+                "Name": "openssl",
+                "Version": "1.0.2l"
+              }
+            }
+          ]
+        }
+      ]
+
+OUTPUT
+    The inventory changes in JSON format
+`
+
+type getInventoryChangesHelpParams struct {
+	SsmCliName                     string
+	GetInventoryChangesCommandName string
+	TypeNameFlag                   string
+	MaxResultsFlag                 string
+}
+
+func init() {
+	cliutil.Register(&GetInventoryChangesCommand{})
+}
+
+type GetInventoryChangesCommand struct {
+	helpText string
+}
+
+// Execute validates and executes the get-inventory-changes cli command
+func (c *GetInventoryChangesCommand) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	validation, typeName, maxResults := c.validateGetInventoryChangesCommandInput(subcommands, parameters)
+	// return validation errors if any were found
+	if len(validation) > 0 {
+		return errors.New(strings.Join(validation, "\n")), ""
+	}
+
+	changeLog, err := datauploader.NewChangeLogImplWithLocation(log.NewMockLog(), appconfig.InventoryRootDirName)
+	if err != nil {
+		return err, ""
+	}
+	changes, err := changeLog.GetChanges(typeName, maxResults)
+	if err != nil {
+		return err, ""
+	}
+
+	output, _ := jsonutil.MarshalIndent(changes)
+	return nil, output
+}
+
+// Help prints help for the get-inventory-changes cli command
+func (c *GetInventoryChangesCommand) Help() string {
+	if len(c.helpText) == 0 {
+		t, _ := template.New("GetInventoryChangesCommandHelp").Parse(getInventoryChangesCommandHelp)
+		params := getInventoryChangesHelpParams{cliutil.SsmCliName, getInventoryChangesCommand,
+			cliutil.FormatFlag(getInventoryChangesTypeName), cliutil.FormatFlag(getInventoryChangesMaxResults)}
+		buf := new(bytes.Buffer)
+		t.Execute(buf, params)
+		c.helpText = buf.String()
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (GetInventoryChangesCommand) Name() string {
+	return getInventoryChangesCommand
+}
+
+// validateGetInventoryChangesCommandInput checks the subcommands and parameters for required values, format, and unsupported values
+func (GetInventoryChangesCommand) validateGetInventoryChangesCommandInput(subcommands []string, parameters map[string][]string) (validation []string, typeName string, maxResults int) {
+	validation = make([]string, 0)
+	if subcommands != nil && len(subcommands) > 0 {
+		validation = append(validation, fmt.Sprintf("%v does not support subcommand %v", getInventoryChangesCommand, subcommands), "")
+		return validation, "", 0 // invalid subcommand is an attempt to execute something that really isn't this command, so the rest of the validation is skipped in this case
+	}
+
+	if values, exists := parameters[getInventoryChangesTypeName]; exists {
+		if len(values) != 1 || len(values[0]) == 0 {
+			validation = append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(getInventoryChangesTypeName)))
+		} else {
+			typeName = values[0]
+		}
+	}
+	if values, exists := parameters[getInventoryChangesMaxResults]; exists {
+		var err error
+		if len(values) != 1 {
+			validation = append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(getInventoryChangesMaxResults)))
+		} else if maxResults, err = strconv.Atoi(values[0]); err != nil || maxResults < 1 {
+			validation = append(validation, fmt.Sprintf("%v must be a positive integer", cliutil.FormatFlag(getInventoryChangesMaxResults)))
+		}
+	}
+
+	// look for unsupported parameters
+	for key := range parameters {
+		if key != getInventoryChangesTypeName && key != getInventoryChangesMaxResults {
+			validation = append(validation, fmt.Sprintf("unknown parameter %v", cliutil.FormatFlag(key)))
+		}
+	}
+	return validation, typeName, maxResults
+}
@@ -0,0 +1,182 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/association/history"
+	"github.com/aws/amazon-ssm-agent/agent/association/schedulestore"
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/platform"
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+const (
+	listAssociationsCommand = "list-associations"
+)
+
+const listAssociationsCommandHelp = `NAME:
+    {{.ListAssociationsCommandName}}
+
+DESCRIPTION
+    Lists the associations scheduled on the instance, as last received from the service,
+    with the last run of each association recorded in the local association history.
+
+SYNOPSIS
+    {{.ListAssociationsCommandName}}
+
+EXAMPLES
+    This example lists the associations of the instance.
+
+    Command:
+
+      {{.SsmCliName}} {{.ListAssociationsCommandName}}
+
+    Output:
+      [
+        {
+          "AssociationId": "8dfe3659-4309-493a-8755-0123456789ab",
+          "Name": "AWS-RunShellScript",
+          "DocumentVersion": "1",
+          "ScheduleExpression": "rate(30 minutes)",
+          "DetailedStatus": "Success",
+          "LastExecutionDate": "2018-01-01T00:00:05Z",
+          "LastRun": {
+            "AssociationID": "8dfe3659-4309-493a-8755-0123456789ab",
+            "DocumentName": "AWS-RunShellScript",
+            "DocumentVersion": "1",
+            "RunID": "2018-01-01T00-00-00.000Z",
+            "StartDateTime": "2018-01-01T00:00:00Z",
+            "EndDateTime": "2018-01-01T00:00:05Z",
+            "Status": "Success",
+            "Message": "1 out of 1 plugin processed, 1 success, 0 failed, 0 timedout, 0 skipped"
+          }
+        }
+      ]
+
+OUTPUT
+    The associations in JSON format
+`
+
+type listAssociationsHelpParams struct {
+	SsmCliName                  string
+	ListAssociationsCommandName string
+}
+
+// associationSummary is an association reported by list-associations, the steps of its last run are only
+// reported by describe-association-runs
+type associationSummary struct {
+	AssociationId      string
+	Name               string
+	DocumentVersion    string
+	ScheduleExpression string       `json:",omitempty"`
+	DetailedStatus     string       `json:",omitempty"`
+	LastExecutionDate  *time.Time   `json:",omitempty"`
+	LastRun            *history.Run `json:",omitempty"`
+}
+
+func init() {
+	cliutil.Register(&ListAssociationsCommand{})
+}
+
+type ListAssociationsCommand struct {
+	helpText string
+}
+
+// Execute validates and executes the list-associations cli command
+func (c *ListAssociationsCommand) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	validation := c.validateListAssociationsCommandInput(subcommands, parameters)
+	// return validation errors if any were found
+	if len(validation) > 0 {
+		return errors.New(strings.Join(validation, "\n")), ""
+	}
+
+	instanceID, err := platform.InstanceID()
+	if err != nil {
+		return fmt.Errorf("unable to retrieve instance id, %v", err), ""
+	}
+	associations, err := schedulestore.NewScheduleStore(instanceID).Load(log.NewMockLog())
+	if err != nil {
+		return err, ""
+	}
+	runs, err := history.ReadRuns(history.GetLocation(instanceID))
+	if err != nil {
+		return err, ""
+	}
+
+	summaries := make([]associationSummary, 0)
+	for _, assoc := range associations {
+		summary := associationSummary{
+			AssociationId:      aws.StringValue(assoc.Association.AssociationId),
+			Name:               aws.StringValue(assoc.Association.Name),
+			DocumentVersion:    aws.StringValue(assoc.Association.DocumentVersion),
+			ScheduleExpression: aws.StringValue(assoc.Association.ScheduleExpression),
+			DetailedStatus:     aws.StringValue(assoc.Association.DetailedStatus),
+			LastExecutionDate:  assoc.Association.LastExecutionDate,
+		}
+		for i := len(runs) - 1; i >= 0; i-- {
+			if runs[i].AssociationID == summary.AssociationId {
+				lastRun := runs[i]
+				lastRun.Steps = nil
+				summary.LastRun = &lastRun
+				break
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+
+	output, _ := jsonutil.MarshalIndent(summaries)
+	return nil, output
+}
+
+// Help prints help for the list-associations cli command
+func (c *ListAssociationsCommand) Help() string {
+	if len(c.helpText) == 0 {
+		t, _ := template.New("ListAssociationsCommandHelp").Parse(listAssociationsCommandHelp)
+		params := listAssociationsHelpParams{cliutil.SsmCliName, listAssociationsCommand}
+		buf := new(bytes.Buffer)
+		t.Execute(buf, params)
+		c.helpText = buf.String()
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (ListAssociationsCommand) Name() string {
+	return listAssociationsCommand
+}
+
+// validateListAssociationsCommandInput checks the subcommands and parameters for required values, format, and unsupported values
+func (ListAssociationsCommand) validateListAssociationsCommandInput(subcommands []string, parameters map[string][]string) []string {
+	validation := make([]string, 0)
+	if subcommands != nil && len(subcommands) > 0 {
+		validation = append(validation, fmt.Sprintf("%v does not support subcommand %v", listAssociationsCommand, subcommands), "")
+		return validation // invalid subcommand is an attempt to execute something that really isn't this command, so the rest of the validation is skipped in this case
+	}
+
+	// look for unsupported parameters
+	for key := range parameters {
+		validation = append(validation, fmt.Sprintf("unknown parameter %v", cliutil.FormatFlag(key)))
+	}
+	return validation
+}
@@ -0,0 +1,144 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/localpackages"
+	"github.com/aws/amazon-ssm-agent/agent/trace"
+)
+
+const listPackagesCommand = "list-packages"
+
+const listPackagesCommandHelp = `NAME:
+    {{.ListPackagesCommandName}}
+
+DESCRIPTION
+    Lists the packages in the local repository used by aws:configurePackage with their install state.
+    Use {{.DescribePackageCommandName}} for the state history of a package.
+
+SYNOPSIS
+    {{.ListPackagesCommandName}}
+
+EXAMPLES
+    This example lists the packages managed by aws:configurePackage.
+
+    Command:
+
+      {{.SsmCliName}} {{.ListPackagesCommandName}}
+
+    Output:
+      [
+        {
+          "name": "AWSPVDriver",
+          "state": "Installed",
+          "inprogress": false,
+          "version": "8.2.3",
+          "installedversion": "8.2.3",
+          "lastinstalledversion": "8.2.3",
+          "retrycount": 0,
+          "time": "2018-01-01T00:00:00Z",
+          "versions": [
+            "8.2.1",
+            "8.2.3"
+          ]
+        }
+      ]
+
+OUTPUT
+    The install state of each package in JSON format
+`
+
+type listPackagesHelpParams struct {
+	SsmCliName                 string
+	ListPackagesCommandName    string
+	DescribePackageCommandName string
+}
+
+func init() {
+	cliutil.Register(&ListPackagesCommand{})
+}
+
+type ListPackagesCommand struct {
+	helpText string
+}
+
+// packageSummary is the state of a package reported by list-packages, the history is only reported by describe-package
+type packageSummary struct {
+	localpackages.PackageDescription
+	History []localpackages.StateDescription `json:"history,omitempty"`
+}
+
+// Execute validates and executes the list-packages cli command
+func (c *ListPackagesCommand) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	validation := c.validateListPackagesCommandInput(subcommands, parameters)
+	// return validation errors if any were found
+	if len(validation) > 0 {
+		return errors.New(strings.Join(validation, "\n")), ""
+	}
+
+	tracer := trace.NewTracer(log.NewMockLog())
+	packages, err := localpackages.NewRepository().ListPackages(tracer)
+	if err != nil {
+		return err, ""
+	}
+
+	summaries := make([]packageSummary, 0)
+	for _, description := range packages {
+		summaries = append(summaries, packageSummary{PackageDescription: description})
+	}
+	output, _ := jsonutil.MarshalIndent(summaries)
+	return nil, output
+}
+
+// Help prints help for the list-packages cli command
+func (c *ListPackagesCommand) Help() string {
+	if len(c.helpText) == 0 {
+		t, _ := template.New("ListPackagesCommandHelp").Parse(listPackagesCommandHelp)
+		params := listPackagesHelpParams{cliutil.SsmCliName, listPackagesCommand, describePackageCommand}
+		buf := new(bytes.Buffer)
+		t.Execute(buf, params)
+		c.helpText = buf.String()
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (ListPackagesCommand) Name() string {
+	return listPackagesCommand
+}
+
+// validateListPackagesCommandInput checks the subcommands and parameters for required values, format, and unsupported values
+func (ListPackagesCommand) validateListPackagesCommandInput(subcommands []string, parameters map[string][]string) []string {
+	validation := make([]string, 0)
+	if subcommands != nil && len(subcommands) > 0 {
+		validation = append(validation, fmt.Sprintf("%v does not support subcommand %v", listPackagesCommand, subcommands), "")
+		return validation // invalid subcommand is an attempt to execute something that really isn't this command, so the rest of the validation is skipped in this case
+	}
+
+	for key := range parameters {
+		validation = append(validation, fmt.Sprintf("unknown parameter %v", cliutil.FormatFlag(key)))
+	}
+	return validation
+}
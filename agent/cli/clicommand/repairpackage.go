@@ -0,0 +1,155 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/localpackages"
+	"github.com/aws/amazon-ssm-agent/agent/trace"
+)
+
+const (
+	repairPackageCommand = "repair-package"
+	repairPackageForce   = "force"
+)
+
+const repairPackageCommandHelp = `NAME:
+    {{.RepairPackageCommandName}}
+
+DESCRIPTION
+    Resets a package in the local repository used by aws:configurePackage that is stuck in an install,
+    uninstall, update or rollback.  The package is returned to its last installed version if that version
+    is still in the repository, otherwise the interrupted version is marked as Failed so the next
+    aws:configurePackage run installs it again.  The retry count of a Failed package is reset.
+
+SYNOPSIS
+    {{.RepairPackageCommandName}}
+    {{.NameFlag}}
+    [{{.ForceFlag}}]
+
+PARAMETERS
+    {{.NameFlag}} (string) Name of the package.
+
+    {{.ForceFlag}} Repair the package even if its state changed within the last hour and may still be in progress.
+
+EXAMPLES
+    This example resets an interrupted upgrade of the AWSPVDriver package.
+
+    Command:
+
+      {{.SsmCliName}} {{.RepairPackageCommandName}} {{.NameFlag}} AWSPVDriver
+
+    Output:
+      {
+        "name": "AWSPVDriver",
+        "state": "Installed",
+        "inprogress": false,
+        "version": "8.2.1",
+        ...
+      }
+
+OUTPUT
+    The install state of the package after the repair in JSON format
+`
+
+type repairPackageHelpParams struct {
+	SsmCliName               string
+	RepairPackageCommandName string
+	NameFlag                 string
+	ForceFlag                string
+}
+
+func init() {
+	cliutil.Register(&RepairPackageCommand{})
+}
+
+type RepairPackageCommand struct {
+	helpText string
+}
+
+// Execute validates and executes the repair-package cli command
+func (c *RepairPackageCommand) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	validation, packageName, force := c.validateRepairPackageCommandInput(subcommands, parameters)
+	// return validation errors if any were found
+	if len(validation) > 0 {
+		return errors.New(strings.Join(validation, "\n")), ""
+	}
+
+	// change the repository under the same lock as the package actions of the agent so garbage collection is excluded
+	unlockRepository, err := localpackages.LockRepository(false)
+	if err != nil {
+		return err, ""
+	}
+	defer unlockRepository()
+
+	tracer := trace.NewTracer(log.NewMockLog())
+	description, err := localpackages.NewRepository().RepairPackage(tracer, packageName, force)
+	if err != nil {
+		return err, ""
+	}
+
+	output, _ := jsonutil.MarshalIndent(description)
+	return nil, output
+}
+
+// Help prints help for the repair-package cli command
+func (c *RepairPackageCommand) Help() string {
+	if len(c.helpText) == 0 {
+		t, _ := template.New("RepairPackageCommandHelp").Parse(repairPackageCommandHelp)
+		params := repairPackageHelpParams{cliutil.SsmCliName, repairPackageCommand, cliutil.FormatFlag(describePackageName), cliutil.FormatFlag(repairPackageForce)}
+		buf := new(bytes.Buffer)
+		t.Execute(buf, params)
+		c.helpText = buf.String()
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (RepairPackageCommand) Name() string {
+	return repairPackageCommand
+}
+
+// validateRepairPackageCommandInput checks the subcommands and parameters for required values, format, and unsupported values
+func (RepairPackageCommand) validateRepairPackageCommandInput(subcommands []string, parameters map[string][]string) (validation []string, packageName string, force bool) {
+	validation = make([]string, 0)
+	if subcommands != nil && len(subcommands) > 0 {
+		validation = append(validation, fmt.Sprintf("%v does not support subcommand %v", repairPackageCommand, subcommands), "")
+		return validation, "", false // invalid subcommand is an attempt to execute something that really isn't this command, so the rest of the validation is skipped in this case
+	}
+
+	validation, packageName = validatePackageNameParameter(validation, parameters)
+
+	_, force = parameters[repairPackageForce]
+	if force && len(parameters[repairPackageForce]) > 0 {
+		validation = append(validation, fmt.Sprintf("flag %v should not have any values", cliutil.FormatFlag(repairPackageForce)))
+	}
+
+	// look for unsupported parameters
+	for key := range parameters {
+		if key != describePackageName && key != repairPackageForce {
+			validation = append(validation, fmt.Sprintf("unknown parameter %v", cliutil.FormatFlag(key)))
+		}
+	}
+	return validation, packageName, force
+}
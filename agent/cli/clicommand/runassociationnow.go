@@ -0,0 +1,169 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/association/runrequest"
+	"github.com/aws/amazon-ssm-agent/agent/association/schedulestore"
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/platform"
+)
+
+const (
+	runAssociationNowCommand       = "run-association-now"
+	runAssociationNowAssociationID = "association-id"
+)
+
+const runAssociationNowCommandHelp = `NAME:
+    {{.RunAssociationNowCommandName}}
+
+DESCRIPTION
+    Requests the agent to run associations of the instance immediately. The agent picks up the request
+    within a few seconds, unless the association is already running. The local maintenance windows and
+    the association freeze still apply. Use {{.SsmCliName}} list-associations to list the associations.
+
+SYNOPSIS
+    {{.RunAssociationNowCommandName}}
+    {{.AssociationIDFlag}} <value> [<value>...]
+
+PARAMETERS
+    {{.AssociationIDFlag}} (string) The ids of the associations to run.
+
+EXAMPLES
+    This example requests an association to run now.
+
+    Command:
+
+      {{.SsmCliName}} {{.RunAssociationNowCommandName}} {{.AssociationIDFlag}} 8dfe3659-4309-493a-8755-0123456789ab
+
+    Output:
+      [
+        {
+          "AssociationID": "8dfe3659-4309-493a-8755-0123456789ab",
+          "RequestedTime": "2018-01-01T00:00:00Z"
+        }
+      ]
+
+OUTPUT
+    The run requests in JSON format
+`
+
+type runAssociationNowHelpParams struct {
+	SsmCliName                   string
+	RunAssociationNowCommandName string
+	AssociationIDFlag            string
+}
+
+func init() {
+	cliutil.Register(&RunAssociationNowCommand{})
+}
+
+type RunAssociationNowCommand struct {
+	helpText string
+}
+
+// Execute validates and executes the run-association-now cli command
+func (c *RunAssociationNowCommand) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	validation, associationIDs := c.validateRunAssociationNowCommandInput(subcommands, parameters)
+	// return validation errors if any were found
+	if len(validation) > 0 {
+		return errors.New(strings.Join(validation, "\n")), ""
+	}
+
+	instanceID, err := platform.InstanceID()
+	if err != nil {
+		return fmt.Errorf("unable to retrieve instance id, %v", err), ""
+	}
+	associations, err := schedulestore.NewScheduleStore(instanceID).Load(log.NewMockLog())
+	if err != nil {
+		return err, ""
+	}
+	scheduled := map[string]bool{}
+	for _, assoc := range associations {
+		scheduled[*assoc.Association.AssociationId] = true
+	}
+
+	requests := []runrequest.Request{}
+	for _, associationID := range associationIDs {
+		if !scheduled[associationID] {
+			return fmt.Errorf("association %v is not scheduled on this instance", associationID), ""
+		}
+		requests = append(requests, runrequest.Request{AssociationID: associationID, RequestedTime: time.Now().UTC()})
+	}
+	for _, request := range requests {
+		if err = runrequest.Add(runrequest.GetLocation(instanceID), request); err != nil {
+			return err, ""
+		}
+	}
+
+	output, _ := jsonutil.MarshalIndent(requests)
+	return nil, output
+}
+
+// Help prints help for the run-association-now cli command
+func (c *RunAssociationNowCommand) Help() string {
+	if len(c.helpText) == 0 {
+		t, _ := template.New("RunAssociationNowCommandHelp").Parse(runAssociationNowCommandHelp)
+		params := runAssociationNowHelpParams{cliutil.SsmCliName, runAssociationNowCommand,
+			cliutil.FormatFlag(runAssociationNowAssociationID)}
+		buf := new(bytes.Buffer)
+		t.Execute(buf, params)
+		c.helpText = buf.String()
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (RunAssociationNowCommand) Name() string {
+	return runAssociationNowCommand
+}
+
+// validateRunAssociationNowCommandInput checks the subcommands and parameters for required values, format, and unsupported values
+func (RunAssociationNowCommand) validateRunAssociationNowCommandInput(subcommands []string, parameters map[string][]string) (validation []string, associationIDs []string) {
+	validation = make([]string, 0)
+	if subcommands != nil && len(subcommands) > 0 {
+		validation = append(validation, fmt.Sprintf("%v does not support subcommand %v", runAssociationNowCommand, subcommands), "")
+		return validation, nil // invalid subcommand is an attempt to execute something that really isn't this command, so the rest of the validation is skipped in this case
+	}
+
+	if values, exists := parameters[runAssociationNowAssociationID]; !exists || len(values) < 1 {
+		validation = append(validation, fmt.Sprintf("expected at least 1 value for parameter %v", cliutil.FormatFlag(runAssociationNowAssociationID)))
+	} else {
+		for _, value := range values {
+			if len(value) == 0 {
+				validation = append(validation, fmt.Sprintf("invalid empty value for parameter %v", cliutil.FormatFlag(runAssociationNowAssociationID)))
+				continue
+			}
+			associationIDs = append(associationIDs, value)
+		}
+	}
+
+	// look for unsupported parameters
+	for key := range parameters {
+		if key != runAssociationNowAssociationID {
+			validation = append(validation, fmt.Sprintf("unknown parameter %v", cliutil.FormatFlag(key)))
+		}
+	}
+	return validation, associationIDs
+}
@@ -0,0 +1,182 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/association/maintenancewindow"
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/platform"
+)
+
+const (
+	setAssociationFreezeCommand         = "set-association-freeze"
+	setAssociationFreezeFrozen          = "frozen"
+	setAssociationFreezeReason          = "reason"
+	setAssociationFreezeDurationMinutes = "duration-minutes"
+)
+
+const setAssociationFreezeCommandHelp = `NAME:
+    {{.SetAssociationFreezeCommandName}}
+
+DESCRIPTION
+    Freezes or unfreezes the associations of the instance. While frozen, due associations are deferred
+    and their status is updated with the deferral. They run once the freeze is lifted or expires.
+
+SYNOPSIS
+    {{.SetAssociationFreezeCommandName}}
+    {{.FrozenFlag}} <value>
+    [{{.ReasonFlag}}]
+    [{{.DurationMinutesFlag}}]
+
+PARAMETERS
+    {{.FrozenFlag}} (boolean) true to freeze the associations, false to lift the freeze.
+
+    {{.ReasonFlag}} (string) The reason of the freeze, it is included in the status of the deferred associations.
+
+    {{.DurationMinutesFlag}} (integer) The freeze is lifted after this number of minutes, it lasts until it is lifted otherwise.
+
+EXAMPLES
+    This example freezes the associations for the duration of a deploy.
+
+    Command:
+
+      {{.SsmCliName}} {{.SetAssociationFreezeCommandName}} {{.FrozenFlag}} true {{.ReasonFlag}} deploying {{.DurationMinutesFlag}} 60
+
+    Output:
+      {
+        "Frozen": true,
+        "Reason": "deploying",
+        "SetTime": "2018-01-01T00:00:00Z",
+        "Until": "2018-01-01T01:00:00Z"
+      }
+
+OUTPUT
+    The association freeze of the instance in JSON format
+`
+
+type setAssociationFreezeHelpParams struct {
+	SsmCliName                      string
+	SetAssociationFreezeCommandName string
+	FrozenFlag                      string
+	ReasonFlag                      string
+	DurationMinutesFlag             string
+}
+
+func init() {
+	cliutil.Register(&SetAssociationFreezeCommand{})
+}
+
+type SetAssociationFreezeCommand struct {
+	helpText string
+}
+
+// Execute validates and executes the set-association-freeze cli command
+func (c *SetAssociationFreezeCommand) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	validation, freeze := c.validateSetAssociationFreezeCommandInput(subcommands, parameters)
+	// return validation errors if any were found
+	if len(validation) > 0 {
+		return errors.New(strings.Join(validation, "\n")), ""
+	}
+
+	instanceID, err := platform.InstanceID()
+	if err != nil {
+		return fmt.Errorf("unable to retrieve instance id, %v", err), ""
+	}
+	if err = maintenancewindow.SaveFreeze(maintenancewindow.GetFreezeLocation(instanceID), freeze); err != nil {
+		return err, ""
+	}
+
+	output, _ := jsonutil.MarshalIndent(freeze)
+	return nil, output
+}
+
+// Help prints help for the set-association-freeze cli command
+func (c *SetAssociationFreezeCommand) Help() string {
+	if len(c.helpText) == 0 {
+		t, _ := template.New("SetAssociationFreezeCommandHelp").Parse(setAssociationFreezeCommandHelp)
+		params := setAssociationFreezeHelpParams{cliutil.SsmCliName, setAssociationFreezeCommand,
+			cliutil.FormatFlag(setAssociationFreezeFrozen),
+			cliutil.FormatFlag(setAssociationFreezeReason),
+			cliutil.FormatFlag(setAssociationFreezeDurationMinutes)}
+		buf := new(bytes.Buffer)
+		t.Execute(buf, params)
+		c.helpText = buf.String()
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (SetAssociationFreezeCommand) Name() string {
+	return setAssociationFreezeCommand
+}
+
+// validateSetAssociationFreezeCommandInput checks the subcommands and parameters for required values, format, and unsupported values
+func (SetAssociationFreezeCommand) validateSetAssociationFreezeCommandInput(subcommands []string, parameters map[string][]string) (validation []string, freeze maintenancewindow.Freeze) {
+	validation = make([]string, 0)
+	if subcommands != nil && len(subcommands) > 0 {
+		validation = append(validation, fmt.Sprintf("%v does not support subcommand %v", setAssociationFreezeCommand, subcommands), "")
+		return validation, freeze // invalid subcommand is an attempt to execute something that really isn't this command, so the rest of the validation is skipped in this case
+	}
+
+	freeze.SetTime = time.Now().UTC()
+	if values, exists := parameters[setAssociationFreezeFrozen]; !exists || len(values) != 1 {
+		validation = append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(setAssociationFreezeFrozen)))
+	} else if frozen, err := strconv.ParseBool(values[0]); err != nil {
+		validation = append(validation, fmt.Sprintf("invalid value %v for parameter %v, expected true or false", values[0], cliutil.FormatFlag(setAssociationFreezeFrozen)))
+	} else {
+		freeze.Frozen = frozen
+	}
+
+	if values, exists := parameters[setAssociationFreezeReason]; exists {
+		if len(values) < 1 || len(values[0]) == 0 {
+			validation = append(validation, fmt.Sprintf("expected a value for parameter %v", cliutil.FormatFlag(setAssociationFreezeReason)))
+		} else {
+			freeze.Reason = strings.Join(values, " ")
+		}
+	}
+
+	if values, exists := parameters[setAssociationFreezeDurationMinutes]; exists {
+		if len(values) != 1 {
+			validation = append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(setAssociationFreezeDurationMinutes)))
+		} else if minutes, err := strconv.Atoi(values[0]); err != nil || minutes < 1 {
+			validation = append(validation, fmt.Sprintf("invalid value %v for parameter %v, expected a positive number of minutes", values[0], cliutil.FormatFlag(setAssociationFreezeDurationMinutes)))
+		} else {
+			until := freeze.SetTime.Add(time.Duration(minutes) * time.Minute)
+			freeze.Until = &until
+		}
+	}
+
+	if !freeze.Frozen && (freeze.Reason != "" || freeze.Until != nil) {
+		validation = append(validation, fmt.Sprintf("parameters %v and %v are only supported when freezing",
+			cliutil.FormatFlag(setAssociationFreezeReason), cliutil.FormatFlag(setAssociationFreezeDurationMinutes)))
+	}
+
+	// look for unsupported parameters
+	for key := range parameters {
+		if key != setAssociationFreezeFrozen && key != setAssociationFreezeReason && key != setAssociationFreezeDurationMinutes {
+			validation = append(validation, fmt.Sprintf("unknown parameter %v", cliutil.FormatFlag(key)))
+		}
+	}
+	return validation, freeze
+}
@@ -14,6 +14,9 @@
 package model
 
 import (
+	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -28,6 +31,12 @@ const NON_COMPLIANT string = ssm.ComplianceStatusNonCompliant
 
 var ASSOCIATION_COMPLIANCE_TITLE string
 
+// CUSTOM_COMPLIANCE_TYPE_PREFIX is the prefix of the compliance types reported by plugins and scripts
+const CUSTOM_COMPLIANCE_TYPE_PREFIX string = "Custom:"
+
+// ASSOCIATION_DRIFT_TITLE is the title of the compliant drift item of an audited association that found no drift
+const ASSOCIATION_DRIFT_TITLE string = "No drift detected"
+
 type AssociationComplianceItem struct {
 	// AssociationId stores the key of compliance status
 	AssociationId      string
@@ -39,8 +48,48 @@ type AssociationComplianceItem struct {
 	ComplianceStatus   string
 }
 
+// AssociationDriftItem is a change an association running in audit mode would have applied,
+// or the compliant item of an audited association that found no drift
+type AssociationDriftItem struct {
+	// AssociationId stores the association that found the drift
+	AssociationId      string
+	ExecutionTime      time.Time
+	DocumentName       string
+	DocumentVersion    string
+	Id                 string
+	Title              string
+	ComplianceSeverity string
+	ComplianceStatus   string
+	Details            map[string]string
+}
+
+// CustomComplianceItem is a compliance item reported by the plugins or scripts of an association,
+// it is uploaded under its custom compliance type
+type CustomComplianceItem struct {
+	// AssociationId stores the association that reported the item
+	AssociationId      string
+	ExecutionTime      time.Time
+	DocumentName       string
+	DocumentVersion    string
+	Type               string
+	Id                 string
+	Title              string
+	ComplianceSeverity string
+	ComplianceStatus   string
+	Details            map[string]string
+}
+
 // Association compliance status is Unspecified by default
 var associationComplianceItems = []*AssociationComplianceItem{}
+
+// associationDriftItems holds the drift items of the last audit of each association, by association id
+var associationDriftItems = map[string][]*AssociationDriftItem{}
+
+// customComplianceItems holds the custom compliance items of the last execution of each association, by association id
+var customComplianceItems = map[string][]*CustomComplianceItem{}
+
+// customComplianceTypes holds every custom compliance type reported, so that a type no longer reported is uploaded empty
+var customComplianceTypes = map[string]bool{}
 var lock = sync.RWMutex{}
 
 /**
@@ -117,6 +166,24 @@ func RefreshAssociationComplianceItems(associations []*model.InstanceAssociation
 	}
 
 	associationComplianceItems = newComplianceItems
+
+	for associationId := range associationDriftItems {
+		if _, exist := associationMap[associationId]; !exist {
+			delete(associationDriftItems, associationId)
+			if store != nil {
+				store.remove(driftDirName, associationId)
+			}
+		}
+	}
+
+	for associationId := range customComplianceItems {
+		if _, exist := associationMap[associationId]; !exist {
+			delete(customComplianceItems, associationId)
+			if store != nil {
+				store.remove(customDirName, associationId)
+			}
+		}
+	}
 }
 
 func GetAssociationComplianceEntries() []*AssociationComplianceItem {
@@ -125,3 +192,212 @@ func GetAssociationComplianceEntries() []*AssociationComplianceItem {
 
 	return associationComplianceItems
 }
+
+/**
+ * Update the drift items of an association with the drift found by its audit, drift items are NON_COMPLIANT
+ * and an audit that found no drift results in a single COMPLIANT item.
+ */
+func UpdateAssociationDriftItems(associationId string, documentName string, documentVersion string, drift []contracts.DriftItem, executionTime time.Time) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if current, found := associationDriftItems[associationId]; found && len(current) > 0 && !current[0].ExecutionTime.Before(executionTime) {
+		return
+	}
+
+	var items = []*AssociationDriftItem{}
+	for _, driftItem := range drift {
+		var severity = driftItem.Severity
+		if severity == "" {
+			severity = UNSPECIFIED
+		}
+		items = append(items, &AssociationDriftItem{
+			AssociationId:      associationId,
+			ExecutionTime:      executionTime,
+			DocumentName:       documentName,
+			DocumentVersion:    documentVersion,
+			Id:                 associationId + ":" + driftItem.ID,
+			Title:              driftItem.Title,
+			ComplianceSeverity: severity,
+			ComplianceStatus:   NON_COMPLIANT,
+			Details:            driftItem.Details,
+		})
+	}
+
+	if len(items) == 0 {
+		items = append(items, &AssociationDriftItem{
+			AssociationId:      associationId,
+			ExecutionTime:      executionTime,
+			DocumentName:       documentName,
+			DocumentVersion:    documentVersion,
+			Id:                 associationId,
+			Title:              ASSOCIATION_DRIFT_TITLE,
+			ComplianceSeverity: UNSPECIFIED,
+			ComplianceStatus:   COMPLIANT,
+		})
+	}
+
+	associationDriftItems[associationId] = items
+	if store != nil {
+		store.save(driftDirName, associationId, items)
+	}
+}
+
+// RemoveAssociationDriftItems removes the drift items of an association that no longer runs in audit mode,
+// it returns false when the association has no drift items or they are newer than the execution
+func RemoveAssociationDriftItems(associationId string, executionTime time.Time) bool {
+	lock.Lock()
+	defer lock.Unlock()
+
+	current, found := associationDriftItems[associationId]
+	if !found || (len(current) > 0 && !current[0].ExecutionTime.Before(executionTime)) {
+		return false
+	}
+
+	delete(associationDriftItems, associationId)
+	if store != nil {
+		store.remove(driftDirName, associationId)
+	}
+	return true
+}
+
+// GetAssociationDriftEntries returns the drift items of all audited associations, ordered by association id
+func GetAssociationDriftEntries() []*AssociationDriftItem {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	var associationIds = []string{}
+	for associationId := range associationDriftItems {
+		associationIds = append(associationIds, associationId)
+	}
+	sort.Strings(associationIds)
+
+	var entries = []*AssociationDriftItem{}
+	for _, associationId := range associationIds {
+		entries = append(entries, associationDriftItems[associationId]...)
+	}
+	return entries
+}
+
+// CustomComplianceType returns the compliance type of an item reported by a plugin or script, with the Custom: prefix
+func CustomComplianceType(complianceType string) string {
+	if strings.HasPrefix(complianceType, CUSTOM_COMPLIANCE_TYPE_PREFIX) {
+		return complianceType
+	}
+	return CUSTOM_COMPLIANCE_TYPE_PREFIX + complianceType
+}
+
+// ValidateComplianceItem returns an error if the compliance item reported by a plugin or script can't be uploaded
+func ValidateComplianceItem(item contracts.ComplianceItem) error {
+	if strings.TrimPrefix(item.Type, CUSTOM_COMPLIANCE_TYPE_PREFIX) == "" {
+		return fmt.Errorf("compliance item %v has no type", item.ID)
+	}
+	if item.ID == "" {
+		return fmt.Errorf("compliance item of type %v has no id", item.Type)
+	}
+	if item.Status != COMPLIANT && item.Status != NON_COMPLIANT {
+		return fmt.Errorf("compliance item %v has invalid status %v, expected %v or %v", item.ID, item.Status, COMPLIANT, NON_COMPLIANT)
+	}
+	switch item.Severity {
+	case "",
+		ssm.ComplianceSeverityCritical,
+		ssm.ComplianceSeverityHigh,
+		ssm.ComplianceSeverityMedium,
+		ssm.ComplianceSeverityLow,
+		ssm.ComplianceSeverityInformational,
+		ssm.ComplianceSeverityUnspecified:
+		return nil
+	}
+	return fmt.Errorf("compliance item %v has invalid severity %v", item.ID, item.Severity)
+}
+
+/**
+ * Update the custom compliance items of an association with the items reported by its last execution, invalid items
+ * are left out and returned as errors. It returns false when nothing changed: the association reported no items
+ * and had none, or the update is older than the current items.
+ */
+func UpdateCustomComplianceItems(associationId string, documentName string, documentVersion string, items []contracts.ComplianceItem, executionTime time.Time) (updated bool, errs []error) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	current := customComplianceItems[associationId]
+	if len(current) > 0 && !current[0].ExecutionTime.Before(executionTime) {
+		return false, nil
+	}
+
+	var newItems = []*CustomComplianceItem{}
+	var newTypes = false
+	for _, item := range items {
+		if err := ValidateComplianceItem(item); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		var severity = item.Severity
+		if severity == "" {
+			severity = UNSPECIFIED
+		}
+		newItems = append(newItems, &CustomComplianceItem{
+			AssociationId:      associationId,
+			ExecutionTime:      executionTime,
+			DocumentName:       documentName,
+			DocumentVersion:    documentVersion,
+			Type:               CustomComplianceType(item.Type),
+			Id:                 item.ID,
+			Title:              item.Title,
+			ComplianceSeverity: severity,
+			ComplianceStatus:   item.Status,
+			Details:            item.Details,
+		})
+		if !customComplianceTypes[CustomComplianceType(item.Type)] {
+			customComplianceTypes[CustomComplianceType(item.Type)] = true
+			newTypes = true
+		}
+	}
+	if store != nil && newTypes {
+		store.saveCustomComplianceTypes(customComplianceTypes)
+	}
+
+	if len(newItems) == 0 {
+		delete(customComplianceItems, associationId)
+		if store != nil && len(current) > 0 {
+			store.remove(customDirName, associationId)
+		}
+		return len(current) > 0, errs
+	}
+	customComplianceItems[associationId] = newItems
+	if store != nil {
+		store.save(customDirName, associationId, newItems)
+	}
+	return true, errs
+}
+
+// GetCustomComplianceEntries returns the custom compliance items of all associations by compliance type, a type that
+// is no longer reported has no items. An item id reported by several associations is kept for the first association by id.
+func GetCustomComplianceEntries() map[string][]*CustomComplianceItem {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	var entries = map[string][]*CustomComplianceItem{}
+	for complianceType := range customComplianceTypes {
+		entries[complianceType] = []*CustomComplianceItem{}
+	}
+
+	var associationIds = []string{}
+	for associationId := range customComplianceItems {
+		associationIds = append(associationIds, associationId)
+	}
+	sort.Strings(associationIds)
+
+	var reported = map[string]bool{}
+	for _, associationId := range associationIds {
+		for _, item := range customComplianceItems[associationId] {
+			key := item.Type + "/" + item.Id
+			if reported[key] {
+				continue
+			}
+			reported[key] = true
+			entries[item.Type] = append(entries[item.Type], item)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,182 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	// driftDirName is the folder of the persisted drift items, one file per association
+	driftDirName = "drift"
+	// customDirName is the folder of the persisted custom compliance items, one file per association with the items
+	// of every custom compliance type it reported
+	customDirName = "custom"
+	// customTypesFileName persists the custom compliance types ever reported, so a type no longer reported is still
+	// uploaded empty after a restart
+	customTypesFileName = "customtypes"
+	itemsFileExtension  = ".json"
+)
+
+// itemStore persists the items of each association, so that the compliance updates after a restart of the agent
+// still carry the items of the associations that did not run since
+type itemStore struct {
+	log      log.T
+	location string
+}
+
+// store is nil until LoadComplianceItems is called, the items are then only held in memory
+var store *itemStore
+
+// GetLocation returns the folder of the persisted compliance items of the instance
+func GetLocation(instanceID string) string {
+	return filepath.Join(appconfig.DefaultDataStorePath, instanceID, appconfig.ComplianceRootDirName)
+}
+
+// LoadComplianceItems restores the drift and custom compliance items persisted in the given folder and persists the
+// later updates there. The drift and custom compliance updates carry the items of every association, an update sent
+// without them would remove the items of the other associations.
+func LoadComplianceItems(log log.T, location string) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	store = &itemStore{log: log, location: location}
+	for associationId, content := range store.readAll(driftDirName) {
+		var items []*AssociationDriftItem
+		err := json.Unmarshal(content, &items)
+		if err == nil && len(items) == 0 {
+			err = fmt.Errorf("no items")
+		}
+		if err != nil {
+			log.Errorf("Discarding the persisted drift items of association %v, %v", associationId, err)
+			store.remove(driftDirName, associationId)
+			continue
+		}
+		if current, found := associationDriftItems[associationId]; !found || current[0].ExecutionTime.Before(items[0].ExecutionTime) {
+			associationDriftItems[associationId] = items
+		}
+	}
+
+	for associationId, content := range store.readAll(customDirName) {
+		var items []*CustomComplianceItem
+		err := json.Unmarshal(content, &items)
+		if err == nil && len(items) == 0 {
+			err = fmt.Errorf("no items")
+		}
+		if err != nil {
+			log.Errorf("Discarding the persisted custom compliance items of association %v, %v", associationId, err)
+			store.remove(customDirName, associationId)
+			continue
+		}
+		if current, found := customComplianceItems[associationId]; !found || current[0].ExecutionTime.Before(items[0].ExecutionTime) {
+			customComplianceItems[associationId] = items
+		}
+		for _, item := range items {
+			customComplianceTypes[item.Type] = true
+		}
+	}
+
+	if content, err := ioutil.ReadFile(filepath.Join(location, customTypesFileName+itemsFileExtension)); err == nil {
+		var types []string
+		if err = json.Unmarshal(content, &types); err != nil {
+			log.Errorf("Discarding the persisted custom compliance types, %v", err)
+		}
+		for _, complianceType := range types {
+			customComplianceTypes[complianceType] = true
+		}
+	}
+}
+
+// saveCustomComplianceTypes persists the custom compliance types ever reported
+func (s *itemStore) saveCustomComplianceTypes(types map[string]bool) {
+	var names = []string{}
+	for complianceType := range types {
+		names = append(names, complianceType)
+	}
+	sort.Strings(names)
+	if err := s.write("", customTypesFileName, names); err != nil {
+		s.log.Errorf("Unable to persist the custom compliance types, %v", err)
+	}
+}
+
+// readAll returns the content of the files of every association in the folder, by association id
+func (s *itemStore) readAll(dirName string) map[string][]byte {
+	contents := map[string][]byte{}
+	files, err := ioutil.ReadDir(filepath.Join(s.location, dirName))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			s.log.Errorf("Unable to read the persisted compliance items, %v", err)
+		}
+		return contents
+	}
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != itemsFileExtension {
+			continue
+		}
+		content, err := ioutil.ReadFile(filepath.Join(s.location, dirName, file.Name()))
+		if err != nil {
+			s.log.Errorf("Unable to read the persisted compliance items %v, %v", file.Name(), err)
+			continue
+		}
+		contents[strings.TrimSuffix(file.Name(), itemsFileExtension)] = content
+	}
+	return contents
+}
+
+// save persists the items of an association
+func (s *itemStore) save(dirName string, associationId string, items interface{}) {
+	if err := s.write(dirName, associationId, items); err != nil {
+		s.log.Errorf("Unable to persist the compliance items of association %v, %v", associationId, err)
+	}
+}
+
+// write persists the content to the file of the given name in the folder, writing a temporary file first so a partial
+// file is never read
+func (s *itemStore) write(dirName string, name string, content interface{}) (err error) {
+	var data []byte
+	if data, err = json.Marshal(content); err != nil {
+		return
+	}
+	location := filepath.Join(s.location, dirName)
+	if err = fileutil.MakeDirs(location); err != nil {
+		return fmt.Errorf("cannot make directory %v, %v", location, err)
+	}
+	fileName := filepath.Join(location, name+itemsFileExtension)
+	tempFileName := fileName + ".tmp"
+	if err = ioutil.WriteFile(tempFileName, data, os.FileMode(int(appconfig.ReadWriteAccess))); err != nil {
+		return
+	}
+	if err = os.Rename(tempFileName, fileName); err != nil {
+		os.Remove(tempFileName)
+	}
+	return
+}
+
+// remove deletes the persisted items of an association
+func (s *itemStore) remove(dirName string, associationId string) {
+	fileName := filepath.Join(s.location, dirName, associationId+itemsFileExtension)
+	if err := os.Remove(fileName); err != nil && !os.IsNotExist(err) {
+		s.log.Errorf("Unable to remove the persisted compliance items of association %v, %v", associationId, err)
+	}
+}
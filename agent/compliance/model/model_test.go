@@ -14,11 +14,14 @@
 package model
 
 import (
+	"io/ioutil"
+	"os"
 	"testing"
 	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/association/model"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/stretchr/testify/assert"
@@ -156,3 +159,145 @@ func TestRefreshAssociationComplianceItems(t *testing.T) {
 	assert.Equal(t, 1, len(complianceItems))
 
 }
+
+func TestUpdateAssociationDriftItems(t *testing.T) {
+	RefreshAssociationComplianceItems([]*model.InstanceAssociation{})
+	executionTime := time.Now()
+	drift := []contracts.DriftItem{
+		{ID: "/etc/motd:content", Title: "update the content of /etc/motd"},
+		{ID: "aws:configurePackage:nginx", Title: "install nginx 1.12", Severity: ssm.ComplianceSeverityHigh},
+	}
+
+	UpdateAssociationDriftItems("association_1", "testDoc", "1", drift, executionTime)
+	UpdateAssociationDriftItems("association_2", "testDoc2", "2", nil, executionTime)
+	// stale audits are ignored
+	UpdateAssociationDriftItems("association_1", "testDoc", "1", nil, executionTime.Add(-100*time.Second))
+
+	driftItems := GetAssociationDriftEntries()
+	assert.Equal(t, 3, len(driftItems))
+	assert.Equal(t, "association_1:/etc/motd:content", driftItems[0].Id)
+	assert.Equal(t, NON_COMPLIANT, driftItems[0].ComplianceStatus)
+	assert.Equal(t, UNSPECIFIED, driftItems[0].ComplianceSeverity)
+	assert.Equal(t, ssm.ComplianceSeverityHigh, driftItems[1].ComplianceSeverity)
+	assert.Equal(t, "association_2", driftItems[2].Id)
+	assert.Equal(t, COMPLIANT, driftItems[2].ComplianceStatus)
+	assert.Equal(t, ASSOCIATION_DRIFT_TITLE, driftItems[2].Title)
+
+	association2 := &model.InstanceAssociation{
+		Association: &ssm.InstanceAssociationSummary{
+			Name:            aws.String("testDoc2"),
+			AssociationId:   aws.String("association_2"),
+			DocumentVersion: aws.String("2"),
+		},
+	}
+	RefreshAssociationComplianceItems([]*model.InstanceAssociation{association2})
+	driftItems = GetAssociationDriftEntries()
+	assert.Equal(t, 1, len(driftItems))
+	assert.Equal(t, "association_2", driftItems[0].AssociationId)
+}
+
+func TestRemoveAssociationDriftItems(t *testing.T) {
+	RefreshAssociationComplianceItems([]*model.InstanceAssociation{})
+	executionTime := time.Now()
+	UpdateAssociationDriftItems("association_1", "testDoc", "1", []contracts.DriftItem{{ID: "/etc/motd:content"}}, executionTime)
+
+	// stale runs don't remove the drift
+	assert.False(t, RemoveAssociationDriftItems("association_1", executionTime.Add(-time.Second)))
+	assert.Equal(t, 1, len(GetAssociationDriftEntries()))
+
+	// the association runs without audit mode
+	assert.True(t, RemoveAssociationDriftItems("association_1", executionTime.Add(time.Second)))
+	assert.Empty(t, GetAssociationDriftEntries())
+	assert.False(t, RemoveAssociationDriftItems("association_1", executionTime.Add(2*time.Second)))
+}
+
+func TestUpdateCustomComplianceItems(t *testing.T) {
+	RefreshAssociationComplianceItems([]*model.InstanceAssociation{})
+	executionTime := time.Now()
+	items := []contracts.ComplianceItem{
+		{Type: "CIS", ID: "1.1.1", Title: "Ensure mounting of cramfs is disabled", Status: COMPLIANT},
+		{Type: "Custom:CIS", ID: "5.2.8", Title: "Ensure SSH root login is disabled", Severity: ssm.ComplianceSeverityHigh, Status: NON_COMPLIANT},
+		{Type: "CIS", ID: "1.1.2", Status: "PASSED"},
+	}
+
+	updated, errs := UpdateCustomComplianceItems("association_1", "testDoc", "1", items, executionTime)
+	assert.True(t, updated)
+	assert.Equal(t, 1, len(errs))
+	// the id reported by another association is kept for the first one
+	updated, _ = UpdateCustomComplianceItems("association_2", "testDoc2", "2", items[:1], executionTime)
+	assert.True(t, updated)
+	// associations that never reported items don't update anything
+	updated, _ = UpdateCustomComplianceItems("association_3", "testDoc3", "3", nil, executionTime)
+	assert.False(t, updated)
+
+	entries := GetCustomComplianceEntries()
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, 2, len(entries["Custom:CIS"]))
+	assert.Equal(t, "association_1", entries["Custom:CIS"][0].AssociationId)
+	assert.Equal(t, UNSPECIFIED, entries["Custom:CIS"][0].ComplianceSeverity)
+	assert.Equal(t, ssm.ComplianceSeverityHigh, entries["Custom:CIS"][1].ComplianceSeverity)
+
+	// a type no longer reported is kept without items, so that it gets cleared
+	updated, _ = UpdateCustomComplianceItems("association_1", "testDoc", "1", nil, executionTime.Add(time.Second))
+	assert.True(t, updated)
+	RefreshAssociationComplianceItems([]*model.InstanceAssociation{})
+	entries = GetCustomComplianceEntries()
+	assert.Equal(t, 1, len(entries))
+	assert.Empty(t, entries["Custom:CIS"])
+}
+
+func TestDriftItemsArePersisted(t *testing.T) {
+	location, err := ioutil.TempDir("", "compliance")
+	assert.Nil(t, err)
+	defer func() {
+		store = nil
+		os.RemoveAll(location)
+	}()
+	RefreshAssociationComplianceItems([]*model.InstanceAssociation{})
+	LoadComplianceItems(log.NewMockLog(), location)
+
+	executionTime := time.Now()
+	UpdateAssociationDriftItems("association_1", "testDoc", "1", []contracts.DriftItem{{ID: "/etc/motd:content"}}, executionTime)
+	UpdateAssociationDriftItems("association_2", "testDoc2", "2", nil, executionTime)
+
+	// the items of both associations are restored after a restart
+	associationDriftItems = map[string][]*AssociationDriftItem{}
+	LoadComplianceItems(log.NewMockLog(), location)
+	driftItems := GetAssociationDriftEntries()
+	assert.Equal(t, 2, len(driftItems))
+	assert.Equal(t, "association_1:/etc/motd:content", driftItems[0].Id)
+	assert.True(t, executionTime.Equal(driftItems[0].ExecutionTime))
+	assert.Equal(t, "association_2", driftItems[1].Id)
+
+	// the items of removed associations are no longer restored
+	RefreshAssociationComplianceItems([]*model.InstanceAssociation{})
+	associationDriftItems = map[string][]*AssociationDriftItem{}
+	LoadComplianceItems(log.NewMockLog(), location)
+	assert.Empty(t, GetAssociationDriftEntries())
+}
+
+func TestCustomComplianceItemsArePersisted(t *testing.T) {
+	location, err := ioutil.TempDir("", "compliance")
+	assert.Nil(t, err)
+	defer func() {
+		store = nil
+		os.RemoveAll(location)
+	}()
+	RefreshAssociationComplianceItems([]*model.InstanceAssociation{})
+	LoadComplianceItems(log.NewMockLog(), location)
+
+	executionTime := time.Now()
+	UpdateCustomComplianceItems("association_1", "testDoc", "1", []contracts.ComplianceItem{{Type: "CIS", ID: "1.1.1", Status: COMPLIANT}}, executionTime)
+	UpdateCustomComplianceItems("association_2", "testDoc2", "2", []contracts.ComplianceItem{{Type: "Patch", ID: "KB1", Status: NON_COMPLIANT}}, executionTime)
+	UpdateCustomComplianceItems("association_2", "testDoc2", "2", nil, executionTime.Add(time.Second))
+
+	// the items of the other associations and the types no longer reported are restored after a restart
+	customComplianceItems = map[string][]*CustomComplianceItem{}
+	customComplianceTypes = map[string]bool{}
+	LoadComplianceItems(log.NewMockLog(), location)
+	entries := GetCustomComplianceEntries()
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, 1, len(entries["Custom:CIS"]))
+	assert.Equal(t, "association_1", entries["Custom:CIS"][0].AssociationId)
+	assert.Empty(t, entries["Custom:Patch"])
+}
@@ -16,6 +16,7 @@ package compliance
 import (
 	"time"
 
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/stretchr/testify/mock"
 )
@@ -38,3 +39,18 @@ func (m *ComplianceUploaderMock) UpdateAssociationCompliance(associationId strin
 	args := m.Called(associationId, instanceId, documentName, documentVersion, associationStatus, executionTime)
 	return args.Error(0)
 }
+
+func (m *ComplianceUploaderMock) UpdateAssociationDrift(associationId string, instanceId string, documentName string, documentVersion string, drift []contracts.DriftItem, executionTime time.Time) error {
+	args := m.Called(associationId, instanceId, documentName, documentVersion, drift, executionTime)
+	return args.Error(0)
+}
+
+func (m *ComplianceUploaderMock) ClearAssociationDrift(associationId string, instanceId string, executionTime time.Time) error {
+	args := m.Called(associationId, instanceId, executionTime)
+	return args.Error(0)
+}
+
+func (m *ComplianceUploaderMock) UpdateCustomCompliance(associationId string, instanceId string, documentName string, documentVersion string, items []contracts.ComplianceItem, executionTime time.Time) error {
+	args := m.Called(associationId, instanceId, documentName, documentVersion, items, executionTime)
+	return args.Error(0)
+}
@@ -17,12 +17,14 @@ import (
 	"crypto/md5"
 	"encoding/base64"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
 	"encoding/json"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/association/outbox"
 	"github.com/aws/amazon-ssm-agent/agent/compliance/model"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
@@ -39,6 +41,11 @@ const (
 	associationComplianceType     = "Association"
 	Name                          = "ComplianceUploader"
 	AssociationComplianceItemName = "AssociationComplianceItem"
+	// associationDriftComplianceType is the compliance type of the drift found by associations running in audit mode
+	associationDriftComplianceType = "Custom:AssociationDrift"
+	AssociationDriftItemName       = "AssociationDriftItem"
+	// CustomComplianceItemName is the prefix of the content hash names of the custom compliance types
+	CustomComplianceItemName = "CustomComplianceItem"
 )
 
 var (
@@ -49,6 +56,9 @@ var (
 type T interface {
 	CreateNewServiceIfUnHealthy(log log.T)
 	UpdateAssociationCompliance(associationId string, instanceId string, documentName string, documentVersion string, associationStatus string, executionTime time.Time) error
+	UpdateAssociationDrift(associationId string, instanceId string, documentName string, documentVersion string, drift []contracts.DriftItem, executionTime time.Time) error
+	ClearAssociationDrift(associationId string, instanceId string, executionTime time.Time) error
+	UpdateCustomCompliance(associationId string, instanceId string, documentName string, documentVersion string, items []contracts.ComplianceItem, executionTime time.Time) error
 }
 
 // ComplianceService wraps the Ssm Service
@@ -58,12 +68,37 @@ type ComplianceUploader struct {
 	name       string
 	context    context.T
 	optimizer  datauploader.Optimizer
+	outbox     outbox.T
 }
 
-// NewComplianceService returns a new compliance service
-func NewComplianceUploader(context context.T) *ComplianceUploader {
+// ComplianceUpdate is an association compliance update waiting in the outbox
+type ComplianceUpdate struct {
+	InstanceID    string
+	ExecutionTime time.Time
+	Entries       []*model.AssociationComplianceItem
+}
+
+// DriftUpdate is an association drift compliance update waiting in the outbox
+type DriftUpdate struct {
+	InstanceID    string
+	ExecutionTime time.Time
+	Entries       []*model.AssociationDriftItem
+}
+
+// CustomComplianceUpdate is a custom compliance update waiting in the outbox, with the items of every custom compliance type
+type CustomComplianceUpdate struct {
+	InstanceID    string
+	ExecutionTime time.Time
+	Entries       map[string][]*model.CustomComplianceItem
+}
+
+// NewComplianceService returns a new compliance service, compliance updates are delivered through the given outbox.
+// The compliance items persisted by an earlier run of the agent on the instance are restored.
+func NewComplianceUploader(context context.T, instanceID string, updates outbox.T) *ComplianceUploader {
 	var err error
 
+	model.LoadComplianceItems(context.Log(), model.GetLocation(instanceID))
+
 	ssmService := ssmSvc.NewService()
 	policy := sdkutil.NewStopPolicy(Name, stopPolicyErrorThreshold)
 	uploader := &ComplianceUploader{
@@ -71,6 +106,12 @@ func NewComplianceUploader(context context.T) *ComplianceUploader {
 		stopPolicy: policy,
 		context:    context,
 		name:       Name,
+		outbox:     updates,
+	}
+	if updates != nil {
+		updates.RegisterSender(outbox.KindAssociationCompliance, uploader.sendAssociationCompliance)
+		updates.RegisterSender(outbox.KindAssociationDrift, uploader.sendAssociationDrift)
+		updates.RegisterSender(outbox.KindCustomCompliance, uploader.sendCustomCompliance)
 	}
 
 	if uploader.optimizer, err = datauploader.NewOptimizerImplWithLocation(
@@ -119,10 +160,41 @@ func (u *ComplianceUploader) UpdateAssociationCompliance(associationId string, i
 	log := u.context.Log()
 
 	model.UpdateAssociationComplianceItem(associationId, documentName, documentVersion, associationStatus, executionTime)
-	var associationComplianceEntries = model.GetAssociationComplianceEntries()
+	update := ComplianceUpdate{
+		InstanceID:    instanceId,
+		ExecutionTime: executionTime,
+		Entries:       model.GetAssociationComplianceEntries(),
+	}
 
+	if u.outbox == nil {
+		return u.putAssociationCompliance(log, update)
+	}
+	// the update carries all association compliance items, so it supersedes the pending one
+	if err := u.outbox.Enqueue(log, outbox.KindAssociationCompliance, associationId, "", update); err != nil {
+		log.Errorf("Unable to persist association compliance update, %v", err)
+		return u.putAssociationCompliance(log, update)
+	}
+	u.outbox.Flush(log)
+	return nil
+}
+
+// sendAssociationCompliance delivers a compliance update from the outbox
+func (u *ComplianceUploader) sendAssociationCompliance(log log.T, message outbox.Message) error {
+	var update ComplianceUpdate
+	if err := json.Unmarshal(message.Payload, &update); err != nil {
+		return outbox.Permanent(fmt.Errorf("invalid association compliance update, %v", err))
+	}
+	if err := u.putAssociationCompliance(log, update); err != nil {
+		return outbox.ClassifyAwsError(err)
+	}
+	return nil
+}
+
+// putAssociationCompliance calls the service to put the association compliance items
+func (u *ComplianceUploader) putAssociationCompliance(log log.T, update ComplianceUpdate) error {
+	executionTime := update.ExecutionTime
 	oldHash := u.optimizer.GetContentHash(AssociationComplianceItemName)
-	newComplianceItems, itemContentHash, err := u.ConvertToSsmAssociationComplianceItems(log, associationComplianceEntries, oldHash)
+	newComplianceItems, itemContentHash, err := u.ConvertToSsmAssociationComplianceItems(log, update.Entries, oldHash)
 
 	// 1. When call PutComplianceItem failed, it will fail silently  with an error message the agent should have permission to call
 	// 2. When old date arrive at server side before new date, the server side will discard and use the new date
@@ -131,13 +203,13 @@ func (u *ComplianceUploader) UpdateAssociationCompliance(associationId string, i
 		&executionTime,
 		"",
 		"",
-		instanceId,
+		update.InstanceID,
 		associationComplianceType,
 		itemContentHash,
 		newComplianceItems)
 
 	if err != nil {
-		err = fmt.Errorf("Unable to update association compliance %v", err)
+		log.Errorf("Unable to update association compliance %v", err)
 		return err
 	}
 
@@ -149,6 +221,248 @@ func (u *ComplianceUploader) UpdateAssociationCompliance(associationId string, i
 	return nil
 }
 
+// UpdateAssociationDrift updates the drift compliance items with the drift found by an association running in audit mode
+func (u *ComplianceUploader) UpdateAssociationDrift(associationId string, instanceId string, documentName string, documentVersion string, drift []contracts.DriftItem, executionTime time.Time) error {
+	log := u.context.Log()
+
+	model.UpdateAssociationDriftItems(associationId, documentName, documentVersion, drift, executionTime)
+	return u.updateAssociationDrift(log, associationId, instanceId, executionTime)
+}
+
+// ClearAssociationDrift removes the drift compliance items of an association that no longer runs in audit mode
+func (u *ComplianceUploader) ClearAssociationDrift(associationId string, instanceId string, executionTime time.Time) error {
+	log := u.context.Log()
+
+	if !model.RemoveAssociationDriftItems(associationId, executionTime) {
+		return nil
+	}
+	return u.updateAssociationDrift(log, associationId, instanceId, executionTime)
+}
+
+// updateAssociationDrift puts the drift items of all audited associations after the drift of an association changed
+func (u *ComplianceUploader) updateAssociationDrift(log log.T, associationId string, instanceId string, executionTime time.Time) error {
+	update := DriftUpdate{
+		InstanceID:    instanceId,
+		ExecutionTime: executionTime,
+		Entries:       model.GetAssociationDriftEntries(),
+	}
+
+	if u.outbox == nil {
+		return u.putAssociationDrift(log, update)
+	}
+	// the update carries the drift items of all audited associations, so it supersedes the pending one
+	if err := u.outbox.Enqueue(log, outbox.KindAssociationDrift, associationId, "", update); err != nil {
+		log.Errorf("Unable to persist association drift update, %v", err)
+		return u.putAssociationDrift(log, update)
+	}
+	u.outbox.Flush(log)
+	return nil
+}
+
+// sendAssociationDrift delivers a drift compliance update from the outbox
+func (u *ComplianceUploader) sendAssociationDrift(log log.T, message outbox.Message) error {
+	var update DriftUpdate
+	if err := json.Unmarshal(message.Payload, &update); err != nil {
+		return outbox.Permanent(fmt.Errorf("invalid association drift update, %v", err))
+	}
+	if err := u.putAssociationDrift(log, update); err != nil {
+		return outbox.ClassifyAwsError(err)
+	}
+	return nil
+}
+
+// putAssociationDrift calls the service to put the drift compliance items
+func (u *ComplianceUploader) putAssociationDrift(log log.T, update DriftUpdate) error {
+	executionTime := update.ExecutionTime
+	oldHash := u.optimizer.GetContentHash(AssociationDriftItemName)
+	driftItems, itemContentHash, err := u.ConvertToSsmAssociationDriftItems(log, update.Entries, oldHash)
+	if err != nil {
+		log.Errorf("Unable to convert association drift items %v", err)
+		return err
+	}
+
+	response, err := u.ssmSvc.PutComplianceItems(
+		log,
+		&executionTime,
+		"",
+		"",
+		update.InstanceID,
+		associationDriftComplianceType,
+		itemContentHash,
+		driftItems)
+
+	if err != nil {
+		log.Errorf("Unable to update association drift %v", err)
+		return err
+	}
+
+	if itemContentHash != oldHash {
+		u.optimizer.UpdateContentHash(AssociationDriftItemName, itemContentHash)
+	}
+
+	log.Debugf("Put drift compliance item %v return response %v", driftItems, response)
+	return nil
+}
+
+// ConvertToSsmAssociationDriftItems converts the drift items into compliance items, only the content hash is returned
+// when the drift items are the same as the ones last uploaded
+func (u *ComplianceUploader) ConvertToSsmAssociationDriftItems(log log.T, driftEntries []*model.AssociationDriftItem, oldHash string) (
+	driftItems []*ssm.ComplianceItemEntry, contentHash string, err error) {
+
+	var dataB []byte
+	if dataB, err = json.Marshal(driftEntries); err != nil {
+		return
+	}
+
+	newHash := calculateCheckSum(dataB)
+	if newHash == oldHash {
+		log.Debugf("Compliance data for %v is same as before - we can just send content hash", AssociationDriftItemName)
+		return []*ssm.ComplianceItemEntry{}, newHash, nil
+	}
+
+	for _, item := range driftEntries {
+		details := map[string]*string{
+			"AssociationId":   aws.String(item.AssociationId),
+			"DocumentName":    aws.String(item.DocumentName),
+			"DocumentVersion": aws.String(item.DocumentVersion),
+		}
+		for key, value := range item.Details {
+			details[key] = aws.String(value)
+		}
+		driftItems = append(driftItems, &ssm.ComplianceItemEntry{
+			Id:       aws.String(item.Id),
+			Status:   aws.String(item.ComplianceStatus),
+			Severity: aws.String(item.ComplianceSeverity),
+			Title:    aws.String(item.Title),
+			Details:  details,
+		})
+	}
+	return driftItems, newHash, nil
+}
+
+// UpdateCustomCompliance updates the custom compliance types with the compliance items reported by the plugins and
+// scripts of an association, invalid items are logged and left out
+func (u *ComplianceUploader) UpdateCustomCompliance(associationId string, instanceId string, documentName string, documentVersion string, items []contracts.ComplianceItem, executionTime time.Time) error {
+	log := u.context.Log()
+
+	updated, errs := model.UpdateCustomComplianceItems(associationId, documentName, documentVersion, items, executionTime)
+	for _, err := range errs {
+		log.Errorf("Ignoring compliance item reported by association %v, %v", associationId, err)
+	}
+	if !updated {
+		return nil
+	}
+	update := CustomComplianceUpdate{
+		InstanceID:    instanceId,
+		ExecutionTime: executionTime,
+		Entries:       model.GetCustomComplianceEntries(),
+	}
+
+	if u.outbox == nil {
+		return u.putCustomCompliance(log, update)
+	}
+	// the update carries the items of all custom compliance types, so it supersedes the pending one
+	if err := u.outbox.Enqueue(log, outbox.KindCustomCompliance, associationId, "", update); err != nil {
+		log.Errorf("Unable to persist custom compliance update, %v", err)
+		return u.putCustomCompliance(log, update)
+	}
+	u.outbox.Flush(log)
+	return nil
+}
+
+// sendCustomCompliance delivers a custom compliance update from the outbox
+func (u *ComplianceUploader) sendCustomCompliance(log log.T, message outbox.Message) error {
+	var update CustomComplianceUpdate
+	if err := json.Unmarshal(message.Payload, &update); err != nil {
+		return outbox.Permanent(fmt.Errorf("invalid custom compliance update, %v", err))
+	}
+	if err := u.putCustomCompliance(log, update); err != nil {
+		return outbox.ClassifyAwsError(err)
+	}
+	return nil
+}
+
+// putCustomCompliance calls the service to put the compliance items of each custom compliance type,
+// it returns the last error after trying every type
+func (u *ComplianceUploader) putCustomCompliance(log log.T, update CustomComplianceUpdate) (err error) {
+	executionTime := update.ExecutionTime
+	complianceTypes := []string{}
+	for complianceType := range update.Entries {
+		complianceTypes = append(complianceTypes, complianceType)
+	}
+	sort.Strings(complianceTypes)
+
+	for _, complianceType := range complianceTypes {
+		hashName := CustomComplianceItemName + ":" + complianceType
+		oldHash := u.optimizer.GetContentHash(hashName)
+		complianceItems, itemContentHash, convertErr := u.ConvertToSsmCustomComplianceItems(log, update.Entries[complianceType], oldHash)
+		if convertErr != nil {
+			log.Errorf("Unable to convert %v compliance items %v", complianceType, convertErr)
+			err = convertErr
+			continue
+		}
+
+		response, putErr := u.ssmSvc.PutComplianceItems(
+			log,
+			&executionTime,
+			"",
+			"",
+			update.InstanceID,
+			complianceType,
+			itemContentHash,
+			complianceItems)
+
+		if putErr != nil {
+			log.Errorf("Unable to update %v compliance %v", complianceType, putErr)
+			err = putErr
+			continue
+		}
+
+		if itemContentHash != oldHash {
+			u.optimizer.UpdateContentHash(hashName, itemContentHash)
+		}
+		log.Debugf("Put %v compliance item %v return response %v", complianceType, complianceItems, response)
+	}
+	return err
+}
+
+// ConvertToSsmCustomComplianceItems converts the custom compliance items of a compliance type, only the content hash
+// is returned when the items are the same as the ones last uploaded
+func (u *ComplianceUploader) ConvertToSsmCustomComplianceItems(log log.T, customComplianceEntries []*model.CustomComplianceItem, oldHash string) (
+	complianceItems []*ssm.ComplianceItemEntry, contentHash string, err error) {
+
+	var dataB []byte
+	if dataB, err = json.Marshal(customComplianceEntries); err != nil {
+		return
+	}
+
+	newHash := calculateCheckSum(dataB)
+	if newHash == oldHash {
+		log.Debugf("Compliance data for %v is same as before - we can just send content hash", CustomComplianceItemName)
+		return []*ssm.ComplianceItemEntry{}, newHash, nil
+	}
+
+	complianceItems = []*ssm.ComplianceItemEntry{}
+	for _, item := range customComplianceEntries {
+		details := map[string]*string{
+			"AssociationId":   aws.String(item.AssociationId),
+			"DocumentName":    aws.String(item.DocumentName),
+			"DocumentVersion": aws.String(item.DocumentVersion),
+		}
+		for key, value := range item.Details {
+			details[key] = aws.String(value)
+		}
+		complianceItems = append(complianceItems, &ssm.ComplianceItemEntry{
+			Id:       aws.String(item.Id),
+			Status:   aws.String(item.ComplianceStatus),
+			Severity: aws.String(item.ComplianceSeverity),
+			Title:    aws.String(item.Title),
+			Details:  details,
+		})
+	}
+	return complianceItems, newHash, nil
+}
+
 // ConvertToSsmComplianceItems converts given array of complianceItem into an array of *ssm.ComplianceItemEntry. It returns 2 such arrays - one is optimized array
 // which contains only contentHash for those compliance types where the dataset hasn't changed from previous collection. The other array is non-optimized array
 // which contains both contentHash & content. This is done to avoid iterating over the compliance data twice. It throws error when it encounters error during
@@ -22,6 +22,7 @@ import (
 	associationModel "github.com/aws/amazon-ssm-agent/agent/association/model"
 	"github.com/aws/amazon-ssm-agent/agent/compliance/model"
 	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/datauploader"
 	ssmSvc "github.com/aws/amazon-ssm-agent/agent/ssm"
 	"github.com/aws/aws-sdk-go/aws"
@@ -184,6 +185,134 @@ func TestUpdateAssociationCompliance(t *testing.T) {
 
 }
 
+func TestUpdateAssociationDrift(t *testing.T) {
+	u := MockComplianceUploader()
+
+	serviceMock := ssmSvc.NewMockDefault()
+	u.ssmSvc = serviceMock
+
+	optimizer := datauploader.NewMockDefault()
+	optimizer.On("GetContentHash", mock.AnythingOfType("string")).Return("RandomDriftItem")
+	optimizer.On("UpdateContentHash", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil)
+	u.optimizer = optimizer
+
+	u.context = context.NewMockDefault()
+
+	serviceMock.On(
+		"PutComplianceItems",
+		mock.AnythingOfType("*log.Mock"),
+		mock.AnythingOfType("*time.Time"),
+		mock.AnythingOfType("string"),
+		mock.AnythingOfType("string"),
+		mock.AnythingOfType("string"),
+		mock.AnythingOfType("string"),
+		mock.AnythingOfType("string"),
+		mock.AnythingOfType("[]*ssm.ComplianceItemEntry")).Return(&ssm.PutComplianceItemsOutput{}, nil)
+
+	executionTime := time.Now()
+	drift := []contracts.DriftItem{{ID: "/etc/motd:content", Title: "update the content of /etc/motd", Details: map[string]string{"Path": "/etc/motd"}}}
+	u.UpdateAssociationDrift("drift_association", "i-123", "testDoc", "1", drift, executionTime)
+
+	assert.True(t, serviceMock.AssertNumberOfCalls(t, "PutComplianceItems", 1))
+
+	arguments := serviceMock.Calls[0].Arguments
+	assert.Equal(t, "i-123", arguments.String(4))
+	assert.Equal(t, "Custom:AssociationDrift", arguments.String(5))
+
+	driftItems := arguments.Get(7).([]*ssm.ComplianceItemEntry)
+	assert.Equal(t, 1, len(driftItems))
+	assert.Equal(t, "drift_association:/etc/motd:content", *driftItems[0].Id)
+	assert.Equal(t, model.NON_COMPLIANT, *driftItems[0].Status)
+	assert.Equal(t, "/etc/motd", *driftItems[0].Details["Path"])
+	assert.Equal(t, "testDoc", *driftItems[0].Details["DocumentName"])
+
+	optimizer.AssertCalled(t, "UpdateContentHash", AssociationDriftItemName, mock.AnythingOfType("string"))
+}
+
+func TestClearAssociationDrift(t *testing.T) {
+	u := MockComplianceUploader()
+
+	serviceMock := ssmSvc.NewMockDefault()
+	u.ssmSvc = serviceMock
+
+	optimizer := datauploader.NewMockDefault()
+	optimizer.On("GetContentHash", mock.AnythingOfType("string")).Return("RandomDriftItem")
+	optimizer.On("UpdateContentHash", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil)
+	u.optimizer = optimizer
+
+	u.context = context.NewMockDefault()
+
+	serviceMock.On(
+		"PutComplianceItems",
+		mock.AnythingOfType("*log.Mock"),
+		mock.AnythingOfType("*time.Time"),
+		mock.AnythingOfType("string"),
+		mock.AnythingOfType("string"),
+		mock.AnythingOfType("string"),
+		mock.AnythingOfType("string"),
+		mock.AnythingOfType("string"),
+		mock.AnythingOfType("[]*ssm.ComplianceItemEntry")).Return(&ssm.PutComplianceItemsOutput{}, nil)
+
+	model.RefreshAssociationComplianceItems([]*associationModel.InstanceAssociation{})
+	executionTime := time.Now()
+	drift := []contracts.DriftItem{{ID: "/etc/motd:content", Title: "update the content of /etc/motd"}}
+	u.UpdateAssociationDrift("drift_association", "i-123", "testDoc", "1", drift, executionTime)
+	u.ClearAssociationDrift("drift_association", "i-123", executionTime.Add(time.Second))
+	// nothing is put once the drift is cleared
+	u.ClearAssociationDrift("drift_association", "i-123", executionTime.Add(2*time.Second))
+
+	assert.True(t, serviceMock.AssertNumberOfCalls(t, "PutComplianceItems", 2))
+	assert.Equal(t, "Custom:AssociationDrift", serviceMock.Calls[1].Arguments.String(5))
+	assert.Empty(t, serviceMock.Calls[1].Arguments.Get(7).([]*ssm.ComplianceItemEntry))
+}
+
+func TestUpdateCustomCompliance(t *testing.T) {
+	u := MockComplianceUploader()
+
+	serviceMock := ssmSvc.NewMockDefault()
+	u.ssmSvc = serviceMock
+
+	optimizer := datauploader.NewMockDefault()
+	optimizer.On("GetContentHash", mock.AnythingOfType("string")).Return("RandomCustomItem")
+	optimizer.On("UpdateContentHash", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil)
+	u.optimizer = optimizer
+
+	u.context = context.NewMockDefault()
+
+	serviceMock.On(
+		"PutComplianceItems",
+		mock.AnythingOfType("*log.Mock"),
+		mock.AnythingOfType("*time.Time"),
+		mock.AnythingOfType("string"),
+		mock.AnythingOfType("string"),
+		mock.AnythingOfType("string"),
+		mock.AnythingOfType("string"),
+		mock.AnythingOfType("string"),
+		mock.AnythingOfType("[]*ssm.ComplianceItemEntry")).Return(&ssm.PutComplianceItemsOutput{}, nil)
+
+	executionTime := time.Now()
+	items := []contracts.ComplianceItem{
+		{Type: "CIS", ID: "5.2.8", Title: "Ensure SSH root login is disabled", Status: model.NON_COMPLIANT, Details: map[string]string{"Profile": "Level 1"}},
+		{Type: "CIS", ID: "5.2.9", Status: "UNKNOWN"},
+	}
+	u.UpdateCustomCompliance("cis_association", "i-123", "testDoc", "1", items, executionTime)
+
+	assert.True(t, serviceMock.AssertNumberOfCalls(t, "PutComplianceItems", 1))
+
+	arguments := serviceMock.Calls[0].Arguments
+	assert.Equal(t, "i-123", arguments.String(4))
+	assert.Equal(t, "Custom:CIS", arguments.String(5))
+
+	complianceItems := arguments.Get(7).([]*ssm.ComplianceItemEntry)
+	assert.Equal(t, 1, len(complianceItems))
+	assert.Equal(t, "5.2.8", *complianceItems[0].Id)
+	assert.Equal(t, model.NON_COMPLIANT, *complianceItems[0].Status)
+	assert.Equal(t, "Level 1", *complianceItems[0].Details["Profile"])
+	assert.Equal(t, "cis_association", *complianceItems[0].Details["AssociationId"])
+
+	optimizer.AssertCalled(t, "UpdateContentHash", CustomComplianceItemName+":Custom:CIS", mock.AnythingOfType("string"))
+}
+
 func TestConvertReturnEmptyForHashMatch(t *testing.T) {
 
 	var items []*model.AssociationComplianceItem
@@ -126,6 +126,8 @@ const (
 	AssociationPendingMessage string = "Association is pending"
 	// DocumentInProgressMessage represents the summary message for inprogress association
 	AssociationInProgressMessage string = "Executing association"
+	// AssociationDeferredMessage represents the summary message for association deferred by the local maintenance windows
+	AssociationDeferredMessage string = "Association is deferred %v, %v"
 )
 
 const (
@@ -177,6 +179,11 @@ type DocumentContent struct {
 	RuntimeConfig map[string]*PluginConfig `json:"runtimeConfig"`
 	MainSteps     []*InstancePluginConfig  `json:"mainSteps"`
 	Parameters    map[string]*Parameter    `json:"parameters"`
+	// Resources are the resources touched by an association document, e.g. lock:package-manager or path:/etc/nginx
+	Resources []string `json:"resources,omitempty"`
+	// Triggers run an association when a local event happens, e.g. agent-start, boot, network-up, file:/etc/nginx/nginx.conf
+	// or package-installed:nginx
+	Triggers []string `json:"triggers,omitempty"`
 }
 
 // AdditionalInfo section in agent response
@@ -221,6 +228,7 @@ type DocumentResult struct {
 	DocumentVersion string
 	MessageID       string
 	AssociationID   string
+	RunID           string `json:",omitempty"`
 	PluginResults   map[string]*PluginResult
 	Status          ResultStatus
 	LastPlugin      string
@@ -53,6 +53,30 @@ type PluginResult struct {
 	Error              error        `json:"-"`
 	StandardOutput     string       `json:"standardOutput"`
 	StandardError      string       `json:"standardError"`
+	// Audited is set by plugins that ran in audit mode, Drift then holds the changes they would have applied
+	Audited bool        `json:"audited,omitempty"`
+	Drift   []DriftItem `json:"drift,omitempty"`
+	// ComplianceItems are uploaded under the custom compliance types of the items when the plugin ran for an association
+	ComplianceItems []ComplianceItem `json:"complianceItems,omitempty"`
+}
+
+// DriftItem is a change a plugin running in audit mode would have applied, e.g. a package to install
+type DriftItem struct {
+	ID       string            `json:"id"`
+	Title    string            `json:"title"`
+	Severity string            `json:"severity,omitempty"`
+	Details  map[string]string `json:"details,omitempty"`
+}
+
+// ComplianceItem is a compliance item reported by a plugin or a script, e.g. the result of a CIS check
+type ComplianceItem struct {
+	// Type is the custom compliance type of the item, the Custom: prefix is added when missing
+	Type     string            `json:"type"`
+	ID       string            `json:"id"`
+	Title    string            `json:"title"`
+	Severity string            `json:"severity,omitempty"`
+	Status   string            `json:"status"`
+	Details  map[string]string `json:"details,omitempty"`
 }
 
 // IPlugin is interface for authoring a functionality of work.
@@ -91,6 +115,8 @@ type Configuration struct {
 	Preconditions           map[string][]string
 	IsPreconditionEnabled   bool
 	CurrentAssociations     []string
+	// AuditOnly asks the plugin to report the changes it would apply without applying them
+	AuditOnly bool
 }
 
 // Plugin wraps the plugin configuration and plugin result.
@@ -40,7 +40,7 @@ const (
 
 // T is the interface type for ShellCommandExecuter.
 type T interface {
-	Execute(log.T, string, string, string, task.CancelFlag, int, string, []string) (io.Reader, io.Reader, int, []error)
+	Execute(log.T, string, string, string, task.CancelFlag, int, string, []string, map[string]string) (io.Reader, io.Reader, int, []error)
 	StartExe(log.T, string, string, string, task.CancelFlag, string, []string) (*os.Process, int, []error)
 }
 
@@ -55,6 +55,7 @@ type timeoutSignal struct {
 }
 
 // Execute executes a list of shell commands in the given working directory.
+// The given environment variables are set for the process in addition to the ssm agent standard ones.
 // If no file path is provided for either stdout or stderr, output will be written to a byte buffer.
 // Returns readers for the standard output and standard error streams, process exit code, and a set of errors.
 // The errors need not be fatal - the output streams may still have data
@@ -77,6 +78,7 @@ func (ShellCommandExecuter) Execute(
 	executionTimeout int,
 	commandName string,
 	commandArguments []string,
+	envVars map[string]string,
 ) (stdout io.Reader, stderr io.Reader, exitCode int, errs []error) {
 
 	var stdoutWriter io.Writer
@@ -120,7 +122,7 @@ func (ShellCommandExecuter) Execute(
 	// writers as long as it is after the process starts.
 
 	var err error
-	exitCode, err = ExecuteCommand(log, cancelFlag, workingDir, stdoutWriter, stderrWriter, executionTimeout, commandName, commandArguments)
+	exitCode, err = ExecuteCommand(log, cancelFlag, workingDir, stdoutWriter, stderrWriter, executionTimeout, commandName, commandArguments, envVars)
 	if err != nil {
 		errs = append(errs, err)
 	}
@@ -260,7 +262,7 @@ func (r *cancellableWriter) Write(p []byte) (n int, err error) {
 	}
 }
 
-// ExecuteCommand executes the given commands using the given working directory.
+// ExecuteCommand executes the given commands using the given working directory and environment variables.
 // Standard output and standard error are sent to the given writers.
 func ExecuteCommand(log log.T,
 	cancelFlag task.CancelFlag,
@@ -270,6 +272,7 @@ func ExecuteCommand(log log.T,
 	executionTimeout int,
 	commandName string,
 	commandArguments []string,
+	envVars map[string]string,
 ) (exitCode int, err error) {
 
 	stdoutInterruptable, stopStdout := newWriter(stdoutWriter)
@@ -300,7 +303,7 @@ func ExecuteCommand(log log.T,
 	prepareProcess(command)
 
 	// configure environment variables
-	prepareEnvironment(command)
+	prepareEnvironment(command, envVars)
 
 	log.Debug()
 	log.Debugf("Running in directory %v, command: %v %v", workingDir, commandName, commandArguments)
@@ -417,7 +420,7 @@ func StartCommand(log log.T,
 	prepareProcess(command)
 
 	// configure environment variables
-	prepareEnvironment(command)
+	prepareEnvironment(command, nil)
 
 	log.Debug()
 	log.Debugf("Running in directory %v, command: %v %v", workingDir, commandName, commandArguments)
@@ -462,8 +465,8 @@ func killProcessOnCancel(log log.T, command *exec.Cmd, cancelStdout chan bool, c
 	}
 }
 
-// prepareEnvironment adds ssm agent standard environment variables to the command
-func prepareEnvironment(command *exec.Cmd) {
+// prepareEnvironment adds ssm agent standard environment variables and the given environment variables to the command
+func prepareEnvironment(command *exec.Cmd, envVars map[string]string) {
 	env := os.Environ()
 	if instance, err := instance.InstanceID(); err == nil {
 		env = append(env, fmtEnvVariable(envVarInstanceId, instance))
@@ -471,6 +474,9 @@ func prepareEnvironment(command *exec.Cmd) {
 	if region, err := instance.Region(); err == nil {
 		env = append(env, fmtEnvVariable(envVarRegionName, region))
 	}
+	for name, val := range envVars {
+		env = append(env, fmtEnvVariable(name, val))
+	}
 	command.Env = env
 
 	// Running powershell on linux erquired the HOME env variable to be set and to remove the TERM env variable
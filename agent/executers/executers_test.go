@@ -72,7 +72,7 @@ func TestEnvironmentVariables_All(t *testing.T) {
 	defer func() { instance = instanceTemp }()
 
 	command := getTestCommand(t)
-	prepareEnvironment(command)
+	prepareEnvironment(command, nil)
 
 	assert.Equal(t, getEnvVariableValue(command.Env, envVarInstanceId), testInstanceId)
 	assert.Equal(t, getEnvVariableValue(command.Env, envVarRegionName), testRegionName)
@@ -84,12 +84,24 @@ func TestEnvironmentVariables_None(t *testing.T) {
 	defer func() { instance = instanceTemp }()
 
 	command := getTestCommand(t)
-	prepareEnvironment(command)
+	prepareEnvironment(command, nil)
 
 	assert.Empty(t, getEnvVariableValue(command.Env, envVarInstanceId))
 	assert.Empty(t, getEnvVariableValue(command.Env, envVarRegionName))
 }
 
+func TestEnvironmentVariables_Given(t *testing.T) {
+	instanceTemp := instance
+	instance = &instanceInfoStub{instanceID: testInstanceId, regionName: testRegionName}
+	defer func() { instance = instanceTemp }()
+
+	command := getTestCommand(t)
+	prepareEnvironment(command, map[string]string{"AWS_SSM_TEST_VARIABLE": "value"})
+
+	assert.Equal(t, getEnvVariableValue(command.Env, envVarInstanceId), testInstanceId)
+	assert.Equal(t, getEnvVariableValue(command.Env, "AWS_SSM_TEST_VARIABLE"), "value")
+}
+
 func TestQuoteShString(t *testing.T) {
 	var result string
 
@@ -53,67 +53,75 @@ type DownloadInput struct {
 }
 
 // httpDownload attempts to download a file via http/s call
-func httpDownload(log log.T, fileURL string, destFile string) (output DownloadOutput, err error) {
+func httpDownload(log log.T, fileURL string, destFile string, config transferConfig) (output DownloadOutput, err error) {
 	log.Debugf("attempting to download as http/https download %v", destFile)
-	eTagFile := destFile + ".etag"
-	var check http.Client
-	var request *http.Request
-	request, err = http.NewRequest("GET", fileURL, nil)
+	output, err = downloadWithResume(log, fileURL, destFile, httpFetcher(fileURL), config)
 	if err != nil {
-		return
-	}
-	if fileutil.Exists(destFile) == true && fileutil.Exists(eTagFile) == true {
-		var existingETag string
-		existingETag, err = fileutil.ReadAllText(eTagFile)
-		request.Header.Add("If-None-Match", existingETag)
+		log.Debug("failed to download from http/https, ", err)
 	}
+	return
+}
 
-	check = http.Client{
+// httpFetcher requests ranges of a file with http/s calls
+func httpFetcher(fileURL string) rangeFetcher {
+	check := http.Client{
 		CheckRedirect: func(r *http.Request, via []*http.Request) error {
 			r.URL.Opaque = r.URL.Path
 			return nil
 		},
 	}
+	return func(rangeReq rangeRequest) (*rangeResponse, error) {
+		request, err := http.NewRequest("GET", fileURL, nil)
+		if err != nil {
+			return nil, permanentError{err}
+		}
+		if rangeReq.Start > 0 || rangeReq.End >= 0 || rangeReq.AcceptPartial {
+			request.Header.Add("Range", formatRange(rangeReq.Start, rangeReq.End))
+		}
+		if rangeReq.IfRange != "" {
+			request.Header.Add("If-Range", rangeReq.IfRange)
+		}
+		if rangeReq.IfNoneMatch != "" {
+			request.Header.Add("If-None-Match", rangeReq.IfNoneMatch)
+		}
 
-	var resp *http.Response
-	resp, err = check.Do(request)
-	if err != nil {
-		log.Debug("failed to download from http/https, ", err)
-		fileutil.DeleteFile(destFile)
-		fileutil.DeleteFile(eTagFile)
-		return
-	}
+		var resp *http.Response
+		if resp, err = check.Do(request); err != nil {
+			return nil, err
+		}
+		switch resp.StatusCode {
+		case http.StatusOK, http.StatusPartialContent:
+		case http.StatusNotModified:
+			resp.Body.Close()
+			return nil, errNotModified
+		case http.StatusRequestedRangeNotSatisfiable:
+			resp.Body.Close()
+			return nil, errRangeNotSatisfiable
+		default:
+			resp.Body.Close()
+			err = fmt.Errorf("http request failed. status:%v statuscode:%v", resp.Status, resp.StatusCode)
+			if resp.StatusCode >= http.StatusInternalServerError {
+				return nil, err
+			}
+			return nil, permanentError{err}
+		}
 
-	if resp.StatusCode == http.StatusNotModified {
-		log.Debugf("Unchanged file.")
-		output.IsUpdated = false
-		output.LocalFilePath = destFile
-		return output, nil
-	} else if resp.StatusCode != http.StatusOK {
-		log.Debug("failed to download from http/https, ", err)
-		fileutil.DeleteFile(destFile)
-		fileutil.DeleteFile(eTagFile)
-		err = fmt.Errorf("http request failed. status:%v statuscode:%v", resp.Status, resp.StatusCode)
-		return
-	}
-	defer resp.Body.Close()
-	eTagValue := resp.Header.Get("Etag")
-	if eTagValue != "" {
-		log.Debug("file eTagValue is ", eTagValue)
-		err = fileutil.WriteAllText(eTagFile, eTagValue)
-		if err != nil {
-			log.Errorf("failed to write eTagfile %v, %v ", eTagFile, err)
-			return
+		response := &rangeResponse{
+			Body:         resp.Body,
+			Total:        resp.ContentLength,
+			ETag:         resp.Header.Get("Etag"),
+			LastModified: resp.Header.Get("Last-Modified"),
 		}
+		if resp.StatusCode == http.StatusPartialContent {
+			var start int64
+			if start, response.Total, err = parseContentRange(resp.Header.Get("Content-Range")); err != nil || start != rangeReq.Start {
+				resp.Body.Close()
+				return nil, errRestart
+			}
+			response.Partial = true
+		}
+		return response, nil
 	}
-	_, err = FileCopy(log, destFile, resp.Body)
-	if err == nil {
-		output.LocalFilePath = destFile
-		output.IsUpdated = true
-	} else {
-		log.Errorf("failed to write destFile %v, %v ", destFile, err)
-	}
-	return
 }
 
 // awsConfig creates a config and sets region and credential information given an S3 URL
@@ -193,63 +201,69 @@ func ListS3Folders(log log.T, amazonS3URL s3util.AmazonS3URL) (folderNames []str
 	return folders, nil
 }
 
-// s3Download attempts to download a file via the aws sdk.
-func s3Download(log log.T, amazonS3URL s3util.AmazonS3URL, destFile string) (output DownloadOutput, err error) {
+// s3Download attempts to download a file via the aws sdk, artifactURL identifies the artifact for resuming the download.
+func s3Download(log log.T, amazonS3URL s3util.AmazonS3URL, artifactURL string, destFile string, config transferConfig) (output DownloadOutput, err error) {
 	log.Debugf("attempting to download as s3 download %v", destFile)
-	eTagFile := destFile + ".etag"
-
-	config, _ := awsConfig(log, amazonS3URL)
-	params := &s3.GetObjectInput{
-		Bucket: aws.String(amazonS3URL.Bucket),
-		Key:    aws.String(amazonS3URL.Key),
-	}
-
-	if fileutil.Exists(destFile) == true && fileutil.Exists(eTagFile) == true {
-		var existingETag string
-		existingETag, err = fileutil.ReadAllText(eTagFile)
-		if err != nil {
-			log.Debugf("failed to read etag file %v, %v", eTagFile, err)
-			return
-		}
-		params.IfNoneMatch = aws.String(existingETag)
+	output, err = downloadWithResume(log, artifactURL, destFile, s3Fetcher(log, amazonS3URL), config)
+	if err != nil {
+		log.Debug("failed to download from s3, ", err)
 	}
+	return
+}
 
+// s3Fetcher requests ranges of an object with the aws sdk
+func s3Fetcher(log log.T, amazonS3URL s3util.AmazonS3URL) rangeFetcher {
+	config, _ := awsConfig(log, amazonS3URL)
 	s3client := s3.New(session.New(config))
-
-	req, resp := s3client.GetObjectRequest(params)
-	err = req.Send()
-	if err != nil {
-		if req.HTTPResponse == nil || req.HTTPResponse.StatusCode != http.StatusNotModified {
-			log.Debug("failed to download from s3, ", err)
-			fileutil.DeleteFile(destFile)
-			fileutil.DeleteFile(eTagFile)
-			return
+	return func(rangeReq rangeRequest) (*rangeResponse, error) {
+		params := &s3.GetObjectInput{
+			Bucket: aws.String(amazonS3URL.Bucket),
+			Key:    aws.String(amazonS3URL.Key),
+		}
+		if rangeReq.Start > 0 || rangeReq.End >= 0 || rangeReq.AcceptPartial {
+			params.Range = aws.String(formatRange(rangeReq.Start, rangeReq.End))
+		}
+		if rangeReq.IfRange != "" {
+			// a changed object fails with 412 instead of returning the whole object
+			params.IfMatch = aws.String(rangeReq.IfRange)
+		}
+		if rangeReq.IfNoneMatch != "" {
+			params.IfNoneMatch = aws.String(rangeReq.IfNoneMatch)
 		}
 
-		log.Debugf("Unchanged file.")
-		output.IsUpdated = false
-		output.LocalFilePath = destFile
-		return output, nil
-	}
-
-	if *resp.ETag != "" {
-		log.Debug("files etag is ", *resp.ETag)
-		err = fileutil.WriteAllText(eTagFile, *resp.ETag)
-		if err != nil {
-			log.Errorf("failed to write eTagfile %v, %v ", eTagFile, err)
-			return
+		req, resp := s3client.GetObjectRequest(params)
+		if err := req.Send(); err != nil {
+			if req.HTTPResponse != nil {
+				switch statusCode := req.HTTPResponse.StatusCode; {
+				case statusCode == http.StatusNotModified:
+					return nil, errNotModified
+				case statusCode == http.StatusPreconditionFailed:
+					return nil, errRestart
+				case statusCode == http.StatusRequestedRangeNotSatisfiable:
+					return nil, errRangeNotSatisfiable
+				case statusCode >= http.StatusBadRequest && statusCode < http.StatusInternalServerError:
+					return nil, permanentError{err}
+				}
+			}
+			return nil, err
 		}
-	}
 
-	defer resp.Body.Close()
-	_, err = FileCopy(log, destFile, resp.Body)
-	if err == nil {
-		output.LocalFilePath = destFile
-		output.IsUpdated = true
-	} else {
-		log.Errorf("failed to write destFile %v, %v ", destFile, err)
+		response := &rangeResponse{
+			Body:  resp.Body,
+			Total: aws.Int64Value(resp.ContentLength),
+			ETag:  aws.StringValue(resp.ETag),
+		}
+		if resp.ContentRange != nil {
+			var start int64
+			var err error
+			if start, response.Total, err = parseContentRange(*resp.ContentRange); err != nil || start != rangeReq.Start {
+				resp.Body.Close()
+				return nil, errRestart
+			}
+			response.Partial = true
+		}
+		return response, nil
 	}
-	return
 }
 
 // FileCopy copies the content from reader to destinationPath file
@@ -312,19 +326,22 @@ func Download(log log.T, input DownloadInput) (output DownloadOutput, err error)
 		urlHash := sha1.Sum([]byte(fileURL.String()))
 		output.LocalFilePath = filepath.Join(destinationDir, fmt.Sprintf("%x", urlHash))
 
+		config := loadTransferConfig(log)
 		amazonS3URL := s3util.ParseAmazonS3URL(log, fileURL)
 		if amazonS3URL.IsBucketAndKeyPresent() {
-			// source is s3
+			// source is s3, a failed s3 download keeps what it downloaded so far for the fallback to resume
 			var tempOutput DownloadOutput
-			tempOutput, err = s3Download(log, amazonS3URL, output.LocalFilePath)
+			s3Config := config
+			s3Config.keepOnFailure = true
+			tempOutput, err = s3Download(log, amazonS3URL, input.SourceURL, output.LocalFilePath, s3Config)
 			// if s3 download fails, attempt http/https download as fallback
 			if err != nil {
-				tempOutput, err = httpDownload(log, input.SourceURL, output.LocalFilePath)
+				tempOutput, err = httpDownload(log, input.SourceURL, output.LocalFilePath, config)
 			}
 			output = tempOutput
 		} else {
 			// simple http/https download
-			output, err = httpDownload(log, input.SourceURL, output.LocalFilePath)
+			output, err = httpDownload(log, input.SourceURL, output.LocalFilePath, config)
 		}
 
 		if err != nil {
@@ -0,0 +1,85 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package artifact contains utilities for working downloading files.
+package artifact
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// maxThrottledRead limits how much a throttled reader reads at once so the bandwidth is shared evenly
+const maxThrottledRead = 32 * 1024
+
+// bandwidth is the limiter shared by all downloads of the process, other processes have their own limiter
+var bandwidth = &bandwidthLimiter{}
+
+// bandwidthLimiter is a token bucket that limits the combined throughput of all readers using it
+type bandwidthLimiter struct {
+	mutex          sync.Mutex
+	bytesPerSecond int64
+	available      float64
+	last           time.Time
+}
+
+// setLimit changes the limit in bytes per second, 0 means unlimited
+func (l *bandwidthLimiter) setLimit(bytesPerSecond int64) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.bytesPerSecond != bytesPerSecond {
+		l.bytesPerSecond = bytesPerSecond
+		l.available = 0
+		l.last = time.Now()
+	}
+}
+
+// take consumes n bytes from the bucket and returns how long the caller has to wait before using them
+func (l *bandwidthLimiter) take(n int) time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.bytesPerSecond <= 0 {
+		return 0
+	}
+	now := time.Now()
+	rate := float64(l.bytesPerSecond)
+	l.available += now.Sub(l.last).Seconds() * rate
+	if l.available > rate {
+		// allow bursts of at most one second
+		l.available = rate
+	}
+	l.last = now
+	l.available -= float64(n)
+	if l.available >= 0 {
+		return 0
+	}
+	return time.Duration(-l.available / rate * float64(time.Second))
+}
+
+// throttledReader reads from a reader no faster than its limiter allows
+type throttledReader struct {
+	reader  io.Reader
+	limiter *bandwidthLimiter
+}
+
+func (r *throttledReader) Read(p []byte) (n int, err error) {
+	if len(p) > maxThrottledRead {
+		p = p[:maxThrottledRead]
+	}
+	n, err = r.reader.Read(p)
+	if delay := r.limiter.take(n); delay > 0 {
+		time.Sleep(delay)
+	}
+	return
+}
@@ -0,0 +1,438 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package artifact contains utilities for working downloading files.
+package artifact
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	// partialSuffix is appended to the destination file for the content downloaded so far
+	partialSuffix = ".partial"
+	// partialStateSuffix is appended to the destination file for the bookkeeping of a partial download
+	partialStateSuffix = ".partial.state"
+
+	copyBufferSize = 32 * 1024
+)
+
+var (
+	errNotModified         = errors.New("content not modified")
+	errRangeNotSatisfiable = errors.New("requested range not satisfiable")
+	// errRestart means the content changed or the server ignored the requested range, the download starts over
+	errRestart = errors.New("content changed or range not supported, restarting download")
+)
+
+// permanentError is a download failure that resuming the download does not fix
+type permanentError struct {
+	error
+}
+
+// transferConfig controls how a file is transferred
+type transferConfig struct {
+	parallelChunks int
+	chunkSize      int64
+	resumeAttempts int
+	retryDelay     time.Duration
+	// keepOnFailure keeps the downloaded file and the partial download when the download fails permanently,
+	// e.g. because the content is requested from another source next
+	keepOnFailure bool
+}
+
+// loadTransferConfig reads the download configuration and applies its bandwidth limit to all downloads of the process.
+// The limit is not shared with other processes, e.g. the document workers of the agent each have their own limit.
+func loadTransferConfig(log log.T) transferConfig {
+	config := appconfig.DefaultConfig().Download
+	if appConfig, err := appconfig.Config(false); err != nil {
+		log.Debugf("failed to read appconfig, using default download configuration. %v", err)
+	} else {
+		config = appConfig.Download
+	}
+	bandwidth.setLimit(int64(config.BandwidthLimitKBps) * 1024)
+	return transferConfig{
+		parallelChunks: config.ParallelChunks,
+		chunkSize:      int64(config.ChunkSizeMB) * 1024 * 1024,
+		resumeAttempts: config.ResumeAttempts,
+		retryDelay:     time.Second,
+	}
+}
+
+// rangeRequest describes the part of the content requested from the source
+type rangeRequest struct {
+	Start         int64
+	End           int64  // inclusive, -1 for the rest of the content
+	AcceptPartial bool   // request a range even if it covers the whole content to find out if ranges are supported
+	IfRange       string // only return the range if the content still matches this validator
+	IfNoneMatch   string // etag of the complete file downloaded earlier
+}
+
+// rangeResponse is the content returned by the source
+type rangeResponse struct {
+	Body         io.ReadCloser
+	Partial      bool  // Body starts at the requested offset
+	Total        int64 // size of the complete content, -1 if unknown
+	ETag         string
+	LastModified string
+}
+
+// rangeFetcher requests (a range of) the content from the source
+type rangeFetcher func(request rangeRequest) (*rangeResponse, error)
+
+// downloadChunk is a range of the content and how much of it has been written to the partial file
+type downloadChunk struct {
+	Start    int64 `json:"start"`
+	End      int64 `json:"end"` // inclusive, -1 if the size of the content is unknown
+	Written  int64 `json:"written"`
+	Complete bool  `json:"complete"`
+}
+
+// partialDownload is the bookkeeping of a download that allows it to be resumed
+type partialDownload struct {
+	URL          string          `json:"url"`
+	ETag         string          `json:"etag"`
+	LastModified string          `json:"lastmodified"`
+	Size         int64           `json:"size"`
+	Chunks       []downloadChunk `json:"chunks"`
+}
+
+// validator returns the value that identifies the content being downloaded
+func (p *partialDownload) validator() string {
+	if p.ETag != "" {
+		return p.ETag
+	}
+	return p.LastModified
+}
+
+// transfer downloads content from a source into a file, resuming interrupted downloads with ranged requests
+type transfer struct {
+	log      log.T
+	url      string // identifies the artifact, whichever source its content is fetched from
+	destFile string
+	fetch    rangeFetcher
+	config   transferConfig
+	mutex    sync.Mutex // guards the Written and Complete fields of the chunks while they are downloaded
+	state    partialDownload
+}
+
+// downloadWithResume downloads the content of the artifact at url into destFile.  Interrupted downloads are resumed from
+// where they stopped, within the call up to the configured number of attempts and across calls using the partial state
+// file.  The url identifies the artifact, so a download started from one source can be resumed from another source of
+// the same artifact, e.g. an S3 object fetched with the aws sdk or with http/s.
+func downloadWithResume(log log.T, url string, destFile string, fetch rangeFetcher, config transferConfig) (output DownloadOutput, err error) {
+	t := &transfer{log: log, url: url, destFile: destFile, fetch: fetch, config: config}
+	eTagFile := destFile + ".etag"
+
+	ifNoneMatch := ""
+	if !t.loadState() && fileutil.Exists(destFile) && fileutil.Exists(eTagFile) {
+		ifNoneMatch, _ = fileutil.ReadAllText(eTagFile)
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err = t.attempt(ifNoneMatch); err == nil {
+			break
+		}
+		if err == errNotModified {
+			log.Debugf("Unchanged file.")
+			t.discardState()
+			output.IsUpdated = false
+			output.LocalFilePath = destFile
+			return output, nil
+		}
+		if _, permanent := err.(permanentError); permanent {
+			if t.config.keepOnFailure {
+				t.saveState()
+				return output, err
+			}
+			t.discardState()
+			fileutil.DeleteFile(destFile)
+			fileutil.DeleteFile(eTagFile)
+			return output, err
+		}
+		if err == errRestart {
+			t.state = partialDownload{}
+		}
+		if attempt >= t.config.resumeAttempts {
+			t.saveState()
+			return output, err
+		}
+		t.saveState()
+		ifNoneMatch = ""
+		log.Infof("download of %v interrupted, resuming (attempt %v of %v): %v", url, attempt+1, t.config.resumeAttempts, err)
+		time.Sleep(t.config.retryDelay * time.Duration(attempt+1))
+	}
+
+	if err = t.complete(); err != nil {
+		log.Errorf("failed to write destFile %v, %v ", destFile, err)
+		return output, err
+	}
+	if t.state.ETag != "" {
+		log.Debug("file eTagValue is ", t.state.ETag)
+		if err = fileutil.WriteAllText(eTagFile, t.state.ETag); err != nil {
+			log.Errorf("failed to write eTagfile %v, %v ", eTagFile, err)
+			return output, err
+		}
+	}
+	output.LocalFilePath = destFile
+	output.IsUpdated = true
+	return output, nil
+}
+
+// attempt downloads all chunks that are not complete yet, starting the download if nothing has been downloaded
+func (t *transfer) attempt(ifNoneMatch string) (err error) {
+	var file *os.File
+	if file, err = os.OpenFile(t.destFile+partialSuffix, os.O_CREATE|os.O_WRONLY, appconfig.ReadWriteAccess); err != nil {
+		return permanentError{err}
+	}
+	defer func() {
+		file.Sync()
+		file.Close()
+	}()
+
+	if len(t.state.Chunks) == 0 {
+		if err = t.start(file, ifNoneMatch); err != nil {
+			return err
+		}
+	}
+	return t.downloadChunks(file)
+}
+
+// start requests the content, splits it into chunks if the source supports ranges and downloads the first chunk
+func (t *transfer) start(file *os.File, ifNoneMatch string) error {
+	parallel := t.config.parallelChunks > 1
+	response, err := t.fetch(rangeRequest{Start: 0, End: -1, AcceptPartial: parallel, IfNoneMatch: ifNoneMatch})
+	if err == errRangeNotSatisfiable {
+		// the range of an empty file can not be satisfied
+		t.state = partialDownload{URL: t.url, Chunks: []downloadChunk{{Start: 0, End: -1, Complete: true}}}
+		return file.Truncate(0)
+	}
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	t.state = partialDownload{URL: t.url, ETag: response.ETag, LastModified: response.LastModified, Size: response.Total}
+	if err = file.Truncate(0); err != nil {
+		return permanentError{err}
+	}
+	if parallel && response.Partial && response.Total >= 2*t.config.chunkSize {
+		for start := int64(0); start < response.Total; start += t.config.chunkSize {
+			end := start + t.config.chunkSize - 1
+			if end >= response.Total {
+				end = response.Total - 1
+			}
+			t.state.Chunks = append(t.state.Chunks, downloadChunk{Start: start, End: end})
+		}
+		t.log.Debugf("downloading %v bytes in %v chunks", response.Total, len(t.state.Chunks))
+	} else if response.Total >= 0 {
+		t.state.Chunks = []downloadChunk{{Start: 0, End: response.Total - 1}}
+	} else {
+		t.state.Chunks = []downloadChunk{{Start: 0, End: -1}}
+	}
+	return t.copyChunk(file, &t.state.Chunks[0], response.Body)
+}
+
+// downloadChunks downloads the remaining content of all chunks that are not complete, up to parallelChunks at a time
+func (t *transfer) downloadChunks(file *os.File) error {
+	pending := make(chan *downloadChunk, len(t.state.Chunks))
+	for i := range t.state.Chunks {
+		if !t.state.Chunks[i].Complete {
+			pending <- &t.state.Chunks[i]
+		}
+	}
+	close(pending)
+
+	workers := t.config.parallelChunks
+	if workers < 1 {
+		workers = 1
+	}
+	errs := make(chan error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range pending {
+				if err := t.downloadChunk(file, chunk); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	// a permanent failure or restart takes precedence over a network error of another chunk
+	var result error
+	for err := range errs {
+		if _, permanent := err.(permanentError); permanent || err == errRestart || result == nil {
+			result = err
+		}
+	}
+	return result
+}
+
+// downloadChunk requests the remaining range of a chunk and writes it to the partial file
+func (t *transfer) downloadChunk(file *os.File, chunk *downloadChunk) error {
+	t.mutex.Lock()
+	offset := chunk.Start + chunk.Written
+	t.mutex.Unlock()
+
+	response, err := t.fetch(rangeRequest{Start: offset, End: chunk.End, IfRange: t.state.validator()})
+	if err == errRangeNotSatisfiable {
+		return errRestart
+	}
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	// a complete response can only be used if it is the whole content and nothing was downloaded yet
+	if !response.Partial && (offset != 0 || len(t.state.Chunks) > 1) {
+		return errRestart
+	}
+	return t.copyChunk(file, chunk, response.Body)
+}
+
+// copyChunk writes the body to the partial file at the current offset of the chunk until the chunk is complete
+func (t *transfer) copyChunk(file *os.File, chunk *downloadChunk, body io.Reader) error {
+	var reader io.Reader = &throttledReader{reader: body, limiter: bandwidth}
+	if chunk.End >= 0 {
+		reader = io.LimitReader(reader, chunk.End-chunk.Start+1-chunk.Written)
+	}
+
+	buffer := make([]byte, copyBufferSize)
+	for {
+		n, readErr := reader.Read(buffer)
+		if n > 0 {
+			if _, err := file.WriteAt(buffer[:n], chunk.Start+chunk.Written); err != nil {
+				return permanentError{err}
+			}
+			t.mutex.Lock()
+			chunk.Written += int64(n)
+			t.mutex.Unlock()
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+
+	if chunk.End >= 0 && chunk.Start+chunk.Written <= chunk.End {
+		return io.ErrUnexpectedEOF
+	}
+	t.mutex.Lock()
+	chunk.Complete = true
+	t.mutex.Unlock()
+	return nil
+}
+
+// complete moves the downloaded content to the destination file and removes the bookkeeping
+func (t *transfer) complete() error {
+	var written int64
+	for _, chunk := range t.state.Chunks {
+		written += chunk.Written
+	}
+	fileutil.DeleteFile(t.destFile)
+	if err := os.Rename(t.destFile+partialSuffix, t.destFile); err != nil {
+		return err
+	}
+	fileutil.DeleteFile(t.destFile + partialStateSuffix)
+	t.log.Infof("%s with %v bytes downloaded", t.destFile, written)
+	return nil
+}
+
+// loadState loads the bookkeeping of an earlier interrupted download of the same url
+// It returns false and removes any partial download if there is nothing to resume
+func (t *transfer) loadState() bool {
+	var state partialDownload
+	content, err := fileutil.ReadAllText(t.destFile + partialStateSuffix)
+	if err == nil && fileutil.Exists(t.destFile+partialSuffix) && jsonutil.Unmarshal(content, &state) == nil &&
+		state.URL == t.url && state.validator() != "" && len(state.Chunks) > 0 {
+		t.log.Debugf("resuming download of %v", t.url)
+		t.state = state
+		return true
+	}
+	t.discardState()
+	return false
+}
+
+// saveState persists the bookkeeping so a later download can resume, content without a validator can not be resumed
+func (t *transfer) saveState() {
+	if t.state.validator() == "" || len(t.state.Chunks) == 0 {
+		t.discardState()
+		return
+	}
+	t.mutex.Lock()
+	content, err := jsonutil.Marshal(t.state)
+	t.mutex.Unlock()
+	if err == nil {
+		err = fileutil.WriteAllText(t.destFile+partialStateSuffix, content)
+	}
+	if err != nil {
+		t.log.Debugf("failed to save partial download state of %v, %v", t.destFile, err)
+	}
+}
+
+// discardState removes the partial content and its bookkeeping
+func (t *transfer) discardState() {
+	fileutil.DeleteFile(t.destFile + partialSuffix)
+	fileutil.DeleteFile(t.destFile + partialStateSuffix)
+}
+
+// formatRange builds the value of an http Range header
+func formatRange(start int64, end int64) string {
+	if end < 0 {
+		return fmt.Sprintf("bytes=%d-", start)
+	}
+	return fmt.Sprintf("bytes=%d-%d", start, end)
+}
+
+// parseContentRange returns the first byte and the total size from a Content-Range header, total is -1 if unknown
+func parseContentRange(contentRange string) (start int64, total int64, err error) {
+	// bytes 0-499/1234 or bytes 0-499/*
+	var rangeSpec, size string
+	if !strings.HasPrefix(contentRange, "bytes ") {
+		return 0, 0, fmt.Errorf("invalid content range %v", contentRange)
+	}
+	parts := strings.SplitN(strings.TrimPrefix(contentRange, "bytes "), "/", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid content range %v", contentRange)
+	}
+	rangeSpec, size = parts[0], parts[1]
+	if start, err = strconv.ParseInt(strings.SplitN(rangeSpec, "-", 2)[0], 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid content range %v", contentRange)
+	}
+	total = -1
+	if size != "*" {
+		if total, err = strconv.ParseInt(size, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid content range %v", contentRange)
+		}
+	}
+	return start, total, nil
+}
@@ -0,0 +1,265 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package artifact
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+var transferLog = log.NewMockLog()
+
+func testContent(size int) []byte {
+	content := make([]byte, size)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	return content
+}
+
+func testTransferConfig(parallelChunks int, chunkSize int64, resumeAttempts int) transferConfig {
+	return transferConfig{parallelChunks: parallelChunks, chunkSize: chunkSize, resumeAttempts: resumeAttempts, retryDelay: time.Millisecond}
+}
+
+func setupTransferDir(t *testing.T) (destFile string, cleanup func()) {
+	dir, err := ioutil.TempDir("", "artifacttransfer")
+	assert.Nil(t, err)
+	return filepath.Join(dir, "download"), func() { os.RemoveAll(dir) }
+}
+
+// rangeServer serves content with range and etag support and counts the range requests
+func rangeServer(content []byte, etag string) (server *httptest.Server, rangeRequests *int) {
+	var mutex sync.Mutex
+	count := 0
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			mutex.Lock()
+			count++
+			mutex.Unlock()
+		}
+		w.Header().Set("Etag", etag)
+		http.ServeContent(w, r, "content", time.Time{}, bytes.NewReader(content))
+	}))
+	return server, &count
+}
+
+// failingReader returns an error after a number of bytes were read
+type failingReader struct {
+	reader    io.Reader
+	remaining int
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		return 0, errors.New("connection reset")
+	}
+	if len(p) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err := r.reader.Read(p)
+	r.remaining -= n
+	return n, err
+}
+
+// fakeSource serves ranges of content from memory and interrupts the first response after failAfter bytes
+type fakeSource struct {
+	content   []byte
+	etag      string
+	failAfter int
+	requests  []rangeRequest
+}
+
+func (s *fakeSource) fetch(request rangeRequest) (*rangeResponse, error) {
+	s.requests = append(s.requests, request)
+	response := &rangeResponse{Total: int64(len(s.content)), ETag: s.etag}
+	body := s.content
+	if (request.Start > 0 || request.End >= 0) && (request.IfRange == "" || request.IfRange == s.etag) {
+		end := int64(len(s.content)) - 1
+		if request.End >= 0 {
+			end = request.End
+		}
+		body = s.content[request.Start : end+1]
+		response.Partial = true
+	}
+	var reader io.Reader = bytes.NewReader(body)
+	if s.failAfter > 0 {
+		reader = &failingReader{reader: reader, remaining: s.failAfter}
+		s.failAfter = 0
+	}
+	response.Body = ioutil.NopCloser(reader)
+	return response, nil
+}
+
+func TestHttpDownload(t *testing.T) {
+	destFile, cleanup := setupTransferDir(t)
+	defer cleanup()
+	content := testContent(10000)
+	server, _ := rangeServer(content, `"v1"`)
+	defer server.Close()
+
+	output, err := httpDownload(transferLog, server.URL, destFile, testTransferConfig(1, 1024, 0))
+	assert.Nil(t, err)
+	assert.True(t, output.IsUpdated)
+	downloaded, _ := ioutil.ReadFile(destFile)
+	assert.Equal(t, content, downloaded)
+	etag, _ := ioutil.ReadFile(destFile + ".etag")
+	assert.Equal(t, `"v1"`, string(etag))
+	_, err = os.Stat(destFile + partialSuffix)
+	assert.True(t, os.IsNotExist(err))
+
+	// the etag of the complete file is used to skip unchanged content
+	output, err = httpDownload(transferLog, server.URL, destFile, testTransferConfig(1, 1024, 0))
+	assert.Nil(t, err)
+	assert.False(t, output.IsUpdated)
+}
+
+func TestHttpDownloadParallelChunks(t *testing.T) {
+	destFile, cleanup := setupTransferDir(t)
+	defer cleanup()
+	content := testContent(10000)
+	server, rangeRequests := rangeServer(content, `"v1"`)
+	defer server.Close()
+
+	output, err := httpDownload(transferLog, server.URL, destFile, testTransferConfig(4, 1024, 0))
+	assert.Nil(t, err)
+	assert.True(t, output.IsUpdated)
+	downloaded, _ := ioutil.ReadFile(destFile)
+	assert.Equal(t, content, downloaded)
+	assert.Equal(t, 10, *rangeRequests)
+}
+
+func TestHttpDownloadNotFound(t *testing.T) {
+	destFile, cleanup := setupTransferDir(t)
+	defer cleanup()
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	_, err := httpDownload(transferLog, server.URL, destFile, testTransferConfig(1, 1024, 3))
+	assert.NotNil(t, err)
+	assert.True(t, strings.Contains(err.Error(), "statuscode:404"))
+}
+
+func TestDownloadResumesWithinCall(t *testing.T) {
+	destFile, cleanup := setupTransferDir(t)
+	defer cleanup()
+	source := &fakeSource{content: testContent(10000), etag: "v1", failAfter: 4000}
+
+	output, err := downloadWithResume(transferLog, "url", destFile, source.fetch, testTransferConfig(1, 1024, 1))
+	assert.Nil(t, err)
+	assert.True(t, output.IsUpdated)
+	downloaded, _ := ioutil.ReadFile(destFile)
+	assert.Equal(t, source.content, downloaded)
+	assert.Equal(t, 2, len(source.requests))
+	assert.Equal(t, int64(4000), source.requests[1].Start)
+	assert.Equal(t, "v1", source.requests[1].IfRange)
+}
+
+func TestDownloadResumesAcrossCalls(t *testing.T) {
+	destFile, cleanup := setupTransferDir(t)
+	defer cleanup()
+	source := &fakeSource{content: testContent(10000), etag: "v1", failAfter: 6000}
+
+	_, err := downloadWithResume(transferLog, "url", destFile, source.fetch, testTransferConfig(1, 1024, 0))
+	assert.NotNil(t, err)
+	_, err = os.Stat(destFile + partialStateSuffix)
+	assert.Nil(t, err)
+
+	_, err = downloadWithResume(transferLog, "url", destFile, source.fetch, testTransferConfig(1, 1024, 0))
+	assert.Nil(t, err)
+	downloaded, _ := ioutil.ReadFile(destFile)
+	assert.Equal(t, source.content, downloaded)
+	assert.Equal(t, int64(6000), source.requests[1].Start)
+	_, err = os.Stat(destFile + partialStateSuffix)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestDownloadRestartsWhenContentChanged(t *testing.T) {
+	destFile, cleanup := setupTransferDir(t)
+	defer cleanup()
+	source := &fakeSource{content: testContent(10000), etag: "v1", failAfter: 6000}
+
+	_, err := downloadWithResume(transferLog, "url", destFile, source.fetch, testTransferConfig(1, 1024, 0))
+	assert.NotNil(t, err)
+
+	source.content = testContent(5000)
+	source.etag = "v2"
+	_, err = downloadWithResume(transferLog, "url", destFile, source.fetch, testTransferConfig(1, 1024, 1))
+	assert.Nil(t, err)
+	downloaded, _ := ioutil.ReadFile(destFile)
+	assert.Equal(t, source.content, downloaded)
+	assert.Equal(t, int64(0), source.requests[len(source.requests)-1].Start)
+}
+
+func TestBandwidthLimiter(t *testing.T) {
+	limiter := &bandwidthLimiter{}
+	assert.Equal(t, time.Duration(0), limiter.take(1000000))
+
+	limiter.setLimit(1000)
+	delay := limiter.take(500)
+	assert.True(t, delay > 400*time.Millisecond && delay <= 500*time.Millisecond)
+	delay = limiter.take(500)
+	assert.True(t, delay > 900*time.Millisecond && delay <= time.Second)
+}
+
+func TestParseContentRange(t *testing.T) {
+	start, total, err := parseContentRange("bytes 100-199/1000")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(100), start)
+	assert.Equal(t, int64(1000), total)
+
+	_, total, err = parseContentRange("bytes 0-99/*")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(-1), total)
+
+	_, _, err = parseContentRange("items 0-99/100")
+	assert.NotNil(t, err)
+}
+
+func TestDownloadResumesFromFallbackSource(t *testing.T) {
+	destFile, cleanup := setupTransferDir(t)
+	defer cleanup()
+	source := &fakeSource{content: testContent(10000), etag: "v1", failAfter: 6000}
+	denied := func(request rangeRequest) (*rangeResponse, error) {
+		return nil, permanentError{errors.New("access denied")}
+	}
+
+	// the first source is interrupted and then denies access, what it downloaded is kept for the fallback
+	keepConfig := testTransferConfig(1, 1024, 0)
+	keepConfig.keepOnFailure = true
+	_, err := downloadWithResume(transferLog, "url", destFile, source.fetch, keepConfig)
+	assert.NotNil(t, err)
+	_, err = downloadWithResume(transferLog, "url", destFile, denied, keepConfig)
+	assert.NotNil(t, err)
+	_, err = os.Stat(destFile + partialStateSuffix)
+	assert.Nil(t, err)
+
+	_, err = downloadWithResume(transferLog, "url", destFile, source.fetch, testTransferConfig(1, 1024, 0))
+	assert.Nil(t, err)
+	downloaded, _ := ioutil.ReadFile(destFile)
+	assert.Equal(t, source.content, downloaded)
+	assert.Equal(t, int64(6000), source.requests[len(source.requests)-1].Start)
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/health"
 	"github.com/aws/amazon-ssm-agent/agent/longrunning/manager"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage"
 	"github.com/aws/amazon-ssm-agent/agent/runcommand"
 	"github.com/aws/amazon-ssm-agent/agent/startup"
 )
@@ -49,6 +50,7 @@ func loadCoreModules(context context.T) {
 	}
 
 	registeredCoreModules = append(registeredCoreModules, startup.NewProcessor(context))
+	registeredCoreModules = append(registeredCoreModules, configurepackage.NewRepositoryGarbageCollector(context))
 
 	// registering the long running plugin manager as a core module
 	manager.EnsureInitialization(context)
@@ -54,6 +54,7 @@ func run(context context.T,
 	//document information summary
 	messageID := docState.DocumentInformation.MessageID
 	associationID := docState.DocumentInformation.AssociationID
+	runID := docState.DocumentInformation.RunID
 	nPlugins := len(docState.InstancePluginsInformation)
 	documentName := docState.DocumentInformation.DocumentName
 	documentVersion := docState.DocumentInformation.DocumentVersion
@@ -79,6 +80,7 @@ func run(context context.T,
 				PluginResults:   results,
 				LastPlugin:      res.PluginID,
 				AssociationID:   associationID,
+				RunID:           runID,
 				MessageID:       messageID,
 				NPlugins:        nPlugins,
 				DocumentName:    documentName,
@@ -103,6 +105,7 @@ func run(context context.T,
 		LastPlugin:      "",
 		MessageID:       messageID,
 		AssociationID:   associationID,
+		RunID:           runID,
 		NPlugins:        nPlugins,
 		DocumentName:    documentName,
 		DocumentVersion: documentVersion,
@@ -128,6 +128,7 @@ func (e *OutOfProcExecuter) generateUnexpectedFailResult(errMsg string) contract
 	var docResult contracts.DocumentResult
 	docResult.MessageID = e.docState.DocumentInformation.MessageID
 	docResult.AssociationID = e.docState.DocumentInformation.AssociationID
+	docResult.RunID = e.docState.DocumentInformation.RunID
 	docResult.DocumentName = e.docState.DocumentInformation.DocumentName
 	docResult.NPlugins = len(e.docState.InstancePluginsInformation)
 	docResult.DocumentVersion = e.docState.DocumentInformation.DocumentVersion
@@ -103,6 +103,7 @@ func (p *ExecuterBackend) formatDocResult(docResult *contracts.DocumentResult) {
 	//fill doc level information that the sub-process wouldn't know
 	docResult.MessageID = p.docState.DocumentInformation.MessageID
 	docResult.AssociationID = p.docState.DocumentInformation.AssociationID
+	docResult.RunID = p.docState.DocumentInformation.RunID
 	docResult.DocumentName = p.docState.DocumentInformation.DocumentName
 	docResult.NPlugins = len(p.docState.InstancePluginsInformation)
 	docResult.DocumentVersion = p.docState.DocumentInformation.DocumentVersion
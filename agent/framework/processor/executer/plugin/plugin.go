@@ -24,6 +24,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurecontainers"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/dockercontainer"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/filestate"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/lrpminvoker"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
@@ -139,5 +140,14 @@ func loadPlatformIndependentPlugins(context context.T) runpluginutil.PluginRegis
 		workerPlugins[configurePackagePluginName] = configurePackagePlugin
 	}
 
+	// registering aws:fileState
+	fileStatePluginName := filestate.Name()
+	fileStatePlugin, err := filestate.NewPlugin(pluginutil.DefaultPluginConfig())
+	if err != nil {
+		log.Errorf("failed to create plugin %s %v", fileStatePluginName, err)
+	} else {
+		workerPlugins[fileStatePluginName] = fileStatePlugin
+	}
+
 	return workerPlugins
 }
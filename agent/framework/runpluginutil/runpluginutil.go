@@ -24,6 +24,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/platform"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 )
 
@@ -59,10 +60,19 @@ var allPlugins = map[string]struct{}{
 	appconfig.PluginNameConfigureDocker:        {},
 	appconfig.PluginNameDockerContainer:        {},
 	appconfig.PluginNameDomainJoin:             {},
+	appconfig.PluginNameAwsFileState:           {},
 	appconfig.PluginEC2ConfigUpdate:            {},
 	appconfig.PluginNameRefreshAssociation:     {},
 }
 
+// auditPlugins is the list of plugins that can report the changes they would apply without applying them,
+// the other plugins are skipped when a document runs in audit mode
+var auditPlugins = map[string]struct{}{
+	appconfig.PluginNameAwsConfigurePackage:  {},
+	appconfig.PluginNameAwsSoftwareInventory: {},
+	appconfig.PluginNameAwsFileState:         {},
+}
+
 // Assign method to global variables to allow unittest to override
 var isSupportedPlugin = IsPluginSupportedForCurrentPlatform
 
@@ -140,6 +150,9 @@ func RunPlugins(
 			pluginHandlerFound,
 			configuration.IsPreconditionEnabled,
 			configuration.Preconditions)
+		if operation == executeStep && configuration.AuditOnly {
+			operation, logMessage = getAuditStepExecutionOperation(pluginName, pluginID)
+		}
 
 		switch operation {
 		case executeStep:
@@ -151,6 +164,14 @@ func RunPlugins(
 			pluginOutputs[pluginID].Output = r.Output
 			pluginOutputs[pluginID].StandardOutput = r.StandardOutput
 			pluginOutputs[pluginID].StandardError = r.StandardError
+			pluginOutputs[pluginID].Audited = r.Audited
+			pluginOutputs[pluginID].Drift = r.Drift
+			pluginOutputs[pluginID].ComplianceItems = r.ComplianceItems
+			if configuration.OrchestrationDirectory != "" {
+				pluginOutputs[pluginID].ComplianceItems = append(
+					pluginOutputs[pluginID].ComplianceItems,
+					pluginutil.ReadComplianceItems(context.Log(), configuration.OrchestrationDirectory)...)
+			}
 
 		case skipStep:
 			context.Log().Info(logMessage)
@@ -293,6 +314,17 @@ func getStepExecutionOperation(
 	}
 }
 
+// getAuditStepExecutionOperation returns if a step of a document running in audit mode should be executed or skipped
+func getAuditStepExecutionOperation(pluginName string, pluginID string) (string, string) {
+	if _, isAuditSupported := auditPlugins[pluginName]; isAuditSupported {
+		return executeStep, ""
+	}
+	return skipStep, fmt.Sprintf(
+		"Step execution skipped, plugin %s does not support audit mode. Step name: %s",
+		pluginName,
+		pluginID)
+}
+
 // Evaluate precondition and return precondition result and unrecognized preconditions (if any)
 func evaluatePreconditions(
 	log log.T,
@@ -19,6 +19,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/log"
@@ -1406,3 +1407,43 @@ func TestRunPluginsWithUnknownPlugin(t *testing.T) {
 
 	assert.Equal(t, pluginResults, outputs)
 }
+
+// TestRunPluginsInAuditMode tests that only the plugins supporting audit mode run when a document audits
+func TestRunPluginsInAuditMode(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	auditPlugin := appconfig.PluginNameAwsFileState
+	pluginNames := []string{auditPlugin, testPlugin1}
+	pluginRegistry := PluginRegistry{}
+	pluginStates := make([]contracts.PluginState, len(pluginNames))
+	pluginInstances := make(map[string]*PluginMock)
+
+	var cancelFlag task.CancelFlag = task.NewChanneledCancelFlag()
+	ctx := context.NewMockDefault()
+	drift := []contracts.DriftItem{{ID: "/etc/motd", Title: "create file /etc/motd"}}
+
+	for index, name := range pluginNames {
+		pluginInstances[name] = new(PluginMock)
+		pluginRegistry[name] = pluginInstances[name]
+		pluginStates[index] = contracts.PluginState{
+			Name:          name,
+			Id:            name,
+			Configuration: contracts.Configuration{PluginID: name, AuditOnly: true},
+		}
+	}
+	pluginInstances[auditPlugin].On("Execute", ctx, pluginStates[0].Configuration, cancelFlag).Return(contracts.PluginResult{
+		Status:  contracts.ResultStatusSuccess,
+		Audited: true,
+		Drift:   drift,
+	})
+
+	ch := make(chan contracts.PluginResult, len(pluginNames))
+	outputs := RunPlugins(ctx, pluginStates, pluginRegistry, ch, cancelFlag)
+
+	pluginInstances[auditPlugin].AssertExpectations(t)
+	pluginInstances[testPlugin1].AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+	assert.Equal(t, contracts.ResultStatusSuccess, outputs[auditPlugin].Status)
+	assert.True(t, outputs[auditPlugin].Audited)
+	assert.Equal(t, drift, outputs[auditPlugin].Drift)
+	assert.Equal(t, contracts.ResultStatusSkipped, outputs[testPlugin1].Status)
+}
@@ -352,7 +352,7 @@ func (p *Plugin) runPowerShell(log logger.T, workingDirectory string, cancelFlag
 
 	//execute the command
 	stdout, stderr, exitCode, errs := p.CommandExecuter.Execute(log, workingDirectory, stdoutFilePath,
-		stderrFilePath, cancelFlag, executionTimeout, commandName, commandArguments)
+		stderrFilePath, cancelFlag, executionTimeout, commandName, commandArguments, nil)
 
 	stdOutBuf := new(bytes.Buffer)
 	stdOutBuf.ReadFrom(stdout)
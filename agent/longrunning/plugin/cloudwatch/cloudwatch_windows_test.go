@@ -55,7 +55,8 @@ func TestStartSuccess(t *testing.T) {
 		mock.Anything,
 		mock.AnythingOfType("int"),
 		mock.AnythingOfType("string"),
-		mock.AnythingOfType("[]string")).Return(stdout, stderr, 0, []error{})
+		mock.AnythingOfType("[]string"),
+		mock.Anything).Return(stdout, stderr, 0, []error{})
 
 	execMock.On("StartExe", mock.Anything,
 		mock.AnythingOfType("string"),
@@ -64,7 +65,8 @@ func TestStartSuccess(t *testing.T) {
 		mock.Anything,
 		mock.AnythingOfType("int"),
 		mock.AnythingOfType("string"),
-		mock.AnythingOfType("[]string")).Return(stdout, stderr, 0, []error{})
+		mock.AnythingOfType("[]string"),
+		mock.Anything).Return(stdout, stderr, 0, []error{})
 
 	fileExist = func(filePath string) bool {
 		return true
@@ -139,7 +141,8 @@ func TestStopFail(t *testing.T) {
 		mock.Anything,
 		mock.AnythingOfType("int"),
 		mock.AnythingOfType("string"),
-		mock.AnythingOfType("[]string")).Return(stdout, stderr, 0, []error{})
+		mock.AnythingOfType("[]string"),
+		mock.Anything).Return(stdout, stderr, 0, []error{})
 
 	var execVar = executers.MockCommandExecuter{*execMock}
 	waitExe = execVar.Execute
@@ -166,7 +169,8 @@ func TestIsCloudWatchExeRunningTrue(t *testing.T) {
 		mock.Anything,
 		mock.AnythingOfType("int"),
 		mock.AnythingOfType("string"),
-		mock.AnythingOfType("[]string")).Return(stdout, stderr, 0, []error{})
+		mock.AnythingOfType("[]string"),
+		mock.Anything).Return(stdout, stderr, 0, []error{})
 
 	fileExist = func(filePath string) bool {
 		return true
@@ -204,7 +208,8 @@ func TestIsCloudWatchExeRunningFalse(t *testing.T) {
 		mock.Anything,
 		mock.AnythingOfType("int"),
 		mock.AnythingOfType("string"),
-		mock.AnythingOfType("[]string")).Return(stdout, stderr, 0, []error{})
+		mock.AnythingOfType("[]string"),
+		mock.Anything).Return(stdout, stderr, 0, []error{})
 
 	fileExist = func(filePath string) bool {
 		return true
@@ -238,7 +243,8 @@ func TestGetPidOfCloudWatchExeNotProcess(t *testing.T) {
 		mock.Anything,
 		mock.AnythingOfType("int"),
 		mock.AnythingOfType("string"),
-		mock.AnythingOfType("[]string")).Return(stdout, stderr, 0, []error{})
+		mock.AnythingOfType("[]string"),
+		mock.Anything).Return(stdout, stderr, 0, []error{})
 
 	var execVar = executers.MockCommandExecuter{*execMock}
 	startExe = execVar.StartExe
@@ -273,7 +279,8 @@ func TestGetPidOfCloudWatchExeSuccess(t *testing.T) {
 		mock.Anything,
 		mock.AnythingOfType("int"),
 		mock.AnythingOfType("string"),
-		mock.AnythingOfType("[]string")).Return(stdout, stderr, 0, []error{})
+		mock.AnythingOfType("[]string"),
+		mock.Anything).Return(stdout, stderr, 0, []error{})
 
 	var execVar = executers.MockCommandExecuter{*execMock}
 	startExe = execVar.StartExe
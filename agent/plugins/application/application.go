@@ -199,7 +199,7 @@ func (p *Plugin) runCommands(log log.T, pluginID string, pluginInput Application
 	}
 
 	// Execute Command
-	_, _, exitCode, errs := p.CommandExecuter.Execute(log, defaultWorkingDirectory, stdoutFilePath, stderrFilePath, cancelFlag, defaultApplicationExecutionTimeoutInSeconds, commandName, commandArguments)
+	_, _, exitCode, errs := p.CommandExecuter.Execute(log, defaultWorkingDirectory, stdoutFilePath, stderrFilePath, cancelFlag, defaultApplicationExecutionTimeoutInSeconds, commandName, commandArguments, nil)
 
 	// Set output status
 	out.ExitCode = exitCode
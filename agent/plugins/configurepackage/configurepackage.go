@@ -31,7 +31,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/localpackages"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/packageservice"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/ssms3"
-	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/trace"
+	"github.com/aws/amazon-ssm-agent/agent/trace"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 )
@@ -316,7 +316,7 @@ func checkAlreadyInstalled(
 		}
 		if (targetVersion == installedVersion &&
 			(installState == localpackages.Installed || installState == localpackages.Unknown)) ||
-			installState == localpackages.Installing {
+			installState == localpackages.Installing || installState == localpackages.Updating {
 			instToCheck = inst
 		}
 		if instToCheck != nil {
@@ -326,7 +326,7 @@ func checkAlreadyInstalled(
 			validateTrace.WithExitcode(int64(validateOutput.GetExitCode()))
 
 			if validateOutput.GetStatus() == contracts.ResultStatusSuccess {
-				if installState == localpackages.Installing {
+				if installState == localpackages.Installing || installState == localpackages.Updating {
 					validateTrace.AppendInfof("Successfully installed %v %v", packageName, targetVersion)
 					if uninst != nil {
 						cleanupAfterUninstall(tracer, repository, uninst, output)
@@ -383,7 +383,12 @@ func (p *Plugin) execute(context context.T, config contracts.Configuration, canc
 	log.Info("RunCommand started with configuration ", config)
 
 	tracer := trace.NewTracer(log)
-	defer tracer.BeginSection("configurePackage").End()
+	traceAttributes := map[string]string{"plugin": Name(), "pluginid": config.PluginID}
+	rootTrace := tracer.BeginSection("configurePackage")
+	defer func() {
+		rootTrace.End()
+		trace.ExportTraces(log, tracer, trace.ConfiguredExporters(context.AppConfig().Trace, config.OrchestrationDirectory), traceAttributes)
+	}()
 
 	res.StartDateTime = time.Now()
 	defer func() {
@@ -405,48 +410,62 @@ func (p *Plugin) execute(context context.T, config contracts.Configuration, canc
 		out.MarkAsFailed(nil, nil)
 	} else {
 		defer unlockPackage(input.Name)
+		traceAttributes["package"] = input.Name
+		traceAttributes["action"] = input.Action
+		// garbage collection of the repository waits for the package actions in every process
+		if unlockRepository, err := localpackages.LockRepository(false); err != nil {
+			log.Warnf("Package repository garbage collection is not excluded during the action, %v", err)
+		} else {
+			defer unlockRepository()
+		}
 
 		packageService := p.packageServiceSelector(tracer, input.Repository, p.localRepository)
 
-		log.Debugf("Prepare for %v %v %v", input.Action, input.Name, input.Version)
-		inst, uninst, installState, installedVersion := prepareConfigurePackage(
-			tracer,
-			config,
-			p.localRepository,
-			packageService,
-			input,
-			&out)
-		log.Debugf("HasInst %v, HasUninst %v, InstallState %v, InstalledVersion %v", inst != nil, uninst != nil, installState, installedVersion)
-		// if already failed or already installed and valid, do not execute install
-		if out.GetStatus() != contracts.ResultStatusFailed && !checkAlreadyInstalled(tracer, context, p.localRepository, installedVersion, installState, inst, uninst, &out) {
-			log.Debugf("Calling execute, current status %v", out.GetStatus())
-			executeConfigurePackage(
+		if config.AuditOnly {
+			log.Debugf("Audit %v %v %v", input.Action, input.Name, input.Version)
+			res.Audited = true
+			res.Drift = auditConfigurePackage(tracer, p.localRepository, packageService, input, &out)
+		} else {
+			log.Debugf("Prepare for %v %v %v", input.Action, input.Name, input.Version)
+			inst, uninst, installState, installedVersion := prepareConfigurePackage(
 				tracer,
-				context,
+				config,
 				p.localRepository,
-				inst,
-				uninst,
-				installState,
+				packageService,
+				input,
 				&out)
-			if !out.GetStatus().IsReboot() {
-				version := input.Version
-				if input.Action == InstallAction {
-					version = inst.Version()
-				} else if input.Action == UninstallAction {
-					version = uninst.Version()
-				}
+			log.Debugf("HasInst %v, HasUninst %v, InstallState %v, InstalledVersion %v", inst != nil, uninst != nil, installState, installedVersion)
+			// if already failed or already installed and valid, do not execute install
+			if out.GetStatus() != contracts.ResultStatusFailed && !checkAlreadyInstalled(tracer, context, p.localRepository, installedVersion, installState, inst, uninst, &out) {
+				log.Debugf("Calling execute, current status %v", out.GetStatus())
+				executeConfigurePackage(
+					tracer,
+					context,
+					p.localRepository,
+					inst,
+					uninst,
+					installState,
+					&out)
+				if !out.GetStatus().IsReboot() {
+					version := input.Version
+					if input.Action == InstallAction {
+						version = inst.Version()
+					} else if input.Action == UninstallAction {
+						version = uninst.Version()
+					}
 
-				err := packageService.ReportResult(tracer, packageservice.PackageResult{
-					Exitcode:               int64(out.GetExitCode()),
-					Operation:              input.Action,
-					PackageName:            input.Name,
-					PreviousPackageVersion: installedVersion,
-					Timing:                 res.StartDateTime.UnixNano(),
-					Version:                version,
-					Trace:                  packageservice.ConvertToPackageServiceTrace(tracer.Traces()),
-				})
-				if err != nil {
-					out.AppendErrorf(log, "Error reporting results: %v", err.Error())
+					err := packageService.ReportResult(tracer, packageservice.PackageResult{
+						Exitcode:               int64(out.GetExitCode()),
+						Operation:              input.Action,
+						PackageName:            input.Name,
+						PreviousPackageVersion: installedVersion,
+						Timing:                 res.StartDateTime.UnixNano(),
+						Version:                version,
+						Trace:                  packageservice.ConvertToPackageServiceTrace(tracer.Traces()),
+					})
+					if err != nil {
+						out.AppendErrorf(log, "Error reporting results: %v", err.Error())
+					}
 				}
 			}
 		}
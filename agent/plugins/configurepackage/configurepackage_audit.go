@@ -0,0 +1,97 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package configurepackage implements the ConfigurePackage plugin.
+package configurepackage
+
+import (
+	"fmt"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/localpackages"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/packageservice"
+	"github.com/aws/amazon-ssm-agent/agent/trace"
+)
+
+// auditConfigurePackage returns the install or uninstall the action would perform, without downloading the package
+// or running any of its scripts
+func auditConfigurePackage(
+	tracer trace.Tracer,
+	repository localpackages.Repository,
+	packageService packageservice.PackageService,
+	input *ConfigurePackagePluginInput,
+	output contracts.PluginOutputter) (drift []contracts.DriftItem) {
+
+	auditTrace := tracer.BeginSection(fmt.Sprintf("audit %s", input.Action))
+	defer auditTrace.End()
+
+	switch input.Action {
+	case InstallAction:
+		version, installedVersion, installState, err := getVersionToInstall(tracer, repository, packageService, input)
+		if err != nil {
+			auditTrace.WithError(err)
+			output.MarkAsFailed(nil, nil)
+			return nil
+		}
+		if installedVersion == version && (installState == localpackages.Installed || installState == localpackages.Unknown) {
+			auditTrace.AppendInfof("%v %v is installed", input.Name, version)
+			break
+		}
+
+		title := fmt.Sprintf("install %v %v", input.Name, version)
+		if installedVersion != "" && installedVersion != version {
+			title = fmt.Sprintf("update %v from %v to %v", input.Name, installedVersion, version)
+		}
+		auditTrace.AppendInfof("Would %v", title)
+		drift = append(drift, newPackageDriftItem(input, title, version, installedVersion))
+
+	case UninstallAction:
+		version, _, err := getVersionToUninstall(tracer, repository, input)
+		if err != nil {
+			auditTrace.WithError(err)
+			output.MarkAsFailed(nil, nil)
+			return nil
+		}
+		if version == "" {
+			auditTrace.AppendInfof("%v is not installed", input.Name)
+			break
+		}
+
+		title := fmt.Sprintf("uninstall %v %v", input.Name, version)
+		auditTrace.AppendInfof("Would %v", title)
+		drift = append(drift, newPackageDriftItem(input, title, "", version))
+
+	default:
+		auditTrace.AppendErrorf("unsupported action: %v", input.Action)
+		output.MarkAsFailed(nil, nil)
+		return nil
+	}
+
+	output.MarkAsSucceeded()
+	return drift
+}
+
+// newPackageDriftItem returns the drift item of a package the action would change
+func newPackageDriftItem(input *ConfigurePackagePluginInput, title string, version string, installedVersion string) contracts.DriftItem {
+	return contracts.DriftItem{
+		ID:    fmt.Sprintf("%v:%v", Name(), input.Name),
+		Title: title,
+		Details: map[string]string{
+			"PackageName":      input.Name,
+			"Action":           input.Action,
+			"Version":          version,
+			"InstalledVersion": installedVersion,
+		},
+	}
+}
@@ -22,7 +22,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/installer"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/localpackages"
-	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/trace"
+	"github.com/aws/amazon-ssm-agent/agent/trace"
 )
 
 // TODO: consider passing in the timeout and cancel channels - does cancel trigger rollback?
@@ -47,12 +47,25 @@ func executeConfigurePackage(
 		executeInstall(tracer, context, repository, uninst, inst, true, output)
 	case localpackages.RollbackUninstall:
 		executeUninstall(tracer, context, repository, uninst, inst, true, output)
-	default:
+	case localpackages.Updating:
+		// This is an update that rebooted before it could be validated
+		executeUpdate(tracer, context, repository, inst, uninst, output)
+	case localpackages.Uninstalling, localpackages.Upgrading:
+		// An uninstall that rebooted is finished the same way it was started, even if the new version can update in place.
+		// Without the version to uninstall, e.g. a reinstall of the same version, only the install is left to run.
 		if uninst != nil {
 			executeUninstall(tracer, context, repository, inst, uninst, false, output)
 		} else {
 			executeInstall(tracer, context, repository, inst, uninst, false, output)
 		}
+	default:
+		if inst != nil && uninst != nil && inst.HasUpdateAction() {
+			executeUpdate(tracer, context, repository, inst, uninst, output)
+		} else if uninst != nil {
+			executeUninstall(tracer, context, repository, inst, uninst, false, output)
+		} else {
+			executeInstall(tracer, context, repository, inst, uninst, false, output)
+		}
 	}
 }
 
@@ -127,6 +140,47 @@ func executeInstall(
 	return
 }
 
+// executeUpdate upgrades the installed version in place using the update action of the new version
+// If the update fails, the new version is uninstalled and the previous version is reinstalled
+func executeUpdate(
+	tracer trace.Tracer,
+	context context.T,
+	repository localpackages.Repository,
+	inst installer.Installer,
+	uninst installer.Installer,
+	output contracts.PluginOutputter) {
+
+	updatetrace := tracer.BeginSection(fmt.Sprintf("update %s/%s to %s", uninst.PackageName(), uninst.Version(), inst.Version()))
+	defer updatetrace.End()
+
+	setNewInstallState(tracer, repository, inst, localpackages.Updating)
+
+	result := inst.Update(tracer, context, uninst.Version())
+
+	updatetrace.WithExitcode(int64(result.GetExitCode()))
+
+	if result.GetStatus() == contracts.ResultStatusSuccess {
+		validatetrace := tracer.BeginSection(fmt.Sprintf("validate %s/%s", inst.PackageName(), inst.Version()))
+		result = inst.Validate(tracer, context)
+		validatetrace.WithExitcode(int64(result.GetExitCode()))
+	}
+	if result.GetStatus().IsReboot() {
+		tracer.BeginSection(fmt.Sprintf("Rebooting to finish update of %v to %v", inst.PackageName(), inst.Version()))
+		output.MarkAsSuccessWithReboot()
+		return
+	}
+	if !result.GetStatus().IsSuccess() {
+		updatetrace.AppendErrorf("Failed to update package; update status %v", result.GetStatus())
+		// Execute rollback
+		executeUninstall(tracer, context, repository, uninst, inst, true, output)
+		return
+	}
+	cleanupAfterUninstall(tracer, repository, uninst, output)
+	updatetrace.AppendInfof("Successfully updated %v %v to %v", inst.PackageName(), uninst.Version(), inst.Version())
+	setNewInstallState(tracer, repository, inst, localpackages.Installed)
+	output.MarkAsSucceeded()
+}
+
 // executeUninstall performs uninstall of a package
 func executeUninstall(
 	tracer trace.Tracer,
@@ -17,10 +17,12 @@ package configurepackage
 import (
 	"testing"
 
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/localpackages"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/localpackages/mock"
-	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/trace"
+	"github.com/aws/amazon-ssm-agent/agent/trace"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -42,6 +44,7 @@ func TestInstallNew(t *testing.T) {
 func TestUpgrade(t *testing.T) {
 	uninstallerMock := uninstallerSuccessMock("SsmTest", "0.0.1")
 	installerMock := installerSuccessMock("SsmTest", "0.0.2")
+	installerMock.On("HasUpdateAction").Return(false)
 	repoMock := &repository_mock.MockedRepository{}
 	repoMock.On("SetInstallState", mock.Anything, "SsmTest", "0.0.1", localpackages.Upgrading).Return(nil)
 	repoMock.On("SetInstallState", mock.Anything, "SsmTest", "0.0.2", localpackages.Installing).Return(nil)
@@ -61,6 +64,7 @@ func TestUpgrade(t *testing.T) {
 func TestUpgradeFailedUninstall(t *testing.T) {
 	uninstallerMock := uninstallerFailedMock("SsmTest", "0.0.1")
 	installerMock := installerSuccessMock("SsmTest", "0.0.2")
+	installerMock.On("HasUpdateAction").Return(false)
 	repoMock := &repository_mock.MockedRepository{}
 	repoMock.On("SetInstallState", mock.Anything, "SsmTest", "0.0.1", localpackages.Upgrading).Return(nil)
 	repoMock.On("SetInstallState", mock.Anything, "SsmTest", "0.0.2", localpackages.Installing).Return(nil)
@@ -141,6 +145,7 @@ func TestUninstall_Failed(t *testing.T) {
 func TestRollback(t *testing.T) {
 	uninstallerMock := uninstallerSuccessWithRollbackMock("SsmTest", "0.0.1")
 	installerMock := installerFailedWithRollbackMock("SsmTest", "0.0.2")
+	installerMock.On("HasUpdateAction").Return(false)
 	repoMock := &repository_mock.MockedRepository{}
 	repoMock.On("SetInstallState", mock.Anything, "SsmTest", "0.0.1", localpackages.Upgrading).Return(nil)
 	repoMock.On("SetInstallState", mock.Anything, "SsmTest", "0.0.2", localpackages.Installing).Return(nil)
@@ -162,6 +167,7 @@ func TestRollback(t *testing.T) {
 func TestRollbackFailed(t *testing.T) {
 	uninstallerMock := uninstallerSuccessWithFailedRollbackMock("SsmTest", "0.0.1")
 	installerMock := installerFailedWithRollbackMock("SsmTest", "0.0.2")
+	installerMock.On("HasUpdateAction").Return(false)
 	repoMock := &repository_mock.MockedRepository{}
 	repoMock.On("SetInstallState", mock.Anything, "SsmTest", "0.0.1", localpackages.Upgrading).Return(nil)
 	repoMock.On("SetInstallState", mock.Anything, "SsmTest", "0.0.2", localpackages.Installing).Return(nil)
@@ -257,6 +263,21 @@ func TestUpgradeAfterUninstallReboot(t *testing.T) {
 	repoMock.AssertExpectations(t)
 }
 
+func TestReinstallAfterUninstallReboot(t *testing.T) {
+	installerMock := installerSuccessMock("SsmTest", "0.0.1")
+	repoMock := &repository_mock.MockedRepository{}
+	repoMock.On("SetInstallState", mock.Anything, "SsmTest", "0.0.1", localpackages.Installing).Return(nil)
+	repoMock.On("SetInstallState", mock.Anything, "SsmTest", "0.0.1", localpackages.Installed).Return(nil)
+	tracer := trace.NewTracer(log.NewMockLog())
+	tracer.BeginSection("test segment root")
+	output := &trace.PluginOutputTrace{Tracer: tracer}
+
+	executeConfigurePackage(tracer, contextMock, repoMock, installerMock, nil, localpackages.Upgrading, output)
+
+	installerMock.AssertExpectations(t)
+	repoMock.AssertExpectations(t)
+}
+
 func TestUpgradeAfterInstallReboot(t *testing.T) {
 	uninstallerMock := installerNameVersionOnlyMock("SsmTest", "0.0.1")
 	installerMock := installerSuccessMock("SsmTest", "0.0.2")
@@ -311,3 +332,62 @@ func TestRollbackAfterInstallReboot(t *testing.T) {
 	uninstallerMock.AssertExpectations(t)
 	repoMock.AssertExpectations(t)
 }
+
+func TestUpdate(t *testing.T) {
+	uninstallerMock := installerNameVersionOnlyMock("SsmTest", "0.0.1")
+	installerMock := installerUpdateMock("SsmTest", "0.0.2", contracts.ResultStatusSuccess)
+	installerMock.On("Validate", mock.Anything).Return(pluginOutputWithStatus(contracts.ResultStatusSuccess)).Once()
+	repoMock := &repository_mock.MockedRepository{}
+	repoMock.On("SetInstallState", mock.Anything, "SsmTest", "0.0.2", localpackages.Updating).Return(nil)
+	repoMock.On("SetInstallState", mock.Anything, "SsmTest", "0.0.2", localpackages.Installed).Return(nil)
+	repoMock.On("RemovePackage", mock.Anything, "SsmTest", "0.0.1").Return(nil)
+	tracer := trace.NewTracer(log.NewMockLog())
+	tracer.BeginSection("test segment root")
+	output := &trace.PluginOutputTrace{Tracer: tracer}
+
+	executeConfigurePackage(tracer, contextMock, repoMock, installerMock, uninstallerMock, localpackages.Installed, output)
+
+	installerMock.AssertExpectations(t)
+	repoMock.AssertExpectations(t)
+	assert.Equal(t, contracts.ResultStatusSuccess, output.GetStatus())
+}
+
+func TestUpdateFailedRollback(t *testing.T) {
+	uninstallerMock := installerNameVersionOnlyMock("SsmTest", "0.0.1")
+	uninstallerMock.On("Install", mock.Anything).Return(pluginOutputWithStatus(contracts.ResultStatusSuccess)).Once()
+	uninstallerMock.On("Validate", mock.Anything).Return(pluginOutputWithStatus(contracts.ResultStatusSuccess)).Once()
+	installerMock := installerUpdateMock("SsmTest", "0.0.2", contracts.ResultStatusFailed)
+	installerMock.On("Uninstall", mock.Anything).Return(pluginOutputWithStatus(contracts.ResultStatusSuccess)).Once()
+	repoMock := &repository_mock.MockedRepository{}
+	repoMock.On("SetInstallState", mock.Anything, "SsmTest", "0.0.2", localpackages.Updating).Return(nil)
+	repoMock.On("SetInstallState", mock.Anything, "SsmTest", "0.0.2", localpackages.RollbackUninstall).Return(nil)
+	repoMock.On("SetInstallState", mock.Anything, "SsmTest", "0.0.1", localpackages.RollbackInstall).Return(nil)
+	repoMock.On("SetInstallState", mock.Anything, "SsmTest", "0.0.1", localpackages.Installed).Return(nil)
+	repoMock.On("RemovePackage", mock.Anything, "SsmTest", "0.0.2").Return(nil)
+	tracer := trace.NewTracer(log.NewMockLog())
+	tracer.BeginSection("test segment root")
+	output := &trace.PluginOutputTrace{Tracer: tracer}
+
+	executeConfigurePackage(tracer, contextMock, repoMock, installerMock, uninstallerMock, localpackages.Installed, output)
+
+	installerMock.AssertExpectations(t)
+	uninstallerMock.AssertExpectations(t)
+	repoMock.AssertExpectations(t)
+	assert.Equal(t, contracts.ResultStatusFailed, output.GetStatus())
+}
+
+func TestUpdateReboot(t *testing.T) {
+	uninstallerMock := installerNameVersionOnlyMock("SsmTest", "0.0.1")
+	installerMock := installerUpdateMock("SsmTest", "0.0.2", contracts.ResultStatusSuccessAndReboot)
+	repoMock := &repository_mock.MockedRepository{}
+	repoMock.On("SetInstallState", mock.Anything, "SsmTest", "0.0.2", localpackages.Updating).Return(nil)
+	tracer := trace.NewTracer(log.NewMockLog())
+	tracer.BeginSection("test segment root")
+	output := &trace.PluginOutputTrace{Tracer: tracer}
+
+	executeConfigurePackage(tracer, contextMock, repoMock, installerMock, uninstallerMock, localpackages.Installed, output)
+
+	installerMock.AssertExpectations(t)
+	repoMock.AssertExpectations(t)
+	assert.Equal(t, contracts.ResultStatusSuccessAndReboot, output.GetStatus())
+}
@@ -0,0 +1,88 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package configurepackage implements the ConfigurePackage plugin.
+package configurepackage
+
+import (
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/localpackages"
+	"github.com/aws/amazon-ssm-agent/agent/trace"
+	"github.com/carlescere/scheduler"
+)
+
+const repositoryGarbageCollectorName = "PackageRepositoryGarbageCollector"
+
+// RepositoryGarbageCollector is the core module that periodically prunes the local package repository
+type RepositoryGarbageCollector struct {
+	context    context.T
+	repository localpackages.Repository
+	job        *scheduler.Job
+}
+
+// NewRepositoryGarbageCollector creates a new package repository garbage collector core module.
+func NewRepositoryGarbageCollector(context context.T) *RepositoryGarbageCollector {
+	return &RepositoryGarbageCollector{
+		context:    context.With("[" + repositoryGarbageCollectorName + "]"),
+		repository: localpackages.NewRepository(),
+	}
+}
+
+// collect runs one garbage collection of the repository while no package action is in progress
+func (g *RepositoryGarbageCollector) collect() {
+	log := g.context.Log()
+	policy := localpackages.NewRetentionPolicy(g.context.AppConfig().Packages)
+
+	unlockRepository, err := localpackages.LockRepository(true)
+	if err != nil {
+		log.Errorf("package repository garbage collection skipped: %v", err)
+		return
+	}
+	defer unlockRepository()
+
+	tracer := trace.NewTracer(log)
+	result, err := g.repository.CollectGarbage(tracer, policy)
+	if err != nil {
+		log.Errorf("package repository garbage collection failed: %v", err)
+		return
+	}
+	log.Infof("package repository garbage collection removed %v versions, reclaimed %v bytes, repository size %v bytes",
+		len(result.Removed), result.ReclaimedBytes, result.RepositoryBytes)
+}
+
+// ICoreModule implementation
+
+// ModuleName returns the module name
+func (g *RepositoryGarbageCollector) ModuleName() string {
+	return repositoryGarbageCollectorName
+}
+
+// ModuleExecute schedules the recurrent garbage collection of the package repository
+func (g *RepositoryGarbageCollector) ModuleExecute(context context.T) (err error) {
+	frequencyHours := g.context.AppConfig().Packages.GarbageCollectionFrequencyHours
+	if g.job, err = scheduler.Every(frequencyHours).Hours().Run(g.collect); err != nil {
+		g.context.Log().Errorf("unable to schedule package repository garbage collection. %v", err)
+	}
+	return
+}
+
+// ModuleRequestStop stops the garbage collection job
+func (g *RepositoryGarbageCollector) ModuleRequestStop(stopType contracts.StopType) (err error) {
+	if g.job != nil {
+		g.context.Log().Info("stopping package repository garbage collection job.")
+		g.job.Quit <- true
+	}
+	return nil
+}
@@ -23,7 +23,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/platform"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/localpackages"
-	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/trace"
+	"github.com/aws/amazon-ssm-agent/agent/trace"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -417,6 +417,49 @@ func TestConfigurePackage_InvalidAction(t *testing.T) {
 	assert.Contains(t, result.Output, "unsupported action")
 }
 
+func TestExecuteInAuditModeReportsInstallWithoutInstalling(t *testing.T) {
+	pluginInformation := createStubPluginInputInstall()
+	installerMock := installerNotCalledMock()
+	repoMock := repoInstallMock(pluginInformation, installerMock)
+	serviceMock := serviceSuccessMock()
+
+	plugin := &Plugin{
+		localRepository:        repoMock,
+		packageServiceSelector: selectMockService(serviceMock),
+	}
+	config := buildConfigSimple(pluginInformation)
+	config.AuditOnly = true
+	result := plugin.execute(contextMock, config, createMockCancelFlag())
+
+	assert.Equal(t, 0, result.Code)
+	assert.True(t, result.Audited)
+	assert.Equal(t, 1, len(result.Drift))
+	assert.Equal(t, "install PVDriver 0.0.1", result.Drift[0].Title)
+	repoMock.AssertNotCalled(t, "ValidatePackage", mock.Anything, mock.Anything, mock.Anything)
+	repoMock.AssertNotCalled(t, "SetInstallState", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	serviceMock.AssertNotCalled(t, "ReportResult", mock.Anything, mock.Anything)
+	installerMock.AssertExpectations(t)
+}
+
+func TestExecuteInAuditModeReportsNoDriftWhenInstalled(t *testing.T) {
+	pluginInformation := createStubPluginInputInstall()
+	installerMock := installerNotCalledMock()
+	repoMock := repoUninstallMock(pluginInformation, installerMock)
+
+	plugin := &Plugin{
+		localRepository:        repoMock,
+		packageServiceSelector: selectMockService(serviceSuccessMock()),
+	}
+	config := buildConfigSimple(pluginInformation)
+	config.AuditOnly = true
+	result := plugin.execute(contextMock, config, createMockCancelFlag())
+
+	assert.Equal(t, 0, result.Code)
+	assert.True(t, result.Audited)
+	assert.Empty(t, result.Drift)
+	installerMock.AssertExpectations(t)
+}
+
 type S3PrefixTestCase struct {
 	PluginID         string
 	OrchestrationDir string
@@ -27,7 +27,7 @@ import (
 	repoMock "github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/localpackages/mock"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/packageservice"
 	serviceMock "github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/packageservice/mock"
-	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/trace"
+	"github.com/aws/amazon-ssm-agent/agent/trace"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 	"github.com/stretchr/testify/mock"
 )
@@ -157,6 +157,15 @@ func uninstallerSuccessWithFailedRollbackMock(packageName string, version string
 	return &mockInst
 }
 
+func installerUpdateMock(packageName string, version string, status contracts.ResultStatus) *installerMock.Mock {
+	mockInst := installerMock.Mock{}
+	mockInst.On("HasUpdateAction").Return(true)
+	mockInst.On("Update", mock.Anything, "0.0.1").Return(pluginOutputWithStatus(status)).Once()
+	mockInst.On("PackageName").Return(packageName)
+	mockInst.On("Version").Return(version)
+	return &mockInst
+}
+
 func installerNameVersionOnlyMock(packageName string, version string) *installerMock.Mock {
 	mockInst := installerMock.Mock{}
 	mockInst.On("PackageName").Return(packageName)
@@ -18,7 +18,7 @@ package installer
 import (
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
-	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/trace"
+	"github.com/aws/amazon-ssm-agent/agent/trace"
 )
 
 // Installer is used to install, uninstall, or upgrade a package that exists in the local repository.
@@ -26,6 +26,8 @@ type Installer interface {
 	Install(tracer trace.Tracer, context context.T) contracts.PluginOutputter
 	Uninstall(tracer trace.Tracer, context context.T) contracts.PluginOutputter
 	Validate(tracer trace.Tracer, context context.T) contracts.PluginOutputter // TODO:MF consider whether we can remove validate in V1 - I think it depends on having truly idempotent installers for anything that reboots
+	Update(tracer trace.Tracer, context context.T, previousVersion string) contracts.PluginOutputter
+	HasUpdateAction() bool
 	PackageName() string
 	Version() string
 }
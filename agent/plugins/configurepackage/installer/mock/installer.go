@@ -18,7 +18,7 @@ package installer_mock
 import (
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
-	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/trace"
+	"github.com/aws/amazon-ssm-agent/agent/trace"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -41,6 +41,16 @@ func (inst *Mock) Validate(tracer trace.Tracer, context context.T) contracts.Plu
 	return args.Get(0).(contracts.PluginOutputter)
 }
 
+func (inst *Mock) Update(tracer trace.Tracer, context context.T, previousVersion string) contracts.PluginOutputter {
+	args := inst.Called(context, previousVersion)
+	return args.Get(0).(contracts.PluginOutputter)
+}
+
+func (inst *Mock) HasUpdateAction() bool {
+	args := inst.Called()
+	return args.Bool(0)
+}
+
 func (inst *Mock) Version() string {
 	args := inst.Called()
 	return args.String(0)
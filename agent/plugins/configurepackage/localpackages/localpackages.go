@@ -30,7 +30,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/envdetect"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/installer"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/ssminstaller"
-	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/trace"
+	"github.com/aws/amazon-ssm-agent/agent/trace"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
 )
 
@@ -53,8 +53,35 @@ const (
 	Installed         InstallState = iota // Successfully installed version of a package
 	RollbackUninstall InstallState = iota // Uninstalling as part of rollback
 	RollbackInstall   InstallState = iota // Installing as part of rollback
+	Updating          InstallState = iota // Updating the previous version in place using the new version's update action
 )
 
+var installStateNames = []string{
+	"None",
+	"Unknown",
+	"Failed",
+	"Uninstalling",
+	"Uninstalled",
+	"New",
+	"Upgrading",
+	"Installing",
+	"Installed",
+	"RollbackUninstall",
+	"RollbackInstall",
+	"Updating",
+}
+
+// String returns the name of the install state
+func (state InstallState) String() string {
+	if int(state) < len(installStateNames) {
+		return installStateNames[state]
+	}
+	return fmt.Sprintf("InstallState(%d)", uint(state))
+}
+
+// maxStateHistory is the number of state transitions kept in the installstate file of a package
+const maxStateHistory = 20
+
 // Repository represents local storage for packages managed by configurePackage
 // Different formats for different versions are managed within the Repository abstraction
 type Repository interface {
@@ -67,6 +94,10 @@ type Repository interface {
 	RemovePackage(tracer trace.Tracer, packageName string, version string) error
 	GetInventoryData(log log.T) []model.ApplicationData
 	GetInstaller(tracer trace.Tracer, configuration contracts.Configuration, packageName string, version string) installer.Installer
+	CollectGarbage(tracer trace.Tracer, policy RetentionPolicy) (GarbageCollectionResult, error)
+	ListPackages(tracer trace.Tracer) ([]PackageDescription, error)
+	DescribePackage(tracer trace.Tracer, packageName string) (PackageDescription, error)
+	RepairPackage(tracer trace.Tracer, packageName string, force bool) (PackageDescription, error)
 
 	ReadManifest(packageName string, packageVersion string) ([]byte, error)
 	WriteManifest(packageName string, packageVersion string, content []byte) error
@@ -89,6 +120,14 @@ type PackageInstallState struct {
 	Time                 time.Time    `json:"time"`
 	LastInstalledVersion string       `json:"lastinstalledversion"`
 	RetryCount           int          `json:"retrycount"`
+	History              []StateEntry `json:"history,omitempty"`
+}
+
+// StateEntry records a transition of a package to a new install state
+type StateEntry struct {
+	Version string       `json:"version"`
+	State   InstallState `json:"state"`
+	Time    time.Time    `json:"time"`
 }
 
 // PackageManifest represents json structure of package's online configuration file.
@@ -127,12 +166,7 @@ func (repo *localRepository) GetInstaller(tracer trace.Tracer,
 
 // GetInstalledVersion returns the version of the last successfully installed package
 func (repo *localRepository) GetInstalledVersion(tracer trace.Tracer, packageName string) string {
-	packageState := repo.loadInstallState(repo.filesysdep, tracer, packageName)
-	if packageState.State == Installed || (packageState.State == Unknown && packageState.LastInstalledVersion == "") {
-		return packageState.Version
-	} else {
-		return packageState.LastInstalledVersion
-	}
+	return repo.loadInstallState(repo.filesysdep, tracer, packageName).installedVersion()
 }
 
 // ValidatePackage returns an error if the given package version artifacts are missing, incomplete, or corrupt
@@ -191,6 +225,7 @@ func (repo *localRepository) SetInstallState(tracer trace.Tracer, packageName st
 	if state == Uninstalled {
 		packageState.LastInstalledVersion = ""
 	}
+	packageState.appendHistory()
 
 	var installStateContent string
 	var err error
@@ -314,12 +349,17 @@ func (repo *localRepository) getManifestPath(packageName string, version string,
 
 // loadInstallState loads the existing installstate file or returns an appropriate default state
 func (repo *localRepository) loadInstallState(filesysdep FileSysDep, tracer trace.Tracer, packageName string) *PackageInstallState {
+	return repo.loadInstallStateAt(filesysdep, tracer, packageName, repo.getPackageRoot(packageName))
+}
+
+// loadInstallStateAt loads the installstate file of the given package directory or returns an appropriate default state
+func (repo *localRepository) loadInstallStateAt(filesysdep FileSysDep, tracer trace.Tracer, packageName string, packageRoot string) *PackageInstallState {
 	packageState := PackageInstallState{Name: packageName, State: None}
 	var fileContent []byte
 	var err error
-	var filePath = repo.getInstallStatePath(packageName)
+	var filePath = filepath.Join(packageRoot, "installstate")
 	if !filesysdep.Exists(filePath) {
-		if dirs, err := filesysdep.GetDirectoryNames(repo.getPackageRoot(packageName)); err == nil && len(dirs) > 0 {
+		if dirs, err := filesysdep.GetDirectoryNames(packageRoot); err == nil && len(dirs) > 0 {
 			// For pre-repository packages, this will be the case, they should be updated and validated
 			return &PackageInstallState{Name: packageName, Version: dirs[len(dirs)-1], State: Unknown}
 		}
@@ -335,6 +375,22 @@ func (repo *localRepository) loadInstallState(filesysdep FileSysDep, tracer trac
 	return &packageState
 }
 
+// installedVersion returns the version of the last successful install of the package
+func (packageState *PackageInstallState) installedVersion() string {
+	if packageState.State == Installed || (packageState.State == Unknown && packageState.LastInstalledVersion == "") {
+		return packageState.Version
+	}
+	return packageState.LastInstalledVersion
+}
+
+// appendHistory records the current state of the package in its state history
+func (packageState *PackageInstallState) appendHistory() {
+	packageState.History = append(packageState.History, StateEntry{Version: packageState.Version, State: packageState.State, Time: packageState.Time})
+	if len(packageState.History) > maxStateHistory {
+		packageState.History = packageState.History[len(packageState.History)-maxStateHistory:]
+	}
+}
+
 // openPackageManifest returns the valid manifest or validation error for a given package version
 func (repo *localRepository) openPackageManifest(filesysdep FileSysDep, packageName string, version string) (manifest *PackageManifest, err error) {
 	manifestPath := repo.getManifestPath(packageName, version, packageName)
@@ -18,6 +18,8 @@ package localpackages
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 )
@@ -31,6 +33,8 @@ type FileSysDep interface {
 	RemoveAll(path string) error
 	ReadFile(filename string) ([]byte, error)
 	WriteFile(filename string, content string) error
+	GetModificationTime(path string) (time.Time, error)
+	GetDirectorySize(path string) (int64, error)
 }
 
 type fileSysDepImp struct{}
@@ -62,3 +66,20 @@ func (fileSysDepImp) ReadFile(filename string) ([]byte, error) {
 func (fileSysDepImp) WriteFile(filename string, content string) error {
 	return fileutil.WriteAllText(filename, content)
 }
+
+func (fileSysDepImp) GetModificationTime(path string) (time.Time, error) {
+	return fileutil.GetFileModificationTime(path)
+}
+
+func (fileSysDepImp) GetDirectorySize(path string) (size int64, err error) {
+	err = filepath.Walk(path, func(_ string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
@@ -0,0 +1,277 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package localpackages implements the local storage for packages managed by the ConfigurePackage plugin.
+package localpackages
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/trace"
+)
+
+// RetentionPolicy describes which package versions the repository keeps when it is garbage collected
+type RetentionPolicy struct {
+	RetainedPreviousVersions int           // versions kept per package in addition to the installed version
+	FailedRetention          time.Duration // Failed and Uninstalled packages older than this are removed
+	QuotaBytes               int64         // maximum size of the repository, 0 means unlimited
+	DryRun                   bool          // report what would be removed without removing anything
+}
+
+// RemovedVersion describes a package version removed (or selected for removal) by garbage collection
+type RemovedVersion struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Reason  string `json:"reason"`
+	Bytes   int64  `json:"bytes"`
+}
+
+// GarbageCollectionResult summarizes a garbage collection run of the repository
+type GarbageCollectionResult struct {
+	Removed         []RemovedVersion `json:"removed"`
+	ReclaimedBytes  int64            `json:"reclaimedbytes"`
+	RepositoryBytes int64            `json:"repositorybytes"`
+	DryRun          bool             `json:"dryrun"`
+}
+
+const (
+	reasonRetention = "exceeds retained previous versions"
+	reasonFailed    = "failed install older than retention duration"
+	reasonRemoved   = "uninstalled package older than retention duration"
+	reasonQuota     = "repository quota exceeded"
+
+	// minimumVersionAge protects versions that are being downloaded by another process from removal
+	minimumVersionAge = time.Hour
+)
+
+// versionEntry is a version directory of a package that is a candidate for removal
+type versionEntry struct {
+	packageName string
+	packageDir  string
+	versionDir  string
+	modTime     time.Time
+}
+
+// byModTime sorts version entries from least to most recently modified
+type byModTime []versionEntry
+
+func (a byModTime) Len() int           { return len(a) }
+func (a byModTime) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byModTime) Less(i, j int) bool { return a[i].modTime.Before(a[j].modTime) }
+
+// NewRetentionPolicy builds the retention policy for the repository from the agent configuration
+func NewRetentionPolicy(config appconfig.PackageCfg) RetentionPolicy {
+	return RetentionPolicy{
+		RetainedPreviousVersions: config.RetainedPreviousVersions,
+		FailedRetention:          time.Duration(config.FailedRetentionDurationHours) * time.Hour,
+		QuotaBytes:               int64(config.RepositoryQuotaMB) * 1024 * 1024,
+	}
+}
+
+// CollectGarbage removes package versions from the repository that are not needed according to the retention policy
+// The installed version of a package and packages in the middle of an install, uninstall or rollback are never removed
+func (repo *localRepository) CollectGarbage(tracer trace.Tracer, policy RetentionPolicy) (result GarbageCollectionResult, err error) {
+	gcTrace := tracer.BeginSection("collect repository garbage")
+	defer func() {
+		if err != nil {
+			gcTrace.WithError(err)
+		}
+		gcTrace.End()
+	}()
+
+	result = GarbageCollectionResult{Removed: make([]RemovedVersion, 0), DryRun: policy.DryRun}
+
+	var packageDirs []string
+	if packageDirs, err = repo.filesysdep.GetDirectoryNames(repo.repoRoot); err != nil {
+		return result, err
+	}
+
+	// versions that may still be removed to satisfy the quota, the installed version is never a candidate
+	quotaCandidates := make([]versionEntry, 0)
+	for _, packageDir := range packageDirs {
+		retained := repo.collectPackage(tracer, policy, packageDir, &result)
+		quotaCandidates = append(quotaCandidates, retained...)
+	}
+
+	if policy.QuotaBytes <= 0 {
+		result.RepositoryBytes, _ = repo.filesysdep.GetDirectorySize(repo.repoRoot)
+		return result, nil
+	}
+
+	var size int64
+	if size, err = repo.filesysdep.GetDirectorySize(repo.repoRoot); err != nil {
+		return result, err
+	}
+	if policy.DryRun {
+		size -= result.ReclaimedBytes
+	}
+
+	// remove least recently used versions across all packages until the repository fits the quota
+	sort.Sort(byModTime(quotaCandidates))
+	for _, entry := range quotaCandidates {
+		if size <= policy.QuotaBytes {
+			break
+		}
+		size -= repo.removeVersion(tracer, policy, entry, reasonQuota, &result)
+	}
+	if size > policy.QuotaBytes {
+		gcTrace.AppendInfof("repository size %v bytes exceeds quota of %v bytes with only installed versions remaining", size, policy.QuotaBytes)
+	}
+	result.RepositoryBytes = size
+
+	return result, nil
+}
+
+// collectPackage applies the retention policy to a single package and returns the retained versions
+// that are not required by the package's current state
+func (repo *localRepository) collectPackage(tracer trace.Tracer, policy RetentionPolicy, packageDir string, result *GarbageCollectionResult) []versionEntry {
+	retained := make([]versionEntry, 0)
+
+	packageState, err := repo.readInstallState(filepath.Join(repo.repoRoot, packageDir))
+	if err != nil {
+		// pre-repository packages and corrupt state files are left alone, the next install will repair them
+		tracer.CurrentTrace().AppendInfof("skipping %v: %v", packageDir, err)
+		return retained
+	}
+
+	protected := make(map[string]bool)
+	switch packageState.State {
+	case Installed:
+		protected[normalizeDirectory(packageState.Version)] = true
+	case Failed:
+		if packageState.LastInstalledVersion != "" {
+			protected[normalizeDirectory(packageState.LastInstalledVersion)] = true
+		}
+		if !isExpired(packageState, policy) {
+			protected[normalizeDirectory(packageState.Version)] = true
+		} else if packageState.LastInstalledVersion == "" {
+			repo.removePackageDirectory(tracer, policy, packageDir, packageState, reasonFailed, result)
+			return retained
+		}
+	case Uninstalled:
+		if isExpired(packageState, policy) {
+			repo.removePackageDirectory(tracer, policy, packageDir, packageState, reasonRemoved, result)
+			return retained
+		}
+	default:
+		// install, uninstall or rollback is in progress, all versions may still be needed
+		return retained
+	}
+
+	entries := repo.getVersionEntries(packageDir, packageState.Name)
+	// most recently used first
+	sort.Sort(sort.Reverse(byModTime(entries)))
+
+	kept := 0
+	for _, entry := range entries {
+		if protected[entry.versionDir] {
+			continue
+		}
+		if packageState.State == Failed && entry.versionDir == normalizeDirectory(packageState.Version) {
+			repo.removeVersion(tracer, policy, entry, reasonFailed, result)
+			continue
+		}
+		if kept < policy.RetainedPreviousVersions {
+			kept++
+			retained = append(retained, entry)
+			continue
+		}
+		repo.removeVersion(tracer, policy, entry, reasonRetention, result)
+	}
+	return retained
+}
+
+// getVersionEntries lists the version directories of a package with their last modification time
+// Versions modified within minimumVersionAge are omitted since they may still be in use
+func (repo *localRepository) getVersionEntries(packageDir string, packageName string) []versionEntry {
+	entries := make([]versionEntry, 0)
+	versionDirs, err := repo.filesysdep.GetDirectoryNames(filepath.Join(repo.repoRoot, packageDir))
+	if err != nil {
+		return entries
+	}
+	for _, versionDir := range versionDirs {
+		modTime, err := repo.filesysdep.GetModificationTime(filepath.Join(repo.repoRoot, packageDir, versionDir))
+		if err != nil || time.Since(modTime) < minimumVersionAge {
+			continue
+		}
+		entries = append(entries, versionEntry{packageName: packageName, packageDir: packageDir, versionDir: versionDir, modTime: modTime})
+	}
+	return entries
+}
+
+// removeVersion deletes a version directory and its cached manifest and returns the number of bytes reclaimed
+func (repo *localRepository) removeVersion(tracer trace.Tracer, policy RetentionPolicy, entry versionEntry, reason string, result *GarbageCollectionResult) int64 {
+	versionPath := filepath.Join(repo.repoRoot, entry.packageDir, entry.versionDir)
+	size, _ := repo.filesysdep.GetDirectorySize(versionPath)
+	if !policy.DryRun {
+		if err := repo.filesysdep.RemoveAll(versionPath); err != nil {
+			tracer.CurrentTrace().AppendErrorf("failed to remove %v %v: %v", entry.packageName, entry.versionDir, err)
+			return 0
+		}
+		repo.filesysdep.RemoveAll(repo.manifestCacheFilePath(entry.packageName, entry.versionDir))
+	}
+	tracer.CurrentTrace().AppendInfof("removed %v %v (%v)", entry.packageName, entry.versionDir, reason)
+	result.Removed = append(result.Removed, RemovedVersion{Name: entry.packageName, Version: entry.versionDir, Reason: reason, Bytes: size})
+	result.ReclaimedBytes += size
+	return size
+}
+
+// removePackageDirectory deletes all versions of a package and its install state once no version is left
+func (repo *localRepository) removePackageDirectory(tracer trace.Tracer, policy RetentionPolicy, packageDir string, packageState *PackageInstallState, reason string, result *GarbageCollectionResult) {
+	for _, entry := range repo.getVersionEntries(packageDir, packageState.Name) {
+		repo.removeVersion(tracer, policy, entry, reason, result)
+	}
+	if policy.DryRun {
+		return
+	}
+	packagePath := filepath.Join(repo.repoRoot, packageDir)
+	if versionDirs, err := repo.filesysdep.GetDirectoryNames(packagePath); err != nil || len(versionDirs) > 0 {
+		return
+	}
+	if err := repo.filesysdep.RemoveAll(packagePath); err != nil {
+		tracer.CurrentTrace().AppendErrorf("failed to remove %v: %v", packageState.Name, err)
+	}
+}
+
+// readInstallState reads the installstate file of a package directory without falling back to defaults
+func (repo *localRepository) readInstallState(packagePath string) (*PackageInstallState, error) {
+	var packageState PackageInstallState
+	filePath := filepath.Join(packagePath, "installstate")
+	if !repo.filesysdep.Exists(filePath) {
+		return nil, fmt.Errorf("no install state")
+	}
+	fileContent, err := repo.filesysdep.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if err = jsonutil.Unmarshal(string(fileContent[:]), &packageState); err != nil {
+		return nil, fmt.Errorf("install state is invalid: %v", err)
+	}
+	return &packageState, nil
+}
+
+// manifestCacheFilePath builds the manifest cache path for a package name and an already normalized version directory
+func (repo *localRepository) manifestCacheFilePath(packageName string, versionDir string) string {
+	return filepath.Join(repo.manifestCachePath, fmt.Sprintf("%s_%s.json", normalizeDirectory(packageName), versionDir))
+}
+
+// isExpired returns true if the package has been in its current state longer than the failed retention duration
+func isExpired(packageState *PackageInstallState, policy RetentionPolicy) bool {
+	return time.Since(packageState.Time) > policy.FailedRetention
+}
@@ -0,0 +1,174 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package localpackages implements the local storage for packages managed by the ConfigurePackage plugin.
+package localpackages
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type gcTestPackage struct {
+	state    PackageInstallState
+	versions []string // oldest first
+}
+
+func setupGCRepository(t *testing.T, packages []gcTestPackage) (repo *localRepository, cleanup func()) {
+	root, err := ioutil.TempDir("", "packagegc")
+	assert.Nil(t, err)
+	repo = &localRepository{
+		filesysdep:        &fileSysDepImp{},
+		repoRoot:          filepath.Join(root, "packages"),
+		manifestCachePath: filepath.Join(root, "manifests"),
+	}
+	assert.Nil(t, os.MkdirAll(repo.manifestCachePath, 0700))
+
+	for _, pkg := range packages {
+		for i, version := range pkg.versions {
+			versionPath := repo.getPackageVersionPath(pkg.state.Name, version)
+			assert.Nil(t, os.MkdirAll(versionPath, 0700))
+			assert.Nil(t, ioutil.WriteFile(filepath.Join(versionPath, "content.bin"), make([]byte, 1024), 0600))
+			assert.Nil(t, ioutil.WriteFile(repo.filePath(pkg.state.Name, version), []byte("{}"), 0600))
+			modTime := time.Now().Add(-time.Duration(100-i) * time.Hour)
+			assert.Nil(t, os.Chtimes(versionPath, modTime, modTime))
+		}
+		content, _ := jsonutil.Marshal(pkg.state)
+		assert.Nil(t, ioutil.WriteFile(repo.getInstallStatePath(pkg.state.Name), []byte(content), 0600))
+	}
+	return repo, func() { os.RemoveAll(root) }
+}
+
+func removedVersions(result GarbageCollectionResult) []string {
+	removed := make([]string, 0)
+	for _, entry := range result.Removed {
+		removed = append(removed, entry.Name+"/"+entry.Version)
+	}
+	return removed
+}
+
+func TestCollectGarbageRetainsPreviousVersions(t *testing.T) {
+	repo, cleanup := setupGCRepository(t, []gcTestPackage{
+		{state: PackageInstallState{Name: "Pkg", Version: "1.0.2", State: Installed, LastInstalledVersion: "1.0.2", Time: time.Now()},
+			versions: []string{"1.0.0", "1.0.1", "1.0.2", "1.0.3"}},
+	})
+	defer cleanup()
+
+	result, err := repo.CollectGarbage(tracerMock, RetentionPolicy{RetainedPreviousVersions: 1, FailedRetention: time.Hour})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Pkg/1.0.1", "Pkg/1.0.0"}, removedVersions(result))
+	assert.Equal(t, int64(2048), result.ReclaimedBytes)
+	assert.True(t, repo.filesysdep.Exists(repo.getPackageVersionPath("Pkg", "1.0.2")))
+	assert.True(t, repo.filesysdep.Exists(repo.getPackageVersionPath("Pkg", "1.0.3")))
+	assert.False(t, repo.filesysdep.Exists(repo.getPackageVersionPath("Pkg", "1.0.0")))
+	assert.False(t, repo.filesysdep.Exists(repo.filePath("Pkg", "1.0.0")))
+	assert.True(t, repo.filesysdep.Exists(repo.filePath("Pkg", "1.0.2")))
+}
+
+func TestCollectGarbageDryRun(t *testing.T) {
+	repo, cleanup := setupGCRepository(t, []gcTestPackage{
+		{state: PackageInstallState{Name: "Pkg", Version: "1.0.1", State: Installed, LastInstalledVersion: "1.0.1", Time: time.Now()},
+			versions: []string{"1.0.0", "1.0.1"}},
+	})
+	defer cleanup()
+
+	result, err := repo.CollectGarbage(tracerMock, RetentionPolicy{FailedRetention: time.Hour, DryRun: true})
+	assert.Nil(t, err)
+	assert.True(t, result.DryRun)
+	assert.Equal(t, []string{"Pkg/1.0.0"}, removedVersions(result))
+	assert.True(t, repo.filesysdep.Exists(repo.getPackageVersionPath("Pkg", "1.0.0")))
+}
+
+func TestCollectGarbageExpiredStates(t *testing.T) {
+	old := time.Now().Add(-48 * time.Hour)
+	repo, cleanup := setupGCRepository(t, []gcTestPackage{
+		{state: PackageInstallState{Name: "Removed", Version: "2.0", State: Uninstalled, Time: old},
+			versions: []string{"1.0", "2.0"}},
+		{state: PackageInstallState{Name: "NeverInstalled", Version: "1.0", State: Failed, Time: old},
+			versions: []string{"1.0"}},
+		{state: PackageInstallState{Name: "FailedUpgrade", Version: "2.0", State: Failed, LastInstalledVersion: "1.0", Time: old},
+			versions: []string{"1.0", "2.0"}},
+		{state: PackageInstallState{Name: "RecentFailure", Version: "1.0", State: Failed, Time: time.Now()},
+			versions: []string{"1.0"}},
+	})
+	defer cleanup()
+
+	result, err := repo.CollectGarbage(tracerMock, RetentionPolicy{RetainedPreviousVersions: 1, FailedRetention: 24 * time.Hour})
+	assert.Nil(t, err)
+	removed := strings.Join(removedVersions(result), ",")
+	assert.Contains(t, removed, "Removed/1.0")
+	assert.Contains(t, removed, "Removed/2.0")
+	assert.Contains(t, removed, "NeverInstalled/1.0")
+	assert.Contains(t, removed, "FailedUpgrade/2.0")
+	assert.NotContains(t, removed, "FailedUpgrade/1.0")
+	assert.NotContains(t, removed, "RecentFailure")
+	assert.False(t, repo.filesysdep.Exists(repo.getPackageRoot("Removed")))
+	assert.False(t, repo.filesysdep.Exists(repo.getPackageRoot("NeverInstalled")))
+	assert.True(t, repo.filesysdep.Exists(repo.getPackageVersionPath("FailedUpgrade", "1.0")))
+	assert.True(t, repo.filesysdep.Exists(repo.getPackageVersionPath("RecentFailure", "1.0")))
+}
+
+func TestCollectGarbageSkipsInProgress(t *testing.T) {
+	repo, cleanup := setupGCRepository(t, []gcTestPackage{
+		{state: PackageInstallState{Name: "Pkg", Version: "1.0.2", State: Installing, LastInstalledVersion: "1.0.0", Time: time.Now()},
+			versions: []string{"1.0.0", "1.0.1", "1.0.2"}},
+	})
+	defer cleanup()
+
+	result, err := repo.CollectGarbage(tracerMock, RetentionPolicy{FailedRetention: time.Hour})
+	assert.Nil(t, err)
+	assert.Empty(t, result.Removed)
+}
+
+func TestCollectGarbageQuota(t *testing.T) {
+	repo, cleanup := setupGCRepository(t, []gcTestPackage{
+		{state: PackageInstallState{Name: "PkgA", Version: "1.0.2", State: Installed, LastInstalledVersion: "1.0.2", Time: time.Now()},
+			versions: []string{"1.0.0", "1.0.1", "1.0.2"}},
+		{state: PackageInstallState{Name: "PkgB", Version: "3.0", State: Installed, LastInstalledVersion: "3.0", Time: time.Now()},
+			versions: []string{"2.0", "3.0"}},
+	})
+	defer cleanup()
+
+	// installed versions and state files stay even when the quota cannot be met
+	result, err := repo.CollectGarbage(tracerMock, RetentionPolicy{RetainedPreviousVersions: 5, FailedRetention: time.Hour, QuotaBytes: 3 * 1024})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(result.Removed))
+	for _, entry := range result.Removed {
+		assert.Equal(t, reasonQuota, entry.Reason)
+	}
+	assert.True(t, repo.filesysdep.Exists(repo.getPackageVersionPath("PkgA", "1.0.2")))
+	assert.True(t, repo.filesysdep.Exists(repo.getPackageVersionPath("PkgB", "3.0")))
+}
+
+func TestCollectGarbageKeepsRecentVersions(t *testing.T) {
+	repo, cleanup := setupGCRepository(t, []gcTestPackage{
+		{state: PackageInstallState{Name: "Pkg", Version: "1.0.0", State: Installed, LastInstalledVersion: "1.0.0", Time: time.Now()},
+			versions: []string{"1.0.0"}},
+	})
+	defer cleanup()
+
+	// a version directory that was just created may be a download in progress
+	assert.Nil(t, os.MkdirAll(repo.getPackageVersionPath("Pkg", "1.0.1"), 0700))
+
+	result, err := repo.CollectGarbage(tracerMock, RetentionPolicy{FailedRetention: time.Hour})
+	assert.Nil(t, err)
+	assert.Empty(t, result.Removed)
+}
@@ -0,0 +1,55 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package localpackages implements the local storage for packages managed by the ConfigurePackage plugin.
+package localpackages
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+)
+
+// RepositoryLockFileName is the file locked by the processes acting on the repository, package actions run in the
+// document worker and the garbage collection runs in the agent or in the cli so an in-process lock doesn't exclude them
+const RepositoryLockFileName = ".repository.lock"
+
+// LockRepository waits for and takes the lock of the repository shared by the processes of the agent.
+// Package actions take it shared, garbage collection takes it exclusive. It returns the function releasing the lock.
+func LockRepository(exclusive bool) (unlock func(), err error) {
+	return lockRepositoryAt(appconfig.PackageRoot, exclusive)
+}
+
+// lockRepositoryAt takes the lock of the repository in the given folder
+func lockRepositoryAt(repoRoot string, exclusive bool) (unlock func(), err error) {
+	if err = fileutil.MakeDirs(repoRoot); err != nil {
+		return nil, fmt.Errorf("cannot make directory of %v because: %v", repoRoot, err)
+	}
+	lockPath := filepath.Join(repoRoot, RepositoryLockFileName)
+	var file *os.File
+	if file, err = os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, os.FileMode(int(appconfig.ReadWriteAccess))); err != nil {
+		return nil, fmt.Errorf("unable to open the repository lock %v, %v", lockPath, err)
+	}
+	if err = lockFile(file, exclusive); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("unable to lock the repository, %v", err)
+	}
+	return func() {
+		unlockFile(file)
+		file.Close()
+	}, nil
+}
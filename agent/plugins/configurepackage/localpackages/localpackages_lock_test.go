@@ -0,0 +1,57 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package localpackages
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExclusiveRepositoryLockWaitsForSharedLocks(t *testing.T) {
+	repoRoot, err := ioutil.TempDir("", "repository")
+	assert.Nil(t, err)
+	defer os.RemoveAll(repoRoot)
+
+	unlockFirstAction, err := lockRepositoryAt(repoRoot, false)
+	assert.Nil(t, err)
+	// package actions don't exclude each other
+	unlockSecondAction, err := lockRepositoryAt(repoRoot, false)
+	assert.Nil(t, err)
+	unlockSecondAction()
+
+	collected := make(chan bool)
+	go func() {
+		unlockCollection, err := lockRepositoryAt(repoRoot, true)
+		assert.Nil(t, err)
+		collected <- true
+		unlockCollection()
+	}()
+
+	select {
+	case <-collected:
+		assert.Fail(t, "garbage collection didn't wait for the package action")
+	case <-time.After(100 * time.Millisecond):
+	}
+	unlockFirstAction()
+	select {
+	case <-collected:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "garbage collection didn't run after the package action")
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package localpackages implements the local storage for packages managed by the ConfigurePackage plugin.
+package localpackages
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/trace"
+)
+
+// PackageDescription describes the state of a package in the repository for reporting
+type PackageDescription struct {
+	Name                 string             `json:"name"`
+	State                string             `json:"state"`
+	InProgress           bool               `json:"inprogress"`
+	Version              string             `json:"version"`
+	InstalledVersion     string             `json:"installedversion"`
+	LastInstalledVersion string             `json:"lastinstalledversion"`
+	RetryCount           int                `json:"retrycount"`
+	Time                 time.Time          `json:"time"`
+	Versions             []string           `json:"versions"`
+	History              []StateDescription `json:"history"`
+}
+
+// StateDescription describes a state transition of a package for reporting
+type StateDescription struct {
+	State   string    `json:"state"`
+	Version string    `json:"version"`
+	Time    time.Time `json:"time"`
+}
+
+// minimumStuckDuration is how long a package must remain in an in progress state before it is repaired without force
+const minimumStuckDuration = time.Hour
+
+// ListPackages describes every package in the repository
+func (repo *localRepository) ListPackages(tracer trace.Tracer) ([]PackageDescription, error) {
+	result := make([]PackageDescription, 0)
+
+	dirs, err := repo.filesysdep.GetDirectoryNames(repo.repoRoot)
+	if err != nil {
+		return result, err
+	}
+	// the directories are already normalized, normalizing them again changes the names of packages like arns
+	for _, packageDir := range dirs {
+		packageRoot := filepath.Join(repo.repoRoot, packageDir)
+		packageState := repo.loadInstallStateAt(repo.filesysdep, tracer, packageDir, packageRoot)
+		result = append(result, repo.describe(packageDir, packageRoot, packageState))
+	}
+	return result, nil
+}
+
+// DescribePackage describes a single package in the repository
+func (repo *localRepository) DescribePackage(tracer trace.Tracer, packageName string) (PackageDescription, error) {
+	packageState := repo.loadInstallState(repo.filesysdep, tracer, packageName)
+	if packageState.State == None {
+		return PackageDescription{}, fmt.Errorf("package %v is not in the repository", packageName)
+	}
+	return repo.describe(packageName, repo.getPackageRoot(packageName), packageState), nil
+}
+
+// RepairPackage resets a package that is stuck in an install, uninstall, update or rollback so the next
+// configurePackage run starts from a stable state.  The package returns to its last installed version
+// if that version is still in the repository, otherwise the interrupted version is marked as Failed.
+// Packages that changed state recently may still be in progress and are only repaired with force.
+func (repo *localRepository) RepairPackage(tracer trace.Tracer, packageName string, force bool) (PackageDescription, error) {
+	repairTrace := tracer.BeginSection(fmt.Sprintf("repair package %v", packageName))
+	defer repairTrace.End()
+
+	packageState := repo.loadInstallState(repo.filesysdep, tracer, packageName)
+	switch {
+	case packageState.State == None:
+		return PackageDescription{}, fmt.Errorf("package %v is not in the repository", packageName)
+	case packageState.State == Unknown:
+		return repo.describe(packageName, repo.getPackageRoot(packageName), packageState), fmt.Errorf("state of package %v is unknown, it is validated by the next install", packageName)
+	case packageState.State == Failed && packageState.RetryCount > 0:
+		// nothing is in progress, only the retry count is reset
+	case !isInProgress(packageState.State):
+		return repo.describe(packageName, repo.getPackageRoot(packageName), packageState), fmt.Errorf("package %v is %v and does not need repair", packageName, packageState.State)
+	case !force && time.Since(packageState.Time) < minimumStuckDuration:
+		return repo.describe(packageName, repo.getPackageRoot(packageName), packageState), fmt.Errorf("package %v changed to %v at %v and may still be in progress, use force to repair it anyway",
+			packageName, packageState.State, packageState.Time.Format(time.RFC3339))
+	case packageState.LastInstalledVersion != "" && repo.filesysdep.Exists(repo.getPackageVersionPath(packageName, packageState.LastInstalledVersion)):
+		packageState.State = Installed
+		packageState.Version = packageState.LastInstalledVersion
+	default:
+		packageState.State = Failed
+	}
+
+	repairTrace.AppendInfof("repairing %v, resetting to %v %v", packageName, packageState.State, packageState.Version)
+	packageState.RetryCount = 0
+	packageState.Time = time.Now()
+	packageState.appendHistory()
+
+	installStateContent, err := jsonutil.Marshal(packageState)
+	if err != nil {
+		return PackageDescription{}, err
+	}
+	if err = repo.filesysdep.WriteFile(repo.getInstallStatePath(packageName), installStateContent); err != nil {
+		return PackageDescription{}, err
+	}
+	return repo.describe(packageName, repo.getPackageRoot(packageName), packageState), nil
+}
+
+// describe builds the description of a package from its install state and the versions in its package directory
+func (repo *localRepository) describe(packageName string, packageRoot string, packageState *PackageInstallState) PackageDescription {
+	description := PackageDescription{
+		Name:                 packageName,
+		State:                packageState.State.String(),
+		InProgress:           isInProgress(packageState.State),
+		Version:              packageState.Version,
+		InstalledVersion:     packageState.installedVersion(),
+		LastInstalledVersion: packageState.LastInstalledVersion,
+		RetryCount:           packageState.RetryCount,
+		Time:                 packageState.Time,
+		Versions:             make([]string, 0),
+		History:              make([]StateDescription, 0),
+	}
+	if packageState.Name != "" {
+		description.Name = packageState.Name
+	}
+	if versions, err := repo.filesysdep.GetDirectoryNames(packageRoot); err == nil {
+		description.Versions = versions
+	}
+	for _, entry := range packageState.History {
+		description.History = append(description.History, StateDescription{State: entry.State.String(), Version: entry.Version, Time: entry.Time})
+	}
+	return description
+}
+
+// isInProgress returns true for states the package is in while an action has started but not yet completed
+func isInProgress(state InstallState) bool {
+	switch state {
+	case Installing, Uninstalling, Upgrading, Updating, RollbackInstall, RollbackUninstall:
+		return true
+	}
+	return false
+}
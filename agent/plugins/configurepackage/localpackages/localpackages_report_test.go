@@ -0,0 +1,156 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package localpackages implements the local storage for packages managed by the ConfigurePackage plugin.
+package localpackages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstallStateString(t *testing.T) {
+	assert.Equal(t, "None", None.String())
+	assert.Equal(t, "RollbackInstall", RollbackInstall.String())
+	assert.Equal(t, "Updating", Updating.String())
+	assert.Equal(t, "InstallState(42)", InstallState(42).String())
+}
+
+func TestSetInstallStateHistory(t *testing.T) {
+	repo, cleanup := setupGCRepository(t, []gcTestPackage{
+		{state: PackageInstallState{Name: "Pkg", Version: "1.0.0", State: Installed, LastInstalledVersion: "1.0.0", Time: time.Now()},
+			versions: []string{"1.0.0"}},
+	})
+	defer cleanup()
+
+	for i := 0; i < maxStateHistory+5; i++ {
+		assert.Nil(t, repo.SetInstallState(tracerMock, "Pkg", "1.0.1", Installing))
+	}
+	assert.Nil(t, repo.SetInstallState(tracerMock, "Pkg", "1.0.1", Installed))
+
+	description, err := repo.DescribePackage(tracerMock, "Pkg")
+	assert.Nil(t, err)
+	assert.Equal(t, maxStateHistory, len(description.History))
+	assert.Equal(t, "Installed", description.History[maxStateHistory-1].State)
+	assert.Equal(t, "1.0.1", description.History[maxStateHistory-1].Version)
+}
+
+func TestListPackages(t *testing.T) {
+	repo, cleanup := setupGCRepository(t, []gcTestPackage{
+		{state: PackageInstallState{Name: "PkgA", Version: "1.0.1", State: Installing, LastInstalledVersion: "1.0.0", RetryCount: 2, Time: time.Now()},
+			versions: []string{"1.0.0", "1.0.1"}},
+		{state: PackageInstallState{Name: "PkgB", Version: "2.0", State: Installed, LastInstalledVersion: "2.0", Time: time.Now()},
+			versions: []string{"2.0"}},
+	})
+	defer cleanup()
+
+	packages, err := repo.ListPackages(tracerMock)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(packages))
+	assert.Equal(t, "PkgA", packages[0].Name)
+	assert.Equal(t, "Installing", packages[0].State)
+	assert.True(t, packages[0].InProgress)
+	assert.Equal(t, "1.0.0", packages[0].InstalledVersion)
+	assert.Equal(t, 2, packages[0].RetryCount)
+	assert.Equal(t, []string{"1.0.0", "1.0.1"}, packages[0].Versions)
+	assert.Equal(t, "PkgB", packages[1].Name)
+	assert.Equal(t, "Installed", packages[1].State)
+	assert.False(t, packages[1].InProgress)
+	assert.Equal(t, "2.0", packages[1].InstalledVersion)
+}
+
+func TestListPackagesNamedByArn(t *testing.T) {
+	arn := "arn:aws:ssm:us-east-1:123456789012:document/Pkg"
+	repo, cleanup := setupGCRepository(t, []gcTestPackage{
+		{state: PackageInstallState{Name: arn, Version: "1.0.0", State: Installed, LastInstalledVersion: "1.0.0", Time: time.Now()},
+			versions: []string{"1.0.0"}},
+	})
+	defer cleanup()
+
+	packages, err := repo.ListPackages(tracerMock)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(packages))
+	assert.Equal(t, arn, packages[0].Name)
+	assert.Equal(t, "Installed", packages[0].State)
+	assert.Equal(t, []string{"1.0.0"}, packages[0].Versions)
+}
+
+func TestDescribePackageMissing(t *testing.T) {
+	repo, cleanup := setupGCRepository(t, []gcTestPackage{})
+	defer cleanup()
+
+	_, err := repo.DescribePackage(tracerMock, "Pkg")
+	assert.NotNil(t, err)
+}
+
+func TestRepairPackageToLastInstalled(t *testing.T) {
+	repo, cleanup := setupGCRepository(t, []gcTestPackage{
+		{state: PackageInstallState{Name: "Pkg", Version: "1.0.1", State: Installing, LastInstalledVersion: "1.0.0", RetryCount: 5, Time: time.Now().Add(-2 * time.Hour)},
+			versions: []string{"1.0.0", "1.0.1"}},
+	})
+	defer cleanup()
+
+	description, err := repo.RepairPackage(tracerMock, "Pkg", false)
+	assert.Nil(t, err)
+	assert.Equal(t, "Installed", description.State)
+	assert.Equal(t, "1.0.0", description.Version)
+	assert.Equal(t, 0, description.RetryCount)
+
+	state, version := repo.GetInstallState(tracerMock, "Pkg")
+	assert.Equal(t, Installed, state)
+	assert.Equal(t, "1.0.0", version)
+}
+
+func TestRepairPackageToFailed(t *testing.T) {
+	repo, cleanup := setupGCRepository(t, []gcTestPackage{
+		{state: PackageInstallState{Name: "Pkg", Version: "1.0.1", State: RollbackInstall, Time: time.Now().Add(-2 * time.Hour)},
+			versions: []string{"1.0.1"}},
+	})
+	defer cleanup()
+
+	description, err := repo.RepairPackage(tracerMock, "Pkg", false)
+	assert.Nil(t, err)
+	assert.Equal(t, "Failed", description.State)
+	assert.Equal(t, "1.0.1", description.Version)
+}
+
+func TestRepairPackageRecentRequiresForce(t *testing.T) {
+	repo, cleanup := setupGCRepository(t, []gcTestPackage{
+		{state: PackageInstallState{Name: "Pkg", Version: "1.0.1", State: Uninstalling, LastInstalledVersion: "1.0.1", Time: time.Now()},
+			versions: []string{"1.0.1"}},
+	})
+	defer cleanup()
+
+	_, err := repo.RepairPackage(tracerMock, "Pkg", false)
+	assert.NotNil(t, err)
+	state, _ := repo.GetInstallState(tracerMock, "Pkg")
+	assert.Equal(t, Uninstalling, state)
+
+	description, err := repo.RepairPackage(tracerMock, "Pkg", true)
+	assert.Nil(t, err)
+	assert.Equal(t, "Installed", description.State)
+}
+
+func TestRepairPackageNotStuck(t *testing.T) {
+	repo, cleanup := setupGCRepository(t, []gcTestPackage{
+		{state: PackageInstallState{Name: "Pkg", Version: "1.0.0", State: Installed, LastInstalledVersion: "1.0.0", Time: time.Now()},
+			versions: []string{"1.0.0"}},
+	})
+	defer cleanup()
+
+	_, err := repo.RepairPackage(tracerMock, "Pkg", true)
+	assert.NotNil(t, err)
+}
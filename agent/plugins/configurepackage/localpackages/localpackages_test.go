@@ -27,7 +27,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
-	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/trace"
+	"github.com/aws/amazon-ssm-agent/agent/trace"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -602,3 +602,13 @@ func (fileMock *MockedFileSys) WriteFile(filename string, content string) error
 	fileMock.ContentWritten += content
 	return args.Error(0)
 }
+
+func (fileMock *MockedFileSys) GetModificationTime(path string) (time.Time, error) {
+	args := fileMock.Called(path)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (fileMock *MockedFileSys) GetDirectorySize(path string) (int64, error) {
+	args := fileMock.Called(path)
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -20,7 +20,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/installer"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/localpackages"
-	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/trace"
+	"github.com/aws/amazon-ssm-agent/agent/trace"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
 	"github.com/stretchr/testify/mock"
 )
@@ -77,6 +77,26 @@ func (repoMock *MockedRepository) GetInstaller(tracer trace.Tracer,
 	return args.Get(0).(installer.Installer)
 }
 
+func (repoMock *MockedRepository) CollectGarbage(tracer trace.Tracer, policy localpackages.RetentionPolicy) (localpackages.GarbageCollectionResult, error) {
+	args := repoMock.Called(tracer, policy)
+	return args.Get(0).(localpackages.GarbageCollectionResult), args.Error(1)
+}
+
+func (repoMock *MockedRepository) ListPackages(tracer trace.Tracer) ([]localpackages.PackageDescription, error) {
+	args := repoMock.Called(tracer)
+	return args.Get(0).([]localpackages.PackageDescription), args.Error(1)
+}
+
+func (repoMock *MockedRepository) DescribePackage(tracer trace.Tracer, packageName string) (localpackages.PackageDescription, error) {
+	args := repoMock.Called(tracer, packageName)
+	return args.Get(0).(localpackages.PackageDescription), args.Error(1)
+}
+
+func (repoMock *MockedRepository) RepairPackage(tracer trace.Tracer, packageName string, force bool) (localpackages.PackageDescription, error) {
+	args := repoMock.Called(tracer, packageName, force)
+	return args.Get(0).(localpackages.PackageDescription), args.Error(1)
+}
+
 func (repoMock *MockedRepository) ReadManifest(packageName string, packageVersion string) ([]byte, error) {
 	args := repoMock.Called(packageName, packageVersion)
 	return args.Get(0).([]byte), args.Error(1)
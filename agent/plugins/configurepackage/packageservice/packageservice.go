@@ -18,7 +18,7 @@ import (
 	"fmt"
 	"sort"
 
-	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/trace"
+	"github.com/aws/amazon-ssm-agent/agent/trace"
 )
 
 // Trace contains one specific operation done for the agent install/upgrade/uninstall
@@ -29,7 +29,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/envdetect"
-	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/trace"
+	"github.com/aws/amazon-ssm-agent/agent/trace"
 	"github.com/aws/amazon-ssm-agent/agent/times"
 )
 
@@ -73,16 +73,45 @@ func New(packageName string,
 	}
 }
 
+const (
+	// updateAction is the optional action that upgrades a previous version in place
+	updateAction = "update"
+
+	// previousVersionParameter and newVersionParameter are passed to a json update action as document parameters
+	previousVersionParameter = "previousVersion"
+	newVersionParameter      = "newVersion"
+)
+
 func (inst *Installer) Install(tracer trace.Tracer, context context.T) contracts.PluginOutputter {
-	return inst.executeAction(tracer, context, "install")
+	return inst.executeAction(tracer, context, "install", nil)
 }
 
 func (inst *Installer) Uninstall(tracer trace.Tracer, context context.T) contracts.PluginOutputter {
-	return inst.executeAction(tracer, context, "uninstall")
+	return inst.executeAction(tracer, context, "uninstall", nil)
 }
 
 func (inst *Installer) Validate(tracer trace.Tracer, context context.T) contracts.PluginOutputter {
-	return inst.executeAction(tracer, context, "validate")
+	return inst.executeAction(tracer, context, "validate", nil)
+}
+
+// Update upgrades previousVersion of the package to this version in place
+// The previous and new version are available to sh and ps1 actions as BWS_PREVIOUS_VERSION and BWS_NEW_VERSION
+// and to json actions as the previousVersion and newVersion parameters
+func (inst *Installer) Update(tracer trace.Tracer, context context.T, previousVersion string) contracts.PluginOutputter {
+	return inst.executeAction(tracer, context, updateAction, map[string]string{
+		previousVersionParameter: previousVersion,
+		newVersionParameter:      inst.version,
+	})
+}
+
+// HasUpdateAction returns true if the package provides an update action (sh, ps1 or json)
+func (inst *Installer) HasUpdateAction() bool {
+	for _, extension := range []string{"sh", "ps1", "json"} {
+		if inst.filesysdep.Exists(inst.getActionPath(updateAction, extension)) {
+			return true
+		}
+	}
+	return false
 }
 
 func (inst *Installer) Version() string {
@@ -93,13 +122,13 @@ func (inst *Installer) PackageName() string {
 	return inst.packageName
 }
 
-func (inst *Installer) executeAction(tracer trace.Tracer, context context.T, actionName string) contracts.PluginOutputter {
+func (inst *Installer) executeAction(tracer trace.Tracer, context context.T, actionName string, versions map[string]string) contracts.PluginOutputter {
 	exectrace := tracer.BeginSection(fmt.Sprintf("execute action: %s", actionName))
 
 	output := &trace.PluginOutputTrace{Tracer: tracer}
 	output.SetStatus(contracts.ResultStatusSuccess)
 
-	exists, pluginsInfo, _, err := inst.readAction(tracer, context, actionName)
+	exists, pluginsInfo, _, err := inst.readAction(tracer, context, actionName, versions)
 	if exists {
 		if err != nil {
 			exectrace.WithError(err)
@@ -195,7 +224,7 @@ func (inst *Installer) readPs1Action(context context.T, action *Action, workingD
 }
 
 // readJsonAction turns an json action into a set of SSM Document Plugins to execute
-func (inst *Installer) readJsonAction(context context.T, action *Action, workingDir string) (pluginsInfo []contracts.PluginState, err error) {
+func (inst *Installer) readJsonAction(context context.T, action *Action, workingDir string, params map[string]interface{}) (pluginsInfo []contracts.PluginState, err error) {
 	if action.actionType != ACTION_TYPE_JSON {
 		return nil, fmt.Errorf("Internal error")
 	}
@@ -234,7 +263,7 @@ func (inst *Installer) readJsonAction(context context.T, action *Action, working
 		return nil, err
 	}
 
-	pluginsInfo, err = docparser.ParseDocument(log, &docContent, parserInfo, nil)
+	pluginsInfo, err = docparser.ParseDocument(log, &docContent, parserInfo, params)
 
 	if err != nil {
 		return nil, err
@@ -314,9 +343,20 @@ func (inst *Installer) getEnvVars(context context.T) (envVars map[string]string,
 	return envVars, err
 }
 
+// addVersionEnvVars exposes the previous and new version of an update to sh and ps1 actions
+func addVersionEnvVars(envVars map[string]string, versions map[string]string) {
+	if previousVersion, ok := versions[previousVersionParameter]; ok {
+		envVars["BWS_PREVIOUS_VERSION"] = previousVersion
+	}
+	if newVersion, ok := versions[newVersionParameter]; ok {
+		envVars["BWS_NEW_VERSION"] = newVersion
+	}
+}
+
 // readAction returns a JSON document describing a management action and its working directory, or an empty string
 // if there is nothing to do for a given action
-func (inst *Installer) readAction(tracer trace.Tracer, context context.T, actionName string) (exists bool, pluginsInfo []contracts.PluginState, workingDir string, err error) {
+// versions are made available to the action as environment variables for scripts and as parameters for json documents
+func (inst *Installer) readAction(tracer trace.Tracer, context context.T, actionName string, versions map[string]string) (exists bool, pluginsInfo []contracts.PluginState, workingDir string, err error) {
 	// TODO: Split into linux and windows
 
 	var action *Action
@@ -332,6 +372,7 @@ func (inst *Installer) readAction(tracer trace.Tracer, context context.T, action
 		if envVars, err = inst.getEnvVars(context); err != nil {
 			return exists, nil, "", err
 		}
+		addVersionEnvVars(envVars, versions)
 
 		if pluginsInfo, err = inst.readShAction(context, action, workingDir, envVars); err != nil {
 			return exists, nil, "", err
@@ -343,6 +384,7 @@ func (inst *Installer) readAction(tracer trace.Tracer, context context.T, action
 		if envVars, err = inst.getEnvVars(context); err != nil {
 			return exists, nil, "", err
 		}
+		addVersionEnvVars(envVars, versions)
 
 		if pluginsInfo, err = inst.readPs1Action(context, action, workingDir, envVars); err != nil {
 			return exists, nil, "", err
@@ -350,7 +392,11 @@ func (inst *Installer) readAction(tracer trace.Tracer, context context.T, action
 
 		return exists, pluginsInfo, workingDir, nil
 	} else if action.actionType == ACTION_TYPE_JSON {
-		if pluginsInfo, err = inst.readJsonAction(context, action, workingDir); err != nil {
+		params := make(map[string]interface{})
+		for name, value := range versions {
+			params[name] = value
+		}
+		if pluginsInfo, err = inst.readJsonAction(context, action, workingDir, params); err != nil {
 			return exists, nil, "", err
 		}
 
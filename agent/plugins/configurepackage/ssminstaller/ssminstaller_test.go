@@ -16,6 +16,7 @@
 package ssminstaller
 
 import (
+	"fmt"
 	"io/ioutil"
 	"path"
 	"testing"
@@ -27,7 +28,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/envdetect"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/envdetect/ec2infradetect"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/envdetect/osdetect"
-	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/trace"
+	"github.com/aws/amazon-ssm-agent/agent/trace"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -88,7 +89,7 @@ func testReadAction(t *testing.T, actionPathNoExt string, contentSh []byte, cont
 	inst := Installer{filesysdep: &mockFileSys, packagePath: testPackagePath, envdetectCollector: mockEnvdetectCollector}
 
 	// Call and validate mock expectations and return value
-	exists, actionDoc, workingDir, err := inst.readAction(tracer, contextMock, "Foo")
+	exists, actionDoc, workingDir, err := inst.readAction(tracer, contextMock, "Foo", nil)
 	mockFileSys.AssertExpectations(t)
 	assert.True(t, exists)
 	assert.NotEmpty(t, actionDoc)
@@ -110,7 +111,7 @@ func testReadActionInvalid(t *testing.T, actionPathNoExt string, contentSh []byt
 	inst := Installer{filesysdep: &mockFileSys, packagePath: testPackagePath, envdetectCollector: mockEnvdetectCollector}
 
 	// Call and validate mock expectations and return value
-	exists, actionDoc, workingDir, err := inst.readAction(tracer, contextMock, "Foo")
+	exists, actionDoc, workingDir, err := inst.readAction(tracer, contextMock, "Foo", nil)
 	mockFileSys.AssertExpectations(t)
 	assert.True(t, exists)
 	assert.Empty(t, actionDoc)
@@ -145,7 +146,7 @@ func TestReadActionMissing(t *testing.T) {
 	repo := Installer{filesysdep: &mockFileSys, packagePath: testPackagePath, envdetectCollector: mockEnvdetectCollector}
 
 	// Call and validate mock expectations and return value
-	exists, actionDoc, workingDir, err := repo.readAction(tracer, contextMock, "Foo")
+	exists, actionDoc, workingDir, err := repo.readAction(tracer, contextMock, "Foo", nil)
 	mockFileSys.AssertExpectations(t)
 	assert.False(t, exists)
 	assert.Empty(t, actionDoc)
@@ -170,7 +171,7 @@ func testReadActionTooManyActionImplementations(t *testing.T, existSh bool, exis
 	repo := Installer{filesysdep: &mockFileSys, packagePath: testPackagePath, envdetectCollector: mockEnvdetectCollector}
 
 	// Call and validate mock expectations and return value
-	exists, actionDoc, workingDir, err := repo.readAction(tracer, contextMock, "Foo")
+	exists, actionDoc, workingDir, err := repo.readAction(tracer, contextMock, "Foo", nil)
 	mockFileSys.AssertExpectations(t)
 	assert.True(t, exists)
 	assert.Empty(t, actionDoc)
@@ -312,3 +313,68 @@ func (execMock *MockedExec) ExecuteDocument(
 	args := execMock.Called(context, pluginInput, documentID, documentCreatedDate)
 	return args.Get(0).(map[string]*contracts.PluginResult)
 }
+
+func TestHasUpdateAction(t *testing.T) {
+	mockFileSys := MockedFileSys{}
+	actionPathNoExt := path.Join(testPackagePath, "update")
+	mockFileSys.On("Exists", actionPathNoExt+".sh").Return(false).Once()
+	mockFileSys.On("Exists", actionPathNoExt+".ps1").Return(true).Once()
+
+	inst := Installer{filesysdep: &mockFileSys, packagePath: testPackagePath}
+
+	assert.True(t, inst.HasUpdateAction())
+	mockFileSys.AssertExpectations(t)
+}
+
+func TestHasUpdateActionMissing(t *testing.T) {
+	mockFileSys := MockedFileSys{}
+	actionPathNoExt := path.Join(testPackagePath, "update")
+	mockReadAction(t, &mockFileSys, actionPathNoExt, []byte{}, []byte{}, []byte{}, false)
+
+	inst := Installer{filesysdep: &mockFileSys, packagePath: testPackagePath}
+
+	assert.False(t, inst.HasUpdateAction())
+	mockFileSys.AssertExpectations(t)
+}
+
+func TestReadActionUpdateVersions(t *testing.T) {
+	mockFileSys := MockedFileSys{}
+	actionPathNoExt := path.Join(testPackagePath, "update")
+	mockReadAction(t, &mockFileSys, actionPathNoExt, []byte("echo sh"), []byte{}, []byte{}, false)
+
+	mockEnvdetectCollector := &envdetect.CollectorMock{}
+	mockEnvdetectCollector.On("CollectData", mock.Anything).Return(&environmentStub, nil).Once()
+
+	tracer := trace.NewTracer(log.NewMockLog())
+	tracer.BeginSection("test segment root")
+
+	inst := Installer{filesysdep: &mockFileSys, packagePath: testPackagePath, version: "2.0.0", envdetectCollector: mockEnvdetectCollector}
+
+	exists, pluginsInfo, _, err := inst.readAction(tracer, contextMock, "update", map[string]string{previousVersionParameter: "1.0.0", newVersionParameter: "2.0.0"})
+	mockFileSys.AssertExpectations(t)
+	assert.True(t, exists)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(pluginsInfo))
+	runCommand := fmt.Sprint(pluginsInfo[0].Configuration.Properties.(map[string]interface{})["runCommand"])
+	assert.Contains(t, runCommand, "export BWS_PREVIOUS_VERSION='1.0.0'")
+	assert.Contains(t, runCommand, "export BWS_NEW_VERSION='2.0.0'")
+	assert.Contains(t, runCommand, "sh update.sh")
+}
+
+func TestUpdate_Success(t *testing.T) {
+	mockFileSys := MockedFileSys{}
+	actionPathNoExt := path.Join(testPackagePath, "update")
+	mockReadAction(t, &mockFileSys, actionPathNoExt, []byte{}, []byte{}, loadFile(t, path.Join(testPackagePath, "valid-action.json")), true)
+
+	mockExec := MockedExec{}
+	mockExec.On("ExecuteDocument", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(map[string]*contracts.PluginResult{"Foo": {Status: contracts.ResultStatusSuccess}}).Once()
+
+	tracer := trace.NewTracer(log.NewMockLog())
+
+	inst := Installer{filesysdep: &mockFileSys, execdep: &mockExec, packagePath: testPackagePath, version: "2.0.0", envdetectCollector: &envdetect.CollectorMock{}}
+
+	output := inst.Update(tracer, contextMock, "1.0.0")
+	mockFileSys.AssertExpectations(t)
+	mockExec.AssertExpectations(t)
+	assert.Equal(t, contracts.ResultStatusSuccess, output.GetStatus())
+}
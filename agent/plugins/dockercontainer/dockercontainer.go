@@ -329,7 +329,7 @@ func (p *Plugin) runCommands(log log.T, pluginID string, pluginInput DockerConta
 	log.Debugf("stdout file %v, stderr file %v", stdoutFilePath, stderrFilePath)
 
 	// Execute Command
-	stdout, stderr, exitCode, errs := p.CommandExecuter.Execute(log, pluginInput.WorkingDirectory, stdoutFilePath, stderrFilePath, cancelFlag, executionTimeout, commandName, commandArguments)
+	stdout, stderr, exitCode, errs := p.CommandExecuter.Execute(log, pluginInput.WorkingDirectory, stdoutFilePath, stderrFilePath, cancelFlag, executionTimeout, commandName, commandArguments, nil)
 
 	// Set output status
 	out.ExitCode = exitCode
@@ -0,0 +1,288 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package filestate implements the aws:fileState plugin, which ensures a file or directory is in the given state.
+package filestate
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+const (
+	// StatePresent represents a file that exists, with the given content and mode when set
+	StatePresent = "present"
+	// StateAbsent represents a file or directory that doesn't exist
+	StateAbsent = "absent"
+	// StateDirectory represents a directory that exists, with the given mode when set
+	StateDirectory = "directory"
+
+	// defaultFileMode and defaultDirectoryMode are the permissions of the files and directories created without a mode
+	defaultFileMode      = 0644
+	defaultDirectoryMode = 0755
+)
+
+// Plugin is the type for the aws:fileState plugin.
+type Plugin struct {
+	pluginutil.DefaultPlugin
+}
+
+// FileStatePluginInput represents the state of the file managed by the aws:fileState plugin.
+type FileStatePluginInput struct {
+	contracts.PluginInput
+	Path  string `json:"path"`
+	State string `json:"state"`
+	// Content is the content of a present file, the content isn't managed when not set
+	Content *string `json:"content"`
+	// Mode is the octal permission of the file or directory, e.g. 0644, it isn't managed when empty or on windows
+	Mode string `json:"mode"`
+	// Recursive allows an absent directory to be removed with its content, directories are not removed without it
+	Recursive bool `json:"recursive"`
+}
+
+// change is a difference between the file and its state, with the operation that resolves it
+type change struct {
+	id    string
+	title string
+	apply func() error
+}
+
+// NewPlugin returns a new instance of the plugin.
+func NewPlugin(pluginConfig pluginutil.PluginConfig) (*Plugin, error) {
+	var plugin Plugin
+	plugin.MaxStdoutLength = pluginConfig.MaxStdoutLength
+	plugin.MaxStderrLength = pluginConfig.MaxStderrLength
+	plugin.StdoutFileName = pluginConfig.StdoutFileName
+	plugin.StderrFileName = pluginConfig.StderrFileName
+	plugin.OutputTruncatedSuffix = pluginConfig.OutputTruncatedSuffix
+	plugin.ExecuteUploadOutputToS3Bucket = pluginutil.UploadOutputToS3BucketExecuter(plugin.UploadOutputToS3Bucket)
+
+	return &plugin, nil
+}
+
+// Name returns the name of the plugin
+func Name() string {
+	return appconfig.PluginNameAwsFileState
+}
+
+// Execute brings the file to its state, or only reports the changes it would apply in audit mode.
+func (p *Plugin) Execute(context context.T, config contracts.Configuration, cancelFlag task.CancelFlag) (res contracts.PluginResult) {
+	log := context.Log()
+	log.Infof("%v started with configuration %v", Name(), config)
+	res.StartDateTime = time.Now()
+	defer func() { res.EndDateTime = time.Now() }()
+
+	out := contracts.PluginOutput{}
+	if cancelFlag.ShutDown() {
+		out.MarkAsShutdown()
+	} else if cancelFlag.Canceled() {
+		out.MarkAsCancelled()
+	} else if input, err := parseAndValidateInput(config.Properties); err != nil {
+		out.MarkAsFailed(log, err)
+	} else if changes, err := planChanges(input); err != nil {
+		out.MarkAsFailed(log, err)
+	} else if config.AuditOnly {
+		res.Audited = true
+		for _, c := range changes {
+			res.Drift = append(res.Drift, contracts.DriftItem{
+				ID:      c.id,
+				Title:   c.title,
+				Details: map[string]string{"Path": input.Path, "State": input.State},
+			})
+			out.AppendInfof(log, "Would %v", c.title)
+		}
+		if len(changes) == 0 {
+			out.AppendInfof(log, "%v is %v", input.Path, input.State)
+		}
+		out.MarkAsSucceeded()
+	} else {
+		out.MarkAsSucceeded()
+		for _, c := range changes {
+			if err := c.apply(); err != nil {
+				out.MarkAsFailed(log, fmt.Errorf("failed to %v, %v", c.title, err))
+				break
+			}
+			out.AppendInfof(log, "Done: %v", c.title)
+		}
+		if len(changes) == 0 {
+			out.AppendInfof(log, "%v is already %v", input.Path, input.State)
+		}
+	}
+
+	res.Code = out.ExitCode
+	res.Status = out.Status
+	res.Output = out.String()
+	res.StandardOutput = pluginutil.StringPrefix(out.Stdout, p.MaxStdoutLength, p.OutputTruncatedSuffix)
+	res.StandardError = pluginutil.StringPrefix(out.Stderr, p.MaxStderrLength, p.OutputTruncatedSuffix)
+	return res
+}
+
+// parseAndValidateInput marshals raw JSON and returns the result of input validation or an error
+func parseAndValidateInput(rawPluginInput interface{}) (*FileStatePluginInput, error) {
+	var input FileStatePluginInput
+	if err := jsonutil.Remarshal(rawPluginInput, &input); err != nil {
+		return nil, fmt.Errorf("invalid format in plugin properties %v; \nerror %v", rawPluginInput, err)
+	}
+
+	if input.Path == "" {
+		return nil, errors.New("invalid input: empty path field")
+	}
+	if !filepath.IsAbs(input.Path) {
+		return nil, fmt.Errorf("invalid input: path %v is not absolute", input.Path)
+	}
+	if input.State == "" {
+		input.State = StatePresent
+	}
+	switch input.State {
+	case StatePresent, StateDirectory, StateAbsent:
+	default:
+		return nil, fmt.Errorf("invalid input: unsupported state %v", input.State)
+	}
+	if input.Content != nil && input.State != StatePresent {
+		return nil, fmt.Errorf("invalid input: content is only supported for state %v", StatePresent)
+	}
+	if input.Recursive && input.State != StateAbsent {
+		return nil, fmt.Errorf("invalid input: recursive is only supported for state %v", StateAbsent)
+	}
+	if input.Mode != "" {
+		if _, err := strconv.ParseUint(input.Mode, 8, 32); err != nil {
+			return nil, fmt.Errorf("invalid input: mode %v is not an octal permission", input.Mode)
+		}
+	}
+	return &input, nil
+}
+
+// planChanges returns the changes that bring the file to its state, none when it is already in the state
+func planChanges(input *FileStatePluginInput) (changes []change, err error) {
+	path := input.Path
+	info, err := os.Stat(path)
+	exists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("unable to read %v, %v", path, err)
+	}
+
+	switch input.State {
+	case StateAbsent:
+		if !exists {
+			return changes, nil
+		}
+		remove := os.Remove
+		if info.IsDir() {
+			if isRootOrTopLevelDirectory(path) {
+				return nil, fmt.Errorf("refusing to remove %v, it is the root or a top-level directory", path)
+			}
+			if !input.Recursive {
+				return nil, fmt.Errorf("%v is a directory, it is only removed with its content when recursive is set", path)
+			}
+			remove = os.RemoveAll
+		}
+		changes = append(changes, change{
+			id:    path + ":absent",
+			title: fmt.Sprintf("remove %v", path),
+			apply: func() error { return remove(path) },
+		})
+		return changes, nil
+
+	case StateDirectory:
+		if exists && !info.IsDir() {
+			return nil, fmt.Errorf("%v exists and is not a directory", path)
+		}
+		if !exists {
+			changes = append(changes, change{
+				id:    path + ":directory",
+				title: fmt.Sprintf("create directory %v", path),
+				apply: func() error { return os.MkdirAll(path, defaultDirectoryMode) },
+			})
+		}
+
+	default:
+		if exists && info.IsDir() {
+			return nil, fmt.Errorf("%v exists and is a directory", path)
+		}
+		if !exists {
+			content := ""
+			if input.Content != nil {
+				content = *input.Content
+			}
+			changes = append(changes, change{
+				id:    path + ":present",
+				title: fmt.Sprintf("create file %v", path),
+				apply: func() error { return writeFile(path, content) },
+			})
+		} else if input.Content != nil {
+			current, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read %v, %v", path, err)
+			}
+			if string(current) != *input.Content {
+				content := *input.Content
+				changes = append(changes, change{
+					id:    path + ":content",
+					title: fmt.Sprintf("update the content of %v", path),
+					apply: func() error { return writeFile(path, content) },
+				})
+			}
+		}
+	}
+
+	if input.Mode == "" || runtime.GOOS == "windows" {
+		return changes, nil
+	}
+	mode, _ := strconv.ParseUint(input.Mode, 8, 32)
+	fileMode := os.FileMode(mode)
+	if !exists || info.Mode().Perm() != fileMode.Perm() {
+		changes = append(changes, change{
+			id:    path + ":mode",
+			title: fmt.Sprintf("set the mode of %v to %v", path, input.Mode),
+			apply: func() error { return os.Chmod(path, fileMode) },
+		})
+	}
+	return changes, nil
+}
+
+// isRootOrTopLevelDirectory returns true if the directory is the root of the file system, or directly under it like
+// /etc, /usr or C:\Windows, also when it is reached through symbolic links
+func isRootOrTopLevelDirectory(path string) bool {
+	paths := []string{filepath.Clean(path)}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		paths = append(paths, resolved)
+	}
+	for _, p := range paths {
+		parent := filepath.Dir(p)
+		if parent == p || filepath.Dir(parent) == parent {
+			return true
+		}
+	}
+	return false
+}
+
+// writeFile writes the content to the file, creating its parent directories
+func writeFile(path string, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), defaultDirectoryMode); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, []byte(content), defaultFileMode)
+}
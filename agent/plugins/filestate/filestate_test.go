@@ -0,0 +1,136 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package filestate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+)
+
+func executeFileState(t *testing.T, properties map[string]interface{}, auditOnly bool) contracts.PluginResult {
+	p, err := NewPlugin(pluginutil.DefaultPluginConfig())
+	assert.Nil(t, err)
+
+	mockCancelFlag := new(task.MockCancelFlag)
+	mockCancelFlag.On("ShutDown").Return(false)
+	mockCancelFlag.On("Canceled").Return(false)
+
+	config := contracts.Configuration{Properties: properties, AuditOnly: auditOnly}
+	return p.Execute(context.NewMockDefault(), config, mockCancelFlag)
+}
+
+func TestExecuteInAuditModeReportsDriftWithoutChangingTheFile(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "filestate")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "motd")
+	ioutil.WriteFile(path, []byte("old"), 0644)
+
+	res := executeFileState(t, map[string]interface{}{"path": path, "content": "new"}, true)
+
+	assert.Equal(t, contracts.ResultStatusSuccess, res.Status)
+	assert.True(t, res.Audited)
+	assert.Equal(t, 1, len(res.Drift))
+	assert.Equal(t, path+":content", res.Drift[0].ID)
+	content, _ := ioutil.ReadFile(path)
+	assert.Equal(t, "old", string(content))
+}
+
+func TestExecuteAppliesTheStateAndReportsNoDriftOnceApplied(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "filestate")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app", "config")
+	properties := map[string]interface{}{"path": path, "content": "key=value"}
+
+	res := executeFileState(t, properties, false)
+	assert.Equal(t, contracts.ResultStatusSuccess, res.Status)
+	assert.False(t, res.Audited)
+	content, _ := ioutil.ReadFile(path)
+	assert.Equal(t, "key=value", string(content))
+
+	res = executeFileState(t, properties, true)
+	assert.Equal(t, contracts.ResultStatusSuccess, res.Status)
+	assert.True(t, res.Audited)
+	assert.Empty(t, res.Drift)
+}
+
+func TestExecuteRemovesAbsentFile(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "filestate")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "stale")
+	ioutil.WriteFile(path, []byte("stale"), 0644)
+
+	res := executeFileState(t, map[string]interface{}{"path": path, "state": StateAbsent}, false)
+
+	assert.Equal(t, contracts.ResultStatusSuccess, res.Status)
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestExecuteRemovesAbsentDirectoryOnlyWhenRecursive(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "filestate")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cache")
+	os.MkdirAll(filepath.Join(path, "entries"), 0755)
+
+	res := executeFileState(t, map[string]interface{}{"path": path, "state": StateAbsent}, false)
+
+	assert.Equal(t, contracts.ResultStatusFailed, res.Status)
+	_, err := os.Stat(path)
+	assert.Nil(t, err)
+
+	res = executeFileState(t, map[string]interface{}{"path": path, "state": StateAbsent, "recursive": true}, false)
+
+	assert.Equal(t, contracts.ResultStatusSuccess, res.Status)
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestPlanChangesRefusesToRemoveRootOrTopLevelDirectories(t *testing.T) {
+	root, _ := filepath.Abs(string(filepath.Separator))
+	dir, _ := ioutil.TempDir("", "filestate")
+	defer os.RemoveAll(dir)
+	topLevel := dir
+	for topLevel != root && filepath.Dir(topLevel) != root {
+		topLevel = filepath.Dir(topLevel)
+	}
+
+	for _, path := range []string{root, topLevel} {
+		_, err := planChanges(&FileStatePluginInput{Path: path, State: StateAbsent, Recursive: true})
+
+		assert.NotNil(t, err, path)
+	}
+}
+
+func TestParseAndValidateInputRejectsInvalidInput(t *testing.T) {
+	for _, properties := range []map[string]interface{}{
+		{"path": ""},
+		{"path": "relative/path"},
+		{"path": "/etc/motd", "state": "linked"},
+		{"path": "/etc/app", "state": StateDirectory, "content": "value"},
+		{"path": "/etc/motd", "mode": "rw-r--r--"},
+		{"path": "/etc/app", "state": StateDirectory, "recursive": true},
+	} {
+		_, err := parseAndValidateInput(properties)
+
+		assert.NotNil(t, err, properties)
+	}
+}
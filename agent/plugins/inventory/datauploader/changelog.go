@@ -0,0 +1,323 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package datauploader contains routines upload inventory data to SSM - Inventory service
+package datauploader
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+const (
+	// maxChangeLogEntries is the number of changes kept in the change log, older changes are dropped
+	maxChangeLogEntries = 100
+	// maxEntriesPerChange is the number of added, removed and changed entries recorded for a single change
+	maxEntriesPerChange = 1000
+)
+
+// identityAttributes are the attributes identifying an entry of an inventory type across uploads, an entry whose identity
+// is unchanged but other attributes differ is recorded as changed. Entries of other types are identified by Name.
+var identityAttributes = map[string][]string{
+	"AWS:Application":         {"Name", "Architecture", "ApplicationType"},
+	"AWS:LanguagePackage":     {"Name", "ApplicationType", "PackageId"},
+	"AWS:AWSComponent":        {"Name", "Architecture"},
+	"AWS:File":                {"Name", "InstalledDir"},
+	"AWS:WindowsUpdate":       {"HotFixId"},
+	"AWS:ListeningPort":       {"Protocol", "LocalAddress", "LocalPort"},
+	"AWS:Container":           {"ContainerId"},
+	"AWS:ContainerImage":      {"Repository", "Tag", "ImageId"},
+	"AWS:InstanceInformation": {"InstanceId"},
+}
+
+var changeLogLock sync.Mutex
+
+// Change records the entries of an inventory type that differ between two consecutive uploads
+type Change struct {
+	TypeName    string
+	CaptureTime string
+	Added       []map[string]*string `json:",omitempty"`
+	Removed     []map[string]*string `json:",omitempty"`
+	Changed     []EntryChange        `json:",omitempty"`
+	Truncated   bool                 `json:",omitempty"`
+}
+
+// EntryChange is an entry whose identity is unchanged but whose attributes differ between two uploads
+type EntryChange struct {
+	Key string
+	Old map[string]*string
+	New map[string]*string
+}
+
+// ChangeTracker defines operations of the change log the uploader records uploaded inventory data in
+type ChangeTracker interface {
+	RecordUpload(items []*ssm.InventoryItem) (err error)
+	GetChanges(typeName string, maxResults int) (changes []Change, err error)
+	PendingChanges(items []*ssm.InventoryItem) (changes []Change)
+}
+
+// ChangeLogImpl keeps the last uploaded content of every inventory type and a log of the differences between uploads
+type ChangeLogImpl struct {
+	log           log.T
+	snapshotDir   string //where the last uploaded content of each inventory type is persisted
+	changeLogPath string //where the change log is persisted
+}
+
+// NewChangeLogImpl creates the change log of the inventory plugin in the data store of the instance
+func NewChangeLogImpl(context context.T) (*ChangeLogImpl, error) {
+	return NewChangeLogImplWithLocation(context.Log(), appconfig.InventoryRootDirName)
+}
+
+// NewChangeLogImplWithLocation creates a change log in the given directory of the data store of the instance
+func NewChangeLogImplWithLocation(log log.T, rootDir string) (*ChangeLogImpl, error) {
+	var changeLog = ChangeLogImpl{log: log}
+
+	//get machineID - return if not able to detect machineID
+	machineID, err := machineIDProvider()
+	if err != nil {
+		err = fmt.Errorf("Unable to detect machineID because of %v - this will hamper execution of inventory plugin",
+			err.Error())
+		return &changeLog, err
+	}
+
+	changeLog.snapshotDir = filepath.Join(appconfig.DefaultDataStorePath, machineID, rootDir, appconfig.InventorySnapshotDirName)
+	changeLog.changeLogPath = filepath.Join(appconfig.DefaultDataStorePath, machineID, rootDir, appconfig.InventoryChangeLogFileName)
+	return &changeLog, nil
+}
+
+// RecordUpload compares the content of the uploaded items with the content previously uploaded for their type, adds the
+// differences to the change log and keeps the content for the next upload. Items uploaded with only a content hash are
+// unchanged and skipped. Nothing is logged for the first upload of a type.
+func (c *ChangeLogImpl) RecordUpload(items []*ssm.InventoryItem) (err error) {
+	changeLogLock.Lock()
+	defer changeLogLock.Unlock()
+
+	var changes []Change
+	for _, item := range items {
+		if item.Content == nil || item.TypeName == nil {
+			continue
+		}
+		typeName := *item.TypeName
+		snapshotPath := c.snapshotPath(typeName)
+
+		if previous, found := c.readSnapshot(typeName); found {
+			change := DiffContent(typeName, previous, item.Content)
+			if len(change.Added) > 0 || len(change.Removed) > 0 || len(change.Changed) > 0 {
+				if item.CaptureTime != nil {
+					change.CaptureTime = *item.CaptureTime
+				}
+				c.log.Debugf("%v changed since last upload - %v added, %v removed, %v changed",
+					typeName, len(change.Added), len(change.Removed), len(change.Changed))
+				changes = append(changes, change)
+			}
+		}
+
+		dataB, _ := json.Marshal(item.Content)
+		if err = fileutil.MakeDirs(c.snapshotDir); err != nil {
+			return fmt.Errorf("Unable to create directory %v because - %v", c.snapshotDir, err.Error())
+		}
+		if _, err = fileutil.WriteIntoFileWithPermissions(snapshotPath, string(dataB), appconfig.ReadWriteAccess); err != nil {
+			return fmt.Errorf("Unable to update last uploaded content in file - %v because - %v", snapshotPath, err.Error())
+		}
+	}
+
+	if len(changes) == 0 {
+		return
+	}
+
+	changeLog := c.readChangeLog()
+	changeLog = append(changeLog, changes...)
+	if len(changeLog) > maxChangeLogEntries {
+		changeLog = changeLog[len(changeLog)-maxChangeLogEntries:]
+	}
+	dataB, _ := json.Marshal(changeLog)
+	if _, err = fileutil.WriteIntoFileWithPermissions(c.changeLogPath, string(dataB), appconfig.ReadWriteAccess); err != nil {
+		err = fmt.Errorf("Unable to update change log in file - %v because - %v", c.changeLogPath, err.Error())
+	}
+	return
+}
+
+// PendingChanges returns the differences between the content of the items and the content last uploaded for their type,
+// without recording them. All entries of a type that was never uploaded are added.
+func (c *ChangeLogImpl) PendingChanges(items []*ssm.InventoryItem) (changes []Change) {
+	changeLogLock.Lock()
+	defer changeLogLock.Unlock()
+
+	for _, item := range items {
+		if item.Content == nil || item.TypeName == nil {
+			continue
+		}
+		previous, _ := c.readSnapshot(*item.TypeName)
+		change := DiffContent(*item.TypeName, previous, item.Content)
+		if len(change.Added) > 0 || len(change.Removed) > 0 || len(change.Changed) > 0 {
+			if item.CaptureTime != nil {
+				change.CaptureTime = *item.CaptureTime
+			}
+			changes = append(changes, change)
+		}
+	}
+	return
+}
+
+// readSnapshot returns the content last uploaded for an inventory type, if any
+func (c *ChangeLogImpl) readSnapshot(typeName string) (previous []map[string]*string, found bool) {
+	dataB, err := ioutil.ReadFile(c.snapshotPath(typeName))
+	if err != nil {
+		return nil, false
+	}
+	if err = json.Unmarshal(dataB, &previous); err != nil {
+		c.log.Debugf("Unable to read last uploaded content of %v - thereby ignoring it", typeName)
+		return nil, false
+	}
+	return previous, true
+}
+
+// GetChanges returns the most recent changes of the change log, oldest first, optionally only those of one inventory type.
+// All changes are returned if maxResults is 0.
+func (c *ChangeLogImpl) GetChanges(typeName string, maxResults int) (changes []Change, err error) {
+	changeLogLock.Lock()
+	defer changeLogLock.Unlock()
+
+	changes = []Change{}
+	for _, change := range c.readChangeLog() {
+		if typeName == "" || change.TypeName == typeName {
+			changes = append(changes, change)
+		}
+	}
+	if maxResults > 0 && len(changes) > maxResults {
+		changes = changes[len(changes)-maxResults:]
+	}
+	return
+}
+
+// readChangeLog returns the persisted change log, an unreadable change log is ignored
+func (c *ChangeLogImpl) readChangeLog() (changeLog []Change) {
+	dataB, err := ioutil.ReadFile(c.changeLogPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			c.log.Debugf("Unable to read change log of inventory plugin - %v", err)
+		}
+		return
+	}
+	if err = json.Unmarshal(dataB, &changeLog); err != nil {
+		c.log.Debugf("Unable to read change log of inventory plugin - thereby ignoring any older changes")
+		return nil
+	}
+	return
+}
+
+// ChangeLogPath returns the file the change log is persisted in
+func (c *ChangeLogImpl) ChangeLogPath() string {
+	return c.changeLogPath
+}
+
+// snapshotPath returns the file the last uploaded content of an inventory type is persisted in
+func (c *ChangeLogImpl) snapshotPath(typeName string) string {
+	return filepath.Join(c.snapshotDir, strings.Replace(typeName, ":", "_", -1))
+}
+
+// DiffContent returns the entries added, removed and changed between two contents of an inventory type
+func DiffContent(typeName string, previous, current []map[string]*string) (change Change) {
+	change.TypeName = typeName
+	previousKeys, previousEntries := indexEntries(typeName, previous)
+	currentKeys, currentEntries := indexEntries(typeName, current)
+
+	count := 0
+	record := func() bool {
+		if count == maxEntriesPerChange {
+			change.Truncated = true
+			return false
+		}
+		count++
+		return true
+	}
+
+	for _, key := range currentKeys {
+		old, found := previousEntries[key]
+		if !found {
+			if record() {
+				change.Added = append(change.Added, currentEntries[key])
+			}
+		} else if !equalEntries(old, currentEntries[key]) {
+			if record() {
+				change.Changed = append(change.Changed, EntryChange{Key: key, Old: old, New: currentEntries[key]})
+			}
+		}
+	}
+	for _, key := range previousKeys {
+		if _, found := currentEntries[key]; !found {
+			if record() {
+				change.Removed = append(change.Removed, previousEntries[key])
+			}
+		}
+	}
+	return
+}
+
+// indexEntries returns the sorted identity keys of the entries of a content and the entries by key. Entries sharing an
+// identity, e.g. several installed versions of a package, are told apart by their order.
+func indexEntries(typeName string, content []map[string]*string) (keys []string, entries map[string]map[string]*string) {
+	attributes, found := identityAttributes[typeName]
+	if !found {
+		attributes = []string{"Name"}
+	}
+
+	entries = make(map[string]map[string]*string)
+	for _, entry := range content {
+		values := make([]string, 0, len(attributes))
+		for _, attribute := range attributes {
+			if value := entry[attribute]; value != nil {
+				values = append(values, *value)
+			} else {
+				values = append(values, "")
+			}
+		}
+		key := strings.Join(values, "/")
+		for occurrence := 2; ; occurrence++ {
+			if _, exists := entries[key]; !exists {
+				break
+			}
+			key = fmt.Sprintf("%v#%v", strings.Join(values, "/"), occurrence)
+		}
+		entries[key] = entry
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return
+}
+
+// equalEntries returns true if both entries have the same attributes with the same values
+func equalEntries(a, b map[string]*string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for attribute, value := range a {
+		other, found := b[attribute]
+		if !found || (value == nil) != (other == nil) || (value != nil && *value != *other) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,159 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package datauploader contains routines upload inventory data to SSM - Inventory service
+package datauploader
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/stretchr/testify/assert"
+)
+
+func applicationEntry(name, version string) map[string]*string {
+	return map[string]*string{"Name": aws.String(name), "Architecture": aws.String("x86_64"), "Version": aws.String(version)}
+}
+
+func inventoryItem(typeName, captureTime string, content ...map[string]*string) *ssm.InventoryItem {
+	return &ssm.InventoryItem{
+		TypeName:      aws.String(typeName),
+		CaptureTime:   aws.String(captureTime),
+		SchemaVersion: aws.String("1.0"),
+		Content:       append([]map[string]*string{}, content...),
+	}
+}
+
+func tempChangeLog(t *testing.T) (changeLog *ChangeLogImpl, cleanup func()) {
+	dir, err := ioutil.TempDir("", "changelog")
+	assert.Nil(t, err)
+	changeLog = &ChangeLogImpl{
+		log:           log.NewMockLog(),
+		snapshotDir:   filepath.Join(dir, "snapshot"),
+		changeLogPath: filepath.Join(dir, "changeLog"),
+	}
+	return changeLog, func() { os.RemoveAll(dir) }
+}
+
+func TestRecordUpload(t *testing.T) {
+	changeLog, cleanup := tempChangeLog(t)
+	defer cleanup()
+
+	//first upload is the baseline
+	assert.Nil(t, changeLog.RecordUpload([]*ssm.InventoryItem{
+		inventoryItem("AWS:Application", "2018-01-01T00:00:00Z", applicationEntry("bash", "4.2"), applicationEntry("openssl", "1.0.2k"), applicationEntry("vim", "7.4")),
+		inventoryItem("AWS:Network", "2018-01-01T00:00:00Z", map[string]*string{"Name": aws.String("eth0"), "IPV4": aws.String("10.0.0.1")}),
+	}))
+	changes, err := changeLog.GetChanges("", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(changes))
+
+	//items uploaded with only a content hash are unchanged
+	assert.Nil(t, changeLog.RecordUpload([]*ssm.InventoryItem{
+		inventoryItem("AWS:Application", "2018-01-02T00:00:00Z", applicationEntry("bash", "4.2"), applicationEntry("openssl", "1.0.2l"), applicationEntry("jq", "1.5")),
+		{TypeName: aws.String("AWS:Network"), CaptureTime: aws.String("2018-01-02T00:00:00Z"), ContentHash: aws.String("hash")},
+	}))
+	changes, err = changeLog.GetChanges("", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(changes))
+	change := changes[0]
+	assert.Equal(t, "AWS:Application", change.TypeName)
+	assert.Equal(t, "2018-01-02T00:00:00Z", change.CaptureTime)
+	assert.Equal(t, []map[string]*string{applicationEntry("jq", "1.5")}, change.Added)
+	assert.Equal(t, []map[string]*string{applicationEntry("vim", "7.4")}, change.Removed)
+	assert.Equal(t, 1, len(change.Changed))
+	assert.Equal(t, "1.0.2k", *change.Changed[0].Old["Version"])
+	assert.Equal(t, "1.0.2l", *change.Changed[0].New["Version"])
+
+	//identical content is not logged
+	assert.Nil(t, changeLog.RecordUpload([]*ssm.InventoryItem{
+		inventoryItem("AWS:Network", "2018-01-03T00:00:00Z", map[string]*string{"Name": aws.String("eth0"), "IPV4": aws.String("10.0.0.2")}),
+		inventoryItem("AWS:Application", "2018-01-03T00:00:00Z", applicationEntry("bash", "4.2"), applicationEntry("openssl", "1.0.2l"), applicationEntry("jq", "1.5")),
+	}))
+	changes, err = changeLog.GetChanges("", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(changes))
+	assert.Equal(t, "AWS:Network", changes[1].TypeName)
+	assert.Equal(t, 1, len(changes[1].Changed))
+
+	changes, err = changeLog.GetChanges("AWS:Application", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(changes))
+	changes, err = changeLog.GetChanges("", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "AWS:Network", changes[0].TypeName)
+}
+
+func TestRecordUploadKeepsMostRecentChanges(t *testing.T) {
+	changeLog, cleanup := tempChangeLog(t)
+	defer cleanup()
+
+	versions := []string{"1", "2"}
+	for i := 0; i <= maxChangeLogEntries+5; i++ {
+		assert.Nil(t, changeLog.RecordUpload([]*ssm.InventoryItem{
+			inventoryItem("AWS:Application", "time", applicationEntry("bash", versions[i%2])),
+		}))
+	}
+	changes, err := changeLog.GetChanges("", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, maxChangeLogEntries, len(changes))
+}
+
+func TestPendingChangesDoesNotRecordChanges(t *testing.T) {
+	changeLog, cleanup := tempChangeLog(t)
+	defer cleanup()
+
+	//a type that was never uploaded is entirely added
+	pending := changeLog.PendingChanges([]*ssm.InventoryItem{
+		inventoryItem("AWS:Application", "2018-01-01T00:00:00Z", applicationEntry("bash", "4.2")),
+	})
+	assert.Equal(t, 1, len(pending))
+	assert.Equal(t, 1, len(pending[0].Added))
+
+	assert.Nil(t, changeLog.RecordUpload([]*ssm.InventoryItem{
+		inventoryItem("AWS:Application", "2018-01-01T00:00:00Z", applicationEntry("bash", "4.2")),
+	}))
+	current := []*ssm.InventoryItem{
+		inventoryItem("AWS:Application", "2018-01-02T00:00:00Z", applicationEntry("bash", "4.3")),
+	}
+	pending = changeLog.PendingChanges(current)
+	assert.Equal(t, 1, len(pending))
+	assert.Equal(t, 1, len(pending[0].Changed))
+
+	//pending changes are neither logged nor kept as the last uploaded content
+	changes, err := changeLog.GetChanges("", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(changes))
+	assert.Equal(t, 1, len(changeLog.PendingChanges(current)))
+	assert.Empty(t, changeLog.PendingChanges([]*ssm.InventoryItem{
+		inventoryItem("AWS:Application", "2018-01-03T00:00:00Z", applicationEntry("bash", "4.2")),
+	}))
+}
+
+func TestDiffContentWithDuplicateIdentities(t *testing.T) {
+	previous := []map[string]*string{applicationEntry("kernel", "4.9.1"), applicationEntry("kernel", "4.9.2")}
+	current := []map[string]*string{applicationEntry("kernel", "4.9.1"), applicationEntry("kernel", "4.9.2"), applicationEntry("kernel", "4.9.3")}
+
+	change := DiffContent("AWS:Application", previous, current)
+	assert.Equal(t, 1, len(change.Added))
+	assert.Equal(t, 0, len(change.Removed))
+	assert.Equal(t, 0, len(change.Changed))
+
+	change = DiffContent("Custom:Unknown", []map[string]*string{{"Id": aws.String("1")}}, []map[string]*string{{"Id": aws.String("2")}})
+	assert.Equal(t, 1, len(change.Changed))
+}
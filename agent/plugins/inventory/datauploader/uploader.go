@@ -38,12 +38,14 @@ const (
 type T interface {
 	SendDataToSSM(context context.T, items []*ssm.InventoryItem) (err error)
 	ConvertToSsmInventoryItems(context context.T, items []model.Item) (optimizedInventoryItems, nonOptimizedInventoryItems []*ssm.InventoryItem, err error)
+	PendingChanges(context context.T, items []model.Item) (changes []Change, err error)
 }
 
 // InventoryUploader implements functionality to upload data to SSM Inventory.
 type InventoryUploader struct {
 	ssm       *ssm.SSM
-	optimizer Optimizer //helps inventory plugin to optimize PutInventory calls
+	optimizer Optimizer     //helps inventory plugin to optimize PutInventory calls
+	changeLog ChangeTracker //records the differences between uploads of an inventory type
 }
 
 // NewInventoryUploader creates a new InventoryUploader (which sends data to SSM Inventory)
@@ -84,6 +86,11 @@ func NewInventoryUploader(context context.T) (*InventoryUploader, error) {
 		return &uploader, err
 	}
 
+	if uploader.changeLog, err = NewChangeLogImpl(context); err != nil {
+		log.Errorf("Unable to load change log for inventory uploader because - %v", err.Error())
+		return &uploader, err
+	}
+
 	return &uploader, nil
 }
 
@@ -117,6 +124,13 @@ func (u *InventoryUploader) SendDataToSSM(context context.T, items []*ssm.Invent
 			log.Errorf("Encountered error while calling PutInventory API %v", err)
 		} else {
 			log.Debugf("PutInventory was called successfully with response - %v", resp)
+
+			//a failure to record the changes doesn't affect the upload
+			if u.changeLog != nil {
+				if changeLogErr := u.changeLog.RecordUpload(items); changeLogErr != nil {
+					log.Errorf("Unable to record inventory changes - %v", changeLogErr)
+				}
+			}
 		}
 	}
 
@@ -214,3 +228,34 @@ func (u *InventoryUploader) ConvertToSsmInventoryItems(context context.T, items
 
 	return
 }
+
+// PendingChanges returns the differences between the given inventory data and the data last uploaded to SSM, without
+// uploading the data or updating the content hash cache
+func (u *InventoryUploader) PendingChanges(context context.T, items []model.Item) (changes []Change, err error) {
+	var changedItems []*ssm.InventoryItem
+	for _, item := range items {
+		var dataB []byte
+		if dataB, err = json.Marshal(item.Content); err != nil {
+			return
+		}
+		if u.optimizer != nil && calculateCheckSum(dataB) == u.optimizer.GetContentHash(item.Name) {
+			continue
+		}
+
+		var inventoryItem *ssm.InventoryItem
+		if inventoryItem, err = ConvertToSSMInventoryItem(item); err != nil {
+			err = fmt.Errorf("formatting inventory data of %v failed due to %v", item.Name, err.Error())
+			return
+		}
+		changedItems = append(changedItems, inventoryItem)
+	}
+
+	if u.changeLog == nil {
+		//without the last uploaded content, only the changed types are known
+		for _, item := range changedItems {
+			changes = append(changes, Change{TypeName: *item.TypeName, CaptureTime: *item.CaptureTime})
+		}
+		return
+	}
+	return u.changeLog.PendingChanges(changedItems), nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package container contains a gatherer for the AWS:Container and AWS:ContainerImage inventory types.
+package container
+
+import (
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const (
+	// GathererName captures name of container gatherer
+	GathererName = "AWS:Container"
+	// ImageTypeName is the inventory type of the images collected by the container gatherer
+	ImageTypeName = "AWS:ContainerImage"
+	// SchemaVersionOfContainer represents schema version of container gatherer
+	SchemaVersionOfContainer = "1.0"
+)
+
+// T represents container gatherer which implements all contracts for gatherers.
+type T struct{}
+
+// decoupling for easy testability
+var collectData = CollectContainerData
+
+// Gatherer returns new container gatherer
+func Gatherer(context context.T) *T {
+	return new(T)
+}
+
+// Name returns name of container gatherer
+func (t *T) Name() string {
+	return GathererName
+}
+
+// Run executes container gatherer and returns an inventory.Item each for the containers and the images
+func (t *T) Run(context context.T, configuration model.Config) (items []model.Item, err error) {
+
+	var data Data
+
+	//CaptureTime must comply with format: 2016-07-30T18:15:37Z to comply with regex at SSM.
+	currentTime := time.Now().UTC()
+	captureTime := currentTime.Format(time.RFC3339)
+
+	if data, err = collectData(context); err != nil {
+		return
+	}
+
+	items = append(items, model.Item{
+		Name:          t.Name(),
+		SchemaVersion: SchemaVersionOfContainer,
+		Content:       data.Containers,
+		CaptureTime:   captureTime,
+	}, model.Item{
+		Name:          ImageTypeName,
+		SchemaVersion: SchemaVersionOfContainer,
+		Content:       data.Images,
+		CaptureTime:   captureTime,
+	})
+	return
+}
+
+// RequestStop stops the execution of container gatherer.
+func (t *T) RequestStop(stopType contracts.StopType) error {
+	var err error
+	return err
+}
@@ -0,0 +1,55 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package container
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var sampleData = Data{
+	Containers: []model.ContainerData{{Name: "web", ContainerId: "4c01db0b339c", Image: "nginx:1.13", State: "running", Status: "Up 2 hours", Ports: "0.0.0.0:80->80/tcp", RestartPolicy: "always"}},
+	Images:     []model.ContainerImageData{{Repository: "nginx", Tag: "1.13", ImageId: "sha256:3f8a4339aadd", Size: "108958610"}},
+}
+
+func MockContainerData(context context.T) (Data, error) {
+	return sampleData, nil
+}
+
+func MockContainerDataWithError(context context.T) (Data, error) {
+	return Data{}, errors.New("docker engine returned 500 Internal Server Error")
+}
+
+func TestGatherer(t *testing.T) {
+	c := context.NewMockDefault()
+	g := Gatherer(c)
+	collectData = MockContainerData
+	items, err := g.Run(c, model.Config{})
+	assert.Nil(t, err, "Unexpected error thrown")
+	assert.Equal(t, 2, len(items))
+	assert.Equal(t, GathererName, items[0].Name)
+	assert.Equal(t, SchemaVersionOfContainer, items[0].SchemaVersion)
+	assert.Equal(t, sampleData.Containers, items[0].Content)
+	assert.Equal(t, ImageTypeName, items[1].Name)
+	assert.Equal(t, sampleData.Images, items[1].Content)
+
+	collectData = MockContainerDataWithError
+	items, err = g.Run(c, model.Config{})
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, len(items))
+}
@@ -0,0 +1,336 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package container
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const (
+	// dockerHostEnv overrides the default Docker Engine API endpoint, e.g. unix:///var/run/docker.sock or tcp://127.0.0.1:2375
+	dockerHostEnv = "DOCKER_HOST"
+	// requestTimeout bounds every request to the Docker Engine API
+	requestTimeout = 30 * time.Second
+	// untagged is reported by docker for the repository and tag of dangling images
+	untagged = "<none>"
+)
+
+// Data is the container inventory of the system
+type Data struct {
+	Containers []model.ContainerData
+	Images     []model.ContainerImageData
+}
+
+// decoupling for easy testability
+var dockerHost = func() string {
+	if host := os.Getenv(dockerHostEnv); host != "" {
+		return host
+	}
+	return defaultDockerHost
+}
+
+// apiImage is an image listed by the Docker Engine API, only the reported fields are defined
+type apiImage struct {
+	Id          string
+	RepoTags    []string
+	RepoDigests []string
+	Created     int64
+	Size        int64
+}
+
+// apiPort is a port of a container listed by the Docker Engine API
+type apiPort struct {
+	IP          string
+	PrivatePort int
+	PublicPort  int
+	Type        string
+}
+
+// apiContainer is a container listed by the Docker Engine API, only the reported fields are defined
+type apiContainer struct {
+	Id      string
+	Names   []string
+	Image   string
+	ImageID string
+	State   string
+	Status  string
+	Ports   []apiPort
+	Created int64
+}
+
+// apiContainerDetail is the part of an inspected container that is not available in the container list
+type apiContainerDetail struct {
+	HostConfig struct {
+		RestartPolicy struct {
+			Name              string
+			MaximumRetryCount int
+		}
+	}
+}
+
+// engineUnavailableError is returned when no Docker Engine listens at the endpoint
+type engineUnavailableError struct {
+	host  string
+	cause error
+}
+
+func (e *engineUnavailableError) Error() string {
+	return fmt.Sprintf("docker engine is not available at %v: %v", e.host, e.cause)
+}
+
+// engineClient sends requests to the Docker Engine API
+type engineClient struct {
+	host    string
+	baseURL string
+	client  *http.Client
+}
+
+// newEngineClient creates a client for a unix socket or tcp endpoint of the Docker Engine API
+func newEngineClient(host string) (*engineClient, error) {
+	switch {
+	case strings.HasPrefix(host, "unix://"):
+		socketPath := strings.TrimPrefix(host, "unix://")
+		if _, err := os.Stat(socketPath); err != nil {
+			return nil, &engineUnavailableError{host: host, cause: err}
+		}
+		transport := &http.Transport{
+			Dial: func(network, address string) (net.Conn, error) {
+				return net.DialTimeout("unix", socketPath, requestTimeout)
+			},
+		}
+		return &engineClient{host: host, baseURL: "http://docker", client: &http.Client{Transport: transport, Timeout: requestTimeout}}, nil
+	case strings.HasPrefix(host, "tcp://"):
+		return &engineClient{host: host, baseURL: "http://" + strings.TrimPrefix(host, "tcp://"), client: &http.Client{Timeout: requestTimeout}}, nil
+	}
+	return nil, &engineUnavailableError{host: host, cause: fmt.Errorf("unsupported endpoint")}
+}
+
+// get decodes the JSON response of the Docker Engine API for the path into result
+func (c *engineClient) get(path string, result interface{}) error {
+	response, err := c.client.Get(c.baseURL + path)
+	if err != nil {
+		if _, ok := err.(*url.Error); ok {
+			return &engineUnavailableError{host: c.host, cause: err}
+		}
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("docker engine at %v returned %v for %v", c.host, response.Status, path)
+	}
+	return json.NewDecoder(response.Body).Decode(result)
+}
+
+// CollectContainerData collects the containers and images of the local Docker Engine.
+// Nothing is collected when the Docker Engine is not installed or not running.
+func CollectContainerData(context context.T) (data Data, err error) {
+	log := context.Log()
+	data = Data{Containers: []model.ContainerData{}, Images: []model.ContainerImageData{}}
+
+	var client *engineClient
+	if client, err = newEngineClient(dockerHost()); err == nil {
+		if data.Images, err = collectImages(client); err == nil {
+			data.Containers, err = collectContainers(context, client)
+		}
+	}
+
+	if _, ok := err.(*engineUnavailableError); ok {
+		log.Debugf("container inventory is empty, %v", err)
+		return Data{Containers: []model.ContainerData{}, Images: []model.ContainerImageData{}}, nil
+	} else if err != nil {
+		log.Errorf("failed to collect container inventory: %v", err)
+		return
+	}
+	log.Debugf("collected %v containers and %v images", len(data.Containers), len(data.Images))
+	return
+}
+
+// collectImages lists the images of the Docker Engine, an image is reported once per repository tag
+func collectImages(client *engineClient) ([]model.ContainerImageData, error) {
+	var images []apiImage
+	if err := client.get("/images/json", &images); err != nil {
+		return nil, err
+	}
+
+	result := []model.ContainerImageData{}
+	for _, image := range images {
+		entry := model.ContainerImageData{
+			Repository:  untagged,
+			Tag:         untagged,
+			ImageId:     image.Id,
+			Size:        strconv.FormatInt(image.Size, 10),
+			CreatedTime: formatTime(image.Created),
+		}
+
+		tagged := false
+		for _, repoTag := range image.RepoTags {
+			if repoTag == untagged+":"+untagged {
+				continue
+			}
+			tagged = true
+			entry.Repository, entry.Tag = splitRepoTag(repoTag)
+			entry.Digest = findDigest(image.RepoDigests, entry.Repository)
+			result = append(result, entry)
+		}
+		if !tagged {
+			// dangling images pulled by digest still report their repository
+			if len(image.RepoDigests) > 0 {
+				if i := strings.LastIndex(image.RepoDigests[0], "@"); i > 0 {
+					entry.Repository = image.RepoDigests[0][:i]
+					entry.Digest = image.RepoDigests[0][i+1:]
+				}
+			}
+			result = append(result, entry)
+		}
+	}
+	sort.Sort(byRepositoryTag(result))
+	return result, nil
+}
+
+// collectContainers lists the running and stopped containers of the Docker Engine with their restart policy
+func collectContainers(context context.T, client *engineClient) ([]model.ContainerData, error) {
+	log := context.Log()
+	var containers []apiContainer
+	if err := client.get("/containers/json?all=1", &containers); err != nil {
+		return nil, err
+	}
+
+	result := []model.ContainerData{}
+	for _, container := range containers {
+		entry := model.ContainerData{
+			ContainerId: container.Id,
+			Image:       container.Image,
+			ImageId:     container.ImageID,
+			State:       container.State,
+			Status:      container.Status,
+			Ports:       formatPorts(container.Ports),
+			CreatedTime: formatTime(container.Created),
+		}
+		if len(container.Names) > 0 {
+			entry.Name = strings.TrimPrefix(container.Names[0], "/")
+		}
+
+		// the container may be removed between the list and the inspect, its restart policy is then left empty
+		var detail apiContainerDetail
+		if err := client.get("/containers/"+container.Id+"/json", &detail); err != nil {
+			if _, ok := err.(*engineUnavailableError); ok {
+				return nil, err
+			}
+			log.Debugf("failed to inspect container %v: %v", entry.Name, err)
+		} else {
+			entry.RestartPolicy = formatRestartPolicy(detail.HostConfig.RestartPolicy.Name, detail.HostConfig.RestartPolicy.MaximumRetryCount)
+		}
+		result = append(result, entry)
+	}
+	sort.Sort(byName(result))
+	return result, nil
+}
+
+// splitRepoTag splits repository and tag of a reference, the repository may contain a registry port
+func splitRepoTag(repoTag string) (repository string, tag string) {
+	i := strings.LastIndex(repoTag, ":")
+	if i < 0 || strings.Contains(repoTag[i+1:], "/") {
+		return repoTag, untagged
+	}
+	return repoTag[:i], repoTag[i+1:]
+}
+
+// findDigest returns the digest of the repository among the repository digests of an image
+func findDigest(repoDigests []string, repository string) string {
+	for _, repoDigest := range repoDigests {
+		if strings.HasPrefix(repoDigest, repository+"@") {
+			return strings.TrimPrefix(repoDigest, repository+"@")
+		}
+	}
+	return ""
+}
+
+// formatPorts formats the ports of a container the way docker ps does, e.g. 0.0.0.0:8080->80/tcp, 443/tcp
+func formatPorts(ports []apiPort) string {
+	sort.Sort(byPort(ports))
+	formatted := make([]string, 0, len(ports))
+	for _, port := range ports {
+		if port.PublicPort != 0 {
+			formatted = append(formatted, fmt.Sprintf("%v:%v->%v/%v", port.IP, port.PublicPort, port.PrivatePort, port.Type))
+		} else {
+			formatted = append(formatted, fmt.Sprintf("%v/%v", port.PrivatePort, port.Type))
+		}
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// formatRestartPolicy formats a restart policy the way docker run accepts it, e.g. on-failure:3
+func formatRestartPolicy(name string, maximumRetryCount int) string {
+	if name == "" {
+		return "no"
+	}
+	if name == "on-failure" && maximumRetryCount > 0 {
+		return fmt.Sprintf("%v:%v", name, maximumRetryCount)
+	}
+	return name
+}
+
+// formatTime formats a unix timestamp of the Docker Engine API
+func formatTime(seconds int64) string {
+	return time.Unix(seconds, 0).UTC().Format(time.RFC3339)
+}
+
+// byName sorts containers by name
+type byName []model.ContainerData
+
+func (s byName) Len() int           { return len(s) }
+func (s byName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byName) Less(i, j int) bool { return s[i].Name < s[j].Name }
+
+// byRepositoryTag sorts images by repository, tag and id
+type byRepositoryTag []model.ContainerImageData
+
+func (s byRepositoryTag) Len() int      { return len(s) }
+func (s byRepositoryTag) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byRepositoryTag) Less(i, j int) bool {
+	if s[i].Repository != s[j].Repository {
+		return s[i].Repository < s[j].Repository
+	}
+	if s[i].Tag != s[j].Tag {
+		return s[i].Tag < s[j].Tag
+	}
+	return s[i].ImageId < s[j].ImageId
+}
+
+// byPort sorts the ports of a container by port, protocol and address
+type byPort []apiPort
+
+func (s byPort) Len() int      { return len(s) }
+func (s byPort) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byPort) Less(i, j int) bool {
+	if s[i].PrivatePort != s[j].PrivatePort {
+		return s[i].PrivatePort < s[j].PrivatePort
+	}
+	if s[i].Type != s[j].Type {
+		return s[i].Type < s[j].Type
+	}
+	return s[i].IP < s[j].IP
+}
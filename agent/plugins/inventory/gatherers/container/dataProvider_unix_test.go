@@ -0,0 +1,137 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build darwin freebsd linux netbsd openbsd
+
+package container
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var sampleResponses = map[string]string{
+	"/images/json": `[
+		{"Id": "sha256:3f8a4339aadd", "RepoTags": ["nginx:1.13", "registry.example.com:5000/web/nginx:stable"],
+		 "RepoDigests": ["nginx@sha256:b1d09e9718890e6ebbbd2bc319ef1611559e30ce1b6f56b2e3b479d9da51dc35"], "Created": 1507149924, "Size": 108958610},
+		{"Id": "sha256:5a6d8f2fa0e4", "RepoTags": ["<none>:<none>"],
+		 "RepoDigests": ["alpine@sha256:7b848083f93822dd21b0a2f14a110bd99f6efb4b838d499df6d04a49d0debf8b"], "Created": 1507149924, "Size": 4148415},
+		{"Id": "sha256:0d0e2c0d9c8f", "RepoTags": null, "RepoDigests": null, "Created": 1507149924, "Size": 1024}
+	]`,
+	"/containers/json?all=1": `[
+		{"Id": "4c01db0b339c", "Names": ["/web"], "Image": "nginx:1.13", "ImageID": "sha256:3f8a4339aadd", "State": "running", "Status": "Up 2 hours",
+		 "Ports": [{"PrivatePort": 443, "Type": "tcp"}, {"IP": "0.0.0.0", "PrivatePort": 80, "PublicPort": 8080, "Type": "tcp"}], "Created": 1507149924},
+		{"Id": "9b2e1f0c7d44", "Names": ["/batch"], "Image": "alpine", "ImageID": "sha256:5a6d8f2fa0e4", "State": "exited", "Status": "Exited (0) 3 days ago",
+		 "Ports": [], "Created": 1507149924},
+		{"Id": "aa3c5d6e7f80", "Names": ["/removed"], "Image": "alpine", "ImageID": "sha256:5a6d8f2fa0e4", "State": "dead", "Status": "Dead", "Created": 1507149924}
+	]`,
+	"/containers/4c01db0b339c/json": `{"HostConfig": {"RestartPolicy": {"Name": "always", "MaximumRetryCount": 0}}}`,
+	"/containers/9b2e1f0c7d44/json": `{"HostConfig": {"RestartPolicy": {"Name": "on-failure", "MaximumRetryCount": 3}}}`,
+}
+
+// startEngine serves the sample responses of the Docker Engine API on a unix socket in a temporary directory
+func startEngine(t *testing.T, handler http.HandlerFunc) (host string, cleanup func()) {
+	dir, err := ioutil.TempDir("", "docker")
+	assert.Nil(t, err)
+	socketPath := filepath.Join(dir, "docker.sock")
+	listener, err := net.Listen("unix", socketPath)
+	assert.Nil(t, err)
+
+	server := httptest.NewUnstartedServer(handler)
+	server.Listener = listener
+	server.Start()
+	return "unix://" + socketPath, func() {
+		server.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func sampleEngine(w http.ResponseWriter, r *http.Request) {
+	if response, ok := sampleResponses[r.URL.RequestURI()]; ok {
+		w.Write([]byte(response))
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func TestCollectContainerData(t *testing.T) {
+	host, cleanup := startEngine(t, sampleEngine)
+	defer cleanup()
+	dockerHost = func() string { return host }
+
+	data, err := CollectContainerData(context.NewMockDefault())
+	assert.Nil(t, err)
+
+	assert.Equal(t, []model.ContainerData{
+		{Name: "batch", ContainerId: "9b2e1f0c7d44", Image: "alpine", ImageId: "sha256:5a6d8f2fa0e4", State: "exited", Status: "Exited (0) 3 days ago",
+			RestartPolicy: "on-failure:3", CreatedTime: "2017-10-04T20:45:24Z"},
+		{Name: "removed", ContainerId: "aa3c5d6e7f80", Image: "alpine", ImageId: "sha256:5a6d8f2fa0e4", State: "dead", Status: "Dead",
+			CreatedTime: "2017-10-04T20:45:24Z"},
+		{Name: "web", ContainerId: "4c01db0b339c", Image: "nginx:1.13", ImageId: "sha256:3f8a4339aadd", State: "running", Status: "Up 2 hours",
+			Ports: "0.0.0.0:8080->80/tcp, 443/tcp", RestartPolicy: "always", CreatedTime: "2017-10-04T20:45:24Z"},
+	}, data.Containers)
+
+	assert.Equal(t, []model.ContainerImageData{
+		{Repository: "<none>", Tag: "<none>", ImageId: "sha256:0d0e2c0d9c8f", Size: "1024", CreatedTime: "2017-10-04T20:45:24Z"},
+		{Repository: "alpine", Tag: "<none>", ImageId: "sha256:5a6d8f2fa0e4", Digest: "sha256:7b848083f93822dd21b0a2f14a110bd99f6efb4b838d499df6d04a49d0debf8b",
+			Size: "4148415", CreatedTime: "2017-10-04T20:45:24Z"},
+		{Repository: "nginx", Tag: "1.13", ImageId: "sha256:3f8a4339aadd", Digest: "sha256:b1d09e9718890e6ebbbd2bc319ef1611559e30ce1b6f56b2e3b479d9da51dc35",
+			Size: "108958610", CreatedTime: "2017-10-04T20:45:24Z"},
+		{Repository: "registry.example.com:5000/web/nginx", Tag: "stable", ImageId: "sha256:3f8a4339aadd", Size: "108958610", CreatedTime: "2017-10-04T20:45:24Z"},
+	}, data.Images)
+}
+
+func TestCollectContainerDataWithoutEngine(t *testing.T) {
+	dockerHost = func() string { return "unix:///nonexistent/docker.sock" }
+	data, err := CollectContainerData(context.NewMockDefault())
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(data.Containers))
+	assert.Equal(t, 0, len(data.Images))
+
+	// the socket of a stopped engine remains
+	host, cleanup := startEngine(t, sampleEngine)
+	cleanup()
+	dockerHost = func() string { return host }
+	data, err = CollectContainerData(context.NewMockDefault())
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(data.Containers))
+}
+
+func TestCollectContainerDataEngineError(t *testing.T) {
+	host, cleanup := startEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	defer cleanup()
+	dockerHost = func() string { return host }
+
+	_, err := CollectContainerData(context.NewMockDefault())
+	assert.NotNil(t, err)
+}
+
+func TestSplitRepoTag(t *testing.T) {
+	repository, tag := splitRepoTag("registry.example.com:5000/nginx")
+	assert.Equal(t, "registry.example.com:5000/nginx", repository)
+	assert.Equal(t, "<none>", tag)
+	repository, tag = splitRepoTag("registry.example.com:5000/nginx:1.13")
+	assert.Equal(t, "registry.example.com:5000/nginx", repository)
+	assert.Equal(t, "1.13", tag)
+}
@@ -24,6 +24,7 @@ import (
 	"reflect"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
@@ -54,7 +55,10 @@ const (
 )
 
 // T represents custom gatherer
-type T struct{}
+type T struct {
+	lock sync.Mutex
+	stop chan struct{} //closed when the running collection is requested to stop
+}
 
 // Gatherer returns a new custom gatherer
 func Gatherer(context context.T) *T {
@@ -128,18 +132,7 @@ func (t *T) Run(context context.T, configuration model.Config) (items []model.It
 	for _, filePath := range fileList {
 
 		if customItem, err := getItemFromFile(log, filePath); err == nil {
-
-			if _, ok := setTypeName[customItem.Name]; ok {
-				err = fmt.Errorf("Custom inventory typeName (%v) from file (%v) already exists,"+
-					" i.e., other file under the same folder contains the same typeName,"+
-					" please remove duplicate custom inventory file.",
-					customItem.Name, filePath)
-				LogError(log, err)
-			} else {
-				// Only append if current TypeName is not duplicate
-				setTypeName[customItem.Name] = true
-				items = append(items, customItem)
-			}
+			items = appendItem(log, items, setTypeName, customItem, filePath)
 		} else {
 			LogError(log,
 				fmt.Errorf("Failed to get item from file %v, error %v. continue...", filePath, err))
@@ -147,6 +140,38 @@ func (t *T) Run(context context.T, configuration model.Config) (items []model.It
 		}
 	}
 
+	// Get custom inventory items written by executables, so they are produced at collection time
+	executableFolder := filepath.Join(customFolder, ExecutableDirName)
+	executableList, err := getExecutablePaths(log, executableFolder)
+	if err != nil {
+		LogError(
+			log,
+			fmt.Errorf("Failed to get inventory executables from folder %v, error %v", executableFolder, err))
+		return
+	}
+
+	timeout := time.Duration(context.AppConfig().Inventory.CustomTimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = appconfig.DefaultInventoryCustomTimeoutSeconds * time.Second
+	}
+	stop := t.startCollection()
+	for _, executablePath := range executableList {
+		select {
+		case <-stop:
+			err = fmt.Errorf("Custom gatherer was stopped before running %v", executablePath)
+			LogError(log, err)
+			return
+		default:
+		}
+
+		if customItem, err := getItemFromExecutable(log, executablePath, timeout, stop); err == nil {
+			items = appendItem(log, items, setTypeName, customItem, executablePath)
+		} else {
+			LogError(log,
+				fmt.Errorf("Failed to get item from executable %v, error %v. continue...", executablePath, err))
+		}
+	}
+
 	count := len(items)
 	log.Debugf("Count of custom inventory items : %v.", count)
 	if count == 0 {
@@ -155,13 +180,41 @@ func (t *T) Run(context context.T, configuration model.Config) (items []model.It
 	return
 }
 
-// RequestStop stops the execution of custom gatherer
+// RequestStop stops the execution of custom gatherer, the running executable is killed and the remaining ones are skipped
 func (t *T) RequestStop(stopType contracts.StopType) error {
-	//TODO: set a stop flag so Run thread would stop when flag is set to true
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.stop != nil {
+		close(t.stop)
+		t.stop = nil
+	}
 	var err error
 	return err
 }
 
+// startCollection returns the channel closed when the collection that starts is requested to stop
+func (t *T) startCollection() <-chan struct{} {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.stop = make(chan struct{})
+	return t.stop
+}
+
+// appendItem appends a custom inventory item unless an item of the same TypeName was already collected
+func appendItem(log log.T, items []model.Item, setTypeName map[string]bool, customItem model.Item, source string) []model.Item {
+	if _, ok := setTypeName[customItem.Name]; ok {
+		err := fmt.Errorf("Custom inventory typeName (%v) from %v already exists,"+
+			" i.e., other file or executable under the same folder contains the same typeName,"+
+			" please remove duplicate custom inventory file.",
+			customItem.Name, source)
+		LogError(log, err)
+		return items
+	}
+	// Only append if current TypeName is not duplicate
+	setTypeName[customItem.Name] = true
+	return append(items, customItem)
+}
+
 // getItemFromFile Reads one custom inventory file
 func getItemFromFile(log log.T, file string) (result model.Item, err error) {
 
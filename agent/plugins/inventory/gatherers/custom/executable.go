@@ -0,0 +1,141 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package custom contains a gatherer for collecting custom inventory items
+package custom
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const (
+	// ExecutableDirName is the sub folder of the custom inventory folder with executables writing custom inventory items
+	ExecutableDirName = "executables"
+	// ExecutableOutputLimit is the size limit of the output of an executable in bytes
+	ExecutableOutputLimit = model.SizeLimitKBPerInventoryType * 1024
+	// executableStderrLimit is the size of the error output of a failed executable that is logged
+	executableStderrLimit = 1024
+)
+
+// decoupling for easy testability
+var executableCommand = newExecutableCommand
+
+// limitedBuffer is a buffer that discards what is written beyond its limit
+type limitedBuffer struct {
+	bytes.Buffer
+	limit    int
+	exceeded bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - b.Len(); len(p) > remaining {
+		b.exceeded = true
+		if remaining > 0 {
+			b.Buffer.Write(p[:remaining])
+		}
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+// getExecutablePaths returns the executables under the given folder, missing folders are ignored
+func getExecutablePaths(log log.T, folder string) (executablePathList []string, err error) {
+	files, readDirError := readDirFunc(folder)
+	if readDirError != nil {
+		log.Debugf("No custom inventory executables, read directory %v failed, error: %v", folder, readDirError)
+		return []string{}, nil
+	}
+
+	for _, f := range files {
+		path := filepath.Clean(filepath.Join(folder, f.Name()))
+		if err := isTrustedExecutable(path, f); err != nil {
+			log.Debugf("Skipping %v - %v", path, err)
+			continue
+		}
+		executablePathList = append(executablePathList, path)
+	}
+
+	if len(executablePathList) > CustomInventoryCountLimit {
+		err = fmt.Errorf("Total custom inventory executable count (%v) exceed limit (%v)",
+			len(executablePathList), CustomInventoryCountLimit)
+		LogError(log, err)
+		return nil, err
+	}
+
+	log.Debugf("Total custom (%v) inventory executables", len(executablePathList))
+	return
+}
+
+// getItemFromExecutable runs one custom inventory executable and converts its output to an inventory item.
+// The executable is killed with its child processes if it doesn't complete within the timeout or stop is closed.
+func getItemFromExecutable(log log.T, executable string, timeout time.Duration, stop <-chan struct{}) (result model.Item, err error) {
+	command := executableCommand(executable)
+	command.Dir = filepath.Dir(executable)
+	stdout := &limitedBuffer{limit: ExecutableOutputLimit}
+	stderr := &limitedBuffer{limit: executableStderrLimit}
+	command.Stdout = stdout
+	command.Stderr = stderr
+	prepareProcess(command)
+
+	log.Debugf("Running custom inventory executable %v", executable)
+	if err = command.Start(); err != nil {
+		err = fmt.Errorf("Failed to start custom inventory executable %v, error: %v", executable, err)
+		LogError(log, err)
+		return
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- command.Wait()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err = <-done:
+	case <-timer.C:
+		killProcess(command)
+		<-done
+		err = fmt.Errorf("Custom inventory executable %v did not complete within %v", executable, timeout)
+		LogError(log, err)
+		return
+	case <-stop:
+		killProcess(command)
+		<-done
+		err = fmt.Errorf("Custom inventory executable %v was stopped", executable)
+		LogError(log, err)
+		return
+	}
+
+	if err != nil {
+		err = fmt.Errorf("Custom inventory executable %v failed, error: %v, output: %v", executable, err, stderr.String())
+		LogError(log, err)
+		return
+	}
+	if stdout.exceeded {
+		err = fmt.Errorf("Output of custom inventory executable %v exceeded the limit: %v bytes", executable, ExecutableOutputLimit)
+		LogError(log, err)
+		return
+	}
+
+	if result, err = convertToItem(log, stdout.Bytes()); err != nil {
+		LogError(log, fmt.Errorf("Failed to convert output of %v to inventory item, error: %v",
+			executable, err))
+	}
+	return
+}
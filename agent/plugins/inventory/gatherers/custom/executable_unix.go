@@ -0,0 +1,80 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build darwin freebsd linux netbsd openbsd
+
+package custom
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+)
+
+// newExecutableCommand returns the command running an executable
+func newExecutableCommand(executable string) *exec.Cmd {
+	return exec.Command(executable)
+}
+
+// isTrustedExecutable returns error unless the file is an executable that only root or the agent user can modify,
+// executables run with the privileges of the agent
+func isTrustedExecutable(path string, file os.FileInfo) error {
+	mode := file.Mode()
+	if !mode.IsRegular() {
+		return errors.New("not a regular file")
+	}
+	if mode&0111 == 0 {
+		return errors.New("not executable")
+	}
+	if err := isTrustedOwnerAndMode(file); err != nil {
+		return err
+	}
+	// whoever can write to the folder can replace the executable
+	folder, err := os.Stat(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	if err := isTrustedOwnerAndMode(folder); err != nil {
+		return fmt.Errorf("folder %v", err)
+	}
+	return nil
+}
+
+// isTrustedOwnerAndMode returns error unless the file or folder is owned by root or the agent user and is not
+// writable by group or others
+func isTrustedOwnerAndMode(file os.FileInfo) error {
+	if file.Mode()&0022 != 0 {
+		return errors.New("writable by group or others")
+	}
+	stat, ok := file.Sys().(*syscall.Stat_t)
+	if !ok {
+		return errors.New("unknown owner")
+	}
+	if stat.Uid != 0 && int(stat.Uid) != os.Geteuid() {
+		return errors.New("not owned by root or the agent user")
+	}
+	return nil
+}
+
+func prepareProcess(command *exec.Cmd) {
+	// make the process the leader of its process group so its child processes are killed with it
+	command.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+func killProcess(command *exec.Cmd) {
+	// '-pid' sends the signal to every process in the process group of the executable
+	syscall.Kill(-command.Process.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,113 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build darwin freebsd linux netbsd openbsd
+
+package custom
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+const validExecutableOutput = `#!/bin/sh
+echo '{"TypeName": "Custom:Executable", "SchemaVersion": "1.0", "Content": [{"Name": "a"}]}'
+`
+
+func writeExecutable(t *testing.T, dir string, name string, script string, mode os.FileMode) string {
+	path := filepath.Join(dir, name)
+	assert.Nil(t, ioutil.WriteFile(path, []byte(script), mode))
+	assert.Nil(t, os.Chmod(path, mode))
+	return path
+}
+
+func TestGetItemFromExecutable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "customexecutable")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := writeExecutable(t, dir, "valid.sh", validExecutableOutput, 0755)
+
+	item, err := getItemFromExecutable(log.NewMockLog(), path, time.Minute, make(chan struct{}))
+	assert.Nil(t, err)
+	assert.Equal(t, "Custom:Executable", item.Name)
+	assert.Equal(t, "1.0", item.SchemaVersion)
+}
+
+func TestGetItemFromExecutableInvalidOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "customexecutable")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	invalid := writeExecutable(t, dir, "invalid.sh", "#!/bin/sh\necho '{\"TypeName\": '\n", 0755)
+	failing := writeExecutable(t, dir, "failing.sh", "#!/bin/sh\necho failed >&2\nexit 3\n", 0755)
+
+	_, err = getItemFromExecutable(log.NewMockLog(), invalid, time.Minute, make(chan struct{}))
+	assert.NotNil(t, err)
+	_, err = getItemFromExecutable(log.NewMockLog(), failing, time.Minute, make(chan struct{}))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "failed")
+}
+
+func TestGetItemFromExecutableTimeout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "customexecutable")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := writeExecutable(t, dir, "slow.sh", "#!/bin/sh\nsleep 30\n", 0755)
+
+	start := time.Now()
+	_, err = getItemFromExecutable(log.NewMockLog(), path, 200*time.Millisecond, make(chan struct{}))
+	assert.NotNil(t, err)
+	assert.True(t, time.Since(start) < 10*time.Second, "executable wasn't killed on timeout")
+
+	stop := make(chan struct{})
+	close(stop)
+	_, err = getItemFromExecutable(log.NewMockLog(), path, time.Minute, stop)
+	assert.NotNil(t, err)
+}
+
+func TestGetExecutablePathsSkipsUntrustedFiles(t *testing.T) {
+	readDirFunc = ReadDir
+	dir, err := ioutil.TempDir("", "customexecutable")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	trusted := writeExecutable(t, dir, "trusted.sh", validExecutableOutput, 0755)
+	writeExecutable(t, dir, "writable.sh", validExecutableOutput, 0775)
+	writeExecutable(t, dir, "notexecutable.sh", validExecutableOutput, 0644)
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, "folder"), 0755))
+
+	paths, err := getExecutablePaths(log.NewMockLog(), dir)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{trusted}, paths)
+
+	paths, err = getExecutablePaths(log.NewMockLog(), filepath.Join(dir, "missing"))
+	assert.Nil(t, err)
+	assert.Empty(t, paths)
+}
+
+func TestGetExecutablePathsSkipsFilesOfWritableFolders(t *testing.T) {
+	readDirFunc = ReadDir
+	dir, err := ioutil.TempDir("", "customexecutable")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	writeExecutable(t, dir, "trusted.sh", validExecutableOutput, 0755)
+	assert.Nil(t, os.Chmod(dir, 0777))
+
+	paths, err := getExecutablePaths(log.NewMockLog(), dir)
+	assert.Nil(t, err)
+	assert.Empty(t, paths)
+}
@@ -0,0 +1,167 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build windows
+
+package custom
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	aclapi "github.com/hectane/go-acl/api"
+	"golang.org/x/sys/windows"
+)
+
+const (
+	accessAllowedAceType = 0
+	inheritOnlyAce       = 0x8
+
+	// writeAccessMask are the rights that allow changing a file, or adding, replacing and deleting files of a folder:
+	// FILE_WRITE_DATA, FILE_APPEND_DATA, FILE_WRITE_EA, FILE_DELETE_CHILD, FILE_WRITE_ATTRIBUTES, DELETE, WRITE_DAC,
+	// WRITE_OWNER, GENERIC_ALL and GENERIC_WRITE
+	writeAccessMask = 0x2 | 0x4 | 0x10 | 0x40 | 0x100 | 0x10000 | 0x40000 | 0x80000 | 0x10000000 | 0x40000000
+)
+
+var (
+	procGetAce = syscall.NewLazyDLL("advapi32.dll").NewProc("GetAce")
+
+	// trustedSids are the accounts that may own and modify executables, the executables run as the agent which runs as
+	// LocalSystem: Administrators, LocalSystem and TrustedInstaller
+	trustedSids = map[string]bool{
+		"S-1-5-32-544": true,
+		"S-1-5-18":     true,
+		"S-1-5-80-956008885-3418522649-1831038044-1853292631-2271478464": true,
+	}
+)
+
+// acl is the header of an access control list, see ACL
+type acl struct {
+	AclRevision byte
+	Sbz1        byte
+	AclSize     uint16
+	AceCount    uint16
+	Sbz2        uint16
+}
+
+// accessAllowedAce is an access allowed entry of an access control list, the sid starts at SidStart,
+// see ACCESS_ALLOWED_ACE
+type accessAllowedAce struct {
+	AceType  byte
+	AceFlags byte
+	AceSize  uint16
+	Mask     uint32
+	SidStart uint32
+}
+
+// executableExtensions are the extensions of the files run as custom inventory executables
+var executableExtensions = map[string]bool{
+	".exe": true,
+	".bat": true,
+	".cmd": true,
+	".ps1": true,
+}
+
+// newExecutableCommand returns the command running an executable, powershell scripts are run by powershell
+func newExecutableCommand(executable string) *exec.Cmd {
+	if strings.ToLower(filepath.Ext(executable)) == ".ps1" {
+		return exec.Command(appconfig.PowerShellPluginCommandName, "-NoProfile", "-NonInteractive", "-ExecutionPolicy", "Bypass", "-File", executable)
+	}
+	return exec.Command(executable)
+}
+
+// isTrustedExecutable returns error unless the file is an executable or a script that only Administrators and
+// LocalSystem can modify, in a folder only they can modify.  Executables run with the privileges of the agent and
+// powershell scripts run with the execution policy bypassed, so their signature isn't checked either.
+func isTrustedExecutable(path string, file os.FileInfo) error {
+	if !file.Mode().IsRegular() {
+		return errors.New("not a regular file")
+	}
+	if !executableExtensions[strings.ToLower(filepath.Ext(file.Name()))] {
+		return errors.New("not an executable or a script")
+	}
+	if err := isTrustedObject(path); err != nil {
+		return err
+	}
+	if err := isTrustedObject(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("folder %v", err)
+	}
+	return nil
+}
+
+// isTrustedObject returns error unless the file or folder is owned by a trusted account and its access control list
+// only allows trusted accounts to modify it
+func isTrustedObject(path string) error {
+	var owner *windows.SID
+	var dacl *acl
+	var securityDescriptor windows.Handle
+	if err := aclapi.GetNamedSecurityInfo(
+		path,
+		aclapi.SE_FILE_OBJECT,
+		aclapi.OWNER_SECURITY_INFORMATION|aclapi.DACL_SECURITY_INFORMATION,
+		&owner,
+		nil,
+		(*windows.Handle)(unsafe.Pointer(&dacl)),
+		nil,
+		&securityDescriptor,
+	); err != nil {
+		return fmt.Errorf("unable to read the security information, %v", err)
+	}
+	defer windows.LocalFree(securityDescriptor)
+
+	if !isTrustedSid(owner) {
+		return errors.New("not owned by Administrators or LocalSystem")
+	}
+	if dacl == nil {
+		// a null access control list grants everyone full access
+		return errors.New("writable by everyone")
+	}
+	for i := uint16(0); i < dacl.AceCount; i++ {
+		var ace *accessAllowedAce
+		if ret, _, err := procGetAce.Call(uintptr(unsafe.Pointer(dacl)), uintptr(i), uintptr(unsafe.Pointer(&ace))); ret == 0 {
+			return fmt.Errorf("unable to read the access control list, %v", err)
+		}
+		// inherit only entries apply to the children of a folder, which are checked themselves
+		if ace.AceType != accessAllowedAceType || ace.AceFlags&inheritOnlyAce != 0 || ace.Mask&writeAccessMask == 0 {
+			continue
+		}
+		if !isTrustedSid((*windows.SID)(unsafe.Pointer(&ace.SidStart))) {
+			return errors.New("writable by accounts other than Administrators or LocalSystem")
+		}
+	}
+	return nil
+}
+
+// isTrustedSid returns true if the sid is one of the trusted accounts
+func isTrustedSid(sid *windows.SID) bool {
+	if sid == nil {
+		return false
+	}
+	value, err := sid.String()
+	return err == nil && trustedSids[value]
+}
+
+func prepareProcess(command *exec.Cmd) {
+	// nothing to do on windows
+}
+
+func killProcess(command *exec.Cmd) {
+	command.Process.Kill()
+}
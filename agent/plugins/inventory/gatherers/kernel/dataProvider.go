@@ -0,0 +1,84 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package kernel
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+// Data is the kernel inventory of the system
+type Data struct {
+	Kernel  []model.KernelData
+	Modules []model.KernelModuleData
+	Sysctl  []model.SysctlData
+}
+
+// CollectKernelData collects the running kernel, its loaded modules and the kernel parameters matching the filters.
+// Filters select kernel parameters by name with shell patterns, either as a JSON array or comma separated, e.g.
+//   ["net.ipv4.*", "kernel.randomize_va_space"]
+//   net.ipv4.*,kernel.randomize_va_space
+// Every parameter is collected without filters.
+func CollectKernelData(context context.T, config model.Config) (data Data, err error) {
+	log := context.Log()
+
+	var patterns []string
+	if patterns, err = parseSysctlFilters(config.Filters); err != nil {
+		log.Error(err)
+		return
+	}
+	return collectPlatformDependentKernelData(context, patterns), nil
+}
+
+// parseSysctlFilters returns the validated name patterns of the filters
+func parseSysctlFilters(filters string) (patterns []string, err error) {
+	filters = strings.TrimSpace(filters)
+	if strings.HasPrefix(filters, "[") {
+		if err = json.Unmarshal([]byte(filters), &patterns); err != nil {
+			return nil, fmt.Errorf("Invalid %v filters %v: %v", GathererName, filters, err)
+		}
+	} else if filters != "" {
+		patterns = strings.Split(filters, ",")
+	}
+
+	result := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		if pattern = strings.TrimSpace(pattern); pattern == "" {
+			continue
+		}
+		if _, err = path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("Invalid %v filter pattern %v: %v", GathererName, pattern, err)
+		}
+		result = append(result, pattern)
+	}
+	return result, nil
+}
+
+// matchesAnyPattern returns true if the parameter name matches one of the patterns, or if there are none
+func matchesAnyPattern(patterns []string, name string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
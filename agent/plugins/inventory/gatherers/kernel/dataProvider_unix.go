@@ -0,0 +1,157 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build darwin freebsd linux netbsd openbsd
+
+package kernel
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const modinfoCmd = "modinfo"
+
+var (
+	procDir      = "/proc"
+	sysModuleDir = "/sys/module"
+)
+
+// decoupling exec.Command for easy testability
+var cmdExecutor = executeCommand
+
+func executeCommand(command string, args ...string) ([]byte, error) {
+	return exec.Command(command, args...).Output()
+}
+
+// collectPlatformDependentKernelData collects kernel data from procfs and sysfs.
+func collectPlatformDependentKernelData(context context.T, patterns []string) Data {
+	log := context.Log()
+	data := Data{
+		Kernel:  []model.KernelData{},
+		Modules: []model.KernelModuleData{},
+		Sysctl:  []model.SysctlData{},
+	}
+
+	release, err := readValue(filepath.Join(procDir, "sys", "kernel", "osrelease"))
+	if err != nil {
+		log.Errorf("Failed to read the kernel release, procfs is not available: %v", err)
+		return data
+	}
+	kernel := model.KernelData{Release: release}
+	kernel.Name, _ = readValue(filepath.Join(procDir, "sys", "kernel", "ostype"))
+	kernel.Version, _ = readValue(filepath.Join(procDir, "sys", "kernel", "version"))
+	kernel.CommandLine, _ = readValue(filepath.Join(procDir, "cmdline"))
+	kernel.Tainted, _ = readValue(filepath.Join(procDir, "sys", "kernel", "tainted"))
+	data.Kernel = append(data.Kernel, kernel)
+
+	if content, err := ioutil.ReadFile(filepath.Join(procDir, "modules")); err != nil {
+		log.Debugf("No loaded kernel modules: %v", err)
+	} else {
+		data.Modules = parseModules(log, string(content))
+	}
+
+	data.Sysctl = collectSysctl(log, patterns)
+	return data
+}
+
+// readValue returns the trimmed content of a procfs or sysfs file
+func readValue(path string) (string, error) {
+	content, err := ioutil.ReadFile(path)
+	return strings.TrimSpace(string(content)), err
+}
+
+// parseModules parses /proc/modules and adds the version and signature of each module, e.g.
+//   xfs 1236992 1 - Live 0x0000000000000000
+//   nvidia 35323904 2 nvidia_modeset,nvidia_uvm, Live 0x0000000000000000 (POE)
+func parseModules(log log.T, content string) []model.KernelModuleData {
+	modules := make([]model.KernelModuleData, 0)
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 {
+			continue
+		}
+		module := model.KernelModuleData{
+			Name:  fields[0],
+			Size:  fields[1],
+			State: fields[4],
+		}
+		if fields[3] != "-" {
+			module.UsedBy = strings.TrimSuffix(fields[3], ",")
+		}
+		if last := fields[len(fields)-1]; len(fields) > 6 && strings.HasPrefix(last, "(") {
+			module.TaintFlags = strings.Trim(last, "()")
+		}
+		module.Version, _ = readValue(filepath.Join(sysModuleDir, module.Name, "version"))
+		setSignature(log, &module)
+		modules = append(modules, module)
+	}
+	return modules
+}
+
+// setSignature sets the signer of a module, modules loaded without a valid signature taint the kernel with E
+func setSignature(log log.T, module *model.KernelModuleData) {
+	if strings.Contains(module.TaintFlags, "E") {
+		module.Signed = "false"
+		return
+	}
+	output, err := cmdExecutor(modinfoCmd, "-F", "signer", module.Name)
+	if err != nil {
+		log.Debugf("Signature of kernel module %v is unknown: %v", module.Name, err)
+		return
+	}
+	module.Signer = strings.TrimSpace(string(output))
+	module.Signed = "false"
+	if module.Signer != "" {
+		module.Signed = "true"
+	}
+}
+
+// collectSysctl reads the readable kernel parameters under /proc/sys whose name matches the patterns
+func collectSysctl(log log.T, patterns []string) []model.SysctlData {
+	parameters := make([]model.SysctlData, 0)
+	root := filepath.Join(procDir, "sys")
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			log.Debugf("Failed to read kernel parameters in %v: %v", path, err)
+			return nil
+		}
+		// write only parameters like vm.drop_caches trigger actions
+		if info.IsDir() || info.Mode()&0444 == 0 {
+			return nil
+		}
+		relativePath, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		name := strings.Replace(relativePath, string(filepath.Separator), ".", -1)
+		if !matchesAnyPattern(patterns, name) {
+			return nil
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.Debugf("Failed to read kernel parameter %v: %v", name, err)
+			return nil
+		}
+		parameters = append(parameters, model.SysctlData{Name: name, Value: strings.Join(strings.Fields(string(content)), " ")})
+		return nil
+	})
+	return parameters
+}
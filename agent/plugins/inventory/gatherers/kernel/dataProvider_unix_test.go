@@ -0,0 +1,127 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build darwin freebsd linux netbsd openbsd
+
+package kernel
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+const sampleModules = `xfs 1236992 1 - Live 0x0000000000000000
+libcrc32c 16384 2 xfs,nf_conntrack, Live 0x0000000000000000
+vboxdrv 483328 0 - Live 0x0000000000000000 (OE)
+`
+
+// setupProc creates a procfs and sysfs layout in a temporary directory
+func setupProc(t *testing.T) (cleanup func()) {
+	dir, err := ioutil.TempDir("", "kernelgatherer")
+	assert.Nil(t, err)
+	savedProc, savedSys := procDir, sysModuleDir
+	procDir = filepath.Join(dir, "proc")
+	sysModuleDir = filepath.Join(dir, "sys", "module")
+
+	files := map[string]string{
+		"proc/sys/kernel/osrelease":             "4.9.51-10.52.amzn1.x86_64\n",
+		"proc/sys/kernel/ostype":                "Linux\n",
+		"proc/sys/kernel/version":               "#1 SMP Fri Sep 29 01:51:51 UTC 2017\n",
+		"proc/sys/kernel/tainted":               "12288\n",
+		"proc/sys/kernel/randomize_va_space":    "2\n",
+		"proc/sys/net/ipv4/ip_forward":          "0\n",
+		"proc/sys/net/ipv4/tcp_rmem":            "4096\t87380\t6291456\n",
+		"proc/sys/net/ipv6/conf/all/forwarding": "0\n",
+		"proc/cmdline":                          "root=LABEL=/ console=ttyS0 selinux=0\n",
+		"proc/modules":                          sampleModules,
+		"sys/module/xfs/version":                "5.0\n",
+	}
+	for path, content := range files {
+		path = filepath.Join(dir, path)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+	// write only parameters are never read
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(procDir, "sys", "vm_drop_caches"), []byte("3"), 0200))
+
+	cmdExecutor = func(command string, args ...string) ([]byte, error) {
+		if args[len(args)-1] == "xfs" {
+			return []byte("Amazon Linux kernel signing key\n"), nil
+		}
+		if args[len(args)-1] == "libcrc32c" {
+			return []byte("\n"), nil
+		}
+		return nil, errors.New("module not found")
+	}
+	return func() {
+		procDir, sysModuleDir = savedProc, savedSys
+		cmdExecutor = executeCommand
+		os.RemoveAll(dir)
+	}
+}
+
+func TestCollectKernelData(t *testing.T) {
+	defer setupProc(t)()
+
+	data := collectPlatformDependentKernelData(context.NewMockDefault(), nil)
+	assert.Equal(t, []model.KernelData{{
+		Name:        "Linux",
+		Release:     "4.9.51-10.52.amzn1.x86_64",
+		Version:     "#1 SMP Fri Sep 29 01:51:51 UTC 2017",
+		CommandLine: "root=LABEL=/ console=ttyS0 selinux=0",
+		Tainted:     "12288",
+	}}, data.Kernel)
+
+	assert.Equal(t, []model.KernelModuleData{
+		{Name: "xfs", Size: "1236992", Version: "5.0", State: "Live", Signed: "true", Signer: "Amazon Linux kernel signing key"},
+		{Name: "libcrc32c", Size: "16384", State: "Live", UsedBy: "xfs,nf_conntrack", Signed: "false"},
+		{Name: "vboxdrv", Size: "483328", State: "Live", Signed: "false", TaintFlags: "OE"},
+	}, data.Modules)
+
+	assert.Equal(t, 8, len(data.Sysctl))
+	for _, parameter := range data.Sysctl {
+		assert.NotEqual(t, "vm_drop_caches", parameter.Name)
+		if parameter.Name == "net.ipv4.tcp_rmem" {
+			assert.Equal(t, "4096 87380 6291456", parameter.Value)
+		}
+	}
+}
+
+func TestCollectKernelDataWithFilters(t *testing.T) {
+	defer setupProc(t)()
+
+	data, err := CollectKernelData(context.NewMockDefault(), model.Config{Filters: "net.ipv4.*,kernel.randomize_va_space"})
+	assert.Nil(t, err)
+	assert.Equal(t, []model.SysctlData{
+		{Name: "kernel.randomize_va_space", Value: "2"},
+		{Name: "net.ipv4.ip_forward", Value: "0"},
+		{Name: "net.ipv4.tcp_rmem", Value: "4096 87380 6291456"},
+	}, data.Sysctl)
+}
+
+func TestCollectKernelDataWithoutProcfs(t *testing.T) {
+	defer setupProc(t)()
+	procDir = filepath.Join(procDir, "missing")
+
+	data := collectPlatformDependentKernelData(context.NewMockDefault(), nil)
+	assert.Equal(t, 0, len(data.Kernel))
+	assert.Equal(t, 0, len(data.Modules))
+	assert.Equal(t, 0, len(data.Sysctl))
+}
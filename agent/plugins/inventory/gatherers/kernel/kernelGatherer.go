@@ -0,0 +1,88 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package kernel contains a gatherer for the AWS:Kernel, AWS:KernelModule and AWS:Sysctl inventory types.
+package kernel
+
+import (
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const (
+	// GathererName captures name of kernel gatherer
+	GathererName = "AWS:Kernel"
+	// ModuleTypeName is the inventory type of the loaded kernel modules collected by the kernel gatherer
+	ModuleTypeName = "AWS:KernelModule"
+	// SysctlTypeName is the inventory type of the kernel parameters collected by the kernel gatherer
+	SysctlTypeName = "AWS:Sysctl"
+	// SchemaVersionOfKernel represents schema version of kernel gatherer
+	SchemaVersionOfKernel = "1.0"
+)
+
+// T represents kernel gatherer which implements all contracts for gatherers.
+type T struct{}
+
+// decoupling for easy testability
+var collectData = CollectKernelData
+
+// Gatherer returns new kernel gatherer
+func Gatherer(context context.T) *T {
+	return new(T)
+}
+
+// Name returns name of kernel gatherer
+func (t *T) Name() string {
+	return GathererName
+}
+
+// Run executes kernel gatherer and returns an inventory.Item each for the kernel, its modules and its parameters
+func (t *T) Run(context context.T, configuration model.Config) (items []model.Item, err error) {
+
+	var data Data
+
+	//CaptureTime must comply with format: 2016-07-30T18:15:37Z to comply with regex at SSM.
+	currentTime := time.Now().UTC()
+	captureTime := currentTime.Format(time.RFC3339)
+
+	if data, err = collectData(context, configuration); err != nil {
+		return
+	}
+
+	items = append(items, model.Item{
+		Name:          t.Name(),
+		SchemaVersion: SchemaVersionOfKernel,
+		Content:       data.Kernel,
+		CaptureTime:   captureTime,
+	}, model.Item{
+		Name:          ModuleTypeName,
+		SchemaVersion: SchemaVersionOfKernel,
+		Content:       data.Modules,
+		CaptureTime:   captureTime,
+	}, model.Item{
+		Name:          SysctlTypeName,
+		SchemaVersion: SchemaVersionOfKernel,
+		Content:       data.Sysctl,
+		CaptureTime:   captureTime,
+	})
+	return
+}
+
+// RequestStop stops the execution of kernel gatherer.
+func (t *T) RequestStop(stopType contracts.StopType) error {
+	var err error
+	return err
+}
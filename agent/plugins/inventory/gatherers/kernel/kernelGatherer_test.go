@@ -0,0 +1,82 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package kernel
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var sampleData = Data{
+	Kernel:  []model.KernelData{{Name: "Linux", Release: "4.9.51-10.52.amzn1.x86_64", Tainted: "0"}},
+	Modules: []model.KernelModuleData{{Name: "xfs", Size: "1236992", State: "Live", Signed: "true", Signer: "Amazon"}},
+	Sysctl:  []model.SysctlData{{Name: "net.ipv4.ip_forward", Value: "0"}},
+}
+
+func MockKernelData(context context.T, config model.Config) (Data, error) {
+	return sampleData, nil
+}
+
+func MockKernelDataWithError(context context.T, config model.Config) (Data, error) {
+	return Data{}, errors.New("invalid filters")
+}
+
+func TestGatherer(t *testing.T) {
+	c := context.NewMockDefault()
+	g := Gatherer(c)
+	collectData = MockKernelData
+	items, err := g.Run(c, model.Config{})
+	assert.Nil(t, err, "Unexpected error thrown")
+	assert.Equal(t, 3, len(items))
+	assert.Equal(t, GathererName, items[0].Name)
+	assert.Equal(t, sampleData.Kernel, items[0].Content)
+	assert.Equal(t, ModuleTypeName, items[1].Name)
+	assert.Equal(t, sampleData.Modules, items[1].Content)
+	assert.Equal(t, SysctlTypeName, items[2].Name)
+	assert.Equal(t, sampleData.Sysctl, items[2].Content)
+
+	collectData = MockKernelDataWithError
+	items, err = g.Run(c, model.Config{})
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, len(items))
+}
+
+func TestParseSysctlFilters(t *testing.T) {
+	patterns, err := parseSysctlFilters("")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(patterns))
+
+	patterns, err = parseSysctlFilters(`["net.ipv4.*", "kernel.randomize_va_space"]`)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"net.ipv4.*", "kernel.randomize_va_space"}, patterns)
+
+	patterns, err = parseSysctlFilters(" net.ipv4.* , kernel.kptr_restrict,")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"net.ipv4.*", "kernel.kptr_restrict"}, patterns)
+
+	_, err = parseSysctlFilters(`["net.ipv4.*"`)
+	assert.NotNil(t, err)
+	_, err = parseSysctlFilters("net.[ipv4")
+	assert.NotNil(t, err)
+}
+
+func TestMatchesAnyPattern(t *testing.T) {
+	assert.True(t, matchesAnyPattern(nil, "vm.swappiness"))
+	assert.True(t, matchesAnyPattern([]string{"net.ipv4.*"}, "net.ipv4.conf.all.rp_filter"))
+	assert.False(t, matchesAnyPattern([]string{"net.ipv4.*"}, "net.ipv6.conf.all.forwarding"))
+}
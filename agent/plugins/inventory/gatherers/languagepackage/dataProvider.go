@@ -0,0 +1,435 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package languagepackage
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+// Ecosystems reported in the ApplicationType attribute
+const (
+	EcosystemPython = "python"
+	EcosystemNode   = "node"
+	EcosystemRuby   = "ruby"
+	EcosystemJava   = "java"
+)
+
+const (
+	// PackageCountLimit is the maximum number of packages reported
+	PackageCountLimit = 10000
+	// PackageCountLimitExceeded is the error returned when more packages are found
+	PackageCountLimitExceeded = "Package Count Limit Exceeded"
+
+	// maxDepth is the maximum depth of directories walked below a root path
+	maxDepth = 16
+	// maxNestedArchiveSize is the maximum size of an archive nested in a jar, e.g. in BOOT-INF/lib, that is read
+	maxNestedArchiveSize = 64 * 1024 * 1024
+	// maxMetadataSize is the maximum size of a metadata file that is read
+	maxMetadataSize = 1024 * 1024
+)
+
+// filterObj is the optional Filters policy of the gatherer, e.g.
+//   {"Paths": ["/opt/app", "$HOME/.local/lib"], "Ecosystems": ["java"]}
+// Paths replace the default root paths of the platform and may contain environment variables and patterns.
+type filterObj struct {
+	Paths      []string
+	Ecosystems []string
+}
+
+var (
+	allEcosystems = []string{EcosystemPython, EcosystemNode, EcosystemRuby, EcosystemJava}
+
+	// skippedPaths are pseudo file systems that are never walked
+	skippedPaths = map[string]bool{"/proc": true, "/sys": true, "/dev": true}
+
+	// gemSpecExp splits the file name of a gem specification, e.g. nokogiri-1.13.3-x86_64-linux.gemspec
+	gemSpecExp = regexp.MustCompile(`^(.+?)-(\d[\w.]*)(?:-.+)?\.gemspec$`)
+	// archiveNameExp splits the file name of a java archive, e.g. log4j-core-2.14.1.jar
+	archiveNameExp = regexp.MustCompile(`^(.+?)-(\d[\w.\-]*)\.[jwe]ar$`)
+	// gemSummaryExp and gemHomepageExp match string attributes of a gem specification, e.g. s.summary = "HTML parser".freeze
+	gemSummaryExp  = regexp.MustCompile(`(?m)^\s*\w+\.summary\s*=\s*"([^"]*)"`)
+	gemHomepageExp = regexp.MustCompile(`(?m)^\s*\w+\.homepage\s*=\s*"([^"]*)"`)
+)
+
+// collector walks root paths and collects the packages of the enabled ecosystems
+type collector struct {
+	log        log.T
+	ecosystems map[string]bool
+	packages   []model.ApplicationData
+	found      map[string]bool
+}
+
+// CollectLanguagePackageData collects the packages of language ecosystems installed under the root paths.
+func CollectLanguagePackageData(context context.T, config model.Config) (data []model.ApplicationData, err error) {
+	log := context.Log()
+
+	var filter filterObj
+	if config.Filters != "" {
+		if err = json.Unmarshal([]byte(strings.Replace(config.Filters, `\`, `/`, -1)), &filter); err != nil {
+			err = fmt.Errorf("Invalid %v filters %v: %v", GathererName, config.Filters, err)
+			log.Error(err)
+			return
+		}
+	}
+
+	c := &collector{log: log, ecosystems: make(map[string]bool), found: make(map[string]bool)}
+	if len(filter.Ecosystems) == 0 {
+		filter.Ecosystems = allEcosystems
+	}
+	for _, ecosystem := range filter.Ecosystems {
+		ecosystem = strings.ToLower(ecosystem)
+		if !contains(allEcosystems, ecosystem) {
+			err = fmt.Errorf("Unsupported %v ecosystem %v, supported ecosystems are %v", GathererName, ecosystem, allEcosystems)
+			log.Error(err)
+			return
+		}
+		c.ecosystems[ecosystem] = true
+	}
+
+	roots := filter.Paths
+	if len(roots) == 0 {
+		roots = defaultRoots
+	}
+	for _, root := range roots {
+		matches, globErr := filepath.Glob(filepath.FromSlash(os.ExpandEnv(root)))
+		if globErr != nil {
+			log.Errorf("Invalid %v path %v: %v", GathererName, root, globErr)
+			continue
+		}
+		for _, match := range matches {
+			if err = c.walk(match); err != nil {
+				log.Error(err)
+				return nil, err
+			}
+		}
+	}
+
+	sort.Sort(model.ByNamePublisherVersion(c.packages))
+	return c.packages, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// walk collects the packages below a root path
+func (c *collector) walk(root string) error {
+	rootDepth := strings.Count(filepath.Clean(root), string(filepath.Separator))
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			c.log.Debugf("Failed to read %v: %v", path, err)
+			if info != nil && info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		name := info.Name()
+		if info.IsDir() {
+			switch {
+			case skippedPaths[filepath.ToSlash(path)] || strings.Count(path, string(filepath.Separator))-rootDepth > maxDepth:
+				return filepath.SkipDir
+			case c.ecosystems[EcosystemPython] && strings.HasSuffix(name, ".dist-info"):
+				c.addPython(path, filepath.Join(path, "METADATA"))
+				return filepath.SkipDir
+			case c.ecosystems[EcosystemPython] && strings.HasSuffix(name, ".egg-info"):
+				c.addPython(path, filepath.Join(path, "PKG-INFO"))
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		switch {
+		case !info.Mode().IsRegular():
+			// sockets, devices and links are never packages
+		case c.ecosystems[EcosystemPython] && strings.HasSuffix(name, ".egg-info"):
+			c.addPython(path, path)
+		case c.ecosystems[EcosystemNode] && name == "package.json" && isNodeModule(path):
+			c.addNode(path)
+		case c.ecosystems[EcosystemRuby] && strings.HasSuffix(name, ".gemspec") && filepath.Base(filepath.Dir(path)) == "specifications":
+			c.addGem(path)
+		case c.ecosystems[EcosystemJava] && isJavaArchive(name):
+			c.addJar(path)
+		}
+		if len(c.packages) > PackageCountLimit {
+			return fmt.Errorf("%v. Max Allowed - %v", PackageCountLimitExceeded, PackageCountLimit)
+		}
+		return nil
+	})
+}
+
+// add records a package once per location
+func (c *collector) add(data model.ApplicationData) {
+	if data.Name == "" || data.Version == "" {
+		return
+	}
+	key := strings.Join([]string{data.ApplicationType, data.PackageId, data.Publisher, data.Name, data.Version}, "|")
+	if c.found[key] {
+		return
+	}
+	c.found[key] = true
+	c.packages = append(c.packages, data)
+}
+
+// addPython adds a python distribution from its core metadata, METADATA of wheels or PKG-INFO of eggs
+func (c *collector) addPython(path string, metadataPath string) {
+	content, err := readLimited(metadataPath)
+	if err != nil {
+		c.log.Debugf("Failed to read python metadata %v: %v", metadataPath, err)
+		return
+	}
+	headers := parseHeaders(content, ":")
+	c.add(model.ApplicationData{
+		Name:            headers["Name"],
+		Version:         headers["Version"],
+		Publisher:       headers["Author"],
+		Summary:         headers["Summary"],
+		URL:             headers["Home-page"],
+		ApplicationType: EcosystemPython,
+		PackageId:       path,
+	})
+}
+
+// isNodeModule returns true if a package.json describes an installed module, node_modules/name or node_modules/@scope/name
+func isNodeModule(path string) bool {
+	dir := filepath.Dir(path)
+	parent := filepath.Dir(dir)
+	if filepath.Base(parent) == "node_modules" {
+		return true
+	}
+	return strings.HasPrefix(filepath.Base(parent), "@") && filepath.Base(filepath.Dir(parent)) == "node_modules"
+}
+
+// nodePackage are the package.json fields reported, author is either a string or an object with a name
+type nodePackage struct {
+	Name        string          `json:"name"`
+	Version     string          `json:"version"`
+	Description string          `json:"description"`
+	Homepage    string          `json:"homepage"`
+	Author      json.RawMessage `json:"author"`
+}
+
+// addNode adds a node module from its package.json
+func (c *collector) addNode(path string) {
+	content, err := readLimited(path)
+	if err != nil {
+		c.log.Debugf("Failed to read node package %v: %v", path, err)
+		return
+	}
+	var pkg nodePackage
+	if err = json.Unmarshal(content, &pkg); err != nil {
+		c.log.Debugf("Invalid node package %v: %v", path, err)
+		return
+	}
+	var author string
+	if json.Unmarshal(pkg.Author, &author) != nil {
+		var authorObj struct {
+			Name string `json:"name"`
+		}
+		json.Unmarshal(pkg.Author, &authorObj)
+		author = authorObj.Name
+	}
+	c.add(model.ApplicationData{
+		Name:            pkg.Name,
+		Version:         pkg.Version,
+		Publisher:       author,
+		Summary:         pkg.Description,
+		URL:             pkg.Homepage,
+		ApplicationType: EcosystemNode,
+		PackageId:       filepath.Dir(path),
+	})
+}
+
+// addGem adds a ruby gem from the name of its specification, the specification is ruby code that is not evaluated
+func (c *collector) addGem(path string) {
+	match := gemSpecExp.FindStringSubmatch(filepath.Base(path))
+	if match == nil {
+		return
+	}
+	data := model.ApplicationData{
+		Name:            match[1],
+		Version:         match[2],
+		ApplicationType: EcosystemRuby,
+		PackageId:       path,
+	}
+	if content, err := readLimited(path); err == nil {
+		data.Summary = gemAttribute(content, gemSummaryExp)
+		data.URL = gemAttribute(content, gemHomepageExp)
+	}
+	c.add(data)
+}
+
+// gemAttribute returns the value of a string attribute of a gem specification
+func gemAttribute(content []byte, exp *regexp.Regexp) string {
+	if match := exp.FindSubmatch(content); match != nil {
+		return string(match[1])
+	}
+	return ""
+}
+
+func isJavaArchive(name string) bool {
+	extension := strings.ToLower(filepath.Ext(name))
+	return extension == ".jar" || extension == ".war" || extension == ".ear"
+}
+
+// addJar adds the java packages of an archive
+func (c *collector) addJar(path string) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		c.log.Debugf("Failed to open java archive %v: %v", path, err)
+		return
+	}
+	defer reader.Close()
+	c.addArchive(path, filepath.Base(path), &reader.Reader, 0)
+}
+
+// addArchive adds the maven artifacts of an archive, shaded artifacts and the archives of fat jars included.
+// Archives without maven metadata are identified by their manifest, or else by their file name.
+func (c *collector) addArchive(path string, name string, archive *zip.Reader, depth int) {
+	found := false
+	var manifest *zip.File
+	nested := make([]*zip.File, 0)
+	for _, file := range archive.File {
+		switch {
+		case strings.HasPrefix(file.Name, "META-INF/maven/") && strings.HasSuffix(file.Name, "/pom.properties"):
+			content, err := readZipFile(file)
+			if err != nil {
+				continue
+			}
+			properties := parseHeaders(content, "=")
+			if properties["artifactId"] != "" {
+				found = true
+				c.add(model.ApplicationData{
+					Name:            properties["artifactId"],
+					Version:         properties["version"],
+					Publisher:       properties["groupId"],
+					ApplicationType: EcosystemJava,
+					PackageId:       path,
+				})
+			}
+		case file.Name == "META-INF/MANIFEST.MF":
+			manifest = file
+		case depth == 0 && isJavaArchive(file.Name) && file.UncompressedSize64 <= maxNestedArchiveSize:
+			nested = append(nested, file)
+		}
+	}
+
+	if !found {
+		data := model.ApplicationData{ApplicationType: EcosystemJava, PackageId: path}
+		if manifest != nil {
+			if content, err := readZipFile(manifest); err == nil {
+				attributes := parseHeaders(content, ":")
+				data.Name = firstNonEmpty(attributes["Implementation-Title"], strings.Split(attributes["Bundle-SymbolicName"], ";")[0])
+				data.Version = firstNonEmpty(attributes["Implementation-Version"], attributes["Bundle-Version"])
+				data.Publisher = firstNonEmpty(attributes["Implementation-Vendor"], attributes["Bundle-Vendor"])
+			}
+		}
+		if data.Name == "" || data.Version == "" {
+			if match := archiveNameExp.FindStringSubmatch(name); match != nil {
+				data.Name, data.Version = match[1], match[2]
+			}
+		}
+		c.add(data)
+	}
+
+	for _, file := range nested {
+		content, err := readZipFile(file)
+		if err != nil {
+			continue
+		}
+		nestedArchive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+		if err != nil {
+			c.log.Debugf("Failed to open java archive %v in %v: %v", file.Name, path, err)
+			continue
+		}
+		c.addArchive(path+"!/"+file.Name, filepath.Base(file.Name), nestedArchive, depth+1)
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func readLimited(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ioutil.ReadAll(io.LimitReader(file, maxMetadataSize))
+}
+
+func readZipFile(file *zip.File) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return ioutil.ReadAll(io.LimitReader(reader, maxNestedArchiveSize))
+}
+
+// parseHeaders parses "key: value" or "key=value" lines up to the first empty line, as used by python core metadata,
+// java manifests and properties. Lines starting with a space continue the previous value.
+func parseHeaders(content []byte, separator string) map[string]string {
+	headers := make(map[string]string)
+	lastKey := ""
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			if separator == ":" {
+				break
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, " ") && lastKey != "" {
+			headers[lastKey] += strings.TrimPrefix(line, " ")
+			continue
+		}
+		if pos := strings.Index(line, separator); pos > 0 {
+			lastKey = strings.TrimSpace(line[:pos])
+			if _, found := headers[lastKey]; !found {
+				headers[lastKey] = strings.TrimSpace(line[pos+len(separator):])
+			}
+		}
+	}
+	return headers
+}
@@ -0,0 +1,139 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package languagepackage
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// createArchive returns the content of a zip archive with the given files
+func createArchive(t *testing.T, files map[string][]byte) []byte {
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	for name, content := range files {
+		file, err := writer.Create(name)
+		assert.Nil(t, err)
+		file.Write(content)
+	}
+	assert.Nil(t, writer.Close())
+	return buffer.Bytes()
+}
+
+// setupPackages creates the install locations of every ecosystem in a temporary directory
+func setupPackages(t *testing.T) (root string, cleanup func()) {
+	root, err := ioutil.TempDir("", "languagepackage")
+	assert.Nil(t, err)
+
+	log4j := createArchive(t, map[string][]byte{
+		"META-INF/MANIFEST.MF": []byte("Manifest-Version: 1.0\n"),
+		"META-INF/maven/org.apache.logging.log4j/log4j-core/pom.properties": []byte("#Created by Apache Maven\nversion=2.14.1\ngroupId=org.apache.logging.log4j\nartifactId=log4j-core\n"),
+	})
+	files := map[string][]byte{
+		"python3.6/site-packages/requests-2.18.4.dist-info/METADATA": []byte("Metadata-Version: 2.0\nName: requests\nVersion: 2.18.4\nSummary: Python HTTP for Humans.\nHome-page: http://python-requests.org\nAuthor: Kenneth Reitz\n\nName: not a header\n"),
+		"python3.6/site-packages/six-1.11.0.egg-info":                []byte("Metadata-Version: 1.1\nName: six\nVersion: 1.11.0\n"),
+		"node_modules/npm/package.json":                              []byte(`{"name": "npm", "version": "5.6.0", "author": "Isaac Z. Schlueter <i@izs.me>"}`),
+		"node_modules/npm/node_modules/@scope/dep/package.json":      []byte(`{"name": "@scope/dep", "version": "1.0.0", "author": {"name": "Scope"}, "homepage": "https://example.com"}`),
+		"node_modules/npm/lib/package.json":                          []byte(`{"name": "not-a-module", "version": "0.0.1"}`),
+		"gems/specifications/nokogiri-1.8.1-x86_64-linux.gemspec":    []byte("Gem::Specification.new do |s|\n  s.name = \"nokogiri\".freeze\n  s.summary = \"Nokogiri is an HTML parser\".freeze\nend\n"),
+		"gems/specifications/net-http-0.1.1.gemspec":                 []byte(""),
+		"app/lib/log4j-core-2.14.1.jar":                              log4j,
+		"app/lib/commons-lang-2.6.jar":                               createArchive(t, map[string][]byte{"META-INF/MANIFEST.MF": []byte("Manifest-Version: 1.0\nImplementation-Title: Commons Lang\nImplementation-Version: 2.6\nImplementation-Vendor: The Apache Software\n  Foundation\n")}),
+		"app/lib/unknown.jar":                                        createArchive(t, map[string][]byte{"a.class": []byte("")}),
+		"app/boot.jar": createArchive(t, map[string][]byte{
+			"META-INF/maven/com.example/boot/pom.properties": []byte("version=1.0\ngroupId=com.example\nartifactId=boot\n"),
+			"BOOT-INF/lib/log4j-core-2.14.1.jar":             log4j,
+		}),
+	}
+	for path, content := range files {
+		path = filepath.Join(root, path)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.Nil(t, ioutil.WriteFile(path, content, 0644))
+	}
+	return root, func() { os.RemoveAll(root) }
+}
+
+func TestCollectLanguagePackages(t *testing.T) {
+	root, cleanup := setupPackages(t)
+	defer cleanup()
+
+	config := model.Config{Filters: `{"Paths": ["` + filepath.ToSlash(root) + `/python*/site-packages", "` + filepath.ToSlash(root) + `"]}`}
+	data, err := CollectLanguagePackageData(context.NewMockDefault(), config)
+	assert.Nil(t, err)
+
+	packages := make(map[string]model.ApplicationData)
+	for _, pkg := range data {
+		packages[pkg.ApplicationType+" "+pkg.Name+" "+pkg.PackageId] = pkg
+	}
+	assert.Equal(t, 10, len(data), "%v", data)
+
+	assert.Equal(t, model.ApplicationData{
+		Name:            "requests",
+		Version:         "2.18.4",
+		Publisher:       "Kenneth Reitz",
+		Summary:         "Python HTTP for Humans.",
+		URL:             "http://python-requests.org",
+		ApplicationType: EcosystemPython,
+		PackageId:       filepath.Join(root, "python3.6", "site-packages", "requests-2.18.4.dist-info"),
+	}, packages["python requests "+filepath.Join(root, "python3.6", "site-packages", "requests-2.18.4.dist-info")])
+	assert.Equal(t, "1.11.0", packages["python six "+filepath.Join(root, "python3.6", "site-packages", "six-1.11.0.egg-info")].Version)
+
+	assert.Equal(t, "Isaac Z. Schlueter <i@izs.me>", packages["node npm "+filepath.Join(root, "node_modules", "npm")].Publisher)
+	scoped := packages["node @scope/dep "+filepath.Join(root, "node_modules", "npm", "node_modules", "@scope", "dep")]
+	assert.Equal(t, "Scope", scoped.Publisher)
+	assert.Equal(t, "https://example.com", scoped.URL)
+
+	nokogiri := packages["ruby nokogiri "+filepath.Join(root, "gems", "specifications", "nokogiri-1.8.1-x86_64-linux.gemspec")]
+	assert.Equal(t, "1.8.1", nokogiri.Version)
+	assert.Equal(t, "Nokogiri is an HTML parser", nokogiri.Summary)
+	assert.Equal(t, "0.1.1", packages["ruby net-http "+filepath.Join(root, "gems", "specifications", "net-http-0.1.1.gemspec")].Version)
+
+	log4j := packages["java log4j-core "+filepath.Join(root, "app", "lib", "log4j-core-2.14.1.jar")]
+	assert.Equal(t, "2.14.1", log4j.Version)
+	assert.Equal(t, "org.apache.logging.log4j", log4j.Publisher)
+	assert.Equal(t, "2.14.1", packages["java log4j-core "+filepath.Join(root, "app", "boot.jar")+"!/BOOT-INF/lib/log4j-core-2.14.1.jar"].Version)
+	assert.Equal(t, "1.0", packages["java boot "+filepath.Join(root, "app", "boot.jar")].Version)
+
+	commons := packages["java Commons Lang "+filepath.Join(root, "app", "lib", "commons-lang-2.6.jar")]
+	assert.Equal(t, "2.6", commons.Version)
+	assert.Equal(t, "The Apache Software Foundation", commons.Publisher)
+}
+
+func TestCollectLanguagePackagesByEcosystem(t *testing.T) {
+	root, cleanup := setupPackages(t)
+	defer cleanup()
+
+	config := model.Config{Filters: `{"Paths": ["` + filepath.ToSlash(root) + `"], "Ecosystems": ["Java"]}`}
+	data, err := CollectLanguagePackageData(context.NewMockDefault(), config)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(data))
+	for _, pkg := range data {
+		assert.Equal(t, EcosystemJava, pkg.ApplicationType)
+	}
+}
+
+func TestCollectLanguagePackagesInvalidFilters(t *testing.T) {
+	_, err := CollectLanguagePackageData(context.NewMockDefault(), model.Config{Filters: `{"Paths": `})
+	assert.NotNil(t, err)
+	_, err = CollectLanguagePackageData(context.NewMockDefault(), model.Config{Filters: `{"Ecosystems": ["go"]}`})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,34 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build darwin freebsd linux netbsd openbsd
+
+package languagepackage
+
+// defaultRoots are the global install locations of the ecosystems on linux distributions
+var defaultRoots = []string{
+	"/usr/lib/python*/site-packages",
+	"/usr/lib/python*/dist-packages",
+	"/usr/lib64/python*/site-packages",
+	"/usr/local/lib/python*/site-packages",
+	"/usr/local/lib/python*/dist-packages",
+	"/usr/lib/node_modules",
+	"/usr/local/lib/node_modules",
+	"/usr/share/gems/specifications",
+	"/usr/local/share/gems/specifications",
+	"/var/lib/gems/*/specifications",
+	"/usr/lib/ruby/gems/*/specifications",
+	"/usr/local/lib/ruby/gems/*/specifications",
+	"/usr/share/java",
+	"/opt",
+}
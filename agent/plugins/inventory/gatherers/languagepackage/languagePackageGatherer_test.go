@@ -0,0 +1,51 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package languagepackage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func MockLanguagePackageData(context context.T, config model.Config) ([]model.ApplicationData, error) {
+	return []model.ApplicationData{
+		{Name: "log4j-core", Publisher: "org.apache.logging.log4j", Version: "2.14.1", ApplicationType: EcosystemJava, PackageId: "/opt/app/app.jar"},
+	}, nil
+}
+
+func MockLanguagePackageDataWithError(context context.T, config model.Config) ([]model.ApplicationData, error) {
+	return nil, errors.New(PackageCountLimitExceeded)
+}
+
+func TestGatherer(t *testing.T) {
+	c := context.NewMockDefault()
+	g := Gatherer(c)
+	collectData = MockLanguagePackageData
+	items, err := g.Run(c, model.Config{})
+	assert.Nil(t, err, "Unexpected error thrown")
+	assert.Equal(t, 1, len(items))
+	assert.Equal(t, GathererName, items[0].Name)
+	assert.Equal(t, SchemaVersionOfLanguagePackage, items[0].SchemaVersion)
+	data, _ := MockLanguagePackageData(c, model.Config{})
+	assert.Equal(t, data, items[0].Content)
+
+	collectData = MockLanguagePackageDataWithError
+	items, err = g.Run(c, model.Config{})
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, len(items))
+}
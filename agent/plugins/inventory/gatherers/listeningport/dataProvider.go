@@ -0,0 +1,46 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package listeningport
+
+import (
+	"sort"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+// CollectListeningPortData collects the listening sockets of the system with the processes owning them.
+func CollectListeningPortData(context context.T) []model.ListeningPortData {
+	ports := collectPlatformDependentListeningPortData(context)
+	sort.Sort(byProtocolPort(ports))
+	return ports
+}
+
+// byProtocolPort sorts listening ports by protocol, port and address
+type byProtocolPort []model.ListeningPortData
+
+func (s byProtocolPort) Len() int      { return len(s) }
+func (s byProtocolPort) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byProtocolPort) Less(i, j int) bool {
+	if s[i].Protocol != s[j].Protocol {
+		return s[i].Protocol < s[j].Protocol
+	}
+	if len(s[i].LocalPort) != len(s[j].LocalPort) {
+		return len(s[i].LocalPort) < len(s[j].LocalPort)
+	}
+	if s[i].LocalPort != s[j].LocalPort {
+		return s[i].LocalPort < s[j].LocalPort
+	}
+	return s[i].LocalAddress < s[j].LocalAddress
+}
@@ -0,0 +1,211 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build darwin freebsd linux netbsd openbsd
+
+package listeningport
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/procnet"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const (
+	// tcpListen is the TCP_LISTEN socket state of /proc/net/tcp
+	tcpListen = "0A"
+	// udpUnconnected is the TCP_CLOSE state udp sockets report while they are bound but not connected
+	udpUnconnected   = "07"
+	socketLinkPrefix = "socket:["
+)
+
+var procDir = "/proc"
+
+// hostByteOrder is the byte order the kernel prints the address words of /proc/net in
+var hostByteOrder = procnet.NativeByteOrder()
+
+// decoupling user.LookupId for easy testability
+var lookupUserName = lookupUser
+
+func lookupUser(uid string) string {
+	if u, err := user.LookupId(uid); err == nil {
+		return u.Username
+	}
+	return uid
+}
+
+// socket is a listening socket of /proc/net
+type socket struct {
+	protocol string
+	address  string
+	port     string
+	uid      string
+	inode    string
+}
+
+// process describes the process owning a socket, its command line is not collected as it may contain secrets
+type process struct {
+	pid        int
+	name       string
+	executable string
+}
+
+// collectPlatformDependentListeningPortData collects listening sockets from /proc/net and their owners from /proc/<pid>.
+func collectPlatformDependentListeningPortData(context context.T) []model.ListeningPortData {
+	log := context.Log()
+	ports := make([]model.ListeningPortData, 0)
+
+	sockets := make([]socket, 0)
+	for _, protocol := range []string{"tcp", "tcp6", "udp", "udp6"} {
+		content, err := ioutil.ReadFile(filepath.Join(procDir, "net", protocol))
+		if err != nil {
+			log.Debugf("Unable to read %v sockets - %v", protocol, err)
+			continue
+		}
+		sockets = append(sockets, parseSockets(protocol, string(content))...)
+	}
+	if len(sockets) == 0 {
+		return ports
+	}
+
+	owners := socketOwners(log)
+	users := make(map[string]string)
+	for _, s := range sockets {
+		if _, found := users[s.uid]; !found {
+			users[s.uid] = lookupUserName(s.uid)
+		}
+		port := model.ListeningPortData{
+			Protocol:     s.protocol,
+			LocalAddress: s.address,
+			LocalPort:    s.port,
+			User:         users[s.uid],
+		}
+		if owner, found := owners[s.inode]; found {
+			port.PID = strconv.Itoa(owner.pid)
+			port.ProcessName = owner.name
+			port.ExecutablePath = owner.executable
+		}
+		ports = append(ports, port)
+	}
+	return ports
+}
+
+// parseSockets returns the listening sockets of a /proc/net socket table, e.g.
+//   sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+//   0: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 15283 1 ...
+// TCP sockets listen, UDP sockets receive from any peer while they are not connected.
+func parseSockets(protocol string, content string) []socket {
+	sockets := make([]socket, 0)
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 10 || fields[0] == "sl" {
+			continue
+		}
+		state := fields[3]
+		if strings.HasPrefix(protocol, "tcp") && state != tcpListen {
+			continue
+		}
+		if strings.HasPrefix(protocol, "udp") && (state != udpUnconnected || !strings.HasSuffix(fields[2], ":0000")) {
+			continue
+		}
+		address, port, err := decodeAddress(fields[1])
+		if err != nil {
+			continue
+		}
+		sockets = append(sockets, socket{
+			protocol: protocol,
+			address:  address,
+			port:     port,
+			uid:      fields[7],
+			inode:    fields[9],
+		})
+	}
+	return sockets
+}
+
+// decodeAddress decodes a hex address and port, the address is made of 32 bit words printed as numbers that are
+// stored in host byte order
+func decodeAddress(hexAddress string) (address string, port string, err error) {
+	parts := strings.Split(hexAddress, ":")
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("invalid socket address %v", hexAddress)
+	}
+	ip, err := procnet.DecodeIP(parts[0], hostByteOrder)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid socket address %v", hexAddress)
+	}
+	portNumber, err := strconv.ParseUint(parts[1], 16, 16)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid socket port %v", hexAddress)
+	}
+	return ip.String(), strconv.FormatUint(portNumber, 10), nil
+}
+
+// socketOwners maps socket inodes to the process with the lowest pid holding the socket open,
+// sockets shared by forked workers are reported with their parent
+func socketOwners(log log.T) map[string]process {
+	owners := make(map[string]process)
+	entries, err := ioutil.ReadDir(procDir)
+	if err != nil {
+		log.Debugf("Unable to read processes - %v", err)
+		return owners
+	}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		processDir := filepath.Join(procDir, entry.Name())
+		fds, err := ioutil.ReadDir(filepath.Join(processDir, "fd"))
+		if err != nil {
+			continue
+		}
+		var owner *process
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(processDir, "fd", fd.Name()))
+			if err != nil || !strings.HasPrefix(link, socketLinkPrefix) {
+				continue
+			}
+			inode := strings.TrimSuffix(strings.TrimPrefix(link, socketLinkPrefix), "]")
+			if existing, found := owners[inode]; found && existing.pid < pid {
+				continue
+			}
+			if owner == nil {
+				owner = readProcess(pid, processDir)
+			}
+			owners[inode] = *owner
+		}
+	}
+	return owners
+}
+
+// readProcess reads the name and executable of a process
+func readProcess(pid int, processDir string) *process {
+	p := &process{pid: pid}
+	if comm, err := ioutil.ReadFile(filepath.Join(processDir, "comm")); err == nil {
+		p.name = strings.TrimSpace(string(comm))
+	}
+	if executable, err := os.Readlink(filepath.Join(processDir, "exe")); err == nil {
+		p.executable = executable
+	}
+	return p
+}
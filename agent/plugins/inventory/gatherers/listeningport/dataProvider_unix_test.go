@@ -0,0 +1,147 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build darwin freebsd linux netbsd openbsd
+
+package listeningport
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/procnet"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	sampleTCP = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 15283 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:0019 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 16001 1 0000000000000000 100 0 0 10 0
+   2: 0A01A8C0:0016 0501A8C0:D431 01 00000000:00000000 02:000A7D3C 00000000     0        0 17002 4 0000000000000000 20 4 31 10 -1
+`
+	sampleTCP6 = `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000001000000:1F90 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 18003 1 0000000000000000 100 0 0 10 0
+`
+	sampleUDP = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when ref pointer drops
+  68: 00000000:0044 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 19004 2 0000000000000000 0
+  70: 0A01A8C0:8AB2 0200A8C0:0035 01 00000000:00000000 00:00000000 00000000     0        0 19005 2 0000000000000000 0
+`
+)
+
+func setupProc(t *testing.T) (cleanup func()) {
+	dir, err := ioutil.TempDir("", "listeningport")
+	assert.Nil(t, err)
+	savedProc := procDir
+	procDir = dir
+	// the samples were taken from a little endian host
+	hostByteOrder = binary.LittleEndian
+
+	files := map[string]string{
+		"net/tcp":        sampleTCP,
+		"net/tcp6":       sampleTCP6,
+		"net/udp":        sampleUDP,
+		"1044/comm":      "sshd\n",
+		"1044/cmdline":   "/usr/sbin/sshd\x00-D\x00",
+		"1045/comm":      "sshd\n",
+		"987/comm":       "dhclient\n",
+		"987/cmdline":    "/sbin/dhclient\x00eth0\x00",
+		"self/something": "",
+	}
+	for path, content := range files {
+		path = filepath.Join(dir, path)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+	links := map[string]string{
+		"1044/fd/3": "socket:[15283]",
+		"1044/fd/1": "/dev/null",
+		"1044/exe":  "/usr/sbin/sshd",
+		"1045/fd/3": "socket:[15283]",
+		"1045/fd/4": "socket:[17002]",
+		"987/fd/6":  "socket:[19004]",
+		"987/exe":   "/sbin/dhclient",
+	}
+	for path, target := range links {
+		path = filepath.Join(dir, path)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.Nil(t, os.Symlink(target, path))
+	}
+
+	lookupUserName = func(uid string) string {
+		if uid == "0" {
+			return "root"
+		}
+		return uid
+	}
+	return func() {
+		procDir = savedProc
+		hostByteOrder = procnet.NativeByteOrder()
+		lookupUserName = lookupUser
+		os.RemoveAll(dir)
+	}
+}
+
+func TestCollectListeningPorts(t *testing.T) {
+	defer setupProc(t)()
+
+	ports := CollectListeningPortData(context.NewMockDefault())
+	assert.Equal(t, []model.ListeningPortData{
+		{Protocol: "tcp", LocalAddress: "0.0.0.0", LocalPort: "22", PID: "1044", ProcessName: "sshd", User: "root",
+			ExecutablePath: "/usr/sbin/sshd"},
+		{Protocol: "tcp", LocalAddress: "127.0.0.1", LocalPort: "25", User: "root"},
+		{Protocol: "tcp6", LocalAddress: "::1", LocalPort: "8080", User: "1000"},
+		{Protocol: "udp", LocalAddress: "0.0.0.0", LocalPort: "68", PID: "987", ProcessName: "dhclient", User: "root",
+			ExecutablePath: "/sbin/dhclient"},
+	}, ports)
+}
+
+func TestDecodeAddress(t *testing.T) {
+	defer func() { hostByteOrder = procnet.NativeByteOrder() }()
+	hostByteOrder = binary.LittleEndian
+
+	address, port, err := decodeAddress("0100007F:0016")
+	assert.Nil(t, err)
+	assert.Equal(t, "127.0.0.1", address)
+	assert.Equal(t, "22", port)
+
+	address, port, err = decodeAddress("B80D01200000000067452301EFCDAB89:01BB")
+	assert.Nil(t, err)
+	assert.Equal(t, "2001:db8::123:4567:89ab:cdef", address)
+	assert.Equal(t, "443", port)
+
+	hostByteOrder = binary.BigEndian
+	address, port, err = decodeAddress("7F000001:0016")
+	assert.Nil(t, err)
+	assert.Equal(t, "127.0.0.1", address)
+	assert.Equal(t, "22", port)
+
+	address, port, err = decodeAddress("20010DB8000000000123456789ABCDEF:01BB")
+	assert.Nil(t, err)
+	assert.Equal(t, "2001:db8::123:4567:89ab:cdef", address)
+
+	_, _, err = decodeAddress("0100007F")
+	assert.NotNil(t, err)
+	_, _, err = decodeAddress("01007F:0016")
+	assert.NotNil(t, err)
+}
+
+func TestCollectWithoutProcNet(t *testing.T) {
+	defer setupProc(t)()
+	procDir = filepath.Join(procDir, "missing")
+	assert.Equal(t, 0, len(CollectListeningPortData(context.NewMockDefault())))
+}
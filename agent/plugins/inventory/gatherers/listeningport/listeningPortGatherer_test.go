@@ -0,0 +1,40 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package listeningport
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func MockListeningPortData(context context.T) []model.ListeningPortData {
+	return []model.ListeningPortData{
+		{Protocol: "tcp", LocalAddress: "0.0.0.0", LocalPort: "22", PID: "1044", ProcessName: "sshd", User: "root", ExecutablePath: "/usr/sbin/sshd"},
+	}
+}
+
+func TestGatherer(t *testing.T) {
+	c := context.NewMockDefault()
+	g := Gatherer(c)
+	collectData = MockListeningPortData
+	items, err := g.Run(c, model.Config{})
+	assert.Nil(t, err, "Unexpected error thrown")
+	assert.Equal(t, 1, len(items))
+	assert.Equal(t, GathererName, items[0].Name)
+	assert.Equal(t, SchemaVersionOfListeningPort, items[0].SchemaVersion)
+	assert.Equal(t, MockListeningPortData(c), items[0].Content)
+}
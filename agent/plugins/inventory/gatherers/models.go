@@ -19,10 +19,16 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/application"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/awscomponent"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/container"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/custom"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/file"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/instancedetailedinformation"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/kernel"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/languagepackage"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/listeningport"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/network"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/service"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/useraccount"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/windowsUpdate"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
 )
@@ -52,12 +58,18 @@ func InitializeGatherers(context context.T) (SupportedGatherer, InstalledGathere
 
 	installedGatherer := InstalledGatherer{
 		application.GathererName:                 application.Gatherer(context),
+		languagepackage.GathererName:             languagepackage.Gatherer(context),
 		awscomponent.GathererName:                awscomponent.Gatherer(context),
+		container.GathererName:                   container.Gatherer(context),
 		custom.GathererName:                      custom.Gatherer(context),
 		network.GathererName:                     network.Gatherer(context),
+		listeningport.GathererName:               listeningport.Gatherer(context),
+		service.GathererName:                     service.Gatherer(context),
+		useraccount.GathererName:                 useraccount.Gatherer(context),
 		windowsUpdate.GathererName:               windowsUpdate.Gatherer(context),
 		file.GathererName:                        file.Gatherer(context),
 		instancedetailedinformation.GathererName: instancedetailedinformation.Gatherer(context),
+		kernel.GathererName:                      kernel.Gatherer(context),
 	}
 
 	for key := range installedGatherer {
@@ -17,19 +17,28 @@
 package network
 
 import (
+	"bufio"
 	"encoding/json"
+	"io/ioutil"
 	"net"
+	"strings"
 
 	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/procnet"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
 )
 
+var (
+	routeTablePath = "/proc/net/route"
+	resolvConfPath = "/etc/resolv.conf"
+	// hostByteOrder is the byte order the kernel prints the addresses of the route table in
+	hostByteOrder = procnet.NativeByteOrder()
+)
+
 // CollectNetworkData collects network information for linux
 func CollectNetworkData(context context.T) (data []model.NetworkData) {
 
-	//TODO: collect dhcp, dns server info from dhcp lease
-	//TODO: collect gateway addresses (possibly from 'route -n')
-	//TODO: collect subnetmask
+	//TODO: collect dhcp server info from dhcp lease
 
 	var interfaces []net.Interface
 	var err error
@@ -45,6 +54,9 @@ func CollectNetworkData(context context.T) (data []model.NetworkData) {
 		return
 	}
 
+	gateways := readDefaultGateways(context)
+	dnsServer := readDNSServer(context)
+
 	for _, i := range interfaces {
 		var networkData model.NetworkData
 
@@ -54,6 +66,8 @@ func CollectNetworkData(context context.T) (data []model.NetworkData) {
 		}
 
 		networkData = setNetworkData(context, i)
+		networkData.Gateway = gateways[i.Name]
+		networkData.DNSServer = dnsServer
 
 		dataB, _ := json.Marshal(networkData)
 
@@ -84,12 +98,14 @@ func setNetworkData(context context.T, networkInterface net.Interface) model.Net
 		//ipaddresses. This behavior will be changed soon.
 		for _, addr := range addresses {
 			var ip net.IP
+			var mask net.IPMask
 
 			switch v := addr.(type) {
 			case *net.IPAddr:
 				ip = v.IP
 			case *net.IPNet:
 				ip = v.IP
+				mask = v.Mask
 			}
 
 			//To4 - return nil if address is not IPV4 address
@@ -100,9 +116,68 @@ func setNetworkData(context context.T, networkInterface net.Interface) model.Net
 				networkData.IPV6 = ip.To16().String()
 			} else {
 				networkData.IPV4 = v4.String()
+				if len(mask) == net.IPv4len {
+					networkData.SubnetMask = net.IP(mask).String()
+				} else if len(mask) == net.IPv6len {
+					networkData.SubnetMask = net.IP(mask[12:]).String()
+				}
 			}
 		}
 	}
 
 	return networkData
 }
+
+// readDefaultGateways returns the gateway of the default route of each interface
+func readDefaultGateways(context context.T) map[string]string {
+	content, err := ioutil.ReadFile(routeTablePath)
+	if err != nil {
+		context.Log().Infof("Unable to read the route table - %v", err)
+		return map[string]string{}
+	}
+	return parseDefaultGateways(string(content))
+}
+
+// parseDefaultGateways parses the default routes of /proc/net/route, addresses are hex in host byte order, e.g.
+//   Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+//   eth0	00000000	0100A8C0	0003	0	0	0	00000000	0	0	0
+func parseDefaultGateways(content string) map[string]string {
+	gateways := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 || fields[1] != "00000000" || fields[7] != "00000000" {
+			continue
+		}
+		if _, found := gateways[fields[0]]; found {
+			continue
+		}
+		gateway, err := procnet.DecodeIP(fields[2], hostByteOrder)
+		if err != nil || len(gateway) != net.IPv4len {
+			continue
+		}
+		gateways[fields[0]] = gateway.String()
+	}
+	return gateways
+}
+
+// readDNSServer returns the first name server of the resolver configuration
+func readDNSServer(context context.T) string {
+	content, err := ioutil.ReadFile(resolvConfPath)
+	if err != nil {
+		context.Log().Infof("Unable to read the resolver configuration - %v", err)
+		return ""
+	}
+	return parseDNSServer(string(content))
+}
+
+// parseDNSServer returns the first nameserver of resolv.conf, like windows DNSServerSearchOrder only one server is reported
+func parseDNSServer(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			return fields[1]
+		}
+	}
+	return ""
+}
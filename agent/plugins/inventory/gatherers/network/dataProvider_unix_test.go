@@ -0,0 +1,53 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build darwin freebsd linux netbsd openbsd
+
+package network
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/procnet"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDefaultGateways(t *testing.T) {
+	// the sample was taken from a little endian host
+	hostByteOrder = binary.LittleEndian
+	defer func() { hostByteOrder = procnet.NativeByteOrder() }()
+	routes := `Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+eth0	00000000	0100A8C0	0003	0	0	0	00000000	0	0	0
+eth0	0000A8C0	00000000	0001	0	0	0	00FFFFFF	0	0	0
+eth1	00000000	01101FAC	0003	0	0	100	00000000	0	0	0
+eth1	00000000	02101FAC	0003	0	0	200	00000000	0	0	0
+docker0	000011AC	00000000	0001	0	0	0	0000FFFF	0	0	0
+`
+	assert.Equal(t, map[string]string{"eth0": "192.168.0.1", "eth1": "172.31.16.1"}, parseDefaultGateways(routes))
+}
+
+func TestParseDefaultGatewaysOfBigEndianHost(t *testing.T) {
+	hostByteOrder = binary.BigEndian
+	defer func() { hostByteOrder = procnet.NativeByteOrder() }()
+	routes := `Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+eth0	00000000	C0A80001	0003	0	0	0	00000000	0	0	0
+`
+	assert.Equal(t, map[string]string{"eth0": "192.168.0.1"}, parseDefaultGateways(routes))
+}
+
+func TestParseDNSServer(t *testing.T) {
+	assert.Equal(t, "172.31.0.2", parseDNSServer("; generated by dhclient\nsearch ec2.internal\nnameserver 172.31.0.2\nnameserver 8.8.8.8\n"))
+	assert.Equal(t, "", parseDNSServer("search ec2.internal\n"))
+}
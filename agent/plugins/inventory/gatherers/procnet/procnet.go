@@ -0,0 +1,45 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package procnet decodes the addresses of the network tables of /proc/net
+package procnet
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"unsafe"
+)
+
+// NativeByteOrder returns the byte order of the host, the kernel prints the address words of /proc/net in it
+func NativeByteOrder() binary.ByteOrder {
+	word := uint32(1)
+	if *(*byte)(unsafe.Pointer(&word)) == 1 {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}
+
+// DecodeIP decodes a hex ipv4 or ipv6 address of /proc/net, the address is made of 32 bit words printed as numbers
+// that are stored in the given byte order
+func DecodeIP(hexAddress string, order binary.ByteOrder) (net.IP, error) {
+	ip, err := hex.DecodeString(hexAddress)
+	if err != nil || (len(ip) != net.IPv4len && len(ip) != net.IPv6len) {
+		return nil, fmt.Errorf("invalid address %v", hexAddress)
+	}
+	for word := 0; word < len(ip); word += 4 {
+		order.PutUint32(ip[word:], binary.BigEndian.Uint32(ip[word:]))
+	}
+	return net.IP(ip), nil
+}
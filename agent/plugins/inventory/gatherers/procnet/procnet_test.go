@@ -0,0 +1,55 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package procnet
+
+import (
+	"encoding/binary"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeIP(t *testing.T) {
+	for _, test := range []struct {
+		hexAddress string
+		order      binary.ByteOrder
+		ip         string
+	}{
+		{"0100007F", binary.LittleEndian, "127.0.0.1"},
+		{"7F000001", binary.BigEndian, "127.0.0.1"},
+		{"B80D01200000000067452301EFCDAB89", binary.LittleEndian, "2001:db8::123:4567:89ab:cdef"},
+		{"20010DB8000000000123456789ABCDEF", binary.BigEndian, "2001:db8::123:4567:89ab:cdef"},
+	} {
+		ip, err := DecodeIP(test.hexAddress, test.order)
+
+		assert.Nil(t, err)
+		assert.Equal(t, test.ip, ip.String())
+	}
+}
+
+func TestDecodeIPReturnsErrorForInvalidAddress(t *testing.T) {
+	for _, hexAddress := range []string{"", "0100007", "0100007G", "0100007F01"} {
+		_, err := DecodeIP(hexAddress, binary.LittleEndian)
+
+		assert.NotNil(t, err, hexAddress)
+	}
+}
+
+func TestNativeByteOrder(t *testing.T) {
+	word := make([]byte, 4)
+	NativeByteOrder().PutUint32(word, 0x0100007F)
+
+	assert.Equal(t, uint32(0x0100007F), *(*uint32)(unsafe.Pointer(&word[0])))
+}
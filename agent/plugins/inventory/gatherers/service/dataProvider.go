@@ -0,0 +1,63 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package service
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+// Service types reported in the ServiceType attribute
+const (
+	ServiceTypeSystemd = "systemd"
+	ServiceTypeUpstart = "upstart"
+	ServiceTypeSysV    = "sysv"
+)
+
+// sizeLimitBytes leaves room for the item envelope below the size limit of one inventory type
+var sizeLimitBytes = model.SizeLimitKBPerInventoryType*1024 - 1024
+
+// CollectServiceData collects the services of the system using the init system specific queries.
+func CollectServiceData(context context.T) []model.ServiceData {
+	log := context.Log()
+	services := collectPlatformDependentServiceData(context)
+	sort.Sort(byName(services))
+	return limitSize(log, services)
+}
+
+// byName sorts services by name
+type byName []model.ServiceData
+
+func (s byName) Len() int           { return len(s) }
+func (s byName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byName) Less(i, j int) bool { return s[i].Name < s[j].Name }
+
+// limitSize drops the services that do not fit in the size limit of one inventory type, the inventory
+// plugin fails the whole upload otherwise
+func limitSize(log log.T, services []model.ServiceData) []model.ServiceData {
+	size := 2
+	for i, service := range services {
+		serviceB, _ := json.Marshal(service)
+		size += len(serviceB) + 1
+		if size > sizeLimitBytes {
+			log.Errorf("%v data exceeds the size limit, reporting %v of %v services", GathererName, i, len(services))
+			return services[:i]
+		}
+	}
+	return services
+}
@@ -0,0 +1,424 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build darwin freebsd linux netbsd openbsd
+
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	agentcontext "github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const (
+	systemctlCmd = "systemctl"
+	initctlCmd   = "initctl"
+	serviceCmd   = "service"
+
+	// systemdProperties are the unit properties queried with systemctl show
+	systemdProperties = "Id,Description,LoadState,ActiveState,SubState,UnitFileState,MainPID,FragmentPath"
+	// systemdBatchSize is the number of units queried with one systemctl show
+	systemdBatchSize = 64
+
+	// serviceStatusTimeout bounds the status query of one SysV service
+	serviceStatusTimeout = 10 * time.Second
+)
+
+var (
+	// systemdRunDir exists only when the system was booted with systemd (see sd_booted)
+	systemdRunDir = "/run/systemd/system"
+	initDir       = "/etc/init.d"
+	rcDir         = "/etc"
+	upstartDir    = "/etc/init"
+	pidDir        = "/var/run"
+
+	// sysvIgnoredScripts are files of the init script directory that are not services
+	sysvIgnoredScripts = map[string]bool{
+		"README":    true,
+		"skeleton":  true,
+		"functions": true,
+		"rc":        true,
+		"rcS":       true,
+		"halt":      true,
+		"killall":   true,
+		"single":    true,
+		"reboot":    true,
+	}
+
+	// initctlJobExp matches the lines of initctl list, e.g. "ssh start/running, process 1044" or
+	// "network-interface (eth0) start/running"
+	initctlJobExp = regexp.MustCompile(`^(\S+)(?: \(([^)]*)\))? ([^\s/]+)/([^\s,]+)(?:, (?:\S+ )?process (\d+))?`)
+
+	// statusActionExp matches the case pattern of the status action of an init script, e.g. "status)" or "  status|st)"
+	statusActionExp = regexp.MustCompile(`(?m)^\s*(?:[\w-]+\|)*status(?:\|[\w-]+)*\)`)
+)
+
+// decoupling exec.Command for easy testability
+var cmdExecutor = executeCommand
+
+// decoupling the service status query for easy testability
+var serviceStatus = executeServiceStatus
+
+func executeCommand(command string, args ...string) ([]byte, error) {
+	return exec.Command(command, args...).Output()
+}
+
+// executeServiceStatus queries the status of a SysV service with the service command, which runs the init script in
+// the clean environment the distribution expects, and returns its exit code, -1 if the query failed
+func executeServiceStatus(name string) int {
+	ctx, cancel := context.WithTimeout(context.Background(), serviceStatusTimeout)
+	defer cancel()
+	err := exec.CommandContext(ctx, serviceCmd, name, "status").Run()
+	if err == nil {
+		return 0
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok && ctx.Err() == nil {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Exited() {
+			return status.ExitStatus()
+		}
+	}
+	return -1
+}
+
+// collectPlatformDependentServiceData collects services from systemd when the system was booted with it,
+// otherwise from upstart jobs and SysV init scripts.
+func collectPlatformDependentServiceData(context agentcontext.T) []model.ServiceData {
+	log := context.Log()
+
+	// updateutil decides on systemd from a platform version table, the run directory covers every distribution
+	if _, err := os.Stat(systemdRunDir); err == nil {
+		services, err := collectSystemdServices(log)
+		if err == nil {
+			return services
+		}
+		log.Errorf("Failed to query systemd services, falling back to init scripts: %v", err)
+	}
+
+	services := collectUpstartServices(log)
+	jobs := make(map[string]bool)
+	for _, service := range services {
+		jobs[service.Name] = true
+	}
+	return append(services, collectSysVServices(log, jobs)...)
+}
+
+// collectSystemdServices queries the properties of every service unit, loaded or not
+func collectSystemdServices(log log.T) (services []model.ServiceData, err error) {
+	var output []byte
+	if output, err = cmdExecutor(systemctlCmd, "list-unit-files", "--type=service", "--no-legend", "--no-pager"); err != nil {
+		return nil, fmt.Errorf("failed to list unit files: %v", err)
+	}
+	names := parseUnitNames(string(output))
+
+	// units without unit file, e.g. generated from init scripts, are only listed as units
+	if output, err = cmdExecutor(systemctlCmd, "list-units", "--all", "--type=service", "--no-legend", "--no-pager"); err != nil {
+		log.Debugf("Failed to list units: %v", err)
+	} else {
+		names = append(names, parseUnitNames(string(output))...)
+	}
+	names = uniqueNames(names)
+
+	services = make([]model.ServiceData, 0, len(names))
+	for start := 0; start < len(names); start += systemdBatchSize {
+		end := start + systemdBatchSize
+		if end > len(names) {
+			end = len(names)
+		}
+		args := append([]string{"show", "--no-pager", "--property=" + systemdProperties}, names[start:end]...)
+		if output, err = cmdExecutor(systemctlCmd, args...); err != nil {
+			return nil, fmt.Errorf("failed to show units: %v", err)
+		}
+		services = append(services, parseSystemctlShow(string(output))...)
+	}
+	return services, nil
+}
+
+// parseUnitNames returns the service names of the list-units and list-unit-files output, e.g.
+//   sshd.service                               enabled
+//   ● rc-local.service  loaded failed failed   /etc/rc.local Compatibility
+// Template units are skipped, only their instances can be queried.
+func parseUnitNames(output string) (names []string) {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && (fields[0] == "●" || fields[0] == "*") {
+			fields = fields[1:]
+		}
+		if len(fields) == 0 || !strings.HasSuffix(fields[0], ".service") || strings.HasSuffix(fields[0], "@.service") {
+			continue
+		}
+		names = append(names, fields[0])
+	}
+	return
+}
+
+func uniqueNames(names []string) []string {
+	sort.Strings(names)
+	unique := make([]string, 0, len(names))
+	for i, name := range names {
+		if i == 0 || name != names[i-1] {
+			unique = append(unique, name)
+		}
+	}
+	return unique
+}
+
+// parseSystemctlShow parses the property blocks of systemctl show, one block per unit separated by an empty line:
+//   Id=sshd.service
+//   Description=OpenSSH server daemon
+//   LoadState=loaded
+//   ...
+// Units that are referenced but have no unit file are skipped.
+func parseSystemctlShow(output string) (services []model.ServiceData) {
+	for _, block := range strings.Split(strings.Replace(output, "\r\n", "\n", -1), "\n\n") {
+		properties := make(map[string]string)
+		for _, line := range strings.Split(block, "\n") {
+			if pos := strings.Index(line, "="); pos > 0 {
+				properties[line[:pos]] = strings.TrimSpace(line[pos+1:])
+			}
+		}
+		if properties["Id"] == "" || properties["LoadState"] == "not-found" {
+			continue
+		}
+		mainPID := properties["MainPID"]
+		if mainPID == "0" {
+			mainPID = ""
+		}
+		services = append(services, model.ServiceData{
+			Name:         properties["Id"],
+			Description:  properties["Description"],
+			ServiceType:  ServiceTypeSystemd,
+			LoadState:    properties["LoadState"],
+			ActiveState:  properties["ActiveState"],
+			SubState:     properties["SubState"],
+			StartType:    properties["UnitFileState"],
+			MainPID:      mainPID,
+			UnitFilePath: properties["FragmentPath"],
+		})
+	}
+	return
+}
+
+// collectUpstartServices returns the upstart jobs, nothing if upstart is not the init system
+func collectUpstartServices(log log.T) []model.ServiceData {
+	output, err := cmdExecutor(initctlCmd, "list")
+	if err != nil {
+		log.Debugf("No upstart jobs, initctl list failed: %v", err)
+		return []model.ServiceData{}
+	}
+	return parseInitctlList(string(output))
+}
+
+// parseInitctlList parses the jobs of initctl list and reads their job configuration
+func parseInitctlList(output string) []model.ServiceData {
+	services := make([]model.ServiceData, 0)
+	for _, line := range strings.Split(output, "\n") {
+		match := initctlJobExp.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		job, instance, goal, status, pid := match[1], match[2], match[3], match[4], match[5]
+		name := job
+		if instance != "" {
+			name = fmt.Sprintf("%v (%v)", job, instance)
+		}
+		activeState := "inactive"
+		if goal == "start" {
+			activeState = "active"
+		}
+		confPath := filepath.Join(upstartDir, job+".conf")
+		description, startOn := readJobConfiguration(confPath)
+		startType := "disabled"
+		if startOn && !isJobOverriddenManual(job) {
+			startType = "enabled"
+		}
+		service := model.ServiceData{
+			Name:        name,
+			Description: description,
+			ServiceType: ServiceTypeUpstart,
+			ActiveState: activeState,
+			SubState:    status,
+			StartType:   startType,
+			MainPID:     pid,
+		}
+		if _, err := os.Stat(confPath); err == nil {
+			service.UnitFilePath = confPath
+		}
+		services = append(services, service)
+	}
+	return services
+}
+
+// readJobConfiguration returns the description of an upstart job and whether it starts on an event
+func readJobConfiguration(path string) (description string, startOn bool) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "description "):
+			description = strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "description ")), `"`)
+		case strings.HasPrefix(line, "start on "):
+			startOn = true
+		case line == "manual":
+			return description, false
+		}
+	}
+	return
+}
+
+// isJobOverriddenManual returns true if the override file of a job disables its start events
+func isJobOverriddenManual(job string) bool {
+	content, err := ioutil.ReadFile(filepath.Join(upstartDir, job+".override"))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.TrimSpace(line) == "manual" {
+			return true
+		}
+	}
+	return false
+}
+
+// collectSysVServices returns the SysV init scripts that are not already reported as upstart jobs
+func collectSysVServices(log log.T, jobs map[string]bool) []model.ServiceData {
+	services := make([]model.ServiceData, 0)
+	files, err := ioutil.ReadDir(initDir)
+	if err != nil {
+		log.Debugf("No SysV init scripts: %v", err)
+		return services
+	}
+	for _, file := range files {
+		name := file.Name()
+		script := filepath.Join(initDir, name)
+		if sysvIgnoredScripts[name] || jobs[name] || strings.Contains(name, ".dpkg-") || strings.HasSuffix(name, ".rpmsave") {
+			continue
+		}
+		// scripts linked to upstart-job only forward to the upstart job
+		if target, err := os.Readlink(script); err == nil && filepath.Base(target) == "upstart-job" {
+			continue
+		}
+		info, err := os.Stat(script)
+		if err != nil || !info.Mode().IsRegular() || info.Mode()&0111 == 0 {
+			continue
+		}
+
+		// a script without a status action may run its default action instead, e.g. start the service
+		activeState, subState := "unknown", ""
+		if hasStatusAction(script) {
+			activeState, subState = sysvState(serviceStatus(name))
+		}
+		service := model.ServiceData{
+			Name:         name,
+			Description:  readScriptDescription(script),
+			ServiceType:  ServiceTypeSysV,
+			ActiveState:  activeState,
+			SubState:     subState,
+			StartType:    "disabled",
+			MainPID:      readPIDFile(name),
+			UnitFilePath: script,
+		}
+		if isScriptEnabled(name) {
+			service.StartType = "enabled"
+		}
+		services = append(services, service)
+	}
+	return services
+}
+
+// sysvState maps the LSB exit codes of the status action to systemd like states
+func sysvState(exitCode int) (activeState string, subState string) {
+	switch exitCode {
+	case 0:
+		return "active", "running"
+	case 1, 2:
+		return "failed", "dead"
+	case 3:
+		return "inactive", "dead"
+	}
+	return "unknown", ""
+}
+
+// readScriptDescription returns the LSB Short-Description or the chkconfig description of an init script
+func readScriptDescription(script string) (description string) {
+	content, err := ioutil.ReadFile(script)
+	if err != nil {
+		return
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			break
+		}
+		comment := strings.TrimSpace(strings.TrimPrefix(line, "#"))
+		if strings.HasPrefix(comment, "Short-Description:") {
+			return strings.TrimSpace(strings.TrimPrefix(comment, "Short-Description:"))
+		}
+		if strings.HasPrefix(comment, "description:") && description == "" {
+			description = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(comment, "description:"), `\`))
+		}
+	}
+	return
+}
+
+// hasStatusAction returns true if the init script handles the status action, e.g. in a case branch "status)"
+func hasStatusAction(script string) bool {
+	content, err := ioutil.ReadFile(script)
+	if err != nil {
+		return false
+	}
+	return statusActionExp.Match(content)
+}
+
+// isScriptEnabled returns true if the script is started in one of the multi-user runlevels
+func isScriptEnabled(name string) bool {
+	for _, runlevel := range []string{"2", "3", "4", "5"} {
+		if links, _ := filepath.Glob(filepath.Join(rcDir, "rc"+runlevel+".d", "S[0-9][0-9]"+name)); len(links) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// readPIDFile returns the process id recorded by a SysV service in its pid file
+func readPIDFile(name string) string {
+	content, err := ioutil.ReadFile(filepath.Join(pidDir, name+".pid"))
+	if err != nil {
+		return ""
+	}
+	pid := strings.TrimSpace(string(content))
+	if _, err := strconv.Atoi(pid); err != nil {
+		return ""
+	}
+	return pid
+}
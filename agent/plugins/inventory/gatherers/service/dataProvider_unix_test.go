@@ -0,0 +1,225 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build darwin freebsd linux netbsd openbsd
+
+package service
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	sampleUnitFiles = `sshd.service                               enabled
+getty@.service                             enabled
+telnet.socket                              disabled
+`
+	sampleUnits = `  sshd.service        loaded active   running OpenSSH server daemon
+● rc-local.service    loaded failed   failed  /etc/rc.local Compatibility
+  plymouth.service    not-found inactive dead plymouth.service
+`
+	sampleShow = `Id=rc-local.service
+Description=/etc/rc.local Compatibility
+LoadState=loaded
+ActiveState=failed
+SubState=failed
+UnitFileState=static
+MainPID=0
+FragmentPath=/usr/lib/systemd/system/rc-local.service
+
+Id=sshd.service
+Description=OpenSSH server daemon
+LoadState=loaded
+ActiveState=active
+SubState=running
+UnitFileState=enabled
+MainPID=1044
+FragmentPath=/usr/lib/systemd/system/sshd.service
+
+Id=plymouth.service
+Description=plymouth.service
+LoadState=not-found
+ActiveState=inactive
+SubState=dead
+UnitFileState=
+MainPID=0
+FragmentPath=
+`
+	sampleInitctlList = `rc stop/waiting
+ssh start/running, process 1044
+network-interface (eth0) start/running
+tty1 start/running, process 1321
+`
+)
+
+// mockExecutor returns the output registered for the command and its first argument
+func mockExecutor(outputs map[string]string) func(string, ...string) ([]byte, error) {
+	return func(command string, args ...string) ([]byte, error) {
+		key := command
+		if len(args) > 0 {
+			key = command + " " + args[0]
+		}
+		if output, ok := outputs[key]; ok {
+			return []byte(output), nil
+		}
+		return nil, errors.New("command not found")
+	}
+}
+
+// setupInitDirs points the init system directories to a temporary directory
+func setupInitDirs(t *testing.T) (root string, cleanup func()) {
+	root, err := ioutil.TempDir("", "servicegatherer")
+	assert.Nil(t, err)
+	saved := []string{systemdRunDir, initDir, rcDir, upstartDir, pidDir}
+	systemdRunDir = filepath.Join(root, "run", "systemd", "system")
+	initDir = filepath.Join(root, "init.d")
+	rcDir = root
+	upstartDir = filepath.Join(root, "init")
+	pidDir = filepath.Join(root, "run")
+	for _, dir := range []string{initDir, upstartDir, pidDir, filepath.Join(rcDir, "rc3.d")} {
+		assert.Nil(t, os.MkdirAll(dir, 0755))
+	}
+	return root, func() {
+		systemdRunDir, initDir, rcDir, upstartDir, pidDir = saved[0], saved[1], saved[2], saved[3], saved[4]
+		cmdExecutor = executeCommand
+		serviceStatus = executeServiceStatus
+		os.RemoveAll(root)
+	}
+}
+
+func writeFile(t *testing.T, path string, content string, mode os.FileMode) {
+	assert.Nil(t, ioutil.WriteFile(path, []byte(content), mode))
+}
+
+func TestParseUnitNames(t *testing.T) {
+	assert.Equal(t, []string{"sshd.service"}, parseUnitNames(sampleUnitFiles))
+	assert.Equal(t, []string{"sshd.service", "rc-local.service", "plymouth.service"}, parseUnitNames(sampleUnits))
+}
+
+func TestCollectSystemdServices(t *testing.T) {
+	_, cleanup := setupInitDirs(t)
+	defer cleanup()
+	assert.Nil(t, os.MkdirAll(systemdRunDir, 0755))
+
+	var showArgs []string
+	outputs := mockExecutor(map[string]string{
+		"systemctl list-unit-files": sampleUnitFiles,
+		"systemctl list-units":      sampleUnits,
+	})
+	cmdExecutor = func(command string, args ...string) ([]byte, error) {
+		if len(args) > 0 && args[0] == "show" {
+			showArgs = args
+			return []byte(sampleShow), nil
+		}
+		return outputs(command, args...)
+	}
+
+	services := CollectServiceData(context.NewMockDefault())
+	assert.Equal(t, []string{"plymouth.service", "rc-local.service", "sshd.service"}, showArgs[3:])
+	assert.Equal(t, 2, len(services))
+	assert.Equal(t, model.ServiceData{
+		Name:         "rc-local.service",
+		Description:  "/etc/rc.local Compatibility",
+		ServiceType:  ServiceTypeSystemd,
+		LoadState:    "loaded",
+		ActiveState:  "failed",
+		SubState:     "failed",
+		StartType:    "static",
+		UnitFilePath: "/usr/lib/systemd/system/rc-local.service",
+	}, services[0])
+	assert.Equal(t, "sshd.service", services[1].Name)
+	assert.Equal(t, "1044", services[1].MainPID)
+	assert.Equal(t, "enabled", services[1].StartType)
+}
+
+func TestCollectUpstartAndSysVServices(t *testing.T) {
+	_, cleanup := setupInitDirs(t)
+	defer cleanup()
+
+	cmdExecutor = mockExecutor(map[string]string{"initctl list": sampleInitctlList})
+	serviceStatus = func(name string) int {
+		if name == "telnetd" {
+			return 0
+		}
+		return 3
+	}
+	writeFile(t, filepath.Join(upstartDir, "ssh.conf"), "description \"OpenSSH server\"\nstart on runlevel [2345]\n", 0644)
+	writeFile(t, filepath.Join(upstartDir, "tty1.conf"), "start on stopped rc RUNLEVEL=[2345]\n", 0644)
+	writeFile(t, filepath.Join(upstartDir, "tty1.override"), "manual\n", 0644)
+	writeFile(t, filepath.Join(initDir, "telnetd"), "#!/bin/sh\n### BEGIN INIT INFO\n# Short-Description: telnet server\n### END INIT INFO\ncase \"$1\" in\n  start|stop) ;;\n  status) ;;\nesac\n", 0755)
+	writeFile(t, filepath.Join(initDir, "crond"), "#!/bin/sh\n# chkconfig: 2345 90 60\n# description: cron is a standard UNIX program \\\n#              that runs commands\ncase \"$1\" in\n  restart|status) ;;\nesac\n", 0755)
+	writeFile(t, filepath.Join(initDir, "README"), "not a service", 0755)
+	writeFile(t, filepath.Join(initDir, "notexecutable"), "#!/bin/sh\n", 0644)
+	writeFile(t, filepath.Join(initDir, "ssh"), "#!/bin/sh\n", 0755)
+	writeFile(t, filepath.Join(initDir, "oneshot"), "#!/bin/sh\ntouch /tmp/started\n", 0755)
+	writeFile(t, filepath.Join(pidDir, "telnetd.pid"), "2048\n", 0644)
+	assert.Nil(t, os.Symlink(filepath.Join(initDir, "telnetd"), filepath.Join(rcDir, "rc3.d", "S20telnetd")))
+
+	services := CollectServiceData(context.NewMockDefault())
+	names := make([]string, 0)
+	for _, service := range services {
+		names = append(names, service.Name)
+	}
+	assert.Equal(t, "crond network-interface (eth0) oneshot rc ssh telnetd tty1", strings.Join(names, " "))
+
+	crond, oneshot, ssh, telnetd, tty1 := services[0], services[2], services[4], services[5], services[6]
+	assert.Equal(t, model.ServiceData{
+		Name:         "telnetd",
+		Description:  "telnet server",
+		ServiceType:  ServiceTypeSysV,
+		ActiveState:  "active",
+		SubState:     "running",
+		StartType:    "enabled",
+		MainPID:      "2048",
+		UnitFilePath: filepath.Join(initDir, "telnetd"),
+	}, telnetd)
+	assert.Equal(t, "cron is a standard UNIX program", crond.Description)
+	assert.Equal(t, "inactive", crond.ActiveState)
+	assert.Equal(t, "disabled", crond.StartType)
+	// the status of a script without a status action is not queried
+	assert.Equal(t, "unknown", oneshot.ActiveState)
+	assert.Equal(t, model.ServiceData{
+		Name:         "ssh",
+		Description:  "OpenSSH server",
+		ServiceType:  ServiceTypeUpstart,
+		ActiveState:  "active",
+		SubState:     "running",
+		StartType:    "enabled",
+		MainPID:      "1044",
+		UnitFilePath: filepath.Join(upstartDir, "ssh.conf"),
+	}, ssh)
+	assert.Equal(t, "disabled", tty1.StartType)
+}
+
+func TestSystemdFailureFallsBackToInitScripts(t *testing.T) {
+	_, cleanup := setupInitDirs(t)
+	defer cleanup()
+	assert.Nil(t, os.MkdirAll(systemdRunDir, 0755))
+
+	cmdExecutor = mockExecutor(map[string]string{})
+	serviceStatus = func(name string) int { return 0 }
+	writeFile(t, filepath.Join(initDir, "sshd"), "#!/bin/sh\n", 0755)
+
+	services := CollectServiceData(context.NewMockDefault())
+	assert.Equal(t, 1, len(services))
+	assert.Equal(t, ServiceTypeSysV, services[0].ServiceType)
+}
@@ -0,0 +1,65 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package service
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func MockServiceData(context context.T) []model.ServiceData {
+	return []model.ServiceData{
+		{
+			Name:         "sshd.service",
+			Description:  "OpenSSH server daemon",
+			ServiceType:  ServiceTypeSystemd,
+			LoadState:    "loaded",
+			ActiveState:  "active",
+			SubState:     "running",
+			StartType:    "enabled",
+			MainPID:      "1044",
+			UnitFilePath: "/usr/lib/systemd/system/sshd.service",
+		},
+	}
+}
+
+func TestGatherer(t *testing.T) {
+	c := context.NewMockDefault()
+	g := Gatherer(c)
+	collectData = MockServiceData
+	items, err := g.Run(c, model.Config{})
+	assert.Nil(t, err, "Unexpected error thrown")
+	assert.Equal(t, 1, len(items))
+	assert.Equal(t, GathererName, items[0].Name)
+	assert.Equal(t, SchemaVersionOfService, items[0].SchemaVersion)
+	assert.Equal(t, MockServiceData(c), items[0].Content)
+	assert.NotNil(t, items[0].CaptureTime)
+}
+
+func TestLimitSize(t *testing.T) {
+	c := context.NewMockDefault()
+	services := make([]model.ServiceData, 0)
+	for i := 0; i < 100; i++ {
+		services = append(services, MockServiceData(c)...)
+	}
+	assert.Equal(t, 100, len(limitSize(c.Log(), services)))
+
+	defer func(limit int) { sizeLimitBytes = limit }(sizeLimitBytes)
+	sizeLimitBytes = 1000
+	limited := limitSize(c.Log(), services)
+	assert.True(t, len(limited) > 0 && len(limited) < 100)
+}
@@ -0,0 +1,98 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package useraccount
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+// filterObj is the optional Filters policy of the gatherer, e.g.
+//   {"Users": ["ec2-user", "app*"], "Groups": ["wheel"], "ExcludeSystemAccounts": true}
+// Names are matched with shell patterns, no pattern matches every name.
+type filterObj struct {
+	Users                 []string
+	Groups                []string
+	ExcludeSystemAccounts bool
+}
+
+// CollectUserAccountData collects the local users and groups of the system that match the filters of the configuration.
+func CollectUserAccountData(context context.T, config model.Config) (users []model.UserAccountData, groups []model.GroupData, err error) {
+	log := context.Log()
+
+	var filter filterObj
+	if config.Filters != "" {
+		if err = json.Unmarshal([]byte(config.Filters), &filter); err != nil {
+			err = fmt.Errorf("Invalid %v filters %v: %v", GathererName, config.Filters, err)
+			log.Error(err)
+			return
+		}
+	}
+	for _, pattern := range append(filter.Users, filter.Groups...) {
+		if _, err = filepath.Match(pattern, ""); err != nil {
+			err = fmt.Errorf("Invalid %v filter pattern %v: %v", GathererName, pattern, err)
+			log.Error(err)
+			return
+		}
+	}
+
+	users, groups = collectPlatformDependentUserAccountData(context)
+	isSystem := func(id string) bool { return false }
+	if filter.ExcludeSystemAccounts {
+		isSystem = systemAccountFilter()
+	}
+	return filterUsers(users, filter, isSystem), filterGroups(groups, filter, isSystem), nil
+}
+
+func filterUsers(users []model.UserAccountData, filter filterObj, isSystem func(id string) bool) []model.UserAccountData {
+	result := make([]model.UserAccountData, 0, len(users))
+	for _, user := range users {
+		if isSystem(user.UID) {
+			continue
+		}
+		if matchesAnyPattern(filter.Users, user.Name) {
+			result = append(result, user)
+		}
+	}
+	return result
+}
+
+func filterGroups(groups []model.GroupData, filter filterObj, isSystem func(id string) bool) []model.GroupData {
+	result := make([]model.GroupData, 0, len(groups))
+	for _, group := range groups {
+		if isSystem(group.GID) {
+			continue
+		}
+		if matchesAnyPattern(filter.Groups, group.Name) {
+			result = append(result, group)
+		}
+	}
+	return result
+}
+
+func matchesAnyPattern(patterns []string, name string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,399 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build darwin freebsd linux netbsd openbsd
+
+package useraccount
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const (
+	// shadowNoExpiry is the password maximum age used for passwords that never expire
+	shadowNoExpiry = 99999
+	// defaultUIDMin is the first uid of regular users when login.defs does not set UID_MIN
+	defaultUIDMin = 1000
+	// nobodyID is the id of the nobody user and nogroup group
+	nobodyID   = 65534
+	dateFormat = "2006-01-02"
+)
+
+var (
+	passwdPath    = "/etc/passwd"
+	shadowPath    = "/etc/shadow"
+	groupPath     = "/etc/group"
+	sudoersPath   = "/etc/sudoers"
+	sudoersDir    = "/etc/sudoers.d"
+	loginDefsPath = "/etc/login.defs"
+
+	// decoupling time.Now for easy testability
+	timeNow = time.Now
+)
+
+// shadowEntry is the password metadata of a user, the password hash is reduced to its status
+type shadowEntry struct {
+	passwordStatus string
+	lastChange     string
+	maxDays        string
+	inactiveDays   string
+	expire         string
+}
+
+// collectPlatformDependentUserAccountData collects users and groups from passwd, shadow, group and sudoers.
+func collectPlatformDependentUserAccountData(context context.T) (users []model.UserAccountData, groups []model.GroupData) {
+	log := context.Log()
+
+	passwd, err := readEntries(passwdPath)
+	if err != nil {
+		log.Errorf("Failed to read %v: %v", passwdPath, err)
+		return []model.UserAccountData{}, []model.GroupData{}
+	}
+	groupEntries, err := readEntries(groupPath)
+	if err != nil {
+		log.Errorf("Failed to read %v: %v", groupPath, err)
+	}
+	shadowEntries, err := readEntries(shadowPath)
+	if err != nil {
+		log.Debugf("Password metadata is not reported, failed to read %v: %v", shadowPath, err)
+	}
+	sudoUsers, sudoGroups := readSudoers(log)
+
+	return buildUsersAndGroups(passwd, groupEntries, parseShadow(shadowEntries), sudoUsers, sudoGroups)
+}
+
+// readEntries returns the colon separated fields of each entry of a passwd style database
+func readEntries(path string) (entries [][]string, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		// skip comments and NIS compat entries
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") {
+			continue
+		}
+		entries = append(entries, strings.Split(line, ":"))
+	}
+	return entries, scanner.Err()
+}
+
+// parseShadow maps the shadow entries by user name, the password field is only used for its status
+func parseShadow(entries [][]string) map[string]shadowEntry {
+	shadow := make(map[string]shadowEntry)
+	for _, fields := range entries {
+		if len(fields) < 8 {
+			continue
+		}
+		shadow[fields[0]] = shadowEntry{
+			passwordStatus: passwordStatus(fields[1]),
+			lastChange:     fields[2],
+			maxDays:        fields[4],
+			inactiveDays:   fields[6],
+			expire:         fields[7],
+		}
+	}
+	return shadow
+}
+
+// passwordStatus describes a password field without revealing it
+func passwordStatus(password string) string {
+	switch {
+	case password == "":
+		return "Empty"
+	case strings.HasPrefix(password, "!"):
+		return "Locked"
+	case password == "*" || password == "x":
+		return "Disabled"
+	}
+	return "Set"
+}
+
+// buildUsersAndGroups joins the databases into users and groups sorted by name
+func buildUsersAndGroups(passwd, groupEntries [][]string, shadow map[string]shadowEntry, sudoUsers, sudoGroups map[string]bool) (users []model.UserAccountData, groups []model.GroupData) {
+	groupNames := make(map[string]string)
+	members := make(map[string][]string)
+	for _, fields := range groupEntries {
+		if len(fields) < 4 {
+			continue
+		}
+		groupNames[fields[2]] = fields[0]
+		for _, member := range strings.Split(fields[3], ",") {
+			if member = strings.TrimSpace(member); member != "" {
+				members[fields[0]] = appendUnique(members[fields[0]], member)
+			}
+		}
+	}
+
+	userGroups := make(map[string][]string)
+	today := daysSinceEpoch(timeNow())
+	users = make([]model.UserAccountData, 0, len(passwd))
+	for _, fields := range passwd {
+		if len(fields) < 7 {
+			continue
+		}
+		name, uid, gid := fields[0], fields[2], fields[3]
+		primaryGroup := groupNames[gid]
+		if primaryGroup != "" {
+			// users are members of their primary group even though group does not list them
+			members[primaryGroup] = appendUnique(members[primaryGroup], name)
+		}
+		user := model.UserAccountData{
+			Name:          name,
+			UID:           uid,
+			GID:           gid,
+			PrimaryGroup:  primaryGroup,
+			FullName:      strings.Split(fields[4], ",")[0],
+			HomeDirectory: fields[5],
+			Shell:         fields[6],
+			Locked:        "false",
+			Expired:       "false",
+			SudoAccess:    strconv.FormatBool(sudoUsers[name] || sudoUsers["#"+uid] || sudoGroups[primaryGroup]),
+		}
+		if entry, found := shadow[name]; found {
+			setShadowAttributes(&user, entry, today)
+		}
+		users = append(users, user)
+	}
+
+	groups = make([]model.GroupData, 0, len(groupEntries))
+	for _, fields := range groupEntries {
+		if len(fields) < 4 {
+			continue
+		}
+		for _, member := range members[fields[0]] {
+			userGroups[member] = appendUnique(userGroups[member], fields[0])
+		}
+		sort.Strings(members[fields[0]])
+		groups = append(groups, model.GroupData{
+			Name:       fields[0],
+			GID:        fields[2],
+			Members:    strings.Join(members[fields[0]], ","),
+			SudoAccess: strconv.FormatBool(sudoGroups[fields[0]]),
+		})
+	}
+
+	for i := range users {
+		sort.Strings(userGroups[users[i].Name])
+		users[i].Groups = strings.Join(userGroups[users[i].Name], ",")
+		for _, group := range userGroups[users[i].Name] {
+			if sudoGroups[group] {
+				users[i].SudoAccess = "true"
+			}
+		}
+	}
+
+	sort.Sort(byUserName(users))
+	sort.Sort(byGroupName(groups))
+	return users, groups
+}
+
+// setShadowAttributes sets the password and expiry state of a user from its shadow entry
+func setShadowAttributes(user *model.UserAccountData, entry shadowEntry, today int) {
+	user.PasswordStatus = entry.passwordStatus
+	user.Locked = strconv.FormatBool(entry.passwordStatus == "Locked")
+
+	expired := false
+	if lastChange, err := strconv.Atoi(entry.lastChange); err == nil && lastChange > 0 {
+		user.LastPasswordChange = formatDays(lastChange)
+		if maxDays, err := strconv.Atoi(entry.maxDays); err == nil && maxDays >= 0 && maxDays < shadowNoExpiry {
+			user.PasswordExpiry = formatDays(lastChange + maxDays)
+			// the account is disabled once the inactive period after password expiry is over
+			if inactiveDays, err := strconv.Atoi(entry.inactiveDays); err == nil && lastChange+maxDays+inactiveDays < today {
+				expired = true
+			}
+		}
+	}
+	if expire, err := strconv.Atoi(entry.expire); err == nil && expire > 0 {
+		user.AccountExpiry = formatDays(expire)
+		if expire <= today {
+			expired = true
+		}
+	}
+	user.Expired = strconv.FormatBool(expired)
+}
+
+func daysSinceEpoch(t time.Time) int {
+	return int(t.Unix() / (24 * 60 * 60))
+}
+
+func formatDays(days int) string {
+	return time.Unix(int64(days)*24*60*60, 0).UTC().Format(dateFormat)
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+// byUserName sorts users by name
+type byUserName []model.UserAccountData
+
+func (s byUserName) Len() int           { return len(s) }
+func (s byUserName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byUserName) Less(i, j int) bool { return s[i].Name < s[j].Name }
+
+// byGroupName sorts groups by name
+type byGroupName []model.GroupData
+
+func (s byGroupName) Len() int           { return len(s) }
+func (s byGroupName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byGroupName) Less(i, j int) bool { return s[i].Name < s[j].Name }
+
+// readSudoers returns the users and groups granted rules in sudoers and its drop-in directory.
+// Users are keyed by name or #uid, User_Alias members are expanded.
+func readSudoers(log log.T) (sudoUsers map[string]bool, sudoGroups map[string]bool) {
+	sudoUsers = make(map[string]bool)
+	sudoGroups = make(map[string]bool)
+	aliases := make(map[string][]string)
+
+	files := []string{sudoersPath}
+	if dropIns, err := ioutil.ReadDir(sudoersDir); err == nil {
+		for _, dropIn := range dropIns {
+			// sudo skips drop-ins containing a dot or ending with ~
+			if dropIn.IsDir() || strings.Contains(dropIn.Name(), ".") || strings.HasSuffix(dropIn.Name(), "~") {
+				continue
+			}
+			files = append(files, filepath.Join(sudoersDir, dropIn.Name()))
+		}
+	}
+
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.Debugf("Failed to read sudoers file %v: %v", file, err)
+			continue
+		}
+		parseSudoers(string(content), aliases, sudoUsers, sudoGroups)
+	}
+	return
+}
+
+// parseSudoers adds the users and groups of the user specifications of a sudoers file, e.g.
+//   User_Alias ADMINS = alice, bob
+//   ADMINS, %wheel ALL=(ALL) ALL
+func parseSudoers(content string, aliases map[string][]string, sudoUsers, sudoGroups map[string]bool) {
+	content = strings.Replace(content, "\\\n", " ", -1)
+	for _, line := range strings.Split(content, "\n") {
+		if pos := strings.Index(line, "#"); pos >= 0 && !isUIDReference(line[pos:]) {
+			line = line[:pos]
+		}
+		line = strings.TrimSpace(line)
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] == "Defaults" || strings.HasPrefix(fields[0], "Defaults") || strings.HasPrefix(fields[0], "@") {
+			continue
+		}
+		switch fields[0] {
+		case "User_Alias":
+			for _, definition := range strings.Split(strings.TrimSpace(strings.TrimPrefix(line, "User_Alias")), ":") {
+				if parts := strings.SplitN(definition, "=", 2); len(parts) == 2 {
+					aliases[strings.TrimSpace(parts[0])] = splitList(parts[1])
+				}
+			}
+			continue
+		case "Host_Alias", "Runas_Alias", "Cmnd_Alias", "Cmd_Alias":
+			continue
+		}
+
+		// the user list is followed by the host list, both are comma separated and precede the first '='
+		pos := strings.Index(line, "=")
+		if pos < 0 {
+			continue
+		}
+		lists := strings.Fields(line[:pos])
+		if len(lists) < 2 {
+			continue
+		}
+		userList := lists[0]
+		i := 1
+		for ; i < len(lists) && (strings.HasSuffix(userList, ",") || strings.HasPrefix(lists[i], ",")); i++ {
+			userList += lists[i]
+		}
+		if i == len(lists) {
+			continue
+		}
+		addSudoers(splitList(userList), aliases, sudoUsers, sudoGroups, 0)
+	}
+}
+
+// addSudoers records the users and groups of a user list, aliases are expanded up to a fixed depth
+func addSudoers(entries []string, aliases map[string][]string, sudoUsers, sudoGroups map[string]bool, depth int) {
+	for _, entry := range entries {
+		if strings.HasPrefix(entry, "!") {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(entry, "%"):
+			sudoGroups[strings.TrimPrefix(strings.TrimPrefix(entry, "%"), ":")] = true
+		case strings.HasPrefix(entry, "+"):
+			// netgroups are not resolved
+		case aliases[entry] != nil && depth < 8:
+			addSudoers(aliases[entry], aliases, sudoUsers, sudoGroups, depth+1)
+		default:
+			sudoUsers[entry] = true
+		}
+	}
+}
+
+// isUIDReference returns true if a # starts a uid in a user list rather than a comment
+func isUIDReference(s string) bool {
+	return len(s) > 1 && s[1] >= '0' && s[1] <= '9'
+}
+
+func splitList(list string) []string {
+	entries := make([]string, 0)
+	for _, entry := range strings.Split(list, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// systemAccountFilter returns a matcher for system accounts, ids below UID_MIN of login.defs and nobody.
+// root is not a system account for reporting purposes.
+func systemAccountFilter() func(id string) bool {
+	uidMin := defaultUIDMin
+	if content, err := ioutil.ReadFile(loginDefsPath); err == nil {
+		for _, line := range strings.Split(string(content), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) == 2 && fields[0] == "UID_MIN" {
+				if value, err := strconv.Atoi(fields[1]); err == nil {
+					uidMin = value
+				}
+			}
+		}
+	}
+	return func(id string) bool {
+		value, err := strconv.Atoi(id)
+		return err == nil && value != 0 && (value < uidMin || value == nobodyID)
+	}
+}
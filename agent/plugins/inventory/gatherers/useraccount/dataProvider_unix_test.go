@@ -0,0 +1,179 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build darwin freebsd linux netbsd openbsd
+
+package useraccount
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	samplePasswd = `root:x:0:0:root:/root:/bin/bash
+daemon:x:2:2:daemon:/sbin:/sbin/nologin
+sshd:x:74:74:Privilege-separated SSH:/var/empty/sshd:/sbin/nologin
+ec2-user:x:1000:1000:EC2 Default User,,,:/home/ec2-user:/bin/bash
+alice:x:1001:1001::/home/alice:/bin/zsh
+bob:x:1002:1002::/home/bob:/bin/bash
++@netgroup::::::
+`
+	// 17000 days is 2016-07-18
+	sampleShadow = `root:$6$salt$hash:17000:0:99999:7:::
+daemon:*:17000:0:99999:7:::
+sshd:!!:17000::::::
+ec2-user:!!:17000:0:99999:7:::
+alice:$6$salt$hash:17000:0:90:7:10::
+bob:$6$salt$hash:17000:0:99999:7::17100:
+`
+	sampleGroup = `root:x:0:
+wheel:x:10:ec2-user,alice
+sshd:x:74:
+ec2-user:x:1000:
+alice:x:1001:
+bob:x:1002:
+ops:x:2000:bob,alice
+`
+	sampleSudoers = `## Allow root to run any commands anywhere
+Defaults    env_reset
+root	ALL=(ALL) 	ALL
+%wheel	ALL=(ALL)	ALL
+#includedir /etc/sudoers.d
+`
+	sampleSudoersDropIn = `User_Alias OPERATORS = carol, \
+	bob
+Cmnd_Alias RESTART = /bin/systemctl restart *
+OPERATORS ALL = (root) NOPASSWD: RESTART
+`
+)
+
+func setupAccountFiles(t *testing.T) (cleanup func()) {
+	dir, err := ioutil.TempDir("", "useraccount")
+	assert.Nil(t, err)
+	saved := []string{passwdPath, shadowPath, groupPath, sudoersPath, sudoersDir, loginDefsPath}
+	passwdPath = filepath.Join(dir, "passwd")
+	shadowPath = filepath.Join(dir, "shadow")
+	groupPath = filepath.Join(dir, "group")
+	sudoersPath = filepath.Join(dir, "sudoers")
+	sudoersDir = filepath.Join(dir, "sudoers.d")
+	loginDefsPath = filepath.Join(dir, "login.defs")
+	timeNow = func() time.Time { return time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC) }
+
+	assert.Nil(t, os.MkdirAll(sudoersDir, 0750))
+	files := map[string]string{
+		passwdPath:                               samplePasswd,
+		shadowPath:                               sampleShadow,
+		groupPath:                                sampleGroup,
+		sudoersPath:                              sampleSudoers,
+		filepath.Join(sudoersDir, "operators"):   sampleSudoersDropIn,
+		filepath.Join(sudoersDir, "ignored.bak"): "daemon ALL=(ALL) ALL\n",
+		loginDefsPath:                            "# comment\nUID_MIN                  1000\n",
+	}
+	for path, content := range files {
+		assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0640))
+	}
+	return func() {
+		passwdPath, shadowPath, groupPath, sudoersPath, sudoersDir, loginDefsPath = saved[0], saved[1], saved[2], saved[3], saved[4], saved[5]
+		timeNow = time.Now
+		os.RemoveAll(dir)
+	}
+}
+
+func findUser(users []model.UserAccountData, name string) model.UserAccountData {
+	for _, user := range users {
+		if user.Name == name {
+			return user
+		}
+	}
+	return model.UserAccountData{}
+}
+
+func TestCollectUsersAndGroups(t *testing.T) {
+	defer setupAccountFiles(t)()
+
+	users, groups := collectPlatformDependentUserAccountData(context.NewMockDefault())
+	assert.Equal(t, 6, len(users))
+	assert.Equal(t, "alice", users[0].Name)
+
+	assert.Equal(t, model.UserAccountData{
+		Name:               "ec2-user",
+		UID:                "1000",
+		GID:                "1000",
+		PrimaryGroup:       "ec2-user",
+		FullName:           "EC2 Default User",
+		HomeDirectory:      "/home/ec2-user",
+		Shell:              "/bin/bash",
+		PasswordStatus:     "Locked",
+		Locked:             "true",
+		Expired:            "false",
+		LastPasswordChange: "2016-07-18",
+		Groups:             "ec2-user,wheel",
+		SudoAccess:         "true",
+	}, findUser(users, "ec2-user"))
+
+	alice := findUser(users, "alice")
+	assert.Equal(t, "Set", alice.PasswordStatus)
+	assert.Equal(t, "2016-10-16", alice.PasswordExpiry)
+	assert.Equal(t, "true", alice.Expired)
+	assert.Equal(t, "alice,ops,wheel", alice.Groups)
+
+	bob := findUser(users, "bob")
+	assert.Equal(t, "2016-10-26", bob.AccountExpiry)
+	assert.Equal(t, "true", bob.Expired)
+	assert.Equal(t, "true", bob.SudoAccess)
+
+	assert.Equal(t, "Disabled", findUser(users, "daemon").PasswordStatus)
+	assert.Equal(t, "false", findUser(users, "daemon").SudoAccess)
+	assert.Equal(t, "true", findUser(users, "root").SudoAccess)
+	assert.Equal(t, "false", findUser(users, "sshd").SudoAccess)
+
+	for _, user := range users {
+		assert.False(t, strings.Contains(user.PasswordStatus, "$"))
+	}
+
+	assert.Equal(t, 7, len(groups))
+	assert.Equal(t, model.GroupData{Name: "wheel", GID: "10", Members: "alice,ec2-user", SudoAccess: "true"}, groups[6])
+	assert.Equal(t, model.GroupData{Name: "ops", GID: "2000", Members: "alice,bob", SudoAccess: "false"}, groups[3])
+}
+
+func TestCollectWithoutShadow(t *testing.T) {
+	defer setupAccountFiles(t)()
+	assert.Nil(t, os.Remove(shadowPath))
+
+	users, _ := collectPlatformDependentUserAccountData(context.NewMockDefault())
+	root := findUser(users, "root")
+	assert.Equal(t, "", root.PasswordStatus)
+	assert.Equal(t, "false", root.Locked)
+}
+
+func TestExcludeSystemAccounts(t *testing.T) {
+	defer setupAccountFiles(t)()
+
+	users, groups, err := CollectUserAccountData(context.NewMockDefault(), model.Config{Filters: `{"ExcludeSystemAccounts": true}`})
+	assert.Nil(t, err)
+	names := make([]string, 0)
+	for _, user := range users {
+		names = append(names, user.Name)
+	}
+	assert.Equal(t, "alice bob ec2-user root", strings.Join(names, " "))
+	assert.Equal(t, 5, len(groups))
+}
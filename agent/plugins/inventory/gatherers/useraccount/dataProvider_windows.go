@@ -0,0 +1,31 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build windows
+
+package useraccount
+
+import (
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+// collectPlatformDependentUserAccountData is not supported on windows, the gatherer is only installed
+func collectPlatformDependentUserAccountData(context context.T) ([]model.UserAccountData, []model.GroupData) {
+	return []model.UserAccountData{}, []model.GroupData{}
+}
+
+// systemAccountFilter matches no account on windows
+func systemAccountFilter() func(id string) bool {
+	return func(id string) bool { return false }
+}
@@ -0,0 +1,82 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package useraccount contains a gatherer for the AWS:UserAccount and AWS:Group inventory types.
+package useraccount
+
+import (
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const (
+	// GathererName captures name of user account gatherer
+	GathererName = "AWS:UserAccount"
+	// GroupTypeName is the inventory type of the groups collected by the user account gatherer
+	GroupTypeName = "AWS:Group"
+	// SchemaVersionOfUserAccount represents schema version of user account gatherer
+	SchemaVersionOfUserAccount = "1.0"
+)
+
+// T represents user account gatherer which implements all contracts for gatherers.
+type T struct{}
+
+// decoupling for easy testability
+var collectData = CollectUserAccountData
+
+// Gatherer returns new user account gatherer
+func Gatherer(context context.T) *T {
+	return new(T)
+}
+
+// Name returns name of user account gatherer
+func (t *T) Name() string {
+	return GathererName
+}
+
+// Run executes user account gatherer and returns an inventory.Item of users and one of groups
+func (t *T) Run(context context.T, configuration model.Config) (items []model.Item, err error) {
+
+	var users []model.UserAccountData
+	var groups []model.GroupData
+
+	//CaptureTime must comply with format: 2016-07-30T18:15:37Z to comply with regex at SSM.
+	currentTime := time.Now().UTC()
+	captureTime := currentTime.Format(time.RFC3339)
+
+	if users, groups, err = collectData(context, configuration); err != nil {
+		return
+	}
+
+	items = append(items, model.Item{
+		Name:          t.Name(),
+		SchemaVersion: SchemaVersionOfUserAccount,
+		Content:       users,
+		CaptureTime:   captureTime,
+	}, model.Item{
+		Name:          GroupTypeName,
+		SchemaVersion: SchemaVersionOfUserAccount,
+		Content:       groups,
+		CaptureTime:   captureTime,
+	})
+	return
+}
+
+// RequestStop stops the execution of user account gatherer.
+func (t *T) RequestStop(stopType contracts.StopType) error {
+	var err error
+	return err
+}
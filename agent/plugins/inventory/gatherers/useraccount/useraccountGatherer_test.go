@@ -0,0 +1,85 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package useraccount
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	sampleUsers = []model.UserAccountData{
+		{Name: "root", UID: "0", GID: "0", Groups: "root", SudoAccess: "true"},
+		{Name: "sshd", UID: "74", GID: "74", Groups: "sshd", SudoAccess: "false"},
+		{Name: "ec2-user", UID: "1000", GID: "1000", Groups: "ec2-user,wheel", SudoAccess: "true"},
+	}
+	sampleGroups = []model.GroupData{
+		{Name: "root", GID: "0", Members: "root", SudoAccess: "false"},
+		{Name: "wheel", GID: "10", Members: "ec2-user", SudoAccess: "true"},
+	}
+)
+
+func MockUserAccountData(context context.T, config model.Config) ([]model.UserAccountData, []model.GroupData, error) {
+	return sampleUsers, sampleGroups, nil
+}
+
+func MockUserAccountDataWithError(context context.T, config model.Config) ([]model.UserAccountData, []model.GroupData, error) {
+	return nil, nil, errors.New("invalid filters")
+}
+
+func TestGatherer(t *testing.T) {
+	c := context.NewMockDefault()
+	g := Gatherer(c)
+	collectData = MockUserAccountData
+	items, err := g.Run(c, model.Config{})
+	assert.Nil(t, err, "Unexpected error thrown")
+	assert.Equal(t, 2, len(items))
+	assert.Equal(t, GathererName, items[0].Name)
+	assert.Equal(t, sampleUsers, items[0].Content)
+	assert.Equal(t, GroupTypeName, items[1].Name)
+	assert.Equal(t, sampleGroups, items[1].Content)
+	assert.Equal(t, SchemaVersionOfUserAccount, items[1].SchemaVersion)
+
+	collectData = MockUserAccountDataWithError
+	items, err = g.Run(c, model.Config{})
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, len(items))
+}
+
+func TestFilters(t *testing.T) {
+	isSystem := func(id string) bool { return id == "74" || id == "10" }
+
+	users := filterUsers(sampleUsers, filterObj{}, isSystem)
+	assert.Equal(t, 2, len(users))
+	assert.Equal(t, "ec2-user", users[1].Name)
+
+	users = filterUsers(sampleUsers, filterObj{Users: []string{"ec2-*", "sshd"}}, func(string) bool { return false })
+	assert.Equal(t, 2, len(users))
+	assert.Equal(t, "sshd", users[0].Name)
+
+	groups := filterGroups(sampleGroups, filterObj{Groups: []string{"wheel"}}, func(string) bool { return false })
+	assert.Equal(t, []model.GroupData{sampleGroups[1]}, groups)
+}
+
+func TestInvalidFilters(t *testing.T) {
+	c := context.NewMockDefault()
+	_, _, err := CollectUserAccountData(c, model.Config{Filters: "{invalid"})
+	assert.NotNil(t, err)
+	_, _, err = CollectUserAccountData(c, model.Config{Filters: `{"Users": ["[a-"]}`})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,222 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package history keeps a local history of the inventory data collected by the inventory plugin
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/datauploader"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const (
+	// snapshotTimeFormat is the format of the capture time in the name of a snapshot file, with nanoseconds so the
+	// snapshots of runs in the same second don't overwrite each other
+	snapshotTimeFormat = "20060102T150405.000000000Z"
+	// snapshotExtension is the extension of snapshot files
+	snapshotExtension = ".json"
+)
+
+// Snapshot is the inventory data collected by a run of the inventory plugin
+type Snapshot struct {
+	CaptureTime string
+	Items       []model.Item
+}
+
+// Store persists snapshots in a directory and keeps the most recent ones
+type Store struct {
+	log       log.T
+	directory string
+	retention int
+}
+
+// NewStore creates the history of the instance in the data store, retention is the number of snapshots kept
+func NewStore(log log.T, machineID string, retention int) *Store {
+	return NewStoreWithLocation(log,
+		filepath.Join(appconfig.DefaultDataStorePath, machineID, appconfig.InventoryRootDirName, appconfig.InventoryHistoryDirName),
+		retention)
+}
+
+// NewStoreWithLocation creates a history in the given directory
+func NewStoreWithLocation(log log.T, directory string, retention int) *Store {
+	return &Store{log: log, directory: directory, retention: retention}
+}
+
+// Save writes the items as a snapshot captured at the given time and removes the oldest snapshots beyond the retention.
+// Nothing is saved if the history is disabled.
+func (s *Store) Save(captureTime time.Time, items []model.Item) (err error) {
+	if s.retention <= 0 {
+		return
+	}
+
+	captureTime = captureTime.UTC()
+	snapshot := Snapshot{CaptureTime: captureTime.Format(time.RFC3339Nano), Items: items}
+	dataB, err := json.Marshal(snapshot)
+	if err != nil {
+		return
+	}
+	if err = fileutil.MakeDirs(s.directory); err != nil {
+		return fmt.Errorf("Unable to create inventory history directory %v because - %v", s.directory, err.Error())
+	}
+	path := filepath.Join(s.directory, captureTime.Format(snapshotTimeFormat)+snapshotExtension)
+	if _, err = fileutil.WriteIntoFileWithPermissions(path, string(dataB), appconfig.ReadWriteAccess); err != nil {
+		return fmt.Errorf("Unable to write inventory snapshot %v because - %v", path, err.Error())
+	}
+	s.log.Debugf("Saved inventory snapshot %v", path)
+
+	captureTimes, err := s.List()
+	if err != nil {
+		return
+	}
+	for len(captureTimes) > s.retention {
+		expired := filepath.Join(s.directory, captureTimes[0].Format(snapshotTimeFormat)+snapshotExtension)
+		if err = os.Remove(expired); err != nil {
+			return fmt.Errorf("Unable to remove expired inventory snapshot %v because - %v", expired, err.Error())
+		}
+		captureTimes = captureTimes[1:]
+	}
+	return
+}
+
+// List returns the capture times of the snapshots in the history, oldest first
+func (s *Store) List() (captureTimes []time.Time, err error) {
+	captureTimes = []time.Time{}
+	files, err := ioutil.ReadDir(s.directory)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasSuffix(name, snapshotExtension) {
+			continue
+		}
+		if captureTime, parseErr := time.Parse(snapshotTimeFormat, strings.TrimSuffix(name, snapshotExtension)); parseErr == nil {
+			captureTimes = append(captureTimes, captureTime)
+		}
+	}
+	sort.Sort(byTime(captureTimes))
+	return
+}
+
+// At returns the most recent snapshot captured at or before the given time
+func (s *Store) At(at time.Time) (snapshot Snapshot, err error) {
+	captureTimes, err := s.List()
+	if err != nil {
+		return
+	}
+	for i := len(captureTimes) - 1; i >= 0; i-- {
+		if !captureTimes[i].After(at) {
+			return s.read(captureTimes[i])
+		}
+	}
+	return snapshot, fmt.Errorf("no inventory snapshot captured at or before %v", at.UTC().Format(time.RFC3339))
+}
+
+// read loads the snapshot captured at the given time
+func (s *Store) read(captureTime time.Time) (snapshot Snapshot, err error) {
+	path := filepath.Join(s.directory, captureTime.Format(snapshotTimeFormat)+snapshotExtension)
+	dataB, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(dataB, &snapshot); err != nil {
+		err = fmt.Errorf("Unable to read inventory snapshot %v because - %v", path, err.Error())
+	}
+	return
+}
+
+// Filter returns the snapshot with only the items of the given inventory type, or the snapshot itself if typeName is empty
+func (snapshot Snapshot) Filter(typeName string) Snapshot {
+	if typeName == "" {
+		return snapshot
+	}
+	filtered := Snapshot{CaptureTime: snapshot.CaptureTime, Items: []model.Item{}}
+	for _, item := range snapshot.Items {
+		if item.Name == typeName {
+			filtered.Items = append(filtered.Items, item)
+		}
+	}
+	return filtered
+}
+
+// Diff returns the changes of every inventory type between two snapshots, a type only present in one of them is
+// reported with all its entries added or removed.
+func Diff(from, to Snapshot) (changes []datauploader.Change) {
+	changes = []datauploader.Change{}
+	fromContent := contentByType(from)
+	toContent := contentByType(to)
+
+	typeNames := make([]string, 0, len(fromContent)+len(toContent))
+	for typeName := range toContent {
+		typeNames = append(typeNames, typeName)
+	}
+	for typeName := range fromContent {
+		if _, found := toContent[typeName]; !found {
+			typeNames = append(typeNames, typeName)
+		}
+	}
+	sort.Strings(typeNames)
+
+	for _, typeName := range typeNames {
+		change := datauploader.DiffContent(typeName, fromContent[typeName], toContent[typeName])
+		if len(change.Added) > 0 || len(change.Removed) > 0 || len(change.Changed) > 0 {
+			change.CaptureTime = to.CaptureTime
+			changes = append(changes, change)
+		}
+	}
+	return
+}
+
+// contentByType returns the entries of each inventory type of a snapshot as attribute maps
+func contentByType(snapshot Snapshot) map[string][]map[string]*string {
+	result := make(map[string][]map[string]*string)
+	for _, item := range snapshot.Items {
+		// content is a list of entries, or a single entry for types like AWS:InstanceInformation
+		var content interface{}
+		dataB, _ := json.Marshal(item.Content)
+		json.Unmarshal(dataB, &content)
+
+		entries := []map[string]*string{}
+		if list, isList := content.([]interface{}); isList {
+			for _, entry := range list {
+				entries = append(entries, datauploader.ConvertToMap(entry))
+			}
+		} else if content != nil {
+			entries = append(entries, datauploader.ConvertToMap(content))
+		}
+		result[item.Name] = entries
+	}
+	return result
+}
+
+// byTime sorts capture times chronologically
+type byTime []time.Time
+
+func (s byTime) Len() int           { return len(s) }
+func (s byTime) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byTime) Less(i, j int) bool { return s[i].Before(s[j]) }
@@ -0,0 +1,140 @@
+// Copyright 2016 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package history
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func collectedItems(version string) []model.Item {
+	return []model.Item{
+		{
+			Name:          "AWS:Application",
+			SchemaVersion: "1.0",
+			Content: []model.ApplicationData{
+				{Name: "bash", Version: "4.2", Architecture: "x86_64"},
+				{Name: "openssl", Version: version, Architecture: "x86_64"},
+			},
+		},
+		{
+			Name:          model.AWSInstanceInformation,
+			SchemaVersion: "1.0",
+			Content:       model.InstanceInformation{InstanceId: "i-1234567890", AgentVersion: version},
+		},
+	}
+}
+
+func tempStore(t *testing.T, retention int) (store *Store, cleanup func()) {
+	dir, err := ioutil.TempDir("", "history")
+	assert.Nil(t, err)
+	return NewStoreWithLocation(log.NewMockLog(), dir, retention), func() { os.RemoveAll(dir) }
+}
+
+func TestSaveAndRotate(t *testing.T) {
+	store, cleanup := tempStore(t, 3)
+	defer cleanup()
+
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, store.Save(start.Add(time.Duration(i)*time.Hour), collectedItems("1.0.2k")))
+	}
+
+	captureTimes, err := store.List()
+	assert.Nil(t, err)
+	assert.Equal(t, []time.Time{start.Add(2 * time.Hour), start.Add(3 * time.Hour), start.Add(4 * time.Hour)}, captureTimes)
+}
+
+func TestSaveInTheSameSecond(t *testing.T) {
+	store, cleanup := tempStore(t, 10)
+	defer cleanup()
+
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Nil(t, store.Save(start, collectedItems("1.0.2k")))
+	assert.Nil(t, store.Save(start.Add(time.Millisecond), collectedItems("1.0.2l")))
+
+	captureTimes, err := store.List()
+	assert.Nil(t, err)
+	assert.Equal(t, []time.Time{start, start.Add(time.Millisecond)}, captureTimes)
+
+	snapshot, err := store.At(start)
+	assert.Nil(t, err)
+	assert.Equal(t, "2018-01-01T00:00:00Z", snapshot.CaptureTime)
+	snapshot, err = store.At(start.Add(time.Second))
+	assert.Nil(t, err)
+	assert.Equal(t, "2018-01-01T00:00:00.001Z", snapshot.CaptureTime)
+}
+
+func TestSaveDisabled(t *testing.T) {
+	store, cleanup := tempStore(t, 0)
+	defer cleanup()
+
+	assert.Nil(t, store.Save(time.Now(), collectedItems("1.0.2k")))
+	captureTimes, err := store.List()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(captureTimes))
+}
+
+func TestAt(t *testing.T) {
+	store, cleanup := tempStore(t, 10)
+	defer cleanup()
+
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Nil(t, store.Save(start, collectedItems("1.0.2k")))
+	assert.Nil(t, store.Save(start.Add(time.Hour), collectedItems("1.0.2l")))
+
+	_, err := store.At(start.Add(-time.Minute))
+	assert.NotNil(t, err)
+
+	snapshot, err := store.At(start.Add(30 * time.Minute))
+	assert.Nil(t, err)
+	assert.Equal(t, "2018-01-01T00:00:00Z", snapshot.CaptureTime)
+	assert.Equal(t, 2, len(snapshot.Items))
+
+	snapshot, err = store.At(time.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, "2018-01-01T01:00:00Z", snapshot.CaptureTime)
+	assert.Equal(t, 1, len(snapshot.Filter("AWS:Application").Items))
+}
+
+func TestDiff(t *testing.T) {
+	store, cleanup := tempStore(t, 10)
+	defer cleanup()
+
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Nil(t, store.Save(start, collectedItems("1.0.2k")))
+	assert.Nil(t, store.Save(start.Add(time.Hour), collectedItems("1.0.2l")))
+	from, _ := store.At(start)
+	to, _ := store.At(start.Add(time.Hour))
+
+	changes := Diff(from, to)
+	assert.Equal(t, 2, len(changes))
+	assert.Equal(t, "AWS:Application", changes[0].TypeName)
+	assert.Equal(t, "2018-01-01T01:00:00Z", changes[0].CaptureTime)
+	assert.Equal(t, 1, len(changes[0].Changed))
+	assert.Equal(t, "1.0.2l", *changes[0].Changed[0].New["Version"])
+	assert.Equal(t, model.AWSInstanceInformation, changes[1].TypeName)
+
+	changes = Diff(from.Filter("AWS:Application"), Snapshot{})
+	assert.Equal(t, 1, len(changes))
+	assert.Equal(t, 2, len(changes[0].Removed))
+
+	assert.Equal(t, 0, len(Diff(from, from)))
+}
@@ -18,7 +18,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
@@ -26,20 +28,29 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/platform"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/datauploader"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/application"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/awscomponent"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/container"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/custom"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/file"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/instancedetailedinformation"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/kernel"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/languagepackage"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/listeningport"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/network"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/service"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/useraccount"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/windowsUpdate"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/history"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
 	"github.com/aws/amazon-ssm-agent/agent/sdkutil"
 	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/aws/amazon-ssm-agent/agent/trace"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/ssm"
 )
@@ -54,6 +65,7 @@ const (
 	errorMsgForInabilityToSendDataToSSM       = "Unable to upload inventory data to SSM"
 	msgWhenNoDataToReturnForInventoryPlugin   = "Inventory policy has been successfully applied but there is no inventory data to upload to SSM"
 	successfulMsgForInventoryPlugin           = "Inventory policy has been successfully applied and collected inventory data has been uploaded to SSM"
+	msgWhenInventoryDataIsUpToDate            = "Inventory policy has been successfully audited and the inventory data uploaded to SSM is up to date"
 )
 
 // PluginInput represents configuration which is applied to inventory plugin during execution.
@@ -61,10 +73,19 @@ type PluginInput struct {
 	contracts.PluginInput
 	Applications                string
 	AWSComponents               string
+	LanguagePackages            string
+	LanguagePackageFilters      string
 	NetworkConfig               string
+	ListeningPorts              string
+	Services                    string
+	Containers                  string
+	UserAccounts                string
+	UserAccountFilters          string
 	Files                       string
 	WindowsUpdates              string
 	InstanceDetailedInformation string
+	Kernel                      string
+	KernelFilters               string
 	CustomInventory             string
 	CustomInventoryDirectory    string
 }
@@ -95,6 +116,15 @@ type Plugin struct {
 
 	// machineID of the machine where agent is running - useful during command detection
 	machineID string
+
+	//gathererConcurrency is the number of gatherers run at the same time
+	gathererConcurrency int
+
+	//gathererTimeout is the time after which a gatherer is stopped and reported as failed, 0 means no timeout
+	gathererTimeout time.Duration
+
+	//history keeps the collected inventory data locally
+	history *history.Store
 }
 
 // Name returns the plugin name
@@ -127,6 +157,9 @@ func NewPlugin(context context.T, pluginConfig pluginutil.PluginConfig) (*Plugin
 
 	p.context = c
 	p.stopPolicy = sdkutil.NewStopPolicy(Name(), model.ErrorThreshold)
+	p.gathererConcurrency = c.AppConfig().Inventory.GathererConcurrency
+	p.gathererTimeout = time.Duration(c.AppConfig().Inventory.GathererTimeoutSeconds) * time.Second
+	p.history = history.NewStore(log, p.machineID, c.AppConfig().Inventory.HistoryRetentionCount)
 
 	//loads all registered gatherers (for now only a dummy application gatherer is loaded in memory)
 	p.supportedGatherers, p.installedGatherers = gatherers.InitializeGatherers(p.context)
@@ -144,6 +177,7 @@ func (p *Plugin) ApplyInventoryPolicy(context context.T, inventoryInput PluginIn
 	var optimizedInventoryItems, nonOptimizedInventoryItems []*ssm.InventoryItem
 	var status, retryWithNonOptimized bool
 	var items []model.Item
+	var results []GathererResult
 	var err error
 
 	//map of all valid gatherers & respective configs to run
@@ -157,13 +191,24 @@ func (p *Plugin) ApplyInventoryPolicy(context context.T, inventoryInput PluginIn
 		return
 	}
 
-	//execute all eligible gatherers with their respective config
-	if items, err = p.RunGatherers(gatherers); err != nil {
+	//execute all eligible gatherers with their respective config, failed gatherers are reported without failing the plugin
+	if items, results, err = p.RunGatherers(gatherers); err != nil {
 		log.Info(err.Error())
 		inventoryOutput.ExitCode = 1
 		inventoryOutput.Stderr = err.Error()
 		return
 	}
+	defer func() {
+		inventoryOutput.AppendInfo(log, formatGathererResults(results, false))
+		inventoryOutput.AppendError(log, formatGathererResults(results, true))
+	}()
+
+	//keep collected data in the local history - failing to do so doesn't affect the upload
+	if p.history != nil && len(items) > 0 {
+		if historyErr := p.history.Save(time.Now(), items); historyErr != nil {
+			log.Errorf("Unable to save collected inventory data to local history - %v", historyErr.Error())
+		}
+	}
 
 	//check if there is data to send to SSM
 	if len(items) == 0 {
@@ -217,6 +262,63 @@ func (p *Plugin) ApplyInventoryPolicy(context context.T, inventoryInput PluginIn
 	return
 }
 
+// AuditInventoryPolicy runs the gatherers of the given inventory policy and returns the inventory data that differs from
+// the data last uploaded to SSM, without uploading it or keeping it in the local history
+func (p *Plugin) AuditInventoryPolicy(context context.T, inventoryInput PluginInput) (inventoryOutput contracts.PluginOutput, drift []contracts.DriftItem) {
+	log := p.context.Log()
+	var items []model.Item
+	var results []GathererResult
+	var changes []datauploader.Change
+	var gatherers map[gatherers.T]model.Config
+	var err error
+
+	if gatherers, err = p.ValidateInventoryInput(context, inventoryInput); err != nil {
+		log.Info(err.Error())
+		inventoryOutput.ExitCode = 1
+		inventoryOutput.Stderr = err.Error()
+		return
+	}
+
+	if items, results, err = p.RunGatherers(gatherers); err != nil {
+		log.Info(err.Error())
+		inventoryOutput.ExitCode = 1
+		inventoryOutput.Stderr = err.Error()
+		return
+	}
+	defer func() {
+		inventoryOutput.AppendInfo(log, formatGathererResults(results, false))
+		inventoryOutput.AppendError(log, formatGathererResults(results, true))
+	}()
+
+	if changes, err = p.uploader.PendingChanges(p.context, items); err != nil {
+		log.Infof("Encountered error in comparing data with the data uploaded to SSM - %v", err.Error())
+		inventoryOutput.ExitCode = 1
+		inventoryOutput.Stderr = err.Error()
+		return
+	}
+
+	for _, change := range changes {
+		title := fmt.Sprintf("upload %v to SSM - %v added, %v removed, %v changed entries",
+			change.TypeName, len(change.Added), len(change.Removed), len(change.Changed))
+		drift = append(drift, contracts.DriftItem{
+			ID:    fmt.Sprintf("%v:%v", Name(), change.TypeName),
+			Title: title,
+			Details: map[string]string{
+				"TypeName": change.TypeName,
+				"Added":    fmt.Sprint(len(change.Added)),
+				"Removed":  fmt.Sprint(len(change.Removed)),
+				"Changed":  fmt.Sprint(len(change.Changed)),
+			},
+		})
+		inventoryOutput.AppendInfof(log, "Would %v", title)
+	}
+	if len(changes) == 0 {
+		inventoryOutput.AppendInfo(log, msgWhenInventoryDataIsUpToDate)
+	}
+	inventoryOutput.ExitCode = 0
+	return
+}
+
 // SendDataToInventory sends data to SSM and returns if data was sent successfully or not. If data is not uploaded successfully,
 // it parses the error message and determines if it should be sent again.
 func (p *Plugin) SendDataToInventory(context context.T, items []*ssm.InventoryItem) (status, retryWithFullData bool) {
@@ -293,6 +395,15 @@ func (p *Plugin) validateGathererWithFilters(context context.T, collectionPolicy
 	return
 }
 
+func (p *Plugin) validatePredefinedGathererWithFilters(context context.T, collectionPolicy, gathererName string, filters string) (status bool, gatherer gatherers.T, policy model.Config, err error) {
+
+	if status, gatherer, policy, err = p.validatePredefinedGatherer(context, collectionPolicy, gathererName); status {
+		policy.Filters = filters
+	}
+
+	return
+}
+
 func (p *Plugin) validateCustomGatherer(context context.T, collectionPolicy, location string) (status bool, gatherer gatherers.T, policy model.Config, err error) {
 
 	if collectionPolicy == model.Enabled {
@@ -341,6 +452,13 @@ func (p *Plugin) ValidateInventoryInput(context context.T, input PluginInput) (c
 		configuredGatherers[gatherer] = cfg
 	}
 
+	//checking language package gatherer
+	if canGathererRun, gatherer, cfg, err = p.validatePredefinedGathererWithFilters(context, input.LanguagePackages, languagepackage.GathererName, input.LanguagePackageFilters); err != nil {
+		return
+	} else if canGathererRun {
+		configuredGatherers[gatherer] = cfg
+	}
+
 	if canGathererRun, gatherer, cfg, err = p.validateGathererWithFilters(context, "", file.GathererName, input.Files); err != nil {
 		return
 	} else if canGathererRun {
@@ -354,6 +472,27 @@ func (p *Plugin) ValidateInventoryInput(context context.T, input PluginInput) (c
 		configuredGatherers[gatherer] = cfg
 	}
 
+	//checking listening port gatherer
+	if canGathererRun, gatherer, cfg, err = p.validatePredefinedGatherer(context, input.ListeningPorts, listeningport.GathererName); err != nil {
+		return
+	} else if canGathererRun {
+		configuredGatherers[gatherer] = cfg
+	}
+
+	//checking service gatherer
+	if canGathererRun, gatherer, cfg, err = p.validatePredefinedGatherer(context, input.Services, service.GathererName); err != nil {
+		return
+	} else if canGathererRun {
+		configuredGatherers[gatherer] = cfg
+	}
+
+	//checking user account gatherer
+	if canGathererRun, gatherer, cfg, err = p.validatePredefinedGathererWithFilters(context, input.UserAccounts, useraccount.GathererName, input.UserAccountFilters); err != nil {
+		return
+	} else if canGathererRun {
+		configuredGatherers[gatherer] = cfg
+	}
+
 	//checking windows updates gatherer
 	if canGathererRun, gatherer, cfg, err = p.validatePredefinedGatherer(context, input.WindowsUpdates, windowsUpdate.GathererName); err != nil {
 		return
@@ -368,6 +507,20 @@ func (p *Plugin) ValidateInventoryInput(context context.T, input PluginInput) (c
 		configuredGatherers[gatherer] = cfg
 	}
 
+	//checking kernel gatherer
+	if canGathererRun, gatherer, cfg, err = p.validatePredefinedGathererWithFilters(context, input.Kernel, kernel.GathererName, input.KernelFilters); err != nil {
+		return
+	} else if canGathererRun {
+		configuredGatherers[gatherer] = cfg
+	}
+
+	//checking container gatherer
+	if canGathererRun, gatherer, cfg, err = p.validatePredefinedGatherer(context, input.Containers, container.GathererName); err != nil {
+		return
+	} else if canGathererRun {
+		configuredGatherers[gatherer] = cfg
+	}
+
 	//checking custom gatherer
 	if canGathererRun, gatherer, cfg, err = p.validateCustomGatherer(context, input.CustomInventory, input.CustomInventoryDirectory); err != nil {
 		return
@@ -378,49 +531,157 @@ func (p *Plugin) ValidateInventoryInput(context context.T, input PluginInput) (c
 	return
 }
 
-// RunGatherers execute given array of gatherers and accordingly returns. It returns error if gatherer is not
-// registered or if at any stage the data returned breaches size limit
-func (p *Plugin) RunGatherers(gatherers map[gatherers.T]model.Config) (items []model.Item, err error) {
+// GathererResult reports the execution of a single gatherer in the plugin output
+type GathererResult struct {
+	Name     string
+	Items    int
+	Duration time.Duration
+	Err      error
+}
+
+// String formats the result of a gatherer as a line of the plugin output
+func (r GathererResult) String() string {
+	if r.Err != nil {
+		return fmt.Sprintf("%v failed after %v: %v", r.Name, r.Duration, r.Err.Error())
+	}
+	return fmt.Sprintf("%v collected %v inventory types in %v", r.Name, r.Items, r.Duration)
+}
+
+// gathererExecution is a gatherer with its configuration and the inventory items it collected
+type gathererExecution struct {
+	gatherer gatherers.T
+	config   model.Config
+	items    []model.Item
+	result   GathererResult
+}
 
-	//NOTE: Currently all gatherers will be invoked in synchronous & sequential fashion.
-	//Parallel execution of gatherers hinges upon inventory plugin becoming a long running plugin - which will be
-	//mainly for custom inventory gatherer to send data independently of associate.
+// byGathererName sorts gatherer executions by gatherer name
+type byGathererName []*gathererExecution
 
-	var gItems []model.Item
+func (s byGathererName) Len() int           { return len(s) }
+func (s byGathererName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byGathererName) Less(i, j int) bool { return s[i].result.Name < s[j].result.Name }
 
+// RunGatherers executes the given gatherers concurrently, at most gathererConcurrency at a time, and stops each
+// gatherer that runs longer than gathererTimeout. The items of a gatherer that fails, times out or breaches the size
+// limits are dropped and the failure is reported in its result, the items of the other gatherers are still returned.
+// It returns error only if every gatherer failed.
+func (p *Plugin) RunGatherers(gatherers map[gatherers.T]model.Config) (items []model.Item, results []GathererResult, err error) {
 	log := p.context.Log()
 
+	executions := make([]*gathererExecution, 0, len(gatherers))
 	for gatherer, config := range gatherers {
-		name := gatherer.Name()
-		log.Infof("Invoking gatherer - %v", name)
-		start := time.Now()
-
-		if gItems, err = gatherer.Run(p.context, config); err != nil {
-			err = fmt.Errorf("Encountered error while executing %v. Error - %v", name, err.Error())
-			break
-
-		} else {
-			elapsed := time.Since(start)
-			log.Infof("execution time for gatherer - %v: %s", name, elapsed)
-
-			items = append(items, gItems...)
-
-			//TODO: Each gatherer shall check each item's size and stop collecting if size exceed immediately
-			//TODO: only check the total item size at this function, whenever total size exceed, stop
-			//TODO: immediately and raise association error
-			//return error if collected data breaches size limit
-			for _, v := range gItems {
-				if !p.VerifyInventoryDataSize(v, items) {
-					err = log.Errorf("Size limit exceeded for collected data.")
+		executions = append(executions, &gathererExecution{gatherer: gatherer, config: config, result: GathererResult{Name: gatherer.Name()}})
+	}
+	sort.Sort(byGathererName(executions))
+
+	concurrency := p.gathererConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	pool := make(chan bool, concurrency)
+	var wg sync.WaitGroup
+	for _, execution := range executions {
+		wg.Add(1)
+		pool <- true
+		go func(execution *gathererExecution) {
+			defer func() {
+				<-pool
+				wg.Done()
+			}()
+			p.runGatherer(log, execution)
+		}(execution)
+	}
+	wg.Wait()
+
+	//items are added in gatherer name order so the gatherer dropped for breaching the total size limit doesn't vary
+	failed := 0
+	for _, execution := range executions {
+		if execution.result.Err == nil {
+			collected := append(append([]model.Item{}, items...), execution.items...)
+			for _, v := range execution.items {
+				if !p.VerifyInventoryDataSize(v, collected) {
+					execution.result.Err = fmt.Errorf("Size limit exceeded for collected data of %v", v.Name)
 					break
 				}
 			}
+			if execution.result.Err == nil {
+				items = collected
+				execution.result.Items = len(execution.items)
+			}
+		}
+
+		if execution.result.Err != nil {
+			log.Errorf("Gatherer %v", execution.result.String())
+			failed++
+		} else {
+			log.Infof("Gatherer %v", execution.result.String())
 		}
+		results = append(results, execution.result)
 	}
 
+	if failed > 0 && failed == len(executions) {
+		err = fmt.Errorf("Encountered errors while executing all gatherers:\n%v", formatGathererResults(results, true))
+	}
 	return
 }
 
+// runGatherer runs a gatherer and records its items and result in the execution. A gatherer that doesn't complete within
+// gathererTimeout is requested to stop and reported as failed, whatever it returns afterwards is discarded.
+func (p *Plugin) runGatherer(log log.T, execution *gathererExecution) {
+	name := execution.result.Name
+	log.Infof("Invoking gatherer - %v", name)
+	start := time.Now()
+
+	type output struct {
+		items []model.Item
+		err   error
+	}
+	done := make(chan output, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- output{err: fmt.Errorf("gatherer panicked: %v", r)}
+			}
+		}()
+		items, err := execution.gatherer.Run(p.context, execution.config)
+		done <- output{items: items, err: err}
+	}()
+
+	var timeout <-chan time.Time
+	if p.gathererTimeout > 0 {
+		timer := time.NewTimer(p.gathererTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case result := <-done:
+		execution.items = result.items
+		if result.err != nil {
+			execution.result.Err = fmt.Errorf("Encountered error while executing %v. Error - %v", name, result.err.Error())
+		}
+	case <-timeout:
+		if err := execution.gatherer.RequestStop(contracts.StopTypeSoftStop); err != nil {
+			log.Debugf("Failed to request stop of gatherer %v - %v", name, err)
+		}
+		execution.result.Err = fmt.Errorf("%v did not complete within %v", name, p.gathererTimeout)
+	}
+	execution.result.Duration = time.Since(start)
+	log.Infof("execution time for gatherer - %v: %s", name, execution.result.Duration)
+}
+
+// formatGathererResults formats the results of the gatherers, one per line, either the failed or the successful ones
+func formatGathererResults(results []GathererResult, failed bool) string {
+	var lines []string
+	for _, result := range results {
+		if (result.Err != nil) == failed {
+			lines = append(lines, result.String())
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
 // VerifyInventoryDataSize returns true if size of collected inventory data is within size restrictions placed by SSM,
 // else false.
 func (p *Plugin) VerifyInventoryDataSize(item model.Item, items []model.Item) bool {
@@ -526,6 +787,14 @@ func (p *Plugin) Execute(context context.T, config contracts.Configuration, canc
 	dataB, _ = json.Marshal(config)
 	log.Debugf("Starting %v with configuration \n%v", pluginName, jsonutil.Indent(string(dataB)))
 
+	tracer := trace.NewTracer(log)
+	rootTrace := tracer.BeginSection(pluginName)
+	defer func() {
+		rootTrace.WithExitcode(int64(res.Code)).End()
+		trace.ExportTraces(log, tracer, trace.ConfiguredExporters(context.AppConfig().Trace, config.OrchestrationDirectory),
+			map[string]string{"plugin": pluginName, "pluginid": config.PluginID})
+	}()
+
 	//set up orchestration dir where stdout & stderr files will be stored to be later uploaded to S3.
 	orchestrationDir := fileutil.BuildPath(config.OrchestrationDirectory, Name())
 	log.Debugf("Setting up orchestrationDir %v for Inventory Plugin's execution", orchestrationDir)
@@ -623,7 +892,20 @@ func (p *Plugin) Execute(context context.T, config contracts.Configuration, canc
 	dataB, _ = json.Marshal(inventoryInput)
 	log.Debugf("Inventory configuration after parsing - %v", string(dataB))
 
-	inventoryOutput = p.ApplyInventoryPolicy(context, inventoryInput)
+	//the gatherers run concurrently, so their results are recorded in the policy section from the plugin output
+	//that already lists them, rather than in a section per gatherer
+	var policyTrace *trace.Trace
+	if config.AuditOnly {
+		res.Audited = true
+		policyTrace = tracer.BeginSection("audit inventory policy")
+		inventoryOutput, res.Drift = p.AuditInventoryPolicy(context, inventoryInput)
+	} else {
+		policyTrace = tracer.BeginSection("apply inventory policy")
+		inventoryOutput = p.ApplyInventoryPolicy(context, inventoryInput)
+	}
+	policyTrace.InfoOut.WriteString(inventoryOutput.Stdout)
+	policyTrace.ErrorOut.WriteString(inventoryOutput.Stderr)
+	policyTrace.WithExitcode(int64(inventoryOutput.ExitCode)).End()
 	res = setPluginResult(inventoryOutput, res)
 	res.StandardOutput = pluginutil.StringPrefix(res.StandardOutput, p.MaxStdoutLength, p.OutputTruncatedSuffix)
 	res.StandardError = pluginutil.StringPrefix(res.StandardError, p.MaxStderrLength, p.OutputTruncatedSuffix)
@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
@@ -134,10 +135,12 @@ func TestRunGatherers(t *testing.T) {
 	//set expectations for errorFree gatherer.
 	errorFreeGatherer.On("Name").Return(errorFreeGathererName)
 	errorFreeGatherer.On("Run", p.context, config).Return(data, nil)
-	items, err = p.RunGatherers(testGathererConfig)
+	items, results, err := p.RunGatherers(testGathererConfig)
 
 	assert.Nil(t, err, "%v shouldn't throw errors", errorFreeGatherer)
 	assert.NotEqual(t, 0, len(items), "%v is expected to return at least few inventory items", errorFreeGatherer)
+	assert.Equal(t, 1, len(results))
+	assert.Nil(t, results[0].Err)
 
 	//testing running multiple gatherers out of which one throws an error
 
@@ -148,9 +151,59 @@ func TestRunGatherers(t *testing.T) {
 	errorProneGatherer.On("Name").Return(errorProneGathererName)
 	e := fmt.Errorf("Fake error executing %v", errorProneGatherer)
 	errorProneGatherer.On("Run", p.context, config).Return(data, e)
-	items, err = p.RunGatherers(testGathererConfig)
+	items, results, err = p.RunGatherers(testGathererConfig)
+
+	assert.Nil(t, err, "failure of %v shouldn't fail other gatherers", errorProneGatherer)
+	assert.Equal(t, data, items, "only items of %v are expected", errorFreeGatherer)
+	assert.Equal(t, 2, len(results))
+	assert.Equal(t, errorFreeGathererName, results[0].Name)
+	assert.Nil(t, results[0].Err)
+	assert.Equal(t, errorProneGathererName, results[1].Name)
+	assert.NotNil(t, results[1].Err)
+
+	//testing running gatherers that all throw errors
+	delete(testGathererConfig, errorFreeGatherer)
+	items, results, err = p.RunGatherers(testGathererConfig)
 
 	assert.NotNil(t, err, "%v should throw errors", errorProneGatherer)
+	assert.Equal(t, 0, len(items))
+}
+
+func TestRunGatherersConcurrentlyWithTimeout(t *testing.T) {
+	var gathererNames []string
+	for i := 0; i < 4; i++ {
+		gathererNames = append(gathererNames, fmt.Sprintf("Slow-%v", i))
+	}
+	gathererNames = append(gathererNames, "Stuck")
+
+	p, _ := MockInventoryPlugin(gathererNames, gathererNames)
+	p.gathererConcurrency = 4
+	p.gathererTimeout = 500 * time.Millisecond
+
+	config := model.Config{Collection: "Enabled"}
+	testGathererConfig := make(map[gatherers.T]model.Config)
+	for _, name := range gathererNames[:4] {
+		slowGatherer := gatherers.NewMockDefault()
+		slowGatherer.On("Name").Return(name)
+		slowGatherer.On("Run", p.context, config).After(200*time.Millisecond).Return(MockInventoryItems(), nil)
+		testGathererConfig[slowGatherer] = config
+	}
+	stuckGatherer := gatherers.NewMockDefault()
+	stuckGatherer.On("Name").Return("Stuck")
+	stuckGatherer.On("Run", p.context, config).After(time.Minute).Return(MockInventoryItems(), nil)
+	stuckGatherer.On("RequestStop", contracts.StopTypeSoftStop).Return(nil)
+	testGathererConfig[stuckGatherer] = config
+
+	start := time.Now()
+	items, results, err := p.RunGatherers(testGathererConfig)
+
+	assert.Nil(t, err)
+	assert.True(t, time.Since(start) < 2*time.Second, "gatherers are expected to run concurrently")
+	assert.Equal(t, 4, len(items))
+	assert.Equal(t, 5, len(results))
+	assert.Equal(t, "Stuck", results[4].Name)
+	assert.NotNil(t, results[4].Err)
+	stuckGatherer.AssertCalled(t, "RequestStop", contracts.StopTypeSoftStop)
 }
 
 func TestVerifyInventoryDataSize(t *testing.T) {
@@ -116,6 +116,40 @@ type NetworkData struct {
 	IPV6       string
 }
 
+// ListeningPortData captures all attributes present in AWS:ListeningPort inventory type
+type ListeningPortData struct {
+	Protocol       string
+	LocalAddress   string
+	LocalPort      string
+	PID            string `json:",omitempty"`
+	ProcessName    string `json:",omitempty"`
+	User           string
+	ExecutablePath string `json:",omitempty"`
+}
+
+// ContainerData captures all attributes present in AWS:Container inventory type
+type ContainerData struct {
+	Name          string
+	ContainerId   string
+	Image         string
+	ImageId       string
+	State         string
+	Status        string
+	Ports         string `json:",omitempty"`
+	RestartPolicy string `json:",omitempty"`
+	CreatedTime   string
+}
+
+// ContainerImageData captures all attributes present in AWS:ContainerImage inventory type
+type ContainerImageData struct {
+	Repository  string
+	Tag         string
+	ImageId     string
+	Digest      string `json:",omitempty"`
+	Size        string
+	CreatedTime string
+}
+
 // WindowsUpdateData captures all attributes present in AWS:WindowsUpdate inventory type
 type WindowsUpdateData struct {
 	// SSM Inventory expects it HotFixId and not HotFixID
@@ -125,6 +159,74 @@ type WindowsUpdateData struct {
 	InstalledBy   string
 }
 
+// ServiceData captures all attributes present in AWS:Service inventory type
+type ServiceData struct {
+	Name         string
+	Description  string
+	ServiceType  string
+	LoadState    string `json:",omitempty"`
+	ActiveState  string
+	SubState     string `json:",omitempty"`
+	StartType    string
+	MainPID      string `json:",omitempty"`
+	UnitFilePath string `json:",omitempty"`
+}
+
+// UserAccountData captures all attributes present in AWS:UserAccount inventory type
+// Password hashes are never collected, only the state of the password.
+type UserAccountData struct {
+	Name               string
+	UID                string
+	GID                string
+	PrimaryGroup       string
+	FullName           string `json:",omitempty"`
+	HomeDirectory      string
+	Shell              string
+	PasswordStatus     string `json:",omitempty"`
+	Locked             string
+	Expired            string
+	LastPasswordChange string `json:",omitempty"`
+	PasswordExpiry     string `json:",omitempty"`
+	AccountExpiry      string `json:",omitempty"`
+	Groups             string
+	SudoAccess         string
+}
+
+// GroupData captures all attributes present in AWS:Group inventory type
+type GroupData struct {
+	Name       string
+	GID        string
+	Members    string
+	SudoAccess string
+}
+
+// KernelData captures all attributes present in AWS:Kernel inventory type
+type KernelData struct {
+	Name        string
+	Release     string
+	Version     string
+	CommandLine string
+	Tainted     string
+}
+
+// KernelModuleData captures all attributes present in AWS:KernelModule inventory type
+type KernelModuleData struct {
+	Name       string
+	Size       string
+	Version    string `json:",omitempty"`
+	State      string
+	UsedBy     string `json:",omitempty"`
+	Signed     string `json:",omitempty"`
+	Signer     string `json:",omitempty"`
+	TaintFlags string `json:",omitempty"`
+}
+
+// SysctlData captures all attributes present in AWS:Sysctl inventory type
+type SysctlData struct {
+	Name  string
+	Value string
+}
+
 // InstanceDetailedInformation captures all attributes present in AWS:InstanceDetailedInformation inventory type
 type InstanceDetailedInformation struct {
 	CPUModel              string
@@ -176,6 +176,32 @@ func CreateScriptFile(log log.T, scriptPath string, runCommand []string, byteOrd
 	return
 }
 
+// ReadComplianceItems returns the compliance items written by scripts to the compliance items file of the orchestration
+// directory, or of its subdirectories created for each set of properties of the plugin.
+// The file holds a JSON array of compliance items, invalid files are logged and ignored.
+func ReadComplianceItems(log log.T, orchestrationDir string) (items []contracts.ComplianceItem) {
+	paths := []string{filepath.Join(orchestrationDir, appconfig.ComplianceItemsFileName)}
+	if directories, err := fileutil.GetDirectoryNames(orchestrationDir); err == nil {
+		for _, directory := range directories {
+			paths = append(paths, filepath.Join(orchestrationDir, directory, appconfig.ComplianceItemsFileName))
+		}
+	}
+
+	for _, path := range paths {
+		if !fileutil.Exists(path) {
+			continue
+		}
+		var fileItems []contracts.ComplianceItem
+		if err := jsonutil.UnmarshalFile(path, &fileItems); err != nil {
+			log.Errorf("Unable to read compliance items from %v, %v", path, err)
+			continue
+		}
+		log.Debugf("Read %v compliance items from %v", len(fileItems), path)
+		items = append(items, fileItems...)
+	}
+	return items
+}
+
 // DownloadFileFromSource downloads file from source
 func DownloadFileFromSource(log log.T, source string, sourceHash string, sourceHashType string) (artifact.DownloadOutput, error) {
 	// download source and verify its integrity
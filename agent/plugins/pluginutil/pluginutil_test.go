@@ -17,9 +17,13 @@ package pluginutil
 import (
 	"bytes"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/stretchr/testify/assert"
@@ -205,3 +209,22 @@ func TestCleanupJSONField(t *testing.T) {
 		assert.Equal(t, output, result)
 	}
 }
+
+// TestReadComplianceItems tests that the compliance items written in the orchestration directory and its subdirectories are read.
+func TestReadComplianceItems(t *testing.T) {
+	orchestrationDir, _ := ioutil.TempDir("", "orchestration")
+	defer os.RemoveAll(orchestrationDir)
+	os.MkdirAll(filepath.Join(orchestrationDir, "0.awsrunShellScript"), 0755)
+	os.MkdirAll(filepath.Join(orchestrationDir, "1.awsrunShellScript"), 0755)
+	ioutil.WriteFile(
+		filepath.Join(orchestrationDir, "0.awsrunShellScript", appconfig.ComplianceItemsFileName),
+		[]byte(`[{"type": "CIS", "id": "5.2.8", "title": "Ensure SSH root login is disabled", "status": "NON_COMPLIANT"}]`),
+		0644)
+	ioutil.WriteFile(filepath.Join(orchestrationDir, "1.awsrunShellScript", appconfig.ComplianceItemsFileName), []byte("invalid"), 0644)
+
+	items := ReadComplianceItems(log.NewMockLog(), orchestrationDir)
+
+	assert.Equal(t, []contracts.ComplianceItem{
+		{Type: "CIS", ID: "5.2.8", Title: "Ensure SSH root login is disabled", Status: "NON_COMPLIANT"},
+	}, items)
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
 	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/aws/amazon-ssm-agent/agent/trace"
 )
 
 // Plugin is the type for the runscript plugin.
@@ -73,6 +74,14 @@ func (p *Plugin) Execute(context context.T, config contracts.Configuration, canc
 	log.Debugf("DefaultWorkingDirectory %v", config.DefaultWorkingDirectory)
 	p.defaultWorkingDirectory = config.DefaultWorkingDirectory
 
+	tracer := trace.NewTracer(log)
+	rootTrace := tracer.BeginSection(p.Name)
+	defer func() {
+		rootTrace.WithExitcode(int64(res.Code)).End()
+		trace.ExportTraces(log, tracer, trace.ConfiguredExporters(context.AppConfig().Trace, config.OrchestrationDirectory),
+			map[string]string{"plugin": p.Name, "pluginid": config.PluginID})
+	}()
+
 	//loading Properties as list since aws:runPowershellScript & aws:runShellScript uses properties as list
 	var properties []interface{}
 	if properties = pluginutil.LoadParametersAsList(log, config.Properties, &res); res.Code != 0 {
@@ -92,7 +101,10 @@ func (p *Plugin) Execute(context context.T, config contracts.Configuration, canc
 			break
 		}
 
-		out.Merge(log, p.runCommandsRawInput(log, config.PluginID, prop, config.OrchestrationDirectory, cancelFlag, config.OutputS3BucketName, config.OutputS3KeyPrefix))
+		commandsTrace := tracer.BeginSection("run commands")
+		commandsOut := p.runCommandsRawInput(log, config.PluginID, prop, config.OrchestrationDirectory, cancelFlag, config.OutputS3BucketName, config.OutputS3KeyPrefix)
+		commandsTrace.WithExitcode(int64(commandsOut.ExitCode)).End()
+		out.Merge(log, commandsOut)
 	}
 	res.Code = out.ExitCode
 	res.Status = out.Status
@@ -157,8 +169,11 @@ func (p *Plugin) runCommands(log log.T, pluginID string, pluginInput RunScriptPl
 	commandName := p.ShellCommand
 	commandArguments := append(p.ShellArguments, scriptPath, appconfig.ExitCodeTrap)
 
+	// Tell the script where to write its compliance items
+	envVars := map[string]string{appconfig.ComplianceItemsFileEnvVariable: filepath.Join(orchestrationDir, appconfig.ComplianceItemsFileName)}
+
 	// Execute Command
-	stdout, stderr, exitCode, errs := p.CommandExecuter.Execute(log, workingDir, stdoutFilePath, stderrFilePath, cancelFlag, executionTimeout, commandName, commandArguments)
+	stdout, stderr, exitCode, errs := p.CommandExecuter.Execute(log, workingDir, stdoutFilePath, stderrFilePath, cancelFlag, executionTimeout, commandName, commandArguments, envVars)
 
 	// Set output status
 	out.ExitCode = exitCode
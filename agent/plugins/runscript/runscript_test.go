@@ -19,6 +19,7 @@ import (
 	"io"
 	"path/filepath"
 	"testing"
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
@@ -367,7 +368,8 @@ func setExecuterExpectations(mockExecuter *executers.MockCommandExecuter, t Test
 	orchestrationDir := fileutil.BuildPath(orchestrationDirectory, t.Input.ID)
 	stdoutFilePath := filepath.Join(orchestrationDir, p.StdoutFileName)
 	stderrFilePath := filepath.Join(orchestrationDir, p.StderrFileName)
-	mockExecuter.On("Execute", mock.Anything, t.Input.WorkingDirectory, stdoutFilePath, stderrFilePath, cancelFlag, mock.Anything, mock.Anything, mock.Anything).Return(
+	envVars := map[string]string{appconfig.ComplianceItemsFileEnvVariable: filepath.Join(orchestrationDir, appconfig.ComplianceItemsFileName)}
+	mockExecuter.On("Execute", mock.Anything, t.Input.WorkingDirectory, stdoutFilePath, stderrFilePath, cancelFlag, mock.Anything, mock.Anything, mock.Anything, envVars).Return(
 		readerFromString(t.ExecuterStdOut), readerFromString(t.ExecuterStdErr), t.Output.ExitCode, t.ExecuterErrors)
 }
 
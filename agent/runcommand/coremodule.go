@@ -70,17 +70,16 @@ func (s *RunCommandService) ModuleExecute(context context.T) (err error) {
 	//TODO move association polling out in the next CR
 	if s.pollAssociations {
 		associationFrequenceMinutes := context.AppConfig().Ssm.AssociationFrequencyMinutes
+		associationMaxFrequencyMinutes := context.AppConfig().Ssm.AssociationMaxFrequencyMinutes
 		log.Info("Starting association polling")
-		log.Debugf("Association polling frequency is %v", associationFrequenceMinutes)
-		var job *scheduler.Job
-		if job, err = asocitscheduler.CreateScheduler(
+		log.Debugf("Association polling frequency is %v, up to %v while associations are unchanged", associationFrequenceMinutes, associationMaxFrequencyMinutes)
+		poller := asocitscheduler.CreatePoller(
 			log,
 			s.assocProcessor.ProcessAssociation,
-			associationFrequenceMinutes); err != nil {
-			context.Log().Errorf("unable to schedule association processor. %v", err)
-		}
+			associationFrequenceMinutes,
+			associationMaxFrequencyMinutes)
 		s.assocProcessor.InitializeAssociationProcessor()
-		s.assocProcessor.SetPollJob(job)
+		s.assocProcessor.SetPoller(poller)
 	}
 	return
 }
@@ -17,7 +17,7 @@ package trace_mock
 import (
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/packageservice"
-	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage/trace"
+	"github.com/aws/amazon-ssm-agent/agent/trace"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -49,6 +49,11 @@ func (m *Mock) CurrentTrace() *trace.Trace {
 	return args.Get(0).(*trace.Trace)
 }
 
+func (m *Mock) TraceID() string {
+	args := m.Called()
+	return args.String(0)
+}
+
 func (m *Mock) ToPackageServiceTrace() []*packageservice.Trace {
 	args := m.Called()
 	return args.Get(0).([]*packageservice.Trace)
@@ -12,12 +12,17 @@
 // implied. See the License for the specific language governing
 // permissions and limitations under the License.
 
+// Package trace collects nested timed sections of plugin executions, e.g. configurePackage, runScript and inventory,
+// and exports them as JSON Lines or OpenTelemetry spans
 package trace
 
 import (
 	"bytes"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
@@ -41,6 +46,9 @@ type Trace struct {
 	Logger log.T
 
 	Operation string
+	// hierarchy
+	SpanID       string
+	ParentSpanID string
 	// results
 	Exitcode int64
 	Error    error
@@ -60,6 +68,7 @@ type Tracer interface {
 
 	Traces() []*Trace
 	CurrentTrace() *Trace
+	TraceID() string
 
 	ToPluginOutput() *contracts.PluginOutput
 }
@@ -70,6 +79,8 @@ type TracerImpl struct {
 	traces       []*Trace
 	tracestack   []*Trace
 	logger       log.T
+	traceID      string
+	traceIDOnce  sync.Once
 }
 
 func NewTracer(logger log.T) Tracer {
@@ -87,8 +98,12 @@ func (t *TracerImpl) BeginSection(message string) *Trace {
 		Tracer:    t,
 		Logger:    t.logger,
 		Operation: message,
+		SpanID:    newID(8),
 		Start:     t.timeProvider.NowUnixNano(),
 	}
+	if parent := t.CurrentTrace(); parent != nil {
+		trace.ParentSpanID = parent.SpanID
+	}
 	t.tracestack = append(t.tracestack, trace)
 
 	return trace
@@ -156,6 +171,12 @@ func (t *TracerImpl) AddTrace(trace *Trace) {
 	if trace.Start == 0 {
 		trace.Start = t.timeProvider.NowUnixNano()
 	}
+	if trace.SpanID == "" {
+		trace.SpanID = newID(8)
+		if parent := t.CurrentTrace(); parent != nil {
+			trace.ParentSpanID = parent.SpanID
+		}
+	}
 
 	t.traces = append(t.traces, trace)
 }
@@ -175,6 +196,21 @@ func (t *TracerImpl) CurrentTrace() *Trace {
 	}
 }
 
+// TraceID returns the identifier shared by all traces of the tracer
+func (t *TracerImpl) TraceID() string {
+	t.traceIDOnce.Do(func() {
+		t.traceID = newID(16)
+	})
+	return t.traceID
+}
+
+// newID returns a random hex encoded identifier of the given number of bytes
+func newID(size int) string {
+	id := make([]byte, size)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
 // ToPluginOutput will convert info and error output into a PluginOutput struct
 // It will sort the output by trace end time
 func (t *TracerImpl) ToPluginOutput() *contracts.PluginOutput {
@@ -0,0 +1,287 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package trace
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// TraceFileName is the name of the JSON Lines file traces are written to in the orchestration directory
+const TraceFileName = "traces.jsonl"
+
+// Exporter writes the closed traces of a tracer to a destination
+// The attributes describe the execution the traces belong to (plugin, document, package...)
+type Exporter interface {
+	Export(tracer Tracer, attributes map[string]string) error
+}
+
+// ConfiguredExporters returns the exporters enabled in the agent configuration
+// JSON Lines are written to the orchestration directory of the plugin, nothing is written if it is empty
+func ConfiguredExporters(config appconfig.TraceCfg, orchestrationDirectory string) []Exporter {
+	exporters := make([]Exporter, 0)
+	if config.JSONLinesEnabled && orchestrationDirectory != "" {
+		exporters = append(exporters, &JSONLinesExporter{Directory: orchestrationDirectory})
+	}
+	if config.OpenTelemetryEndpoint != "" {
+		exporters = append(exporters, NewOpenTelemetryExporter(config.OpenTelemetryEndpoint,
+			appconfig.DefaultAgentName,
+			time.Duration(config.OpenTelemetryTimeoutSeconds)*time.Second))
+	}
+	return exporters
+}
+
+// ExportTraces exports the closed traces of the tracer with each exporter
+// Export failures are logged and never affect the result of the plugin
+func ExportTraces(log log.T, tracer Tracer, exporters []Exporter, attributes map[string]string) {
+	for _, exporter := range exporters {
+		if err := exporter.Export(tracer, attributes); err != nil {
+			log.Warnf("failed to export traces: %v", err)
+		}
+	}
+}
+
+// SpanRecord is the structured representation of a single trace
+type SpanRecord struct {
+	TraceID      string            `json:"traceid"`
+	SpanID       string            `json:"spanid"`
+	ParentSpanID string            `json:"parentspanid,omitempty"`
+	Operation    string            `json:"operation"`
+	Start        int64             `json:"start"`
+	Stop         int64             `json:"stop,omitempty"`
+	DurationMs   int64             `json:"durationms"`
+	Exitcode     int64             `json:"exitcode"`
+	Error        string            `json:"error,omitempty"`
+	Info         string            `json:"info,omitempty"`
+	ErrorOutput  string            `json:"erroroutput,omitempty"`
+	Attributes   map[string]string `json:"attributes,omitempty"`
+}
+
+// byStart sorts span records by start time
+type byStart []SpanRecord
+
+func (a byStart) Len() int           { return len(a) }
+func (a byStart) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byStart) Less(i, j int) bool { return a[i].Start < a[j].Start }
+
+// ToSpanRecords converts the closed traces of a tracer into span records ordered by start time
+func ToSpanRecords(tracer Tracer, attributes map[string]string) []SpanRecord {
+	records := make([]SpanRecord, 0, len(tracer.Traces()))
+	for _, trace := range tracer.Traces() {
+		record := SpanRecord{
+			TraceID:      tracer.TraceID(),
+			SpanID:       trace.SpanID,
+			ParentSpanID: trace.ParentSpanID,
+			Operation:    trace.Operation,
+			Start:        trace.Start,
+			Stop:         trace.Stop,
+			Exitcode:     trace.Exitcode,
+			Info:         trace.InfoOut.String(),
+			ErrorOutput:  trace.ErrorOut.String(),
+			Attributes:   attributes,
+		}
+		if trace.Stop != 0 {
+			record.DurationMs = (trace.Stop - trace.Start) / int64(time.Millisecond)
+		}
+		if trace.Error != nil {
+			record.Error = trace.Error.Error()
+		}
+		records = append(records, record)
+	}
+	sort.Stable(byStart(records))
+	return records
+}
+
+// JSONLinesExporter appends span records as JSON Lines to a file in a directory
+type JSONLinesExporter struct {
+	Directory string
+}
+
+// Export appends one line per trace to the trace file of the exporter's directory
+func (e *JSONLinesExporter) Export(tracer Tracer, attributes map[string]string) error {
+	if err := os.MkdirAll(e.Directory, 0750); err != nil {
+		return err
+	}
+	var content bytes.Buffer
+	encoder := json.NewEncoder(&content)
+	for _, record := range ToSpanRecords(tracer, attributes) {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.OpenFile(filepath.Join(e.Directory, TraceFileName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(content.Bytes())
+	return err
+}
+
+// OpenTelemetryExporter posts traces as OTLP/HTTP JSON to a collector endpoint, e.g. http://localhost:4318/v1/traces
+type OpenTelemetryExporter struct {
+	Endpoint    string
+	ServiceName string
+	Client      *http.Client
+}
+
+// NewOpenTelemetryExporter creates an exporter for the collector endpoint with a request timeout
+func NewOpenTelemetryExporter(endpoint string, serviceName string, timeout time.Duration) *OpenTelemetryExporter {
+	return &OpenTelemetryExporter{
+		Endpoint:    endpoint,
+		ServiceName: serviceName,
+		Client:      &http.Client{Timeout: timeout},
+	}
+}
+
+// OTLP JSON encoding of an export request, only the fields the agent populates are defined
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+const (
+	otlpSpanKindInternal = 1
+	otlpStatusCodeOk     = 1
+	otlpStatusCodeError  = 2
+)
+
+func stringAttribute(key string, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{StringValue: &value}}
+}
+
+func intAttribute(key string, value int64) otlpAttribute {
+	encoded := strconv.FormatInt(value, 10) // OTLP JSON encodes 64 bit integers as strings
+	return otlpAttribute{Key: key, Value: otlpValue{IntValue: &encoded}}
+}
+
+// buildRequest converts the traces of a tracer into an OTLP export request
+func (e *OpenTelemetryExporter) buildRequest(tracer Tracer, attributes map[string]string) otlpRequest {
+	resource := otlpResource{Attributes: []otlpAttribute{stringAttribute("service.name", e.ServiceName)}}
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		resource.Attributes = append(resource.Attributes, stringAttribute(key, attributes[key]))
+	}
+
+	spans := make([]otlpSpan, 0)
+	for _, record := range ToSpanRecords(tracer, nil) {
+		stop := record.Stop
+		if stop == 0 {
+			stop = record.Start
+		}
+		span := otlpSpan{
+			TraceID:           record.TraceID,
+			SpanID:            record.SpanID,
+			ParentSpanID:      record.ParentSpanID,
+			Name:              record.Operation,
+			Kind:              otlpSpanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(record.Start, 10),
+			EndTimeUnixNano:   strconv.FormatInt(stop, 10),
+			Status:            otlpStatus{Code: otlpStatusCodeOk},
+		}
+		if record.Exitcode != 0 {
+			span.Attributes = append(span.Attributes, intAttribute("exitcode", record.Exitcode))
+		}
+		if record.Error != "" {
+			span.Status = otlpStatus{Code: otlpStatusCodeError, Message: record.Error}
+		} else if record.Exitcode != 0 {
+			span.Status = otlpStatus{Code: otlpStatusCodeError, Message: fmt.Sprintf("exitcode %v", record.Exitcode)}
+		}
+		spans = append(spans, span)
+	}
+
+	return otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource:   resource,
+			ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "amazon-ssm-agent"}, Spans: spans}},
+		}},
+	}
+}
+
+// Export posts the traces of the tracer to the collector endpoint
+func (e *OpenTelemetryExporter) Export(tracer Tracer, attributes map[string]string) error {
+	body, err := json.Marshal(e.buildRequest(tracer, attributes))
+	if err != nil {
+		return err
+	}
+	response, err := e.Client.Post(e.Endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("collector %v returned status %v", e.Endpoint, response.Status)
+	}
+	return nil
+}
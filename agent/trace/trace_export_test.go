@@ -0,0 +1,125 @@
+// Copyright 2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package trace
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/stretchr/testify/assert"
+)
+
+func createNestedTraces() Tracer {
+	tracer := NewTracer(loggerMock)
+	traceA := tracer.BeginSection("traceA")
+	traceB := tracer.BeginSection("traceB")
+	traceB.AppendInfo("traceBinfo")
+	traceB.WithError(errors.New("testerror")).End()
+	tracer.AddTrace(&Trace{Operation: "traceC", Exitcode: 3})
+	traceA.End()
+	return tracer
+}
+
+func TestSpanHierarchy(t *testing.T) {
+	tracer := createNestedTraces()
+
+	records := ToSpanRecords(tracer, nil)
+	assert.Equal(t, 3, len(records))
+	assert.Equal(t, "traceA", records[0].Operation)
+	assert.Equal(t, "", records[0].ParentSpanID)
+	assert.Equal(t, "traceB", records[1].Operation)
+	assert.Equal(t, records[0].SpanID, records[1].ParentSpanID)
+	assert.Equal(t, "testerror", records[1].Error)
+	assert.Equal(t, "traceBinfo\n", records[1].Info)
+	assert.Equal(t, "traceC", records[2].Operation)
+	assert.Equal(t, records[0].SpanID, records[2].ParentSpanID)
+	for _, record := range records {
+		assert.Equal(t, tracer.TraceID(), record.TraceID)
+		assert.Equal(t, 32, len(record.TraceID))
+		assert.Equal(t, 16, len(record.SpanID))
+	}
+}
+
+func TestJSONLinesExporter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "traceexport")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	exporter := &JSONLinesExporter{Directory: dir}
+	assert.Nil(t, exporter.Export(createNestedTraces(), map[string]string{"plugin": "test"}))
+	assert.Nil(t, exporter.Export(createNestedTraces(), map[string]string{"plugin": "test"}))
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, TraceFileName))
+	assert.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	assert.Equal(t, 6, len(lines))
+
+	var record SpanRecord
+	assert.Nil(t, json.Unmarshal([]byte(lines[1]), &record))
+	assert.Equal(t, "traceB", record.Operation)
+	assert.Equal(t, "test", record.Attributes["plugin"])
+}
+
+func TestOpenTelemetryExporter(t *testing.T) {
+	var request otlpRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&request))
+	}))
+	defer server.Close()
+
+	exporter := NewOpenTelemetryExporter(server.URL, "amazon-ssm-agent", time.Second)
+	assert.Nil(t, exporter.Export(createNestedTraces(), map[string]string{"package": "AWSPVDriver"}))
+
+	assert.Equal(t, 1, len(request.ResourceSpans))
+	resource := request.ResourceSpans[0].Resource
+	assert.Equal(t, "service.name", resource.Attributes[0].Key)
+	assert.Equal(t, "package", resource.Attributes[1].Key)
+	assert.Equal(t, "AWSPVDriver", *resource.Attributes[1].Value.StringValue)
+
+	spans := request.ResourceSpans[0].ScopeSpans[0].Spans
+	assert.Equal(t, 3, len(spans))
+	assert.Equal(t, otlpStatusCodeOk, spans[0].Status.Code)
+	assert.Equal(t, otlpStatusCodeError, spans[1].Status.Code)
+	assert.Equal(t, "testerror", spans[1].Status.Message)
+	assert.Equal(t, spans[0].SpanID, spans[1].ParentSpanID)
+	assert.Equal(t, otlpStatusCodeError, spans[2].Status.Code)
+	assert.Equal(t, spans[2].StartTimeUnixNano, spans[2].EndTimeUnixNano)
+}
+
+func TestOpenTelemetryExporterError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	exporter := NewOpenTelemetryExporter(server.URL, "amazon-ssm-agent", time.Second)
+	assert.Error(t, exporter.Export(createNestedTraces(), nil))
+}
+
+func TestConfiguredExporters(t *testing.T) {
+	assert.Equal(t, 0, len(ConfiguredExporters(appconfig.TraceCfg{JSONLinesEnabled: true}, "")))
+	assert.Equal(t, 1, len(ConfiguredExporters(appconfig.TraceCfg{JSONLinesEnabled: true}, "orchestration")))
+	assert.Equal(t, 2, len(ConfiguredExporters(appconfig.TraceCfg{JSONLinesEnabled: true, OpenTelemetryEndpoint: "http://localhost:4318/v1/traces"}, "orchestration")))
+}